@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/vitessio/vt/go/dbinfo"
+)
+
+func dbinfoCmd() *cobra.Command {
+	var cfg dbinfo.Config
+
+	cmd := &cobra.Command{
+		Use:     "dbinfo",
+		Short:   "Collects the schema, table sizes, index cardinalities and global variables of a live MySQL or vtgate endpoint",
+		Example: "vt dbinfo --host 127.0.0.1 --port 3306 --user root --database shop > dbinfo.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return dbinfo.Run(cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.Host, "host", "127.0.0.1", "Host of the MySQL server or vtgate")
+	cmd.Flags().IntVar(&cfg.Port, "port", 3306, "Port of the MySQL server or vtgate")
+	cmd.Flags().StringVar(&cfg.Socket, "socket", "", "Unix socket to connect to, instead of --host and --port")
+	cmd.Flags().StringVar(&cfg.User, "user", "root", "User to connect as")
+	cmd.Flags().StringVar(&cfg.Password, "password", "", "Password of the user")
+	cmd.Flags().StringVar(&cfg.Database, "database", "", "Database, or keyspace, to collect. Defaults to the database of the connection")
+
+	cmd.Flags().BoolVar(&cfg.SampleCardinality, "sample-cardinality", false, "Count the distinct values of the columns the queries of --keys-file filter, join or group on")
+	cmd.Flags().StringVar(&cfg.KeysFile, "keys-file", "", "File written by 'vt keys', used with --sample-cardinality")
+	cmd.Flags().IntVar(&cfg.SampleRows, "sample-rows", dbinfo.DefaultSampleRows, "Number of rows of each table read by --sample-cardinality")
+
+	cmd.Flags().BoolVar(&cfg.QueryStats, "query-stats", false, "Snapshot the execution counts and latencies of the query digests of performance_schema")
+
+	return cmd
+}
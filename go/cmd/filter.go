@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vitessio/vt/go/data"
+)
+
+type filterFlags struct {
+	tables         []string
+	regex          string
+	statementTypes []string
+}
+
+func addFilterFlags(cmd *cobra.Command, ff *filterFlags) {
+	cmd.Flags().StringSliceVar(&ff.tables, "filter-table", nil, "Only use the queries touching at least one of these tables")
+	cmd.Flags().StringVar(&ff.regex, "filter-regex", "", "Only use the queries matching this regular expression")
+	cmd.Flags().StringSliceVar(&ff.statementTypes, "statement-types", nil, "Only use the queries of these statement types, e.g. SELECT,UPDATE")
+}
+
+func (ff *filterFlags) filter() (data.Filter, error) {
+	f := data.Filter{
+		Tables:         ff.tables,
+		StatementTypes: ff.statementTypes,
+	}
+	if ff.regex != "" {
+		re, err := regexp.Compile(ff.regex)
+		if err != nil {
+			return data.Filter{}, fmt.Errorf("invalid --filter-regex: %w", err)
+		}
+		f.Regex = re
+	}
+	return f, nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	vttester "github.com/vitessio/vt/go/tester"
+)
+
+func fuzzCmd() *cobra.Command {
+	var cfg vttester.FuzzConfig
+
+	cmd := &cobra.Command{
+		Use:     "fuzz",
+		Short:   "Compare MySQL and Vitess on random queries generated from a schema.",
+		Example: "vt fuzz --schema schema.sql --duration 10m",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return usageErr(cmd, vttester.Fuzz(cmd.Context(), cfg))
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.SchemaFile, "schema", "", "The file with the CREATE TABLE statements to generate the queries from.")
+	cmd.Flags().DurationVar(&cfg.Duration, "duration", time.Minute, "How long to run random queries for.")
+	cmd.Flags().Int64Var(&cfg.Seed, "seed", 0, "The seed of the random generator, to reproduce a run. A random seed is used when 0.")
+	cmd.Flags().IntVar(&cfg.Rows, "rows", 20, "Number of random rows inserted in every table.")
+	cmd.Flags().IntVar(&cfg.MaxFailures, "max-failures", 10, "Stop after finding this many mismatches, 0 for no limit.")
+	cmd.Flags().StringVar(&cfg.OutputDir, "output-dir", "fuzz", "The directory the reproductions of the mismatches are written to.")
+	cmd.Flags().StringVar(&cfg.VschemaFile, "vschema", "", "Disable auto-vschema by providing your own vschema file. By default the tables are sharded on their primary key.")
+	cmd.Flags().IntVar(&cfg.NumberOfShards, "number-of-shards", 0, "Number of shards to use for the sharded keyspace.")
+	cmd.Flags().StringVar(&cfg.LogLevel, "log-level", "", "The log level of vt fuzz: info, warn, error, debug. Overrides -q and -v.")
+
+	return cmd
+}
@@ -17,19 +17,131 @@ limitations under the License.
 package cmd
 
 import (
+	"os"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"github.com/vitessio/vt/go/data"
 	"github.com/vitessio/vt/go/keys"
 )
 
 func keysCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:     "keys file.test",
-		Short:   "Runs vexplain keys on all queries of the test file",
+	var inputType string
+	var pcapPort int
+	var tz timezoneFlags
+	var since, until string
+	var normalizePlaceholders bool
+	var ff filterFlags
+	var sample data.Sample
+	var analyzers []string
+	var orderByTimestamp bool
+	var renameFile string
+	var format string
+	var schemaFile string
+	var live keys.LiveSchema
+	var parallel int
+	var maxLiteralLength int
+	var mergeInto string
+	var bucket time.Duration
+	var topValues int
+	var progress bool
+	defaults := keys.DefaultConfig()
+
+	cmd := &cobra.Command{
+		Use:     "keys file.test [more files...]",
+		Short:   "Runs vexplain keys on all queries of the test files",
 		Example: "vt keys file.test",
-		Args:    cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return keys.Run(args[0])
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loader, err := data.LoaderFor(inputType)
+			if err != nil {
+				return err
+			}
+			filter, err := ff.filter()
+			if err != nil {
+				return err
+			}
+			loc, err := tz.location()
+			if err != nil {
+				return err
+			}
+			if filter.Since, err = parseTime("since", since, loc); err != nil {
+				return err
+			}
+			if filter.Until, err = parseTime("until", until, loc); err != nil {
+				return err
+			}
+			if pcap, ok := loader.(data.PcapLoader); ok {
+				pcap.Port = pcapPort
+				loader = pcap
+			}
+			if vtgate, ok := loader.(data.VtGateLogLoader); ok {
+				vtgate.Location = loc
+				loader = vtgate
+			}
+			var renames keys.Renames
+			if renameFile != "" {
+				renames, err = keys.ReadRenames(renameFile)
+				if err != nil {
+					return err
+				}
+			}
+			var liveSchema *keys.LiveSchema
+			if live.Host != "" || live.Socket != "" {
+				liveSchema = &live
+			}
+			return keys.RunTo(cmd.Context(), os.Stdout, keys.Config{
+				FileNames:             args,
+				Loader:                loader,
+				OrderByTimestamp:      orderByTimestamp,
+				NormalizePlaceholders: normalizePlaceholders,
+				Filter:                filter,
+				Sample:                sample,
+				Analyzers:             analyzers,
+				Renames:               renames,
+				Format:                format,
+				SchemaFile:            schemaFile,
+				LiveSchema:            liveSchema,
+				Parallel:              parallel,
+				MaxLiteralLength:      maxLiteralLength,
+				MergeInto:             mergeInto,
+				Bucket:                bucket,
+				TopValues:             topValues,
+				Progress:              progress,
+			})
 		},
 	}
+
+	cmd.Flags().StringVar(&inputType, "input-type", data.InputTypeMySQLTest, "The format of the input file: "+strings.Join(data.InputTypes, ", "))
+	cmd.Flags().BoolVar(&orderByTimestamp, "order-by-timestamp", false, "When several files are given, merge their queries in the order they were executed")
+	cmd.Flags().IntVar(&pcapPort, "pcap-port", 3306, "The port the MySQL server listens on, used with --input-type=pcap")
+	addTimezoneFlags(cmd, &tz, "The time zone of the vtgate that wrote the log, such as Europe/Berlin or Local, used with --input-type=vtgate-log, "+
+		"and of --since and --until. The timestamps are converted to UTC")
+
+	cmd.Flags().BoolVar(&normalizePlaceholders, "normalize-placeholders", false, "Treat literals and ? placeholders the same, so queries from digest tools and raw logs aggregate together")
+	addFilterFlags(cmd, &ff)
+	cmd.Flags().StringVar(&since, "since", "", "Only use the queries executed from this time, such as 2024-10-01 or 2024-10-01 08:00:00. The queries without a timestamp are kept")
+	cmd.Flags().StringVar(&until, "until", "", "Only use the queries executed before this time, in the same formats as --since")
+	cmd.Flags().Float64Var(&sample.Rate, "sample-rate", 0, "Only analyse this fraction of the queries, between 0 and 1. Usage counts are scaled to estimate the whole log")
+	cmd.Flags().IntVar(&sample.MaxQueries, "max-queries", 0, "Stop after analysing this many queries")
+	cmd.Flags().StringArrayVar(&analyzers, "analyzer", nil, "Binary to run on the queries: it reads one JSON query per line on stdin and writes one JSON finding per line on stdout. Can be repeated")
+	cmd.Flags().StringVar(&mergeInto, "merge-into", "", "A previous JSON output of 'vt keys' to add the queries of the logs to. The file is replaced by the merged output, and created if it doesn't exist")
+	cmd.Flags().IntVar(&maxLiteralLength, "max-literal-length", defaults.MaxLiteralLength, "Truncate the string and hexadecimal literals longer than this number of bytes, such as blobs, 0 keeps them whole")
+	cmd.Flags().DurationVar(&bucket, "bucket", 0, "Count the usage of every query structure per bucket of this duration, such as 1h, for the input types with timestamps")
+	cmd.Flags().IntVar(&topValues, "top-values", 0, "Record this number of the most used values of every filter column, and estimate its number of distinct values, to evaluate the sharding keys")
+	cmd.Flags().BoolVar(&progress, "progress", false, "Log the number of queries analysed, the failures and the estimated time left every 10 seconds")
+	cmd.Flags().IntVar(&parallel, "parallel", defaults.Parallel, "Number of queries to analyse concurrently, the output is the same whatever the number")
+	cmd.Flags().StringVar(&format, "format", defaults.Format, "The output format: json, read by 'vt summarize', jsonl, one query structure, failed query or finding per line, or markdown and csv, tables of the query structures")
+	cmd.Flags().StringVar(&schemaFile, "schema", "", "SQL file with the CREATE TABLE statements of the tables, such as the output of mysqldump --no-data, for the logs that don't create their tables")
+	cmd.Flags().StringVar(&live.Host, "host", "", "Host of a MySQL server or vtgate to read the schema of the tables from, with SHOW CREATE TABLE, for the tables the logs don't create")
+	cmd.Flags().IntVar(&live.Port, "port", 3306, "Port of the server given with --host")
+	cmd.Flags().StringVar(&live.Socket, "socket", "", "Unix socket of the server to read the schema from, instead of --host and --port")
+	cmd.Flags().StringVar(&live.User, "user", "root", "User to connect to the server of the schema as")
+	cmd.Flags().StringVar(&live.Password, "password", "", "Password of the user")
+	cmd.Flags().StringVar(&live.Database, "database", "", "Database, or keyspace, of the tables. Defaults to the database of the connection")
+	cmd.Flags().StringVar(&renameFile, "rename-file", "", "JSON file mapping old tables to their new names, e.g. {\"old_db.orders\": \"commerce.order\"}, applied before the analysis")
+
+	return cmd
 }
@@ -0,0 +1,44 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/vitessio/vt/go/probe"
+)
+
+func probeCmd() *cobra.Command {
+	var cfg probe.Config
+
+	cmd := &cobra.Command{
+		Use:     "probe",
+		Short:   "Checks the Vitess version and the features of a vtgate, and writes them as a capabilities file",
+		Example: "vt probe --vtgate 127.0.0.1:15306 > capabilities.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return probe.Run(cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.VTGate, "vtgate", "127.0.0.1:15306", "host:port of the MySQL protocol listener of the vtgate")
+	cmd.Flags().StringVar(&cfg.User, "user", "root", "User to connect as")
+	cmd.Flags().StringVar(&cfg.Password, "password", "", "Password of the user")
+
+	return cmd
+}
@@ -17,28 +17,104 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 
+	"github.com/fatih/color"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+// interruptibleAnnotation marks the commands that stop cleanly when interrupted, see interruptible
+const interruptibleAnnotation = "interruptible"
+
+// interruptible marks a command as stopping cleanly when its context is canceled: it flushes its partial output
+// and tears down the clusters it started. The other commands exit as soon as they are interrupted.
+func interruptible(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[interruptibleAnnotation] = "true"
+	return cmd
+}
+
+// handleInterrupts cancels the context of an interruptible command on the first SIGINT or SIGTERM,
+// and exits on the second one, or on the first one for the other commands
+func handleInterrupts(cancel context.CancelFunc, running *atomic.Bool) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if !running.Load() {
+			os.Exit(130)
+		}
+		log.Warn("Interrupted, stopping and writing the partial results. Interrupt again to exit immediately")
+		cancel()
+		<-signals
+		os.Exit(130)
+	}()
+}
+
+// setVerbosity sets the log level from the -q and -v flags shared by all the commands
+func setVerbosity(quiet bool, verbosity int) error {
+	switch {
+	case quiet && verbosity > 0:
+		return errors.New("--quiet and --verbose can't be used together")
+	case quiet:
+		log.SetLevel(log.ErrorLevel)
+	case verbosity == 1:
+		log.SetLevel(log.InfoLevel)
+	case verbosity > 1:
+		log.SetLevel(log.DebugLevel)
+	default:
+		log.SetLevel(log.WarnLevel)
+	}
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	// rootCmd represents the base command when called without any subcommands
+	var quiet, noColor bool
+	var verbosity int
+	var interruptibleRunning atomic.Bool
 	root := &cobra.Command{
 		Use:   "vt",
 		Short: "Utils tools for testing, running and benchmarking Vitess.",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			interruptibleRunning.Store(cmd.Annotations[interruptibleAnnotation] == "true")
+			if noColor {
+				// NO_COLOR in the environment is already honored by the color package
+				color.NoColor = true
+			}
+			return setVerbosity(quiet, verbosity)
+		},
 	}
+	root.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only log errors")
+	root.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Log more details, -v for informational messages and -vv for debugging messages")
+	root.PersistentFlags().BoolVar(&noColor, "no-color", false, "Print plain text, without colors or syntax highlighting, as when NO_COLOR is set")
 
 	root.CompletionOptions.HiddenDefaultCmd = true
 
 	root.AddCommand(summarizeCmd())
-	root.AddCommand(testerCmd())
-	root.AddCommand(tracerCmd())
-	root.AddCommand(keysCmd())
+	root.AddCommand(interruptible(testerCmd()))
+	root.AddCommand(interruptible(tracerCmd()))
+	root.AddCommand(interruptible(keysCmd()))
+	root.AddCommand(interruptible(wizardCmd()))
+	root.AddCommand(dbinfoCmd())
+	root.AddCommand(probeCmd())
+	root.AddCommand(interruptible(fuzzCmd()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handleInterrupts(cancel, &interruptibleRunning)
 
-	err := root.Execute()
+	err := root.ExecuteContext(ctx)
+	cancel()
 	if err != nil {
 		os.Exit(1)
 	}
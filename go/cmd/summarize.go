@@ -17,20 +17,142 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 
+	"github.com/vitessio/vt/go/keys"
 	"github.com/vitessio/vt/go/summarize"
 )
 
 func summarizeCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:     "summarize old_file.json [new_file.json]",
+	var tenancyFile string
+	var shardingKeysFile string
+	var testFiles []string
+	var dbinfoFile string
+	var renameFile string
+	var diff bool
+	var diffThreshold float64
+	var strict bool
+	var latencyThreshold float64
+	var failOnSeverity string
+	var allowVersionMismatch bool
+	var format string
+	var output string
+	var vschemaFile string
+	var trendDir string
+	var hotMetric string
+	var limits summarize.ReportLimits
+	var tz timezoneFlags
+
+	cmd := &cobra.Command{
+		Use:     "summarize old_file.json [new_file.json...]",
 		Aliases: []string{"benchstat"},
 		Short:   "Compares and analyses a trace output",
-		Example: "vt summarize old.json new.json",
-		Args:    cobra.RangeArgs(1, 2),
-		Run: func(_ *cobra.Command, args []string) {
-			summarize.Run(args)
+		Example: "vt summarize old.json new.json\nvt summarize --trend keys-logs/ --format=html -o trend.html",
+		Args:    cobra.ArbitraryArgs, // --trend reads a directory instead of files, Run checks there are files otherwise
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// --no-color is a flag of the root command
+			noColor, err := cmd.Flags().GetBool("no-color")
+			if err != nil {
+				return err
+			}
+			var severity keys.Severity
+			if failOnSeverity != "" {
+				severity, err = keys.ParseSeverity(failOnSeverity)
+				if err != nil {
+					return err
+				}
+			}
+			var metric summarize.HotMetric
+			if hotMetric != "" {
+				metric, err = summarize.ParseHotMetric(hotMetric)
+				if err != nil {
+					return err
+				}
+			}
+			loc, err := tz.location()
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			summary, err := summarize.Run(summarize.Config{
+				Files:                args,
+				TenancyFile:          tenancyFile,
+				ShardingKeysFile:     shardingKeysFile,
+				TestFiles:            testFiles,
+				DBInfoFile:           dbinfoFile,
+				RenameFile:           renameFile,
+				Diff:                 diff,
+				DiffThreshold:        diffThreshold,
+				Strict:               strict,
+				LatencyThreshold:     latencyThreshold,
+				FailOnSeverity:       severity,
+				AllowVersionMismatch: allowVersionMismatch,
+				Format:               format,
+				TrendDir:             trendDir,
+				VSchemaFile:          vschemaFile,
+				HotMetric:            metric,
+				Limits:               limits,
+				// the colors of the queries are escape codes that only terminals render
+				NoColor:  noColor || output != "",
+				Location: loc,
+			})
+			if err != nil {
+				return err
+			}
+			if err := writeSummary(summary, output); err != nil {
+				return err
+			}
+			if summary.BlockingFindings > 0 {
+				return fmt.Errorf("%d new findings of severity %s or above", summary.BlockingFindings, severity)
+			}
+			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&tenancyFile, "tenancy-config", "", "JSON file mapping tables to their tenancy column, e.g. {\"orders\": \"tenant_id\"}. Reports the queries of a keys file that don't filter on it")
+	cmd.Flags().StringVar(&shardingKeysFile, "sharding-keys", "", "JSON file mapping tables to the column they would be sharded by, e.g. {\"orders\": \"customer_id\"}. Reports the share of the queries of a keys file that constrain it")
+	cmd.Flags().StringSliceVar(&testFiles, "test-files", nil, "Test files of a test suite, in the mysqltest format. Reports the share of the queries of a keys file the tests run, and the most used ones they don't")
+	cmd.Flags().StringVar(&dbinfoFile, "dbinfo", "", "File written by 'vt dbinfo'. Reports the filter columns of a keys file that are not indexed, and weights its queries with the query statistics of the file")
+	cmd.Flags().StringVar(&renameFile, "rename-file", "", "JSON file mapping old tables to their new names, e.g. {\"old_db.orders\": \"commerce.order\"}, applied to the files before summarizing them")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Print a changelog of two keys files: new hot queries, tables whose usage shifted and new failures")
+	cmd.Flags().Float64Var(&diffThreshold, "diff-threshold", summarize.DefaultDiffThreshold, "Report the tables whose share of queries changed by more than this percentage, used with --diff")
+	cmd.Flags().StringVar(&failOnSeverity, "fail-on-severity", "", "Exit with an error when the new keys file has new findings of this severity or above (info, low, medium, high or critical), used with --diff")
+
+	cmd.Flags().Float64Var(&latencyThreshold, "latency-threshold", summarize.DefaultLatencyThreshold, "List the queries of a latency file whose median latency is more than this percentage higher on Vitess than on MySQL")
+	cmd.Flags().BoolVar(&allowVersionMismatch, "allow-version-mismatch", false, "Compare two trace files written with different major versions of Vitess, instead of refusing to")
+	cmd.Flags().StringVar(&format, "format", "", "Format of the summary of a keys file: markdown (the default), json, html (a self-contained report that can be shared), pdf, or text for the terminal, the only format of the other files")
+	cmd.Flags().IntVar(&limits.TopQueries, "top-queries", 0, "List at most this many queries, or other items, in every section of the report formats that doesn't list tables, the hot queries are limited to 20 by default")
+	cmd.Flags().IntVar(&limits.TopTables, "top-tables", 0, "List at most this many tables, the most used ones, in every section of the report formats that lists tables")
+	cmd.Flags().Float64Var(&limits.MinUsagePercentage, "min-usage-pct", 0, "Leave out the queries and tables used by less than this percentage of the query uses from the report formats")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the summary to, instead of stdout")
+	addTimezoneFlags(cmd, &tz, "The time zone the traffic buckets of a keys file are printed in, such as Europe/Berlin or Local")
+	cmd.Flags().StringVar(&trendDir, "trend", "", "Directory of keys files, such as one per day, to report the growth of the usage of every query structure and table across, in the text, markdown, json or html format")
+	cmd.Flags().StringVar(&hotMetric, "hot-metric", "", "Rank the query statistics of --dbinfo, and the hot queries section of the report formats, by an expression of executions "+
+		"(or usage-count), total-latency, avg-latency, rows-examined and avg-rows-examined, such as usage-count*avg-rows-examined, "+
+		"or by a JSON file mapping these metrics to weights. Needs --dbinfo. The query statistics are ranked by "+summarize.DefaultHotMetric+
+		" and the hot queries by their usage count by default")
+	cmd.Flags().StringVar(&vschemaFile, "emit-vschema", "", "File to write a sharded vschema to, with the recommended sharding key of every table of a keys file as its primary vindex")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Fail on truncated or corrupted files instead of summarizing the entries that could be read")
+
+	return cmd
+}
+
+// writeSummary writes the summary to the output file, or to stdout when there is none
+func writeSummary(summary *summarize.Summary, output string) error {
+	if output == "" {
+		_, err := summary.WriteTo(os.Stdout)
+		return err
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	if _, err := summary.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
@@ -26,6 +26,7 @@ import (
 
 func testerCmd() *cobra.Command {
 	var cfg vttester.Config
+	var ff filterFlags
 
 	cmd := &cobra.Command{
 		Aliases: []string{"test"},
@@ -34,9 +35,14 @@ func testerCmd() *cobra.Command {
 		Example: "vt tester ",
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := ff.filter()
+			if err != nil {
+				return err
+			}
 			cfg.Tests = args
 			cfg.Compare = true
-			return usageErr(cmd, vttester.Run(cfg))
+			cfg.Filter = filter
+			return usageErr(cmd, vttester.RunContext(cmd.Context(), cfg))
 		},
 	}
 
@@ -44,12 +50,17 @@ func testerCmd() *cobra.Command {
 
 	cmd.Flags().BoolVar(&cfg.OLAP, "olap", false, "Use OLAP to run the queries.")
 	cmd.Flags().BoolVar(&cfg.XUnit, "xunit", false, "Get output in an xml file instead of errors directory")
+	cmd.Flags().BoolVar(&cfg.VerifyShards, "verify-shards", false, "After DML statements, check on every shard that the rows are stored on the shard their vindex dictates.")
+	cmd.Flags().StringVar(&cfg.LatencyFile, "latency-file", "", "Time the SELECT queries on both MySQL and Vitess and store the latency percentiles in the given file, to be read by `vt summarize`.")
+	cmd.Flags().IntVar(&cfg.LatencyRuns, "latency-runs", 5, "Number of times every SELECT query is timed on each system, used with --latency-file.")
+	addFilterFlags(cmd, &ff)
 
 	return cmd
 }
 
 func tracerCmd() *cobra.Command {
 	var cfg vttester.Config
+	var ff filterFlags
 
 	cmd := &cobra.Command{
 		Use:   "trace ",
@@ -59,13 +70,19 @@ func tracerCmd() *cobra.Command {
 			if cfg.TraceFile == "" {
 				return errors.New("flag --trace-file is required when tracing")
 			}
+			filter, err := ff.filter()
+			if err != nil {
+				return err
+			}
 			cfg.Tests = args
 			cfg.Compare = false
-			return usageErr(cmd, vttester.Run(cfg))
+			cfg.Filter = filter
+			return usageErr(cmd, vttester.RunContext(cmd.Context(), cfg))
 		},
 	}
 
 	commonFlags(cmd, &cfg)
+	addFilterFlags(cmd, &ff)
 
 	return cmd
 }
@@ -78,11 +95,14 @@ func usageErr(cmd *cobra.Command, err error) error {
 }
 
 func commonFlags(cmd *cobra.Command, cfg *vttester.Config) {
-	cmd.Flags().StringVar(&cfg.LogLevel, "log-level", "error", "The log level of vt tester: info, warn, error, debug.")
+	cmd.Flags().StringVar(&cfg.LogLevel, "log-level", "", "The log level of vt tester: info, warn, error, debug. Overrides -q and -v.")
 	cmd.Flags().IntVar(&cfg.NumberOfShards, "number-of-shards", 0, "Number of shards to use for the sharded keyspace.")
 	cmd.Flags().StringVar(&cfg.VschemaFile, "vschema", "", "Disable auto-vschema by providing your own vschema file. This cannot be used with either -vtexplain-vschema or -sharded.")
 	cmd.Flags().StringVar(&cfg.VtExplainVschemaFile, "vtexplain-vschema", "", "Disable auto-vschema by providing your own vtexplain vschema file. This cannot be used with either -vschema or -sharded.")
 	cmd.Flags().StringVar(&cfg.TraceFile, "trace-file", "", "Do a vexplain trace on all queries and store the output in the given file.")
 	cmd.Flags().BoolVar(&cfg.Sharded, "sharded", false, "Run all tests on a sharded keyspace and using auto-vschema. This cannot be used with either -vschema or -vtexplain-vschema.")
 	cmd.Flags().StringVar(&cfg.BackupDir, "backup-path", "", "Restore from backup before running the tester")
+	cmd.Flags().BoolVar(&cfg.KeepCluster, "keep-cluster", false, "Leave the cluster running after the tests, and print its ID to reuse it with --use-cluster.")
+	cmd.Flags().StringVar(&cfg.ReuseCluster, "use-cluster", "", "Run the tests on the cluster with this ID, kept running by an earlier run, instead of starting one. It is torn down after the tests unless --keep-cluster is given.")
+	cmd.Flags().StringVar(&cfg.CapabilitiesFile, "capabilities", "", "Capabilities file written by `vt probe`. Unsupported features are reported before starting, or skipped, instead of failing midway.")
 }
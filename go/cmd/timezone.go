@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+//nolint:gochecknoglobals // these are instead of consts
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// timezoneFlags are the time zone the times without one are read in, or the times of a summary printed in
+type timezoneFlags struct {
+	timezone string
+	utc      bool
+}
+
+func addTimezoneFlags(cmd *cobra.Command, tf *timezoneFlags, usage string) {
+	cmd.Flags().StringVar(&tf.timezone, "timezone", "UTC", usage)
+	cmd.Flags().BoolVar(&tf.utc, "utc", false, "Use UTC, as --timezone=UTC does, so the outputs written in different regions line up")
+}
+
+func (tf *timezoneFlags) location() (*time.Location, error) {
+	if tf.utc {
+		if tf.timezone != "UTC" {
+			return nil, fmt.Errorf("--utc and --timezone=%s can't be used together", tf.timezone)
+		}
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tf.timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// parseTime reads the value of a time flag, in the time zone of the flags unless the value has its own offset
+func parseTime(flag, value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q, use a time such as 2024-10-01, 2024-10-01 08:00:00 or 2024-10-01T08:00:00+02:00", flag, value)
+}
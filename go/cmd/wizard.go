@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vitessio/vt/go/wizard"
+)
+
+func wizardCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "wizard",
+		Short:   "Interactively walks you through analysing a workload",
+		Example: "vt wizard",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return wizard.Run(cmd.Context(), os.Stdin, os.Stdout)
+		},
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// auditTimeFormat is the format of the timestamps in the Percona audit log
+const auditTimeFormat = "2006-01-02T15:04:05 MST"
+
+// AuditLogLoader reads the audit log of the Percona audit log plugin, written with audit_log_format=JSON.
+// Every line of the log is a JSON object with a single audit_record. Only the records of executed
+// statements are kept; connects, disconnects and the other events are skipped. The queries are
+// tagged with the connection, the user and the time they were executed at.
+type AuditLogLoader struct{}
+
+type auditLogLine struct {
+	AuditRecord *auditRecord `json:"audit_record"`
+}
+
+// auditRecord holds the fields of an audit record we care about
+type auditRecord struct {
+	Name         string `json:"name"`
+	Timestamp    string `json:"timestamp"`
+	ConnectionID string `json:"connection_id"`
+	SQLText      string `json:"sqltext"`
+	User         string `json:"user"`
+}
+
+var _ Loader = AuditLogLoader{}
+
+func (AuditLogLoader) Load(url string) ([]Query, error) {
+	data, err := readData(url)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parseAuditLog(data))
+}
+
+func (AuditLogLoader) Read(r io.Reader) ([]Query, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parseAuditLog(data))
+}
+
+func parseAuditLog(data []byte) ([]Query, error) {
+	var queries []Query
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry auditLogLine
+		err := json.Unmarshal(line, &entry)
+		if err != nil || entry.AuditRecord == nil {
+			return nil, fmt.Errorf("line %d is not a JSON audit record: %v", i+1, err)
+		}
+		record := entry.AuditRecord
+		if record.SQLText == "" || (record.Name != "Query" && record.Name != "Execute") {
+			continue
+		}
+
+		var ts time.Time
+		if record.Timestamp != "" {
+			ts, err = time.Parse(auditTimeFormat, record.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("line %d has an invalid timestamp: %w", i+1, err)
+			}
+			ts = ts.UTC()
+		}
+
+		// a missing or malformed connection id leaves the connection unknown
+		connID, _ := strconv.Atoi(record.ConnectionID)
+
+		queries = append(queries, Query{
+			Query:        record.SQLText,
+			Line:         i + 1,
+			Type:         typ.Query,
+			ConnectionID: connID,
+			Timestamp:    ts,
+			User:         auditUser(record.User),
+		})
+	}
+	return queries, nil
+}
+
+// auditUser returns the name of the user from the user field of an audit record,
+// which looks like "user[user] @ host [ip]"
+func auditUser(user string) string {
+	name, _, _ := strings.Cut(user, "[")
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestParseAuditLog(t *testing.T) {
+	log := `{"audit_record":{"name":"Connect","record":"1_2024-11-05T10:00:00","timestamp":"2024-11-05T10:00:00 UTC","connection_id":"7","status":0,"user":"app","priv_user":"app","host":"localhost","db":"shop"}}
+{"audit_record":{"name":"Query","record":"2_2024-11-05T10:00:01","timestamp":"2024-11-05T10:00:01 UTC","command_class":"select","connection_id":"7","status":0,"sqltext":"select * from orders where id = 1","user":"app[app] @ localhost []","host":"localhost","os_user":"","ip":"","db":"shop"}}
+
+{"audit_record":{"name":"Query","record":"3_2024-11-05T10:00:02","timestamp":"2024-11-05T10:00:02 UTC","command_class":"update","connection_id":"8","status":0,"sqltext":"update orders set state = 'paid' where id = 1","user":"billing[billing] @  [10.0.0.3]","host":"","os_user":"","ip":"10.0.0.3","db":"shop"}}
+{"audit_record":{"name":"Quit","record":"4_2024-11-05T10:00:03","timestamp":"2024-11-05T10:00:03 UTC","connection_id":"7","status":0,"user":"app","priv_user":"app","host":"localhost","db":"shop"}}
+`
+	queries, err := parseAuditLog([]byte(log))
+	require.NoError(t, err)
+	require.Equal(t, []Query{{
+		Query:        "select * from orders where id = 1",
+		Line:         2,
+		Type:         typ.Query,
+		ConnectionID: 7,
+		Timestamp:    time.Date(2024, 11, 5, 10, 0, 1, 0, time.UTC),
+		User:         "app",
+	}, {
+		Query:        "update orders set state = 'paid' where id = 1",
+		Line:         4,
+		Type:         typ.Query,
+		ConnectionID: 8,
+		Timestamp:    time.Date(2024, 11, 5, 10, 0, 2, 0, time.UTC),
+		User:         "billing",
+	}}, queries)
+
+	_, err = parseAuditLog([]byte(`{"not_an":"audit record"}`))
+	require.ErrorContains(t, err, "line 1 is not a JSON audit record")
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{name: "utf8", input: []byte("select 'café'"), want: "select 'café'"},
+		{name: "latin1", input: []byte("select 'caf\xe9'"), want: "select 'café'"},
+		{name: "cp1252", input: []byte("select '\x80 10'"), want: "select '€ 10'"},
+		{name: "mixed", input: []byte("select 'café', 'na\xefve'"), want: "select 'café', 'naïve'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, string(toUTF8(tt.input)))
+		})
+	}
+}
+
+func TestLoadLatin1Queries(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "latin1.test")
+	require.NoError(t, os.WriteFile(fileName, []byte("select * from customer where city = 'K\xf6ln';\n"), 0o600))
+
+	queries, err := MySQLTestLoader{}.Load(fileName)
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	require.Equal(t, "select * from customer where city = 'Köln';", queries[0].Query)
+	_, err = sqlparser.NewTestParser().Parse(queries[0].Query)
+	require.NoError(t, err)
+}
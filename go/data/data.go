@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -35,10 +36,131 @@ type (
 		Query     string
 		Line      int
 		Type      typ.CmdType
+
+		// ConnectionID identifies the client connection that sent the query,
+		// when the source format provides one. Zero means unknown.
+		ConnectionID int
+
+		// Timestamp is when the query was sent or started executing, when the source format provides it.
+		// The zero value means unknown.
+		Timestamp time.Time
+
+		// User is the database user that sent the query, when the source format provides it
+		User string
+
+		// Weight is the number of executions this entry stands for, for sources that aggregate
+		// identical queries, such as digests. Zero means a single execution, see Executions.
+		Weight int
+
+		// Hostgroup is the ProxySQL hostgroup the query was sent to, when known
+		Hostgroup string
+
+		// File is the log the query was read from, set when several logs are analysed together
+		File string
+
+		// Execution is what the server observed when it executed the query,
+		// when the source format provides it. Nil means unknown.
+		Execution *ExecutionInfo
+	}
+
+	// ExecutionInfo describes how vtgate executed a query in production
+	ExecutionInfo struct {
+		StmtType string
+		// PlanType is empty when the log doesn't include it
+		PlanType string
+		// ShardQueries is the number of queries vtgate sent to the shards
+		ShardQueries int
+	}
+
+	// Loader loads the queries of a workload from a file or URL, or reads them from a reader
+	Loader interface {
+		Load(url string) ([]Query, error)
+		Read(r io.Reader) ([]Query, error)
 	}
+
+	// MySQLTestLoader loads files in the mysqltest format, as used by the files in the t/ directory
+	MySQLTestLoader struct{}
 )
 
+const (
+	InputTypeMySQLTest = "mysqltest"
+	InputTypePcap      = "pcap"
+	InputTypeVtGateLog = "vtgate-log"
+	InputTypeAuditLog  = "audit-log"
+
+	InputTypeProxySQLDigest = "proxysql-digest"
+	InputTypeProxySQLEvents = "proxysql-events"
+)
+
+// InputTypes lists the supported input file formats
+var InputTypes = []string{ //nolint:gochecknoglobals // this is instead of a const
+	InputTypeMySQLTest,
+	InputTypePcap,
+	InputTypeVtGateLog,
+	InputTypeAuditLog,
+	InputTypeProxySQLDigest,
+	InputTypeProxySQLEvents,
+}
+
+// LoaderFor returns the Loader for the given input type
+func LoaderFor(inputType string) (Loader, error) {
+	switch inputType {
+	case "", InputTypeMySQLTest:
+		return MySQLTestLoader{}, nil
+	case InputTypePcap:
+		return PcapLoader{}, nil
+	case InputTypeVtGateLog:
+		return VtGateLogLoader{}, nil
+	case InputTypeAuditLog:
+		return AuditLogLoader{}, nil
+	case InputTypeProxySQLDigest:
+		return ProxySQLDigestLoader{}, nil
+	case InputTypeProxySQLEvents:
+		return ProxySQLEventsLoader{}, nil
+	default:
+		return nil, fmt.Errorf("unknown input type %q, supported types are: %s", inputType, strings.Join(InputTypes, ", "))
+	}
+}
+
+// Executions returns the number of times the query was executed
+func (q Query) Executions() int {
+	if q.Weight > 0 {
+		return q.Weight
+	}
+	return 1
+}
+
+func (MySQLTestLoader) Load(url string) ([]Query, error) {
+	return LoadQueries(url)
+}
+
+func (MySQLTestLoader) Read(r io.Reader) ([]Query, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseMySQLTest(data)
+}
+
+// readData returns the content of the file or URL, transcoded to utf8 if needed
 func readData(url string) ([]byte, error) {
+	data, err := readRawData(url)
+	if err != nil {
+		return nil, err
+	}
+	return toUTF8(data), nil
+}
+
+// readAll returns the content of r, transcoded to utf8 if needed
+func readAll(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return toUTF8(data), nil
+}
+
+func readRawData(url string) ([]byte, error) {
 	if strings.HasPrefix(url, "http") {
 		client := http.Client{}
 		res, err := client.Get(url)
@@ -59,6 +181,11 @@ func LoadQueries(url string) ([]Query, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseMySQLTest(data)
+}
+
+// parseMySQLTest parses the statements and the commands of a file in the mysqltest format
+func parseMySQLTest(data []byte) ([]Query, error) {
 	seps := bytes.Split(data, []byte("\n"))
 	queries := make([]Query, 0, len(seps))
 	newStmt := true
@@ -89,7 +216,7 @@ func LoadQueries(url string) ([]Query, error) {
 		newStmt = strings.HasSuffix(s, ";")
 	}
 
-	return ParseQueries(queries...)
+	return splitMySQLTestStatements(ParseQueries(queries...))
 }
 
 // ParseQueries parses an array of string into an array of Query object.
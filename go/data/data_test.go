@@ -14,9 +14,14 @@
 package data
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vitessio/vt/go/typ"
 )
@@ -36,3 +41,28 @@ func TestParseQueries(t *testing.T) {
 	_, err := ParseQueries(Query{Query: sql, Line: 1})
 	assert.ErrorContains(t, err, "invalid command")
 }
+
+func TestMySQLTestLoaderRead(t *testing.T) {
+	files, err := filepath.Glob("../../t/*.test")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			loaded, err := MySQLTestLoader{}.Load(file)
+			require.NoError(t, err)
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+			read, err := MySQLTestLoader{}.Read(bytes.NewReader(content))
+			require.NoError(t, err)
+			assert.Equal(t, loaded, read)
+		})
+	}
+}
+
+func TestReadTranscodes(t *testing.T) {
+	queries, err := MySQLTestLoader{}.Read(strings.NewReader("select * from t where name = 'caf\xe9';\n"))
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "select * from t where name = 'café';", queries[0].Query)
+}
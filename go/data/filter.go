@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// Filter selects the subset of the queries of a log to analyse. A query is kept when it matches
+// all the conditions that are set; the zero value keeps everything.
+// DDL statements are always kept, since the tools need them to know the schema.
+type Filter struct {
+	// Tables keeps the queries using at least one of these tables
+	Tables []string
+
+	// Regex keeps the queries whose text matches it
+	Regex *regexp.Regexp
+
+	// StatementTypes keeps the queries of these types, such as SELECT or UPDATE
+	StatementTypes []string
+
+	// Since and Until keep the queries executed from Since and before Until, when they are set.
+	// The queries without a timestamp, such as those of mysqltest files, are kept.
+	Since, Until time.Time
+}
+
+// IsEmpty returns true if the filter keeps all queries
+func (f Filter) IsEmpty() bool {
+	return len(f.Tables) == 0 && f.Regex == nil && len(f.StatementTypes) == 0 && f.Since.IsZero() && f.Until.IsZero()
+}
+
+// Apply returns the queries kept by the filter
+func (f Filter) Apply(queries []Query) []Query {
+	if f.IsEmpty() {
+		return queries
+	}
+
+	parser := sqlparser.NewTestParser()
+	return selectQueries(queries, func(q Query) bool {
+		return f.keep(parser, q)
+	})
+}
+
+// selectQueries returns the queries for which keep returns true, along with everything that is not a query.
+// Commands that apply to the next query, such as --error or --skip, are dropped along with the query they apply to.
+func selectQueries(queries []Query, keep func(Query) bool) []Query {
+	result := make([]Query, 0, len(queries))
+	var pending []Query
+	for _, q := range queries {
+		switch q.Type {
+		case typ.Skip, typ.Error, typ.VExplain:
+			pending = append(pending, q)
+			continue
+		case typ.Query:
+			if !keep(q) {
+				pending = nil
+				continue
+			}
+		}
+		result = append(result, pending...)
+		result = append(result, q)
+		pending = nil
+	}
+	return append(result, pending...)
+}
+
+func (f Filter) keep(parser *sqlparser.Parser, q Query) bool {
+	query := q.Query
+	stmtType := sqlparser.Preview(query)
+	if stmtType == sqlparser.StmtDDL {
+		return true
+	}
+	if !q.Timestamp.IsZero() && (q.Timestamp.Before(f.Since) || !f.Until.IsZero() && !q.Timestamp.Before(f.Until)) {
+		return false
+	}
+	if f.Regex != nil && !f.Regex.MatchString(query) {
+		return false
+	}
+	if len(f.StatementTypes) > 0 && !slices.ContainsFunc(f.StatementTypes, func(s string) bool {
+		return strings.EqualFold(s, stmtType.String())
+	}) {
+		return false
+	}
+	if len(f.Tables) == 0 {
+		return true
+	}
+
+	ast, err := parser.Parse(query)
+	if err != nil {
+		// we can't tell which tables the query uses
+		return false
+	}
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		tbl, ok := node.(sqlparser.TableName)
+		if ok && slices.ContainsFunc(f.Tables, func(t string) bool { return strings.EqualFold(t, tbl.Name.String()) }) {
+			found = true
+		}
+		return !found, nil
+	}, ast)
+	return found
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestFilter(t *testing.T) {
+	queries := []Query{
+		{Query: "create table t (id int)", Type: typ.Query},
+		{Query: "create table u (id int)", Type: typ.Query},
+		{Query: "select * from t where id = 1", Type: typ.Query},
+		{Query: "--error", Type: typ.Error},
+		{Query: "select * from u join v on u.id = v.id", Type: typ.Query},
+		{Query: "# a comment", Type: typ.Comment},
+		{Query: "update t set id = 2", Type: typ.Query},
+		{Query: "--error", Type: typ.Error},
+		{Query: "select * from t where", Type: typ.Query},
+	}
+	text := func(queries []Query) []string {
+		var res []string
+		for _, q := range queries {
+			res = append(res, q.Query)
+		}
+		return res
+	}
+
+	require.Equal(t, queries, Filter{}.Apply(queries))
+
+	require.Equal(t, []string{
+		"create table t (id int)",
+		"create table u (id int)",
+		"--error",
+		"select * from u join v on u.id = v.id",
+		"# a comment",
+	}, text(Filter{Tables: []string{"V"}}.Apply(queries)))
+
+	require.Equal(t, []string{
+		"create table t (id int)",
+		"create table u (id int)",
+		"# a comment",
+		"update t set id = 2",
+	}, text(Filter{StatementTypes: []string{"update"}}.Apply(queries)))
+
+	require.Equal(t, []string{
+		"create table t (id int)",
+		"create table u (id int)",
+		"select * from t where id = 1",
+		"# a comment",
+		"--error",
+		"select * from t where",
+	}, text(Filter{Regex: regexp.MustCompile(`from t\b`), StatementTypes: []string{"SELECT"}}.Apply(queries)))
+}
+
+func TestFilterTimeWindow(t *testing.T) {
+	start := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	queries := []Query{
+		{Query: "create table t (id int)", Type: typ.Query, Timestamp: start.Add(-time.Hour)},
+		{Query: "select 1", Type: typ.Query, Timestamp: start.Add(-time.Second)},
+		{Query: "select 2", Type: typ.Query, Timestamp: start},
+		{Query: "select 3", Type: typ.Query},
+		{Query: "select 4", Type: typ.Query, Timestamp: start.Add(time.Hour - time.Second)},
+		{Query: "select 5", Type: typ.Query, Timestamp: start.Add(time.Hour)},
+	}
+	var kept []string
+	for _, q := range (Filter{Since: start, Until: start.Add(time.Hour)}).Apply(queries) {
+		kept = append(kept, q.Query)
+	}
+	// the DDL and the queries without a timestamp are kept
+	require.Equal(t, []string{"create table t (id int)", "select 2", "select 3", "select 4"}, kept)
+
+	kept = nil
+	for _, q := range (Filter{Until: start}).Apply(queries) {
+		kept = append(kept, q.Query)
+	}
+	require.Equal(t, []string{"create table t (id int)", "select 1", "select 3"}, kept)
+}
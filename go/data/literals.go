@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// TruncateLiterals shortens the string and hexadecimal literals of the queries that are longer than maxLength bytes,
+// such as the blobs of logged INSERTs. A truncated literal keeps its first maxLength bytes, and is followed by a comment
+// with its length, like 'abc' /* 1048576 bytes */, so the query still parses into the same query structure.
+// The loaders have already transcoded the binary values to utf8, see toUTF8, and the literals are cut on a character.
+// The queries are returned untouched when maxLength is 0.
+func TruncateLiterals(queries []Query, maxLength int) []Query {
+	if maxLength <= 0 {
+		return queries
+	}
+	result := make([]Query, 0, len(queries))
+	for _, q := range queries {
+		if q.Type == typ.Query {
+			q.Query = truncateLiterals(q.Query, maxLength)
+		}
+		result = append(result, q)
+	}
+	return result
+}
+
+func truncateLiterals(query string, maxLength int) string {
+	var sb strings.Builder
+	last := 0 // the end of the part of the query copied to sb
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			end := closingQuote(query, i)
+			body := query[i+1 : end]
+			kept, length, truncated := body, len(body), false
+			if c == '\'' && i > 0 && (query[i-1] == 'x' || query[i-1] == 'X') && (i == 1 || !isIdentifierByte(query[i-2])) {
+				// a hexadecimal literal, X'0A1B', whose digits are kept by pairs
+				length = len(body) / 2
+				if truncated = len(body) > maxLength; truncated {
+					kept = body[:maxLength&^1]
+				}
+			} else {
+				kept, truncated = truncateLiteral(body, c, maxLength)
+			}
+			if truncated {
+				sb.WriteString(query[last : i+1])
+				sb.WriteString(kept)
+				sb.WriteByte(c)
+				fmt.Fprintf(&sb, " /* %d bytes */", length)
+				last = min(end+1, len(query))
+			}
+			i = end
+		case c == '`':
+			i = closingQuote(query, i)
+		case c == '#' || (c == '-' && strings.HasPrefix(query[i:], "-- ")):
+			if nl := strings.IndexByte(query[i:], '\n'); nl >= 0 {
+				i += nl
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+		case c == '0' && i+1 < len(query) && query[i+1] == 'x' && (i == 0 || !isIdentifierByte(query[i-1])):
+			end := i + 2
+			for end < len(query) && isHexDigit(query[end]) {
+				end++
+			}
+			if digits := end - i - 2; digits > maxLength {
+				sb.WriteString(query[last : i+2])
+				// two digits make a byte
+				sb.WriteString(query[i+2 : i+2+maxLength&^1])
+				fmt.Fprintf(&sb, " /* %d bytes */", digits/2)
+				last = end
+			}
+			i = end - 1
+		}
+	}
+	if last == 0 {
+		return query
+	}
+	sb.WriteString(query[last:])
+	return sb.String()
+}
+
+// closingQuote returns the index of the quote closing the quoted string starting at start,
+// or the length of the query when the string is not closed. The quote is escaped by doubling it,
+// or with a backslash in string literals.
+func closingQuote(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(query)
+}
+
+// truncateLiteral returns the body of a quoted string literal truncated to maxLength bytes when it is longer,
+// and whether it was truncated. The body is cut on a character, not in the middle of an escape sequence.
+func truncateLiteral(body string, quote byte, maxLength int) (string, bool) {
+	if len(body) <= maxLength {
+		return body, false
+	}
+	cut := 0
+	for cut < maxLength {
+		size := 1
+		switch {
+		case body[cut] == '\\' || body[cut] == quote:
+			// an escaped byte, or a doubled quote
+			size = 2
+		case body[cut] >= utf8.RuneSelf:
+			_, size = utf8.DecodeRuneInString(body[cut:])
+		}
+		if cut+size > maxLength {
+			break
+		}
+		cut += size
+	}
+	return body[:cut], true
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestTruncateLiterals(t *testing.T) {
+	tests := []struct {
+		query, expected string
+	}{{
+		query:    "insert into t values (1, 'abcdefghij', 'abc')",
+		expected: "insert into t values (1, 'abcdefgh' /* 10 bytes */, 'abc')",
+	}, {
+		// the escape sequences and the characters are not cut
+		query:    `insert into t values ('abcdefg\'ij', 'abcdefgé', "abcdefg""ij")`,
+		expected: `insert into t values ('abcdefg' /* 11 bytes */, 'abcdefg' /* 9 bytes */, "abcdefg" /* 11 bytes */)`,
+	}, {
+		query:    "insert into t values (X'0123456789', 0x0123456789, 0x01)",
+		expected: "insert into t values (X'01234567' /* 5 bytes */, 0x01234567 /* 5 bytes */, 0x01)",
+	}, {
+		// the quotes of the identifiers and the comments are not literals
+		query:    "select `abcdefghij` from t /* 'abcdefghij' */ where a = 'abcdefghij' -- 'abcdefghij'",
+		expected: "select `abcdefghij` from t /* 'abcdefghij' */ where a = 'abcdefgh' /* 10 bytes */ -- 'abcdefghij'",
+	}, {
+		query:    "select a0x0123456789 from t",
+		expected: "select a0x0123456789 from t",
+	}}
+
+	parser := sqlparser.NewTestParser()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			queries := TruncateLiterals([]Query{{Query: tt.query, Type: typ.Query}}, 8)
+			require.Equal(t, tt.expected, queries[0].Query)
+			_, err := parser.Parse(queries[0].Query)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTruncateLiteralsDisabled(t *testing.T) {
+	query := "insert into t values ('" + strings.Repeat("x", 100000) + "')"
+	queries := TruncateLiterals([]Query{{Query: query, Type: typ.Query}}, 0)
+	require.Equal(t, query, queries[0].Query)
+}
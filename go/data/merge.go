@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"time"
+)
+
+// MergeByTimestamp interleaves the queries of several logs, such as the logs of the replicas of a database,
+// in the order they were executed. The order of the queries within each log is kept: entries without
+// a timestamp, such as the commands of a test file, stay right after the entry that precedes them.
+func MergeByTimestamp(logs ...[]Query) []Query {
+	var total int
+	for _, log := range logs {
+		total += len(log)
+	}
+	result := make([]Query, 0, total)
+
+	next := make([]int, len(logs))
+	// lastSeen is the timestamp of the last entry of each log that had one
+	lastSeen := make([]time.Time, len(logs))
+	for len(result) < total {
+		pick := -1
+		var pickTime time.Time
+		for i, log := range logs {
+			if next[i] == len(log) {
+				continue
+			}
+			ts := log[next[i]].Timestamp
+			if ts.IsZero() {
+				ts = lastSeen[i]
+			}
+			if pick == -1 || ts.Before(pickTime) {
+				pick, pickTime = i, ts
+			}
+		}
+		q := logs[pick][next[pick]]
+		if !q.Timestamp.IsZero() {
+			lastSeen[pick] = q.Timestamp
+		}
+		result = append(result, q)
+		next[pick]++
+	}
+	return result
+}
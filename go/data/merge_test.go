@@ -0,0 +1,41 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeByTimestamp(t *testing.T) {
+	start := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	at := func(query string, sec int) Query {
+		return Query{Query: query, Timestamp: start.Add(time.Duration(sec) * time.Second)}
+	}
+
+	replica1 := []Query{at("a1", 1), at("a3", 3), {Query: "a3 comment"}, at("a6", 6)}
+	replica2 := []Query{{Query: "b0 comment"}, at("b2", 2), at("b4", 4), at("b5", 5)}
+	var texts []string
+	for _, q := range MergeByTimestamp(replica1, replica2) {
+		texts = append(texts, q.Query)
+	}
+	require.Equal(t, []string{"b0 comment", "a1", "b2", "a3", "a3 comment", "b4", "b5", "a6"}, texts)
+
+	require.Empty(t, MergeByTimestamp())
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// digestListMarkers are the ways digest tools (performance_schema, pt-query-digest, ProxySQL)
+// write a list of values that has been collapsed into one placeholder
+var digestListMarkers = strings.NewReplacer( //nolint:gochecknoglobals // this is instead of a const
+	"(...)", "(?)",
+	"?+", "?",
+	"?,...", "?",
+	"?, ...", "?",
+)
+
+// NormalizePlaceholders rewrites the queries so that literal values and placeholders end up looking the same.
+// Logs coming from digest tools contain `?` placeholders instead of literals, which parse into untyped bind
+// variables, while logs with literals get typed bind variables when normalized. In this mode, literals and
+// placeholders are all replaced by sequentially numbered bind variables (:v1, :v2, ...) and IN lists are
+// collapsed to a single list argument, so both forms of a query aggregate into the same query structure.
+// Queries that can't be parsed are returned untouched.
+func NormalizePlaceholders(queries []Query) []Query {
+	parser := sqlparser.NewTestParser()
+	result := make([]Query, 0, len(queries))
+	for _, q := range queries {
+		if q.Type == typ.Query {
+			q.Query = normalizePlaceholders(parser, q.Query)
+		}
+		result = append(result, q)
+	}
+	return result
+}
+
+func normalizePlaceholders(parser *sqlparser.Parser, query string) string {
+	ast, err := parser.Parse(query)
+	if err != nil {
+		ast, err = parser.Parse(digestListMarkers.Replace(query))
+		if err != nil {
+			return query
+		}
+	}
+
+	switch ast.(type) {
+	case sqlparser.SelectStatement, *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete:
+	default:
+		// DDL and other statements keep their literals
+		return query
+	}
+
+	var count int
+	nextArg := func() string {
+		count++
+		return "v" + strconv.Itoa(count)
+	}
+
+	ast = sqlparser.Rewrite(ast, func(cursor *sqlparser.Cursor) bool {
+		switch node := cursor.Node().(type) {
+		case *sqlparser.ComparisonExpr:
+			if node.Operator != sqlparser.InOp && node.Operator != sqlparser.NotInOp {
+				return true
+			}
+			tuple, ok := node.Right.(sqlparser.ValTuple)
+			if !ok || !onlyValues(tuple) {
+				return true
+			}
+			node.Right = sqlparser.ListArg(nextArg())
+		case *sqlparser.Literal, *sqlparser.Argument:
+			switch cursor.Parent().(type) {
+			case *sqlparser.Order, *sqlparser.GroupBy:
+				// ORDER BY 1 and GROUP BY 1 refer to columns, not values
+				return true
+			}
+			cursor.Replace(sqlparser.NewArgument(nextArg()))
+		}
+		return true
+	}, nil).(sqlparser.Statement)
+
+	return sqlparser.String(ast)
+}
+
+func onlyValues(tuple sqlparser.ValTuple) bool {
+	for _, expr := range tuple {
+		switch expr.(type) {
+		case *sqlparser.Literal, *sqlparser.Argument:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestNormalizePlaceholders(t *testing.T) {
+	queries := NormalizePlaceholders([]Query{
+		{Query: "select * from t where id = 1 and x in (1, 2, 3) order by 1 limit 10", Type: typ.Query},
+		{Query: "select * from t where id = ? and x in (?, ?) order by 1 limit ?", Type: typ.Query},
+		{Query: "SELECT * FROM t WHERE id = ? AND x IN (...) ORDER BY 1 LIMIT ?", Type: typ.Query},
+		{Query: "create table t (id int default 1)", Type: typ.Query},
+		{Query: "this is not sql", Type: typ.Query},
+		{Query: "select 1", Type: typ.Comment},
+	})
+
+	expected := "select * from t where id = :v1 and x in ::v2 order by 1 asc limit :v3"
+	require.Equal(t, expected, queries[0].Query)
+	require.Equal(t, expected, queries[1].Query)
+	require.Equal(t, expected, queries[2].Query)
+	require.Equal(t, "create table t (id int default 1)", queries[3].Query)
+	require.Equal(t, "this is not sql", queries[4].Query)
+	require.Equal(t, "select 1", queries[5].Query)
+}
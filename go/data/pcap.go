@@ -0,0 +1,442 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// PcapLoader reads a tcpdump/libpcap capture of MySQL protocol traffic and reconstructs
+// the stream of queries sent by the clients. Every query is tagged with the TCP connection
+// it was sent on, so transaction boundaries can be followed per connection.
+// The Line of each query is the number of the captured packet that completed it,
+// and its Timestamp is the capture time of that packet.
+// Encrypted (TLS) connections and the pcapng format are not supported.
+type PcapLoader struct {
+	// Port is the TCP port the MySQL server listens on. Defaults to 3306.
+	Port int
+}
+
+const (
+	defaultMySQLPort = 3306
+
+	pcapMagicMicros = 0xa1b2c3d4
+	pcapMagicNanos  = 0xa1b23c4d
+	pcapngMagic     = 0x0a0d0d0a
+
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+	linkTypeSLL2     = 276
+
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+
+	ipProtoTCP = 6
+
+	tcpFlagFin = 0x01
+	tcpFlagSyn = 0x02
+	tcpFlagRst = 0x04
+
+	comQuit   = 0x01
+	comInitDB = 0x02
+	comQuery  = 0x03
+
+	clientSSL             = 1 << 11
+	clientQueryAttributes = 1 << 27
+
+	maxMySQLPacketLength = 0xffffff
+)
+
+var _ Loader = PcapLoader{}
+
+func (l PcapLoader) Load(url string) ([]Query, error) {
+	// captures are binary, only the text of the queries is transcoded
+	data, err := readRawData(url)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parsePcap(data, l.port()))
+}
+
+func (l PcapLoader) Read(r io.Reader) ([]Query, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parsePcap(data, l.port()))
+}
+
+// port is the port of the MySQL server in the capture
+func (l PcapLoader) port() uint16 {
+	if l.Port == 0 {
+		return defaultMySQLPort
+	}
+	return uint16(l.Port)
+}
+
+type (
+	// tcpStream holds the reassembly state of the client to server direction of a single MySQL connection
+	tcpStream struct {
+		id       int
+		nextSeq  uint32
+		seqKnown bool
+		buf      []byte
+
+		// large holds the payload of a MySQL packet that was split over several protocol packets
+		large []byte
+
+		handshakeSeen bool
+		encrypted     bool
+		capabilities  uint32
+	}
+
+	pcapReader struct {
+		order    binary.ByteOrder
+		nanos    bool
+		linkType uint32
+		port     uint16
+
+		streams     map[string]*tcpStream
+		nextConnID  int
+		queries     []Query
+		packetCount int
+		packetTime  time.Time
+	}
+)
+
+func parsePcap(data []byte, port uint16) ([]Query, error) {
+	if len(data) < 24 {
+		return nil, errors.New("pcap file too short")
+	}
+
+	r := &pcapReader{
+		port:    port,
+		streams: make(map[string]*tcpStream),
+	}
+
+	switch magic := binary.LittleEndian.Uint32(data); magic {
+	case pcapMagicMicros, pcapMagicNanos:
+		r.order = binary.LittleEndian
+		r.nanos = magic == pcapMagicNanos
+	case pcapngMagic:
+		return nil, errors.New("pcapng files are not supported, convert the capture with `editcap -F pcap`")
+	default:
+		be := binary.BigEndian.Uint32(data)
+		if be != pcapMagicMicros && be != pcapMagicNanos {
+			return nil, fmt.Errorf("not a pcap file: unknown magic number %#x", magic)
+		}
+		r.order = binary.BigEndian
+		r.nanos = be == pcapMagicNanos
+	}
+	r.linkType = r.order.Uint32(data[20:24])
+
+	data = data[24:]
+	for len(data) >= 16 {
+		inclLen := int(r.order.Uint32(data[8:12]))
+		if len(data) < 16+inclLen {
+			log.Warnf("pcap file truncated after %d packets", r.packetCount)
+			break
+		}
+		r.packetCount++
+		r.packetTime = r.timestamp(data[0:8])
+		r.handleFrame(data[16 : 16+inclLen])
+		data = data[16+inclLen:]
+	}
+
+	return r.queries, nil
+}
+
+// timestamp reads the capture time of a packet from its record header
+func (r *pcapReader) timestamp(b []byte) time.Time {
+	sec := int64(r.order.Uint32(b[0:4]))
+	frac := int64(r.order.Uint32(b[4:8]))
+	if !r.nanos {
+		frac *= int64(time.Microsecond)
+	}
+	return time.Unix(sec, frac).UTC()
+}
+
+func (r *pcapReader) handleFrame(frame []byte) {
+	var etherType uint16
+	switch r.linkType {
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return
+		}
+		etherType = binary.BigEndian.Uint16(frame[12:14])
+		frame = frame[14:]
+		for etherType == etherTypeVLAN && len(frame) >= 4 {
+			etherType = binary.BigEndian.Uint16(frame[2:4])
+			frame = frame[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return
+		}
+		etherType = binary.BigEndian.Uint16(frame[14:16])
+		frame = frame[16:]
+	case linkTypeSLL2:
+		if len(frame) < 20 {
+			return
+		}
+		etherType = binary.BigEndian.Uint16(frame[0:2])
+		frame = frame[20:]
+	case linkTypeNull:
+		if len(frame) < 4 {
+			return
+		}
+		// the address family is stored in the byte order of the capturing host
+		family := r.order.Uint32(frame[0:4])
+		if family == 2 {
+			etherType = etherTypeIPv4
+		} else {
+			etherType = etherTypeIPv6
+		}
+		frame = frame[4:]
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		if len(frame) == 0 {
+			return
+		}
+		if frame[0]>>4 == 4 {
+			etherType = etherTypeIPv4
+		} else {
+			etherType = etherTypeIPv6
+		}
+	default:
+		return
+	}
+
+	switch etherType {
+	case etherTypeIPv4:
+		r.handleIPv4(frame)
+	case etherTypeIPv6:
+		r.handleIPv6(frame)
+	}
+}
+
+func (r *pcapReader) handleIPv4(packet []byte) {
+	if len(packet) < 20 {
+		return
+	}
+	headerLen := int(packet[0]&0x0f) * 4
+	totalLen := int(binary.BigEndian.Uint16(packet[2:4]))
+	fragment := binary.BigEndian.Uint16(packet[6:8]) & 0x1fff
+	if packet[9] != ipProtoTCP || fragment != 0 || headerLen < 20 || totalLen < headerLen || len(packet) < totalLen {
+		return
+	}
+	r.handleTCP(net.IP(packet[12:16]), net.IP(packet[16:20]), packet[headerLen:totalLen])
+}
+
+func (r *pcapReader) handleIPv6(packet []byte) {
+	if len(packet) < 40 {
+		return
+	}
+	payloadLen := int(binary.BigEndian.Uint16(packet[4:6]))
+	// extension headers are not supported
+	if packet[6] != ipProtoTCP || len(packet) < 40+payloadLen {
+		return
+	}
+	r.handleTCP(net.IP(packet[8:24]), net.IP(packet[24:40]), packet[40:40+payloadLen])
+}
+
+func (r *pcapReader) handleTCP(src, dst net.IP, segment []byte) {
+	if len(segment) < 20 {
+		return
+	}
+	srcPort := binary.BigEndian.Uint16(segment[0:2])
+	dstPort := binary.BigEndian.Uint16(segment[2:4])
+	if dstPort != r.port {
+		// we only care about what the clients send to the server
+		return
+	}
+	seq := binary.BigEndian.Uint32(segment[4:8])
+	dataOffset := int(segment[12]>>4) * 4
+	flags := segment[13]
+	if dataOffset < 20 || len(segment) < dataOffset {
+		return
+	}
+
+	key := net.JoinHostPort(src.String(), strconv.Itoa(int(srcPort))) + "->" + net.JoinHostPort(dst.String(), strconv.Itoa(int(dstPort)))
+	stream, found := r.streams[key]
+	if !found || flags&tcpFlagSyn != 0 {
+		r.nextConnID++
+		stream = &tcpStream{id: r.nextConnID}
+		r.streams[key] = stream
+	}
+
+	if flags&tcpFlagSyn != 0 {
+		stream.nextSeq = seq + 1
+		stream.seqKnown = true
+		return
+	}
+
+	r.handleSegment(stream, seq, segment[dataOffset:])
+
+	if flags&(tcpFlagFin|tcpFlagRst) != 0 {
+		delete(r.streams, key)
+	}
+}
+
+func (r *pcapReader) handleSegment(stream *tcpStream, seq uint32, payload []byte) {
+	if len(payload) == 0 || stream.encrypted {
+		return
+	}
+
+	if stream.seqKnown {
+		switch diff := int32(seq - stream.nextSeq); {
+		case diff < 0:
+			// retransmission, only keep the part of the payload we haven't seen yet
+			if -int(diff) >= len(payload) {
+				return
+			}
+			payload = payload[-diff:]
+		case diff > 0:
+			// we missed some data, so what we have buffered can't be trusted anymore.
+			// we start over and hope this segment starts a new MySQL packet.
+			log.Warnf("pcap: lost %d bytes on connection %d around packet %d", diff, stream.id, r.packetCount)
+			stream.buf = nil
+			stream.large = nil
+		}
+	} else {
+		stream.seqKnown = true
+	}
+	stream.nextSeq = seq + uint32(len(payload))
+	stream.buf = append(stream.buf, payload...)
+
+	for len(stream.buf) >= 4 {
+		length := int(stream.buf[0]) | int(stream.buf[1])<<8 | int(stream.buf[2])<<16
+		seqID := stream.buf[3]
+		if len(stream.buf) < 4+length {
+			return
+		}
+		packet := stream.buf[4 : 4+length]
+		stream.buf = stream.buf[4+length:]
+
+		if length == maxMySQLPacketLength || stream.large != nil {
+			stream.large = append(stream.large, packet...)
+			if length == maxMySQLPacketLength {
+				continue
+			}
+			packet = stream.large
+			stream.large = nil
+		}
+		r.handleMySQLPacket(stream, seqID, packet)
+	}
+	if len(stream.buf) == 0 {
+		stream.buf = nil
+	}
+}
+
+func (r *pcapReader) handleMySQLPacket(stream *tcpStream, seqID byte, packet []byte) {
+	if seqID == 1 && !stream.handshakeSeen && len(packet) >= 4 {
+		// the handshake response (or SSL request) is the first packet a client sends
+		stream.handshakeSeen = true
+		stream.capabilities = binary.LittleEndian.Uint32(packet[0:4])
+		if stream.capabilities&clientSSL != 0 {
+			log.Warnf("pcap: connection %d uses TLS and will be ignored", stream.id)
+			stream.encrypted = true
+		}
+		return
+	}
+
+	// commands always start a new sequence
+	if seqID != 0 || len(packet) == 0 {
+		return
+	}
+
+	switch packet[0] {
+	case comQuery:
+		query, ok := comQueryText(packet[1:], stream.capabilities)
+		if !ok {
+			log.Warnf("pcap: skipping query with query attributes on connection %d at packet %d", stream.id, r.packetCount)
+			return
+		}
+		r.addQuery(stream, query)
+	case comInitDB:
+		r.addQuery(stream, fmt.Sprintf("use `%s`", packet[1:]))
+	case comQuit:
+		stream.buf = nil
+	}
+}
+
+// comQueryText returns the SQL of a COM_QUERY packet. If the client negotiated query attributes,
+// the SQL is preceded by the attribute counts; we only support queries without attributes.
+func comQueryText(payload []byte, capabilities uint32) (string, bool) {
+	if capabilities&clientQueryAttributes == 0 {
+		return string(toUTF8(payload)), true
+	}
+	paramCount, n := readLenEncInt(payload)
+	if n == 0 || paramCount != 0 {
+		return "", false
+	}
+	_, m := readLenEncInt(payload[n:])
+	if m == 0 {
+		return "", false
+	}
+	return string(toUTF8(payload[n+m:])), true
+}
+
+// readLenEncInt reads a MySQL length encoded integer, returning the value and the number of bytes read
+func readLenEncInt(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	switch b[0] {
+	case 0xfc:
+		if len(b) < 3 {
+			return 0, 0
+		}
+		return uint64(binary.LittleEndian.Uint16(b[1:3])), 3
+	case 0xfd:
+		if len(b) < 4 {
+			return 0, 0
+		}
+		return uint64(b[1]) | uint64(b[2])<<8 | uint64(b[3])<<16, 4
+	case 0xfe:
+		if len(b) < 9 {
+			return 0, 0
+		}
+		return binary.LittleEndian.Uint64(b[1:9]), 9
+	default:
+		return uint64(b[0]), 1
+	}
+}
+
+func (r *pcapReader) addQuery(stream *tcpStream, query string) {
+	r.queries = append(r.queries, Query{
+		Query:        query,
+		Line:         r.packetCount,
+		Type:         typ.Query,
+		ConnectionID: stream.id,
+		Timestamp:    r.packetTime,
+	})
+}
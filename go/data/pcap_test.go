@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// pcapBuilder writes a little endian, ethernet pcap file with IPv4/TCP packets
+type pcapBuilder struct {
+	buf     bytes.Buffer
+	seqs    map[uint16]uint32
+	packets int
+}
+
+const pcapStartTime = 1700000000
+
+// packetTime is the capture time the builder gives to the nth packet
+func packetTime(n int) time.Time {
+	return time.Unix(pcapStartTime+int64(n), 250_000_000).UTC()
+}
+
+func newPcapBuilder() *pcapBuilder {
+	b := &pcapBuilder{seqs: make(map[uint16]uint32)}
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], pcapMagicMicros)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:], linkTypeEthernet)
+	b.buf.Write(hdr)
+	return b
+}
+
+// tcp writes a segment from the client port to the mysql port, using and advancing the sequence number of the connection
+func (b *pcapBuilder) tcp(clientPort uint16, flags byte, payload []byte) {
+	seq := b.seqs[clientPort]
+	b.segment(clientPort, 3306, seq, flags, payload)
+	if flags&tcpFlagSyn != 0 {
+		seq++
+	}
+	b.seqs[clientPort] = seq + uint32(len(payload))
+}
+
+func (b *pcapBuilder) segment(srcPort, dstPort uint16, seq uint32, flags byte, payload []byte) {
+	tcp := make([]byte, 20)
+	binary.BigEndian.PutUint16(tcp[0:], srcPort)
+	binary.BigEndian.PutUint16(tcp[2:], dstPort)
+	binary.BigEndian.PutUint32(tcp[4:], seq)
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+	tcp = append(tcp, payload...)
+
+	ip := make([]byte, 20)
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(tcp)))
+	ip[9] = ipProtoTCP
+	copy(ip[12:], []byte{10, 0, 0, 1})
+	copy(ip[16:], []byte{10, 0, 0, 2})
+	ip = append(ip, tcp...)
+
+	frame := make([]byte, 14)
+	binary.BigEndian.PutUint16(frame[12:], etherTypeIPv4)
+	frame = append(frame, ip...)
+
+	b.packets++
+	rec := make([]byte, 16)
+	binary.LittleEndian.PutUint32(rec[0:], uint32(pcapStartTime+b.packets))
+	binary.LittleEndian.PutUint32(rec[4:], 250_000)
+	binary.LittleEndian.PutUint32(rec[8:], uint32(len(frame)))
+	binary.LittleEndian.PutUint32(rec[12:], uint32(len(frame)))
+	b.buf.Write(rec)
+	b.buf.Write(frame)
+}
+
+func mysqlPacket(seqID byte, payload []byte) []byte {
+	p := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seqID}
+	return append(p, payload...)
+}
+
+func comQueryPacket(query string) []byte {
+	return mysqlPacket(0, append([]byte{comQuery}, query...))
+}
+
+func TestLoadPcap(t *testing.T) {
+	b := newPcapBuilder()
+	handshake := mysqlPacket(1, []byte{0x0d, 0xa2, 0x00, 0x00, 0, 0, 0, 0})
+
+	b.tcp(5000, tcpFlagSyn, nil)                                       // 1
+	b.tcp(5001, tcpFlagSyn, nil)                                       // 2
+	b.tcp(5000, 0, handshake)                                          // 3
+	b.tcp(5001, 0, handshake)                                          // 4
+	b.tcp(5000, 0, mysqlPacket(0, append([]byte{comInitDB}, "ks"...))) // 5
+	b.tcp(5000, 0, comQueryPacket("begin"))                            // 6
+	b.tcp(5001, 0, comQueryPacket("select 1 from dual"))               // 7
+
+	// a query split over two TCP segments, with the first segment retransmitted
+	split := comQueryPacket("update t set a = 1 where id = 2")
+	b.tcp(5000, 0, split[:10]) // 8
+	b.segment(5000, 3306, b.seqs[5000]-10, 0, split[:10])
+	b.tcp(5000, 0, split[10:]) // 10
+
+	// the server response should be ignored
+	b.segment(3306, 5000, 0, 0, mysqlPacket(1, []byte{0, 0, 0, 2, 0, 0, 0}))
+	b.tcp(5000, 0, comQueryPacket("commit"))                 // 12
+	b.tcp(5000, tcpFlagFin, mysqlPacket(0, []byte{comQuit})) // 13
+
+	queries, err := parsePcap(b.buf.Bytes(), 3306)
+	require.NoError(t, err)
+	require.Equal(t, []Query{
+		{Query: "use `ks`", Line: 5, Type: typ.Query, Timestamp: packetTime(5), ConnectionID: 1},
+		{Query: "begin", Line: 6, Type: typ.Query, Timestamp: packetTime(6), ConnectionID: 1},
+		{Query: "select 1 from dual", Line: 7, Type: typ.Query, Timestamp: packetTime(7), ConnectionID: 2},
+		{Query: "update t set a = 1 where id = 2", Line: 10, Type: typ.Query, Timestamp: packetTime(10), ConnectionID: 1},
+		{Query: "commit", Line: 12, Type: typ.Query, Timestamp: packetTime(12), ConnectionID: 1},
+	}, queries)
+}
+
+func TestLoadPcapFile(t *testing.T) {
+	b := newPcapBuilder()
+	b.tcp(5000, tcpFlagSyn, nil)
+	b.tcp(5000, 0, comQueryPacket("select * from t where city = 'K\xf6ln'"))
+
+	fileName := filepath.Join(t.TempDir(), "capture.pcap")
+	require.NoError(t, os.WriteFile(fileName, b.buf.Bytes(), 0o600))
+
+	queries, err := PcapLoader{}.Load(fileName)
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	require.Equal(t, "select * from t where city = 'Köln'", queries[0].Query)
+}
+
+func TestLoadPcapErrors(t *testing.T) {
+	_, err := parsePcap([]byte{0x0a, 0x0d, 0x0d, 0x0a, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 3306)
+	require.ErrorContains(t, err, "pcapng files are not supported")
+
+	_, err = parsePcap(make([]byte, 24), 3306)
+	require.ErrorContains(t, err, "not a pcap file")
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+type (
+	// ProxySQLDigestLoader reads an export of ProxySQL's stats_mysql_query_digest table, as written by
+	// `mysql -B` (tab separated) or as CSV, with a header line naming the columns. Every row stands for
+	// count_star executions of the digest on a hostgroup.
+	ProxySQLDigestLoader struct{}
+
+	// ProxySQLEventsLoader reads the events log of ProxySQL written with eventslog_format=2,
+	// one JSON object per executed query. The binary format is not supported.
+	ProxySQLEventsLoader struct{}
+
+	proxySQLEvent struct {
+		Event       string `json:"event"`
+		Query       string `json:"query"`
+		Hostgroup   *int   `json:"hostgroup_id"`
+		ThreadID    int    `json:"thread_id"`
+		Username    string `json:"username"`
+		StartTimeUS int64  `json:"starttime_timestamp_us"`
+	}
+)
+
+var (
+	_ Loader = ProxySQLDigestLoader{}
+	_ Loader = ProxySQLEventsLoader{}
+)
+
+func (ProxySQLDigestLoader) Load(url string) ([]Query, error) {
+	data, err := readData(url)
+	if err != nil {
+		return nil, err
+	}
+	return parseProxySQLDigest(data)
+}
+
+func (ProxySQLDigestLoader) Read(r io.Reader) ([]Query, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseProxySQLDigest(data)
+}
+
+func (ProxySQLEventsLoader) Load(url string) ([]Query, error) {
+	data, err := readData(url)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parseProxySQLEvents(data))
+}
+
+func (ProxySQLEventsLoader) Read(r io.Reader) ([]Query, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parseProxySQLEvents(data))
+}
+
+func parseProxySQLDigest(data []byte) ([]Query, error) {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.ContainsRune(header, '\t') {
+		r.Comma = '\t'
+	}
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	columns, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the header of the digest export: %w", err)
+	}
+	index := make(map[string]int, len(columns))
+	for i, col := range columns {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	textIdx, found := index["digest_text"]
+	if !found {
+		return nil, errors.New("the digest export has no digest_text column")
+	}
+	field := func(record []string, name string) string {
+		i, found := index[name]
+		if !found || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var queries []Query
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading line %d of the digest export: %w", line, err)
+		}
+		if textIdx >= len(record) || strings.TrimSpace(record[textIdx]) == "" {
+			continue
+		}
+
+		count := 1
+		if s := field(record, "count_star"); s != "" {
+			count, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d has an invalid count_star: %w", line, err)
+			}
+		}
+		var firstSeen time.Time
+		if s := field(record, "first_seen"); s != "" {
+			sec, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d has an invalid first_seen: %w", line, err)
+			}
+			firstSeen = time.Unix(sec, 0).UTC()
+		}
+
+		queries = append(queries, Query{
+			// digests collapse the lists of values to `?,...`, which doesn't parse
+			Query:     digestListMarkers.Replace(strings.TrimSpace(record[textIdx])),
+			Line:      line,
+			Type:      typ.Query,
+			Weight:    count,
+			Timestamp: firstSeen,
+			User:      field(record, "username"),
+			Hostgroup: field(record, "hostgroup"),
+		})
+	}
+	return queries, nil
+}
+
+func parseProxySQLEvents(data []byte) ([]Query, error) {
+	var queries []Query
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var event proxySQLEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("line %d is not a ProxySQL JSON event, only eventslog_format=2 is supported: %w", i+1, err)
+		}
+		if event.Query == "" || (event.Event != "" && event.Event != "COM_QUERY") {
+			continue
+		}
+
+		q := Query{
+			Query:        event.Query,
+			Line:         i + 1,
+			Type:         typ.Query,
+			ConnectionID: event.ThreadID,
+			User:         event.Username,
+		}
+		if event.StartTimeUS > 0 {
+			q.Timestamp = time.UnixMicro(event.StartTimeUS).UTC()
+		}
+		if event.Hostgroup != nil {
+			q.Hostgroup = strconv.Itoa(*event.Hostgroup)
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
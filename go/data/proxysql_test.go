@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestParseProxySQLDigest(t *testing.T) {
+	export := "hostgroup\tschemaname\tusername\tclient_address\tdigest\tdigest_text\tcount_star\tfirst_seen\tlast_seen\tsum_time\n" +
+		"10\tshop\tapp\t\t0x226CD90D52A2BA0B\tselect * from orders where id = ?\t1200\t1730800000\t1730803600\t5400\n" +
+		"20\tshop\tbilling\t\t0x3B5A9F4C1A0E3D21\tupdate orders set state = ? where id in (?,...)\t37\t1730800100\t1730803500\t900\n"
+	queries, err := parseProxySQLDigest([]byte(export))
+	require.NoError(t, err)
+	require.Equal(t, []Query{{
+		Query:     "select * from orders where id = ?",
+		Line:      2,
+		Type:      typ.Query,
+		Weight:    1200,
+		Timestamp: time.Unix(1730800000, 0).UTC(),
+		User:      "app",
+		Hostgroup: "10",
+	}, {
+		Query:     "update orders set state = ? where id in (?)",
+		Line:      3,
+		Type:      typ.Query,
+		Weight:    37,
+		Timestamp: time.Unix(1730800100, 0).UTC(),
+		User:      "billing",
+		Hostgroup: "20",
+	}}, queries)
+
+	csvExport := "hostgroup,digest_text,count_star\n" +
+		`0,"select a, b from t where c = ?",3` + "\n"
+	queries, err = parseProxySQLDigest([]byte(csvExport))
+	require.NoError(t, err)
+	require.Equal(t, []Query{{
+		Query:     "select a, b from t where c = ?",
+		Line:      2,
+		Type:      typ.Query,
+		Weight:    3,
+		Hostgroup: "0",
+	}}, queries)
+
+	_, err = parseProxySQLDigest([]byte("hostgroup,count_star\n0,3\n"))
+	require.ErrorContains(t, err, "no digest_text column")
+}
+
+func TestParseProxySQLEvents(t *testing.T) {
+	log := `{"client":"10.0.0.5:39954","digest":"0x226CD90D52A2BA0B","duration_us":140,"endtime":"2024-11-05 10:00:01.000140","endtime_timestamp_us":1730800801000140,"event":"COM_QUERY","hostgroup_id":10,"query":"select * from orders where id = 1","rows_affected":0,"rows_sent":1,"schemaname":"shop","server":"10.0.0.10:3306","starttime":"2024-11-05 10:00:01.000000","starttime_timestamp_us":1730800801000000,"thread_id":9,"username":"app"}
+
+{"client":"10.0.0.5:39954","event":"COM_STMT_PREPARE","hostgroup_id":10,"query":"select * from orders where id = ?","thread_id":9,"username":"app"}
+{"client":"10.0.0.6:40120","digest":"0x3B5A9F4C1A0E3D21","duration_us":380,"event":"COM_QUERY","hostgroup_id":0,"query":"update orders set state = 'paid' where id = 1","schemaname":"shop","starttime_timestamp_us":1730800802000000,"thread_id":12,"username":"billing"}
+`
+	queries, err := parseProxySQLEvents([]byte(log))
+	require.NoError(t, err)
+	require.Equal(t, []Query{{
+		Query:        "select * from orders where id = 1",
+		Line:         1,
+		Type:         typ.Query,
+		ConnectionID: 9,
+		Timestamp:    time.Date(2024, 11, 5, 10, 0, 1, 0, time.UTC),
+		User:         "app",
+		Hostgroup:    "10",
+	}, {
+		Query:        "update orders set state = 'paid' where id = 1",
+		Line:         4,
+		Type:         typ.Query,
+		ConnectionID: 12,
+		Timestamp:    time.Date(2024, 11, 5, 10, 0, 2, 0, time.UTC),
+		User:         "billing",
+		Hostgroup:    "0",
+	}}, queries)
+
+	_, err = parseProxySQLEvents([]byte("\x00\x01binary"))
+	require.ErrorContains(t, err, "only eventslog_format=2 is supported")
+}
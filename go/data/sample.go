@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// Sample selects a random sample of the queries of a log, so very large logs can be analysed quickly.
+// The sample is the same every time the same log is sampled with the same options.
+// DDL statements are always kept, since the tools need them to know the schema.
+type Sample struct {
+	// Rate is the fraction of the queries to keep, between 0 and 1. Zero keeps all queries.
+	Rate float64
+
+	// MaxQueries stops the sample after this many queries. Zero means no limit.
+	MaxQueries int
+}
+
+// sampleSeed makes the samples reproducible
+const sampleSeed = 0x5eed
+
+// IsEmpty returns true if the sample keeps all queries
+func (s Sample) IsEmpty() bool {
+	return (s.Rate == 0 || s.Rate == 1) && s.MaxQueries == 0
+}
+
+// Validate returns an error if the options are out of range
+func (s Sample) Validate() error {
+	if s.Rate < 0 || s.Rate > 1 {
+		return fmt.Errorf("sample rate must be between 0 and 1, got %v", s.Rate)
+	}
+	if s.MaxQueries < 0 {
+		return fmt.Errorf("max queries must be positive, got %d", s.MaxQueries)
+	}
+	return nil
+}
+
+// Scale returns the factor to multiply the counts of a sample with to estimate the counts of the whole log
+func (s Sample) Scale() float64 {
+	if s.Rate == 0 {
+		return 1
+	}
+	return 1 / s.Rate
+}
+
+// Apply returns the sampled queries
+func (s Sample) Apply(queries []Query) []Query {
+	if s.IsEmpty() {
+		return queries
+	}
+
+	rnd := rand.New(rand.NewPCG(sampleSeed, sampleSeed)) //nolint:gosec // the sample doesn't need a secure random source
+	var kept int
+	return selectQueries(queries, func(q Query) bool {
+		if sqlparser.Preview(q.Query) == sqlparser.StmtDDL {
+			return true
+		}
+		if s.MaxQueries > 0 && kept >= s.MaxQueries {
+			return false
+		}
+		if s.Rate > 0 && rnd.Float64() >= s.Rate {
+			return false
+		}
+		kept++
+		return true
+	})
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestSample(t *testing.T) {
+	queries := []Query{{Query: "create table t (id int)", Type: typ.Query}}
+	for i := range 1000 {
+		queries = append(queries, Query{Query: fmt.Sprintf("select * from t where id = %d", i), Type: typ.Query})
+	}
+
+	require.Equal(t, queries, Sample{}.Apply(queries))
+
+	sampled := Sample{Rate: 0.1}.Apply(queries)
+	require.Equal(t, queries[0], sampled[0], "DDL should always be kept")
+	require.InDelta(t, 100, len(sampled)-1, 30)
+	require.Equal(t, sampled, Sample{Rate: 0.1}.Apply(queries), "samples should be reproducible")
+
+	require.Equal(t, queries[:11], Sample{MaxQueries: 10}.Apply(queries))
+	require.Len(t, Sample{Rate: 0.5, MaxQueries: 10}.Apply(queries), 11)
+
+	require.Equal(t, 10.0, Sample{Rate: 0.1}.Scale())
+	require.Equal(t, 1.0, Sample{MaxQueries: 10}.Scale())
+
+	require.ErrorContains(t, Sample{Rate: 2}.Validate(), "sample rate must be between 0 and 1")
+	require.ErrorContains(t, Sample{MaxQueries: -1}.Validate(), "max queries must be positive")
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// splitStatements splits the queries holding several statements, such as `insert ...; update ...;`,
+// into one query per statement, since the parser only accepts a single statement.
+// The statements keep the line number and the other information of the original query.
+func splitStatements(queries []Query, err error) ([]Query, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var parser *sqlparser.Parser
+	result := make([]Query, 0, len(queries))
+	for _, q := range queries {
+		result = append(result, splitQuery(&parser, q)...)
+	}
+	return result, nil
+}
+
+// splitMySQLTestStatements splits the queries of a mysqltest file like splitStatements. The directives applying
+// to the next query, such as --error or --skip, are repeated in front of every statement of a split query,
+// so that they apply to all of them.
+func splitMySQLTestStatements(queries []Query, err error) ([]Query, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var parser *sqlparser.Parser
+	var pending []Query
+	result := make([]Query, 0, len(queries))
+	for _, q := range queries {
+		switch q.Type {
+		case typ.Error, typ.Skip, typ.SkipIfBelowVersion, typ.VExplain, typ.Reference:
+			pending = append(pending, q)
+			result = append(result, q)
+			continue
+		case typ.Query:
+		default:
+			result = append(result, q)
+			continue
+		}
+
+		for i, stmt := range splitQuery(&parser, q) {
+			if i > 0 {
+				result = append(result, pending...)
+			}
+			result = append(result, stmt)
+		}
+		pending = nil
+	}
+	return result, nil
+}
+
+// splitQuery returns the statements of a query, or the query itself when it holds a single statement.
+// The parser is created the first time a query has to be split.
+func splitQuery(parser **sqlparser.Parser, q Query) []Query {
+	// the fast path: most queries have no semicolon, or only a trailing one
+	if q.Type != typ.Query || !strings.Contains(strings.TrimRight(q.Query, "; \t\r\n"), ";") {
+		return []Query{q}
+	}
+
+	if *parser == nil {
+		*parser = sqlparser.NewTestParser()
+	}
+	pieces, err := (*parser).SplitStatementToPieces(q.Query)
+	if err != nil || len(pieces) < 2 {
+		return []Query{q}
+	}
+	result := make([]Query, 0, len(pieces))
+	for _, piece := range pieces {
+		stmt := q
+		stmt.Query = strings.TrimSpace(piece)
+		result = append(result, stmt)
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestSplitStatements(t *testing.T) {
+	queries, err := splitStatements([]Query{
+		{Query: "insert into t (id, a) values (1, 'x;y'); update t set a = 'z' where id = 1;", Line: 1, Type: typ.Query},
+		{Query: "select * from t;", Line: 2, Type: typ.Query},
+		{Query: " ER_PARSE_ERROR", Line: 3, Type: typ.Error},
+		{Query: "select ';' from t\n;", Line: 4, Type: typ.Query},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Query{
+		{Query: "insert into t (id, a) values (1, 'x;y')", Line: 1, Type: typ.Query},
+		{Query: "update t set a = 'z' where id = 1", Line: 1, Type: typ.Query},
+		{Query: "select * from t;", Line: 2, Type: typ.Query},
+		{Query: " ER_PARSE_ERROR", Line: 3, Type: typ.Error},
+		{Query: "select ';' from t\n;", Line: 4, Type: typ.Query},
+	}, queries)
+}
+
+func TestMySQLTestSplit(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "multi.test")
+	content := "--error ER_DUP_ENTRY\n" +
+		"insert into t (id) values (1); update t set a = 1 where id = 1;\n" +
+		"select * from t;\n"
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+
+	queries, err := LoadQueries(fileName)
+	require.NoError(t, err)
+
+	var got []Query
+	for _, q := range queries {
+		got = append(got, Query{Query: q.Query, Line: q.Line, Type: q.Type})
+	}
+	// the --error directive applies to both statements of the split query
+	require.Equal(t, []Query{
+		{Query: " ER_DUP_ENTRY", Line: 1, Type: typ.Error},
+		{Query: "insert into t (id) values (1)", Line: 2, Type: typ.Query},
+		{Query: " ER_DUP_ENTRY", Line: 1, Type: typ.Error},
+		{Query: "update t set a = 1 where id = 1", Line: 2, Type: typ.Query},
+		{Query: "select * from t;", Line: 3, Type: typ.Query},
+	}, got)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+// vtgateTimeFormat is the format of the timestamps in the vtgate query log
+const vtgateTimeFormat = "2006-01-02 15:04:05.000000"
+
+// VtGateLogLoader reads the query log of vtgate, written with --querylog-format=json.
+// Every line of the log is a JSON object describing one executed query. Besides the query itself,
+// the loader keeps what vtgate observed while executing it: the number of queries sent to
+// the shards and, when the log has it, the plan type. The queries are tagged with their session
+// and start time, and with their user: the effective caller ID the application set, which usually names
+// the calling service, or else the immediate caller or the MySQL user. The log doesn't record a time zone: the start times are read in Location, UTC when
+// it is nil, and converted to UTC, like the timestamps of the other formats.
+type VtGateLogLoader struct {
+	Location *time.Location
+}
+
+// vtgateLogEntry holds the fields of a vtgate query log entry we care about
+type vtgateLogEntry struct {
+	Start        string `json:"Start"`
+	SQL          string `json:"SQL"`
+	StmtType     string `json:"StmtType"`
+	PlanType     string `json:"PlanType"`
+	ShardQueries int    `json:"ShardQueries"`
+	SessionUUID  string `json:"SessionUUID"`
+
+	Username        string `json:"Username"`
+	ImmediateCaller string `json:"ImmediateCaller"`
+	EffectiveCaller string `json:"Effective Caller"`
+}
+
+// user returns the principal that sent the query, the most specific one the entry has
+func (e vtgateLogEntry) user() string {
+	switch {
+	case e.EffectiveCaller != "":
+		return e.EffectiveCaller
+	case e.ImmediateCaller != "":
+		return e.ImmediateCaller
+	default:
+		return e.Username
+	}
+}
+
+var _ Loader = VtGateLogLoader{}
+
+func (l VtGateLogLoader) Load(url string) ([]Query, error) {
+	data, err := readData(url)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parseVtGateLog(data, l.location()))
+}
+
+func (l VtGateLogLoader) Read(r io.Reader) ([]Query, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(parseVtGateLog(data, l.location()))
+}
+
+// location is the time zone of the timestamps of the log
+func (l VtGateLogLoader) location() *time.Location {
+	if l.Location == nil {
+		return time.UTC
+	}
+	return l.Location
+}
+
+func parseVtGateLog(data []byte, loc *time.Location) ([]Query, error) {
+	sessions := make(map[string]int)
+	var queries []Query
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry vtgateLogEntry
+		err := json.Unmarshal(line, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("line %d is not a vtgate json log entry: %w", i+1, err)
+		}
+		if entry.SQL == "" {
+			continue
+		}
+
+		var connID int
+		if entry.SessionUUID != "" {
+			connID = sessions[entry.SessionUUID]
+			if connID == 0 {
+				connID = len(sessions) + 1
+				sessions[entry.SessionUUID] = connID
+			}
+		}
+
+		var start time.Time
+		if entry.Start != "" {
+			start, err = time.ParseInLocation(vtgateTimeFormat, entry.Start, loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d has an invalid start time: %w", i+1, err)
+			}
+			start = start.UTC()
+		}
+
+		queries = append(queries, Query{
+			Query:        entry.SQL,
+			Line:         i + 1,
+			Type:         typ.Query,
+			ConnectionID: connID,
+			Timestamp:    start,
+			User:         entry.user(),
+			Execution: &ExecutionInfo{
+				StmtType:     entry.StmtType,
+				PlanType:     entry.PlanType,
+				ShardQueries: entry.ShardQueries,
+			},
+		})
+	}
+	return queries, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestParseVtGateLog(t *testing.T) {
+	log := `{"Method": "Execute", "Start": "2024-11-05 10:15:30.123456", "StmtType": "SELECT", "SQL": "select * from t", "ShardQueries": 8, "SessionUUID": "a"}
+{"Method": "Execute", "StmtType": "INSERT", "SQL": "insert into t(id) values (1)", "ShardQueries": 1, "SessionUUID": "b", "PlanType": "Insert"}
+
+{"Method": "Execute", "StmtType": "SELECT", "SQL": "select 1", "ShardQueries": 0, "SessionUUID": "a"}
+{"Method": "Execute", "SQL": ""}
+`
+	queries, err := parseVtGateLog([]byte(log), time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, []Query{{
+		Query:        "select * from t",
+		Line:         1,
+		Type:         typ.Query,
+		ConnectionID: 1,
+		Timestamp:    time.Date(2024, 11, 5, 10, 15, 30, 123456000, time.UTC),
+		Execution:    &ExecutionInfo{StmtType: "SELECT", ShardQueries: 8},
+	}, {
+		Query:        "insert into t(id) values (1)",
+		Line:         2,
+		Type:         typ.Query,
+		ConnectionID: 2,
+		Execution:    &ExecutionInfo{StmtType: "INSERT", PlanType: "Insert", ShardQueries: 1},
+	}, {
+		Query:        "select 1",
+		Line:         4,
+		Type:         typ.Query,
+		ConnectionID: 1,
+		Execution:    &ExecutionInfo{StmtType: "SELECT"},
+	}}, queries)
+
+	_, err = parseVtGateLog([]byte("Execute\t[]\t...\n"), time.UTC)
+	require.ErrorContains(t, err, "line 1 is not a vtgate json log entry")
+
+	_, err = parseVtGateLog([]byte(`{"Start": "yesterday", "SQL": "select 1"}`), time.UTC)
+	require.ErrorContains(t, err, "line 1 has an invalid start time")
+}
+
+func TestParseVtGateLogUser(t *testing.T) {
+	log := `{"Method": "Execute", "Username": "app", "ImmediateCaller": "vtgate-user", "Effective Caller": "orders-service", "SQL": "select 1"}
+{"Method": "Execute", "Username": "app", "ImmediateCaller": "vtgate-user", "Effective Caller": "", "SQL": "select 2"}
+{"Method": "Execute", "Username": "app", "SQL": "select 3"}
+{"Method": "Execute", "SQL": "select 4"}
+`
+	queries, err := parseVtGateLog([]byte(log), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, queries, 4)
+	require.Equal(t, "orders-service", queries[0].User)
+	require.Equal(t, "vtgate-user", queries[1].User)
+	require.Equal(t, "app", queries[2].User)
+	require.Empty(t, queries[3].User)
+}
+
+func TestParseVtGateLogLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	queries, err := parseVtGateLog([]byte(`{"Start": "2024-11-05 10:15:30.000000", "SQL": "select 1"}`), loc)
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	// the start time is read in the location of the log, and kept in UTC
+	require.Equal(t, time.Date(2024, 11, 5, 8, 15, 30, 0, time.UTC), queries[0].Timestamp)
+	require.Equal(t, time.UTC, queries[0].Timestamp.Location())
+}
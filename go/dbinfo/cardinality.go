@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// DefaultSampleRows is the number of rows of a table read to estimate the cardinality of its columns
+const DefaultSampleRows = 100_000
+
+// CardinalitySample is the number of distinct values of a column in the first rows of its table.
+// It is exact when the table has fewer rows than the sample.
+type CardinalitySample struct {
+	Rows     int `json:"rows"`
+	Distinct int `json:"distinct"`
+}
+
+// Selectivity is the share of distinct values in the sample, 1 for a column without duplicates
+func (s CardinalitySample) Selectivity() float64 {
+	if s.Rows == 0 {
+		return 0
+	}
+	return float64(s.Distinct) / float64(s.Rows)
+}
+
+// readKeysColumns returns the columns that the queries of a keys file filter, join or group on, per table
+func readKeysColumns(fileName string) (map[string]map[string]bool, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var output keys.Output
+	if err := json.Unmarshal(b, &output); err != nil {
+		return nil, fmt.Errorf("reading keys file %s: %w", fileName, err)
+	}
+
+	columns := make(map[string]map[string]bool)
+	add := func(col operators.Column) {
+		table := strings.ToLower(col.Table)
+		if columns[table] == nil {
+			columns[table] = make(map[string]bool)
+		}
+		columns[table][strings.ToLower(col.Name)] = true
+	}
+	for _, q := range output.Queries {
+		for _, col := range q.GroupingColumns {
+			add(col)
+		}
+		for _, use := range q.FilterColumns {
+			add(use.Column)
+		}
+		for _, use := range q.JoinColumns {
+			add(use.Column)
+		}
+		for _, pred := range q.JoinPredicates {
+			add(pred.LHS)
+			add(pred.RHS)
+		}
+	}
+	return columns, nil
+}
+
+// sampleCardinality counts the distinct values of the given columns in the first sampleRows rows of their tables
+func sampleCardinality(conn executor, info *Info, columns map[string]map[string]bool, sampleRows int) error {
+	for i := range info.Tables {
+		table := &info.Tables[i]
+		used := columns[strings.ToLower(table.Name)]
+		for j := range table.Columns {
+			col := &table.Columns[j]
+			if !used[strings.ToLower(col.Name)] {
+				continue
+			}
+			name := sqlparser.String(sqlparser.NewIdentifierCI(col.Name))
+			rs, err := conn.ExecuteFetch(fmt.Sprintf("select count(*) as sample_rows, count(distinct %s) as distinct_values from (select %s from %s limit %d) as sample",
+				name, name, sqlparser.String(sqlparser.NewIdentifierCS(table.Name)), sampleRows), 1, true)
+			if err != nil {
+				return fmt.Errorf("sampling the cardinality of %s.%s: %w", table.Name, col.Name, err)
+			}
+			for _, row := range rs.Named().Rows {
+				col.Sample = &CardinalitySample{
+					Rows:     int(row.AsInt64("sample_rows", 0)),
+					Distinct: int(row.AsInt64("distinct_values", 0)),
+				}
+			}
+		}
+	}
+	return nil
+}
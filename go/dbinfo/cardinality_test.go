@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbinfo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestSampleCardinality(t *testing.T) {
+	output := keys.Output{Queries: []keys.QueryAnalysisResult{{
+		QueryStructure: "select * from orders join customer on orders.customer_id = customer.id where orders.status = :1",
+		FilterColumns:  []operators.ColumnUse{{Column: operators.Column{Table: "orders", Name: "status"}, Uses: sqlparser.EqualOp}},
+		JoinPredicates: []operators.JoinPredicate{{
+			LHS:  operators.Column{Table: "orders", Name: "customer_id"},
+			RHS:  operators.Column{Table: "Customer", Name: "ID"},
+			Uses: sqlparser.EqualOp,
+		}},
+	}}}
+	b, err := json.Marshal(output)
+	require.NoError(t, err)
+	fileName := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(fileName, b, 0o600))
+
+	columns, err := readKeysColumns(fileName)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]bool{
+		"orders":   {"status": true, "customer_id": true},
+		"customer": {"id": true},
+	}, columns)
+
+	conn := &fakeExecutor{results: map[string]*sqltypes.Result{
+		"select count(*) as sample_rows": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("sample_rows|distinct_values", "int64|int64"),
+			"1000|250",
+		),
+	}}
+	info := &Info{Tables: []TableInfo{{
+		Name:    "orders",
+		Columns: []ColumnInfo{{Name: "id"}, {Name: "status"}, {Name: "customer_id"}},
+	}, {
+		Name:    "customer",
+		Columns: []ColumnInfo{{Name: "id"}, {Name: "email"}},
+	}}}
+	require.NoError(t, sampleCardinality(conn, info, columns, 1000))
+	require.Equal(t, []string{
+		"select count(*) as sample_rows, count(distinct `status`) as distinct_values from (select `status` from orders limit 1000) as sample",
+		"select count(*) as sample_rows, count(distinct customer_id) as distinct_values from (select customer_id from orders limit 1000) as sample",
+		"select count(*) as sample_rows, count(distinct id) as distinct_values from (select id from customer limit 1000) as sample",
+	}, conn.queries)
+	require.Nil(t, info.Tables[0].Columns[0].Sample)
+	require.Equal(t, &CardinalitySample{Rows: 1000, Distinct: 250}, info.Tables[0].Columns[1].Sample)
+	require.InDelta(t, 0.25, info.Tables[0].Columns[1].Sample.Selectivity(), 0.001)
+	require.Nil(t, info.Tables[1].Columns[1].Sample)
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// Config contains the options of a 'vt dbinfo' run. The endpoint can be a MySQL server or a vtgate.
+type Config struct {
+	Host     string
+	Port     int
+	Socket   string
+	User     string
+	Password string
+
+	// Database is the database, or the keyspace of a vtgate, to collect. Defaults to the database of the connection.
+	Database string
+
+	// KeysFile is a file written by 'vt keys'. With SampleCardinality, the cardinality of the columns its queries
+	// filter, join or group on is sampled, reading up to SampleRows rows of each table.
+	KeysFile          string
+	SampleCardinality bool
+	SampleRows        int
+
+	// QueryStats snapshots the query digests of performance_schema, with their execution counts and latencies.
+	// It needs a MySQL server with performance_schema enabled.
+	QueryStats bool
+}
+
+type (
+	// Info is the content of a dbinfo file: what the tools need to know about a live database
+	// beyond the queries of its workload
+	Info struct {
+		Database        string            `json:"database"`
+		Tables          []TableInfo       `json:"tables"`
+		GlobalVariables map[string]string `json:"globalVariables,omitempty"`
+		QueryStats      []QueryStat       `json:"queryStats,omitempty"`
+	}
+
+	// TableInfo holds the schema and the size of a table. The sizes and row counts are the estimates of information_schema.
+	// AutoIncrement is the next value of the auto-increment column, when the table has one.
+	TableInfo struct {
+		Name          string        `json:"name"`
+		Engine        string        `json:"engine,omitempty"`
+		Rows          int           `json:"rows"`
+		DataLength    int           `json:"dataLength"`
+		IndexLength   int           `json:"indexLength"`
+		AutoIncrement uint64        `json:"autoIncrement,omitempty"`
+		Columns       []ColumnInfo  `json:"columns"`
+		Indexes       []IndexInfo   `json:"indexes,omitempty"`
+		ForeignKeys   []ForeignKey  `json:"foreignKeys,omitempty"`
+		Partitioning  *Partitioning `json:"partitioning,omitempty"`
+	}
+
+	ColumnInfo struct {
+		Name          string `json:"name"`
+		Type          string `json:"type"`
+		Nullable      bool   `json:"nullable,omitempty"`
+		AutoIncrement bool   `json:"autoIncrement,omitempty"`
+
+		// Sample is only set when the cardinality of the column was sampled
+		Sample *CardinalitySample `json:"sample,omitempty"`
+	}
+
+	// Partitioning describes how a partitioned table is split, such as RANGE on year(created)
+	Partitioning struct {
+		Method     string `json:"method"`
+		Expression string `json:"expression,omitempty"`
+		Partitions int    `json:"partitions"`
+	}
+
+	// IndexInfo holds the definition of an index and its estimated number of distinct values
+	IndexInfo struct {
+		Name        string   `json:"name"`
+		Columns     []string `json:"columns"`
+		Unique      bool     `json:"unique,omitempty"`
+		Cardinality int      `json:"cardinality"`
+	}
+
+	// ForeignKey is a foreign key constraint of a table, its columns reference the columns of another table
+	ForeignKey struct {
+		Name              string   `json:"name"`
+		Columns           []string `json:"columns"`
+		ReferencedTable   string   `json:"referencedTable"`
+		ReferencedColumns []string `json:"referencedColumns"`
+	}
+
+	// executor runs the queries collecting the information, it is implemented by *mysql.Conn
+	executor interface {
+		ExecuteFetch(query string, maxrows int, wantfields bool) (*sqltypes.Result, error)
+	}
+)
+
+// maxRows is the maximum number of rows read from a single information_schema query
+const maxRows = 1_000_000
+
+func Run(cfg Config) error {
+	return run(os.Stdout, cfg)
+}
+
+// RunTo collects the information of the database like Run, writing it to out instead of stdout
+func RunTo(out io.Writer, cfg Config) error {
+	return run(out, cfg)
+}
+
+func run(out io.Writer, cfg Config) error {
+	var columns map[string]map[string]bool
+	if cfg.SampleCardinality {
+		if cfg.KeysFile == "" {
+			return errors.New("--sample-cardinality needs a keys file, use --keys-file to give one")
+		}
+		var err error
+		columns, err = readKeysColumns(cfg.KeysFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	conn, err := mysql.Connect(context.Background(), &mysql.ConnParams{
+		Host:       cfg.Host,
+		Port:       cfg.Port,
+		UnixSocket: cfg.Socket,
+		Uname:      cfg.User,
+		Pass:       cfg.Password,
+		DbName:     cfg.Database,
+	})
+	if err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
+	}
+	defer conn.Close()
+
+	info, err := collect(conn, cfg.Database)
+	if err != nil {
+		return err
+	}
+	if cfg.SampleCardinality {
+		sampleRows := cfg.SampleRows
+		if sampleRows <= 0 {
+			sampleRows = DefaultSampleRows
+		}
+		if err := sampleCardinality(conn, info, columns, sampleRows); err != nil {
+			return err
+		}
+	}
+	if cfg.QueryStats {
+		info.QueryStats, err = collectQueryStats(conn, info.Database)
+		if err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(info)
+}
+
+// collect reads the information of the database from information_schema
+func collect(conn executor, database string) (*Info, error) {
+	if database == "" {
+		rs, err := conn.ExecuteFetch("select database()", 1, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(rs.Rows) == 1 && !rs.Rows[0][0].IsNull() {
+			database = rs.Rows[0][0].ToString()
+		}
+		if database == "" {
+			return nil, errors.New("no database selected, use --database to choose one")
+		}
+	}
+	schema := sqlparser.String(sqlparser.NewStrLiteral(database))
+	info := &Info{Database: database}
+
+	rs, err := conn.ExecuteFetch(fmt.Sprintf("select table_name as name, engine as engine, table_rows as row_count, data_length, index_length, auto_increment "+
+		"from information_schema.tables where table_schema = %s and table_type = 'BASE TABLE' order by table_name", schema), maxRows, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading the tables: %w", err)
+	}
+	tables := make(map[string]*TableInfo, len(rs.Rows))
+	info.Tables = make([]TableInfo, 0, len(rs.Rows))
+	for _, row := range rs.Named().Rows {
+		info.Tables = append(info.Tables, TableInfo{
+			Name:          row.AsString("name", ""),
+			Engine:        row.AsString("engine", ""),
+			Rows:          int(row.AsInt64("row_count", 0)),
+			DataLength:    int(row.AsInt64("data_length", 0)),
+			IndexLength:   int(row.AsInt64("index_length", 0)),
+			AutoIncrement: row.AsUint64("auto_increment", 0),
+		})
+	}
+	for i := range info.Tables {
+		tables[info.Tables[i].Name] = &info.Tables[i]
+	}
+
+	rs, err = conn.ExecuteFetch(fmt.Sprintf("select table_name as table_name, column_name as name, column_type as type, is_nullable as nullable, extra as extra "+
+		"from information_schema.columns where table_schema = %s order by table_name, ordinal_position", schema), maxRows, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading the columns: %w", err)
+	}
+	for _, row := range rs.Named().Rows {
+		table, found := tables[row.AsString("table_name", "")]
+		if !found {
+			// a view
+			continue
+		}
+		table.Columns = append(table.Columns, ColumnInfo{
+			Name:          row.AsString("name", ""),
+			Type:          row.AsString("type", ""),
+			Nullable:      row.AsString("nullable", "") == "YES",
+			AutoIncrement: strings.Contains(strings.ToLower(row.AsString("extra", "")), "auto_increment"),
+		})
+	}
+
+	// the cardinality of a multi-column index is the one of its last column
+	rs, err = conn.ExecuteFetch(fmt.Sprintf("select table_name as table_name, index_name as name, column_name as column_name, "+
+		"non_unique as non_unique, cardinality as cardinality from information_schema.statistics where table_schema = %s "+
+		"order by table_name, index_name, seq_in_index", schema), maxRows, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading the indexes: %w", err)
+	}
+	for _, row := range rs.Named().Rows {
+		table, found := tables[row.AsString("table_name", "")]
+		if !found {
+			continue
+		}
+		name := row.AsString("name", "")
+		if n := len(table.Indexes); n == 0 || table.Indexes[n-1].Name != name {
+			table.Indexes = append(table.Indexes, IndexInfo{
+				Name:   name,
+				Unique: row.AsInt64("non_unique", 1) == 0,
+			})
+		}
+		index := &table.Indexes[len(table.Indexes)-1]
+		index.Columns = append(index.Columns, row.AsString("column_name", ""))
+		index.Cardinality = max(index.Cardinality, int(row.AsInt64("cardinality", 0)))
+	}
+
+	rs, err = conn.ExecuteFetch(fmt.Sprintf("select table_name as table_name, constraint_name as name, column_name as column_name, "+
+		"referenced_table_name as referenced_table, referenced_column_name as referenced_column from information_schema.key_column_usage "+
+		"where table_schema = %s and referenced_table_name is not null order by table_name, constraint_name, ordinal_position", schema), maxRows, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading the foreign keys: %w", err)
+	}
+	for _, row := range rs.Named().Rows {
+		table, found := tables[row.AsString("table_name", "")]
+		if !found {
+			continue
+		}
+		name := row.AsString("name", "")
+		if n := len(table.ForeignKeys); n == 0 || table.ForeignKeys[n-1].Name != name {
+			table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+				Name:            name,
+				ReferencedTable: row.AsString("referenced_table", ""),
+			})
+		}
+		fk := &table.ForeignKeys[len(table.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, row.AsString("column_name", ""))
+		fk.ReferencedColumns = append(fk.ReferencedColumns, row.AsString("referenced_column", ""))
+	}
+
+	// a table that is not partitioned has a single row with a null partition name
+	rs, err = conn.ExecuteFetch(fmt.Sprintf("select table_name as table_name, partition_method as method, partition_expression as expression, "+
+		"count(distinct partition_name) as partitions from information_schema.partitions where table_schema = %s and partition_name is not null "+
+		"group by table_name, partition_method, partition_expression order by table_name", schema), maxRows, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading the partitions: %w", err)
+	}
+	for _, row := range rs.Named().Rows {
+		table, found := tables[row.AsString("table_name", "")]
+		if !found {
+			continue
+		}
+		table.Partitioning = &Partitioning{
+			Method:     row.AsString("method", ""),
+			Expression: row.AsString("expression", ""),
+			Partitions: int(row.AsInt64("partitions", 0)),
+		}
+	}
+
+	rs, err = conn.ExecuteFetch("show global variables", maxRows, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading the global variables: %w", err)
+	}
+	info.GlobalVariables = make(map[string]string, len(rs.Rows))
+	for _, row := range rs.Rows {
+		info.GlobalVariables[row[0].ToString()] = row[1].ToString()
+	}
+
+	return info, nil
+}
+
+// AutoIncrementColumn returns the auto-increment column of the table, if it has one
+func (t TableInfo) AutoIncrementColumn() (ColumnInfo, bool) {
+	for _, col := range t.Columns {
+		if col.AutoIncrement {
+			return col, true
+		}
+	}
+	return ColumnInfo{}, false
+}
+
+// MaxValue returns the largest value of an integer column type, such as int or bigint unsigned
+func (c ColumnInfo) MaxValue() (uint64, bool) {
+	fields := strings.Fields(strings.ToLower(c.Type))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	name, _, _ := strings.Cut(fields[0], "(")
+	unsigned := slices.Contains(fields[1:], "unsigned")
+
+	var bits uint
+	switch name {
+	case "tinyint":
+		bits = 8
+	case "smallint":
+		bits = 16
+	case "mediumint":
+		bits = 24
+	case "int", "integer":
+		bits = 32
+	case "bigint":
+		bits = 64
+	default:
+		return 0, false
+	}
+	if unsigned {
+		return math.MaxUint64 >> (64 - bits), true
+	}
+	return math.MaxUint64 >> (65 - bits), true
+}
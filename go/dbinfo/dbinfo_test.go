@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbinfo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// fakeExecutor returns the result of the first registered query prefix the executed query starts with
+type fakeExecutor struct {
+	results map[string]*sqltypes.Result
+	queries []string
+}
+
+func (f *fakeExecutor) ExecuteFetch(query string, _ int, _ bool) (*sqltypes.Result, error) {
+	f.queries = append(f.queries, query)
+	for prefix, rs := range f.results {
+		if strings.HasPrefix(query, prefix) {
+			return rs, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected query %s", query)
+}
+
+func TestCollect(t *testing.T) {
+	conn := &fakeExecutor{results: map[string]*sqltypes.Result{
+		"select database()": sqltypes.MakeTestResult(sqltypes.MakeTestFields("database()", "varchar"), "shop"),
+		"select table_name as name": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("name|engine|row_count|data_length|index_length|auto_increment", "varchar|varchar|uint64|uint64|uint64|uint64"),
+			"customer|InnoDB|1000|163840|32768|1001",
+			"orders|InnoDB|250000|31014912|12075008|null",
+		),
+		"select table_name as table_name, column_name": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("table_name|name|type|nullable|extra", "varchar|varchar|varchar|varchar|varchar"),
+			"customer|id|bigint|NO|auto_increment",
+			"customer|email|varchar(255)|YES|",
+			"orders|id|bigint|NO|",
+			"orders|customer_id|bigint|NO|",
+			"customer_view|id|bigint|NO|",
+		),
+		"select table_name as table_name, index_name": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("table_name|name|column_name|non_unique|cardinality", "varchar|varchar|varchar|int64|int64"),
+			"customer|PRIMARY|id|0|1000",
+			"customer|uk_email|email|0|990",
+			"orders|PRIMARY|id|0|248213",
+			"orders|idx_customer|customer_id|1|null",
+			"orders|idx_customer|id|1|null",
+		),
+		"select table_name as table_name, constraint_name": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("table_name|name|column_name|referenced_table|referenced_column", "varchar|varchar|varchar|varchar|varchar"),
+			"orders|fk_customer|customer_id|customer|id",
+		),
+		"select table_name as table_name, partition_method": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("table_name|method|expression|partitions", "varchar|varchar|varchar|int64"),
+			"orders|RANGE|year(`created`)|4",
+		),
+		"show global variables": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("Variable_name|Value", "varchar|varchar"),
+			"innodb_buffer_pool_size|134217728",
+			"version|8.0.40",
+		),
+	}}
+
+	info, err := collect(conn, "")
+	require.NoError(t, err)
+	require.Equal(t, &Info{
+		Database: "shop",
+		Tables: []TableInfo{{
+			Name:          "customer",
+			Engine:        "InnoDB",
+			Rows:          1000,
+			DataLength:    163840,
+			IndexLength:   32768,
+			AutoIncrement: 1001,
+			Columns:       []ColumnInfo{{Name: "id", Type: "bigint", AutoIncrement: true}, {Name: "email", Type: "varchar(255)", Nullable: true}},
+			Indexes: []IndexInfo{
+				{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Cardinality: 1000},
+				{Name: "uk_email", Columns: []string{"email"}, Unique: true, Cardinality: 990},
+			},
+		}, {
+			Name:        "orders",
+			Engine:      "InnoDB",
+			Rows:        250000,
+			DataLength:  31014912,
+			IndexLength: 12075008,
+			Columns:     []ColumnInfo{{Name: "id", Type: "bigint"}, {Name: "customer_id", Type: "bigint"}},
+			Indexes: []IndexInfo{
+				{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Cardinality: 248213},
+				{Name: "idx_customer", Columns: []string{"customer_id", "id"}},
+			},
+			ForeignKeys:  []ForeignKey{{Name: "fk_customer", Columns: []string{"customer_id"}, ReferencedTable: "customer", ReferencedColumns: []string{"id"}}},
+			Partitioning: &Partitioning{Method: "RANGE", Expression: "year(`created`)", Partitions: 4},
+		}},
+		GlobalVariables: map[string]string{"innodb_buffer_pool_size": "134217728", "version": "8.0.40"},
+	}, info)
+	require.Contains(t, conn.queries[1], "table_schema = 'shop'")
+
+	conn.results["select database()"] = sqltypes.MakeTestResult(sqltypes.MakeTestFields("database()", "varchar"), "null")
+	_, err = collect(conn, "")
+	require.ErrorContains(t, err, "no database selected")
+}
+
+func TestColumnMaxValue(t *testing.T) {
+	tests := map[string]uint64{
+		"tinyint":                  127,
+		"tinyint(3) unsigned":      255,
+		"smallint":                 32767,
+		"mediumint unsigned":       16777215,
+		"int(11)":                  2147483647,
+		"int unsigned":             4294967295,
+		"bigint":                   9223372036854775807,
+		"bigint unsigned zerofill": 18446744073709551615,
+	}
+	for columnType, expected := range tests {
+		maxValue, ok := ColumnInfo{Type: columnType}.MaxValue()
+		require.True(t, ok, columnType)
+		require.Equal(t, expected, maxValue, columnType)
+	}
+	_, ok := ColumnInfo{Type: "varchar(10)"}.MaxValue()
+	require.False(t, ok)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbinfo
+
+import (
+	"fmt"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// picosecondsPerMillisecond converts the timers of performance_schema, which count picoseconds
+const picosecondsPerMillisecond = 1e9
+
+// QueryStat is what performance_schema.events_statements_summary_by_digest counted for the executions
+// of a query digest since the server started, or since the table was truncated. Latencies are in milliseconds.
+type QueryStat struct {
+	Digest       string  `json:"digest"`
+	DigestText   string  `json:"digestText"`
+	Executions   int     `json:"executions"`
+	TotalLatency float64 `json:"totalLatency"`
+	MaxLatency   float64 `json:"maxLatency"`
+	RowsExamined int     `json:"rowsExamined"`
+	RowsSent     int     `json:"rowsSent"`
+}
+
+// AvgLatency is the mean latency of an execution of the digest, in milliseconds
+func (s QueryStat) AvgLatency() float64 {
+	if s.Executions == 0 {
+		return 0
+	}
+	return s.TotalLatency / float64(s.Executions)
+}
+
+// collectQueryStats reads the digests of the statements run on the database, the most time-consuming first
+func collectQueryStats(conn executor, database string) ([]QueryStat, error) {
+	rs, err := conn.ExecuteFetch(fmt.Sprintf("select digest as digest, digest_text as digest_text, count_star as executions, "+
+		"sum_timer_wait as total_latency, max_timer_wait as max_latency, sum_rows_examined as rows_examined, sum_rows_sent as rows_sent "+
+		"from performance_schema.events_statements_summary_by_digest where schema_name = %s and digest_text is not null "+
+		"order by sum_timer_wait desc", sqlparser.String(sqlparser.NewStrLiteral(database))), maxRows, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading the query digests of performance_schema, which vtgate doesn't have: %w", err)
+	}
+	stats := make([]QueryStat, 0, len(rs.Rows))
+	for _, row := range rs.Named().Rows {
+		stats = append(stats, QueryStat{
+			Digest:       row.AsString("digest", ""),
+			DigestText:   row.AsString("digest_text", ""),
+			Executions:   int(row.AsInt64("executions", 0)),
+			TotalLatency: float64(row.AsUint64("total_latency", 0)) / picosecondsPerMillisecond,
+			MaxLatency:   float64(row.AsUint64("max_latency", 0)) / picosecondsPerMillisecond,
+			RowsExamined: int(row.AsInt64("rows_examined", 0)),
+			RowsSent:     int(row.AsInt64("rows_sent", 0)),
+		})
+	}
+	return stats, nil
+}
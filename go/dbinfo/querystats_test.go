@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+func TestCollectQueryStats(t *testing.T) {
+	conn := &fakeExecutor{results: map[string]*sqltypes.Result{
+		"select digest as digest": sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("digest|digest_text|executions|total_latency|max_latency|rows_examined|rows_sent",
+				"varchar|text|uint64|uint64|uint64|uint64|uint64"),
+			"3f2a|SELECT * FROM `orders` WHERE `customer_id` = ?|2000|5000000000000|40000000000|8000|4000",
+			"9b1c|UPDATE `customer` SET `email` = ? WHERE `id` = ?|10|2500000000|500000000|10|0",
+		),
+	}}
+	stats, err := collectQueryStats(conn, "shop")
+	require.NoError(t, err)
+	require.Contains(t, conn.queries[0], "where schema_name = 'shop'")
+	require.Equal(t, []QueryStat{{
+		Digest:       "3f2a",
+		DigestText:   "SELECT * FROM `orders` WHERE `customer_id` = ?",
+		Executions:   2000,
+		TotalLatency: 5000,
+		MaxLatency:   40,
+		RowsExamined: 8000,
+		RowsSent:     4000,
+	}, {
+		Digest:       "9b1c",
+		DigestText:   "UPDATE `customer` SET `email` = ? WHERE `id` = ?",
+		Executions:   10,
+		TotalLatency: 2.5,
+		MaxLatency:   0.5,
+		RowsExamined: 10,
+	}}, stats)
+	require.InDelta(t, 2.5, stats[0].AvgLatency(), 0.001)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package markdown has the helpers writing the markdown tables of vt keys and vt summarize
+package markdown
+
+import (
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// cellReplacer replaces the line breaks and tabs with spaces and escapes the pipes, which would end the cell.
+// The other spaces are kept, they can be part of the string literals of a query.
+var cellReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ", "|", `\|`) //nolint:gochecknoglobals // this is instead of a const
+
+// Cell keeps a value on a single line and escapes the pipes, which would end the cell
+func Cell(value string) string {
+	return cellReplacer.Replace(value)
+}
+
+// Table returns a table writer rendering a markdown table with these columns
+func Table(w io.Writer, cols []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeader(cols)
+	table.SetAutoWrapText(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	return table
+}
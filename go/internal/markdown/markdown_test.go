@@ -0,0 +1,29 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCell(t *testing.T) {
+	require.Equal(t, `select a \|\| b   from t where c = 1`, Cell("select a || b\n  from t\twhere c = 1"))
+	// the spaces of the string literals are kept
+	require.Equal(t, `select * from t where a = 'x  y'`, Cell("select *\r\nfrom t where a = 'x  y'"))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vitessio/vt/go/data"
+)
+
+// Options contains the options of Analyze. They are the options of Config that don't read nor write files.
+type Options struct {
+	// Loader reads the queries of the log. Defaults to the mysqltest format.
+	Loader data.Loader
+
+	// NormalizePlaceholders makes queries with literals and queries with `?` placeholders
+	// aggregate into the same query structure. See data.NormalizePlaceholders.
+	NormalizePlaceholders bool
+
+	// Filter selects the queries to analyse
+	Filter data.Filter
+
+	// Sample analyses a sample of the queries only. The usage counts in the output are scaled to estimate the whole log.
+	Sample data.Sample
+
+	// Renames renames the tables of the queries before they are analysed, see Config.Renames
+	Renames Renames
+
+	// Schema holds the CREATE TABLE statements of the tables, like the schema file of Config.
+	// The schema is inferred from the queries when it is empty.
+	Schema string
+
+	// MaxLiteralLength truncates the string and hexadecimal literals longer than this number of bytes,
+	// see Config.MaxLiteralLength. They are kept whole when it is 0.
+	MaxLiteralLength int
+
+	// Parallel is the number of queries analysed concurrently, see Config.Parallel
+	Parallel int
+
+	// Bucket, when set, counts the usage of every query structure per bucket of this duration, see Config.Bucket
+	Bucket time.Duration
+
+	// TopValues, when set, records this number of the most used literal values of every filter column, see Config.TopValues
+	TopValues int
+}
+
+// Analyze runs the keys analysis on the log read from r and returns its output, the document 'vt keys' writes.
+// It is meant for embedding the analysis in other tools and tests: it doesn't read nor write files.
+func Analyze(r io.Reader, opts Options) (*Output, error) {
+	if err := opts.Sample.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Bucket < 0 {
+		return nil, fmt.Errorf("the bucket duration can't be negative: %s", opts.Bucket)
+	}
+	loader := opts.Loader
+	if loader == nil {
+		loader = data.MySQLTestLoader{}
+	}
+	queries, err := loader.Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Config{
+		NormalizePlaceholders: opts.NormalizePlaceholders,
+		Filter:                opts.Filter,
+		Sample:                opts.Sample,
+		Renames:               opts.Renames,
+		MaxLiteralLength:      opts.MaxLiteralLength,
+		Parallel:              opts.Parallel,
+		Bucket:                opts.Bucket,
+		TopValues:             opts.TopValues,
+	}
+	si := newSchemaInfo()
+	ql := newQueryList(cfg)
+	if opts.Schema != "" {
+		if err := si.parseSchema(opts.Schema, "the schema of the options", opts.Renames); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err := analyseLog(context.Background(), queries, si, ql, cfg, opts.Schema == ""); err != nil {
+		return nil, err
+	}
+	output := ql.output()
+	return &output, nil
+}
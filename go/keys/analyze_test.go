@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+)
+
+func TestAnalyze(t *testing.T) {
+	log, err := os.ReadFile("../../t/tpch_failing_queries.test")
+	require.NoError(t, err)
+	output, err := Analyze(bytes.NewReader(log), Options{})
+	require.NoError(t, err)
+
+	// the output is the one of 'vt keys'
+	sb := &strings.Builder{}
+	require.NoError(t, run(context.Background(), sb, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}}))
+	var expected Output
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &expected))
+	require.Equal(t, FileType, output.FileType)
+	require.Equal(t, expected, *output)
+}
+
+func TestAnalyzeOptions(t *testing.T) {
+	schema := "CREATE TABLE `orders` (`id` bigint NOT NULL, `customer_id` bigint, PRIMARY KEY (`id`));\n" +
+		"CREATE TABLE `customer` (`cid` bigint NOT NULL, `region` varchar(10), PRIMARY KEY (`cid`));\n"
+	queries := "select id from orders join customer on customer_id = cid where region = 'eu';\n" +
+		"select id from orders where id = 3;\n"
+
+	output, err := Analyze(strings.NewReader(queries), Options{Schema: schema})
+	require.NoError(t, err)
+	require.Empty(t, output.Failed)
+	require.Len(t, output.Queries, 2)
+	require.Equal(t, "orders.customer_id = customer.cid", output.Queries[0].JoinPredicates[0].String())
+
+	output, err = Analyze(strings.NewReader(queries), Options{Schema: schema, Filter: data.Filter{Tables: []string{"customer"}}})
+	require.NoError(t, err)
+	require.Len(t, output.Queries, 1)
+
+	_, err = Analyze(strings.NewReader(queries), Options{Schema: "DROP TABLE orders;"})
+	require.ErrorContains(t, err, "no CREATE TABLE statement")
+
+	_, err = Analyze(strings.NewReader("not json\n"), Options{Loader: data.VtGateLogLoader{}})
+	require.ErrorContains(t, err, "line 1")
+}
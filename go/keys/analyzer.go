@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+// analyzerQuery is how a query is sent to a custom analyzer
+type analyzerQuery struct {
+	Query        string     `json:"query"`
+	LineNumber   int        `json:"lineNumber"`
+	ConnectionID int        `json:"connectionId,omitempty"`
+	User         string     `json:"user,omitempty"`
+	Timestamp    *time.Time `json:"timestamp,omitempty"`
+	// Count is the number of executions the query stands for, only set when the log aggregates them, as digests do
+	Count int `json:"count,omitempty"`
+}
+
+// runAnalyzer runs a user provided binary on the queries of the log. The binary reads the queries on its
+// standard input, one JSON object per line with the query and its line number, and writes its findings on
+// its standard output, also one JSON object per line. This lets users add their own checks without forking vt.
+func runAnalyzer(path string, queries []data.Query) ([]Finding, error) {
+	var stdin bytes.Buffer
+	enc := json.NewEncoder(&stdin)
+	for _, q := range queries {
+		if q.Type != typ.Query {
+			continue
+		}
+		aq := analyzerQuery{
+			Query:        q.Query,
+			LineNumber:   q.Line,
+			ConnectionID: q.ConnectionID,
+			User:         q.User,
+			Count:        q.Weight,
+		}
+		if !q.Timestamp.IsZero() {
+			aq.Timestamp = &q.Timestamp
+		}
+		if err := enc.Encode(aq); err != nil {
+			return nil, err
+		}
+	}
+
+	name := filepath.Base(path)
+	cmd := exec.Command(path)
+	cmd.Stdin = &stdin
+	stdout, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("analyzer %s failed: %w: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("analyzer %s failed: %w", name, err)
+	}
+
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(stdout))
+	scanner.Buffer(nil, len(stdout)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var finding Finding
+		if err := json.Unmarshal(line, &finding); err != nil {
+			return nil, fmt.Errorf("analyzer %s wrote an invalid finding %q: %w", name, line, err)
+		}
+		finding.Analyzer = name
+		findings = append(findings, finding)
+	}
+	return findings, scanner.Err()
+}
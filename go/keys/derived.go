@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+// maxDerivedDepth bounds how many nested derived tables a column is followed through
+const maxDerivedDepth = 10
+
+// addDerivedKeys adds the keys the vexplain keys extraction leaves out of a query:
+//   - the columns read through a derived table, such as x.a in `select ... from (select a from t) as x where x.a = 1`.
+//     They are attributed to the column of the physical table the derived table selects, t.a.
+//     The semantic analysis rewrites the non-recursive CTEs to derived tables, the columns of a recursive CTE
+//     are attributed to the columns its first, non-recursive, SELECT reads.
+//   - the semi-joins of the `col IN (SELECT col ...)` predicates, which are join predicates between the two columns.
+func addDerivedKeys(ctx *plancontext.PlanningContext, stmt sqlparser.Statement, keys *operators.VExplainKeys) {
+	var filters, joins []operators.ColumnUse
+	var grouping []operators.Column
+	var predicates []operators.JoinPredicate
+
+	addComparison := func(cmp *sqlparser.ComparisonExpr) {
+		if cmp.Operator == sqlparser.InOp {
+			if lhs, ok := cmp.Left.(*sqlparser.ColName); ok {
+				if rhs := subqueryColumn(cmp.Right); rhs != nil {
+					addSemiJoin(ctx, lhs, rhs, &joins, &predicates)
+				}
+			}
+		}
+
+		lhs, lhsOK := cmp.Left.(*sqlparser.ColName)
+		rhs, rhsOK := cmp.Right.(*sqlparser.ColName)
+		if !(lhsOK && throughDerived(ctx, lhs)) && !(rhsOK && throughDerived(ctx, rhs)) {
+			// vexplain keys has the comparisons of the physical tables already
+			return
+		}
+		output := &filters
+		if lhsOK && rhsOK && ctx.SemTable.RecursiveDeps(lhs) != ctx.SemTable.RecursiveDeps(rhs) {
+			output = &joins
+			l, lOK := physicalColumn(ctx, lhs)
+			r, rOK := physicalColumn(ctx, rhs)
+			if lOK && rOK {
+				predicates = append(predicates, operators.JoinPredicate{LHS: l, RHS: r, Uses: cmp.Operator})
+			}
+		}
+		if col, ok := physicalColumn(ctx, lhs); lhsOK && ok {
+			*output = append(*output, operators.ColumnUse{Column: col, Uses: cmp.Operator})
+		}
+		if switched, ok := cmp.Operator.SwitchSides(); rhsOK && ok {
+			if col, ok := physicalColumn(ctx, rhs); ok {
+				*output = append(*output, operators.ColumnUse{Column: col, Uses: switched})
+			}
+		}
+	}
+	addPredicate := func(expr sqlparser.Expr) {
+		for _, predicate := range sqlparser.SplitAndExpression(nil, expr) {
+			switch predicate := predicate.(type) {
+			case *sqlparser.ComparisonExpr:
+				addComparison(predicate)
+			case *sqlparser.BetweenExpr:
+				col, ok := predicate.Left.(*sqlparser.ColName)
+				if !ok || !throughDerived(ctx, col) {
+					continue
+				}
+				if c, ok := physicalColumn(ctx, col); ok {
+					filters = append(filters,
+						operators.ColumnUse{Column: c, Uses: sqlparser.GreaterEqualOp},
+						operators.ColumnUse{Column: c, Uses: sqlparser.LessEqualOp})
+				}
+			}
+		}
+	}
+
+	_ = sqlparser.VisitSQLNode(stmt, func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Where:
+			addPredicate(node.Expr)
+		case *sqlparser.JoinCondition:
+			addPredicate(node.On)
+		case *sqlparser.GroupBy:
+			for _, expr := range node.Exprs {
+				if col, ok := expr.(*sqlparser.ColName); ok && throughDerived(ctx, col) {
+					if c, ok := physicalColumn(ctx, col); ok {
+						grouping = append(grouping, c)
+					}
+				}
+			}
+		}
+		return true, nil
+	})
+
+	if len(filters) > 0 {
+		keys.FilterColumns = uniqueColumnUses(append(keys.FilterColumns, filters...))
+	}
+	if len(joins) > 0 {
+		keys.JoinColumns = uniqueColumnUses(append(keys.JoinColumns, joins...))
+	}
+	if len(grouping) > 0 {
+		keys.GroupingColumns = append(keys.GroupingColumns, grouping...)
+		slices.SortFunc(keys.GroupingColumns, func(a, b operators.Column) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		keys.GroupingColumns = slices.Compact(keys.GroupingColumns)
+	}
+	for _, predicate := range predicates {
+		if !slices.ContainsFunc(keys.JoinPredicates, predicate.Equal) {
+			keys.JoinPredicates = append(keys.JoinPredicates, predicate)
+		}
+	}
+}
+
+// addSemiJoin adds `lhs IN (SELECT rhs ...)` as the join predicate lhs = rhs
+func addSemiJoin(ctx *plancontext.PlanningContext, lhs, rhs *sqlparser.ColName, joins *[]operators.ColumnUse, predicates *[]operators.JoinPredicate) {
+	l, lOK := physicalColumn(ctx, lhs)
+	r, rOK := physicalColumn(ctx, rhs)
+	if !lOK || !rOK {
+		return
+	}
+	*joins = append(*joins,
+		operators.ColumnUse{Column: l, Uses: sqlparser.EqualOp},
+		operators.ColumnUse{Column: r, Uses: sqlparser.EqualOp})
+	*predicates = append(*predicates, operators.JoinPredicate{LHS: l, RHS: r, Uses: sqlparser.EqualOp})
+}
+
+// subqueryColumn returns the column a subquery selects, when it selects a single column
+func subqueryColumn(expr sqlparser.Expr) *sqlparser.ColName {
+	subquery, ok := expr.(*sqlparser.Subquery)
+	if !ok {
+		return nil
+	}
+	sel, ok := subquery.Select.(*sqlparser.Select)
+	if !ok || len(sel.SelectExprs) != 1 {
+		return nil
+	}
+	aliased, ok := sel.SelectExprs[0].(*sqlparser.AliasedExpr)
+	if !ok {
+		return nil
+	}
+	col, _ := aliased.Expr.(*sqlparser.ColName)
+	return col
+}
+
+// throughDerived tells if the column is a column of a derived table or of a recursive CTE
+func throughDerived(ctx *plancontext.PlanningContext, col *sqlparser.ColName) bool {
+	info, err := ctx.SemTable.TableInfoForExpr(col)
+	if err != nil {
+		return false
+	}
+	_, _, ok := tableSelect(info)
+	return ok
+}
+
+// tableSelect returns the SELECT of a derived table or of a recursive CTE, with the names given to its columns
+func tableSelect(info semantics.TableInfo) (sqlparser.Columns, sqlparser.SelectStatement, bool) {
+	switch info := info.(type) {
+	case *semantics.DerivedTable:
+		aliased := info.GetAliasedTableExpr()
+		derived, ok := aliased.Expr.(*sqlparser.DerivedTable)
+		if !ok {
+			return nil, nil, false
+		}
+		return aliased.Columns, derived.Select, true
+	case *semantics.RealTable:
+		// a reference to a recursive CTE, out of the CTE
+		if info.CTE == nil {
+			return nil, nil, false
+		}
+		return info.CTE.Columns, info.CTE.Query, true
+	case *semantics.CTETable:
+		// the reference of a recursive CTE to itself
+		return info.Columns, info.Query, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// physicalColumn returns the column of a physical table a column stands for, following it through the derived tables
+// and the recursive CTEs. It fails for the columns of derived tables that select an expression rather than a column.
+func physicalColumn(ctx *plancontext.PlanningContext, col *sqlparser.ColName) (operators.Column, bool) {
+	for range maxDerivedDepth {
+		info, err := ctx.SemTable.TableInfoForExpr(col)
+		if err != nil {
+			return operators.Column{}, false
+		}
+		if tbl := info.GetVindexTable(); tbl != nil {
+			return operators.Column{
+				Table: sqlparser.String(tbl.Name),
+				Name:  sqlparser.String(col.Name),
+			}, true
+		}
+		columns, sel, ok := tableSelect(info)
+		if !ok {
+			return operators.Column{}, false
+		}
+		col = derivedColumn(columns, sel, col.Name)
+		if col == nil {
+			return operators.Column{}, false
+		}
+	}
+	return operators.Column{}, false
+}
+
+// derivedColumn returns the column a derived table selects under the given name, or nil when it selects an expression.
+// The columns are the names the derived table gives to the columns of its SELECT, if any.
+func derivedColumn(columns sqlparser.Columns, stmt sqlparser.SelectStatement, name sqlparser.IdentifierCI) *sqlparser.ColName {
+	sel := sqlparser.GetFirstSelect(stmt)
+	for i, expr := range sel.SelectExprs {
+		ae, ok := expr.(*sqlparser.AliasedExpr)
+		if !ok {
+			continue
+		}
+		col, isCol := ae.Expr.(*sqlparser.ColName)
+		var exprName sqlparser.IdentifierCI
+		switch {
+		case i < len(columns):
+			exprName = columns[i]
+		case !ae.As.IsEmpty():
+			exprName = ae.As
+		case isCol:
+			exprName = col.Name
+		}
+		if exprName.Equal(name) {
+			return col
+		}
+	}
+	return nil
+}
+
+func uniqueColumnUses(uses []operators.ColumnUse) []operators.ColumnUse {
+	slices.SortFunc(uses, func(a, b operators.ColumnUse) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return slices.Compact(uses)
+}
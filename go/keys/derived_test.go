@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestDerivedKeys(t *testing.T) {
+	tests := []struct {
+		query          string
+		filters, joins []string
+		predicates     []string
+		grouping       []string
+	}{{
+		query:   "select x.a from (select a, b from t where b = 7) as x where x.a = 2",
+		filters: []string{"t.a =", "t.b ="},
+	}, {
+		query:      "select x.id, u.c from (select id, a from t where b = 7) as x join u on u.t_id = x.id where u.d = 1",
+		filters:    []string{"t.b =", "u.d ="},
+		joins:      []string{"t.id =", "u.t_id ="},
+		predicates: []string{"u.t_id = t.id"},
+	}, {
+		query:    "select y.k, count(*) from (select x.v as k from (select a as v from t) as x) as y where y.k between 1 and 5 group by y.k",
+		filters:  []string{"t.a ge", "t.a le"},
+		grouping: []string{"t.a"},
+	}, {
+		query:      "select t.a from t where t.id in (select u.t_id from u where u.d = 3)",
+		filters:    []string{"t.id in", "u.d ="},
+		joins:      []string{"t.id =", "u.t_id ="},
+		predicates: []string{"t.id = u.t_id"},
+	}, {
+		query:      "select t.a from t where exists (select 1 from u where u.t_id = t.id and u.c = 5)",
+		filters:    []string{"u.c ="},
+		joins:      []string{"t.id =", "u.t_id ="},
+		predicates: []string{"u.t_id = t.id"},
+	}, {
+		// the column of an expression of the derived table has no physical column
+		query: "select x.total from (select a + b as total from t) as x where x.total = 3",
+	}, {
+		query:      "with x as (select id, a from t where b = 7) select x.a, count(*) from x join u on x.id = u.t_id where u.c = 3 group by x.a",
+		filters:    []string{"t.b =", "u.c ="},
+		joins:      []string{"t.id =", "u.t_id ="},
+		predicates: []string{"t.id = u.t_id"},
+		grouping:   []string{"t.a"},
+	}, {
+		query: "with recursive r (n) as (select 1 union all select n + 1 from r where n < 10) select n from r",
+	}, {
+		query:      "with recursive r as (select id, a from t where id = 1 union all select t.id, t.a from t join r on t.a = r.id) select u.c from u join r on u.t_id = r.id where r.a = 4",
+		filters:    []string{"t.a =", "t.id ="},
+		joins:      []string{"t.a =", "t.id =", "u.t_id ="},
+		predicates: []string{"t.a = t.id", "u.t_id = t.id"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			si := &schemaInfo{tables: make(map[string]columns)}
+			ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+			process(data.Query{Query: "create table t (id bigint primary key, a int, b int)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: "create table u (id bigint primary key, t_id bigint, c int, d int)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: tt.query, Line: 1, Type: typ.Query}, si, ql)
+			require.Empty(t, ql.failed)
+			require.Len(t, ql.queries, 1)
+
+			for _, result := range ql.queries {
+				require.Equal(t, tt.filters, stringsOf(result.FilterColumns))
+				require.Equal(t, tt.joins, stringsOf(result.JoinColumns))
+				require.Equal(t, tt.predicates, stringsOf(result.JoinPredicates))
+				require.Equal(t, tt.grouping, stringsOf(result.GroupingColumns))
+			}
+		})
+	}
+}
+
+func stringsOf[T interface{ String() string }](values []T) []string {
+	var strs []string
+	for _, v := range values {
+		strs = append(strs, v.String())
+	}
+	return strs
+}
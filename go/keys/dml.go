@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// affectedTables returns the tables a statement writes to: the table an INSERT or REPLACE inserts into,
+// the tables whose columns an UPDATE sets, and the tables a DELETE deletes from. The other tables of a write,
+// such as the ones of an INSERT ... SELECT or the joined tables of a multi-table UPDATE, are only read.
+// It returns nil for the statements that don't write.
+func affectedTables(ctx *plancontext.PlanningContext, ast sqlparser.Statement) []string {
+	var tables []string
+	switch ast := ast.(type) {
+	case *sqlparser.Insert:
+		if tbl, err := ast.Table.TableName(); err == nil {
+			tables = append(tables, tbl.Name.String())
+		}
+	case *sqlparser.Update:
+		for _, expr := range ast.Exprs {
+			if name, found := columnTable(ctx, expr.Name); found {
+				tables = append(tables, name)
+			}
+		}
+		if len(tables) == 0 {
+			tables = singleTable(ast.TableExprs)
+		}
+	case *sqlparser.Delete:
+		if len(ast.Targets) == 0 {
+			tables = singleTable(ast.TableExprs)
+			break
+		}
+		aliases := tableAliases(ast.TableExprs)
+		for _, target := range ast.Targets {
+			if name, found := aliases[strings.ToLower(target.Name.String())]; found {
+				tables = append(tables, name)
+			}
+		}
+	default:
+		return nil
+	}
+	sort.Strings(tables)
+	return slices.Compact(tables)
+}
+
+// columnTable returns the name of the table of a column, as bound by the semantic analysis
+func columnTable(ctx *plancontext.PlanningContext, col *sqlparser.ColName) (string, bool) {
+	info, err := ctx.SemTable.TableInfoForExpr(col)
+	if err != nil {
+		return "", false
+	}
+	vtbl := info.GetVindexTable()
+	if vtbl == nil {
+		return "", false
+	}
+	return vtbl.Name.String(), true
+}
+
+// singleTable returns the table of a statement on a single table
+func singleTable(exprs sqlparser.TableExprs) []string {
+	if len(exprs) != 1 {
+		return nil
+	}
+	aliased, ok := exprs[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return nil
+	}
+	tbl, err := aliased.TableName()
+	if err != nil {
+		return nil
+	}
+	return []string{tbl.Name.String()}
+}
+
+// tableAliases maps the aliases of the tables, or their names when they have none, to the names of the tables
+func tableAliases(exprs sqlparser.TableExprs) map[string]string {
+	aliases := make(map[string]string)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return true, nil
+		}
+		tbl, err := aliased.TableName()
+		if err != nil {
+			// a derived table
+			return true, nil
+		}
+		alias := tbl.Name.String()
+		if !aliased.As.IsEmpty() {
+			alias = aliased.As.String()
+		}
+		aliases[strings.ToLower(alias)] = tbl.Name.String()
+		return true, nil
+	}, exprs)
+	return aliases
+}
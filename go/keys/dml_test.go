@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestKeysDML(t *testing.T) {
+	si := &schemaInfo{tables: make(map[string]columns)}
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+
+	queries := []string{
+		"create table t (id bigint primary key, a int, b int)",
+		"create table u (id bigint primary key, t_id bigint, c int)",
+		"insert into t (id, a) select id, c from u where c > 10",
+		"update t join u on t.id = u.t_id set t.a = u.c where u.c = 5",
+		"delete t from t join u on t.id = u.t_id where u.c = 7",
+		"update t set a = 1 where b = 2",
+	}
+	for i, query := range queries {
+		process(data.Query{Query: query, Line: i + 1, Type: typ.Query}, si, ql)
+	}
+	require.Empty(t, ql.failed)
+
+	byType := make(map[string][]QueryAnalysisResult)
+	for _, result := range ql.queries {
+		byType[result.StatementType] = append(byType[result.StatementType], *result)
+	}
+
+	require.Len(t, byType["INSERT"], 1)
+	insert := byType["INSERT"][0]
+	require.Equal(t, []string{"t"}, insert.AffectedTables)
+	require.ElementsMatch(t, []string{"t", "u"}, insert.TableName)
+	require.NotEmpty(t, insert.FilterColumns, "the filter on the selected table")
+
+	// the tables of a multi-table UPDATE or DELETE are joined on t.id = u.t_id
+	joined := []operators.JoinPredicate{{
+		LHS:  operators.Column{Table: "t", Name: "id"},
+		RHS:  operators.Column{Table: "u", Name: "t_id"},
+		Uses: sqlparser.EqualOp,
+	}}
+
+	require.Len(t, byType["DELETE"], 1)
+	require.Equal(t, []string{"t"}, byType["DELETE"][0].AffectedTables)
+	require.Equal(t, joined, byType["DELETE"][0].JoinPredicates)
+
+	require.Len(t, byType["UPDATE"], 2)
+	for _, update := range byType["UPDATE"] {
+		require.Equal(t, []string{"t"}, update.AffectedTables)
+		require.NotEmpty(t, update.FilterColumns, "the filter columns of %s", update.QueryStructure)
+		if len(update.TableName) == 1 {
+			require.Empty(t, update.JoinPredicates, "a single-table update joins nothing")
+		} else {
+			require.Equal(t, joined, update.JoinPredicates)
+		}
+	}
+
+	// the tables a write reads from count as reads
+	stats := tableStats(ql.output().Queries)
+	require.Equal(t, []TableStats{
+		{Table: "t", Writes: 4},
+		{Table: "u", Reads: 3},
+	}, stats)
+}
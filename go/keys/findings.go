@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type (
+	// Finding is something an analyzer reports about the workload, usually about a single query.
+	// The findings of the built-in analyzers and of the custom analyzers share this model,
+	// so they can be listed, sorted and compared together.
+	Finding struct {
+		// ID identifies the kind of finding, such as explicit-target. It is stable across runs.
+		ID       string   `json:"id,omitempty"`
+		Analyzer string   `json:"analyzer"`
+		Severity Severity `json:"severity,omitempty"`
+		Category Category `json:"category,omitempty"`
+		Message  string   `json:"message"`
+		// Evidence tells what in the workload triggered the finding
+		Evidence       string `json:"evidence,omitempty"`
+		Recommendation string `json:"recommendation,omitempty"`
+		LineNumber     int    `json:"lineNumber,omitempty"`
+		Query          string `json:"query,omitempty"`
+	}
+
+	// Severity is how urgent a finding is: info, low, medium, high or critical.
+	// The warning and error severities of custom analyzers stand for medium and high.
+	Severity string
+
+	// Category is the kind of problem a finding is about
+	Category string
+)
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+
+	// CategoryAntiPattern is for queries that work, but are slow or wasteful on Vitess
+	CategoryAntiPattern Category = "anti-pattern"
+	// CategoryBlocker is for queries that don't work on Vitess
+	CategoryBlocker Category = "blocker"
+	// CategoryRisk is for queries that work today, but can break or misbehave later
+	CategoryRisk Category = "risk"
+
+	// builtinAnalyzer is the analyzer name of the findings of vt itself
+	builtinAnalyzer = "vt"
+)
+
+// Rank orders the severities, from 0 for info and unknown severities to 4 for critical
+func (s Severity) Rank() int {
+	switch strings.ToLower(string(s)) {
+	case "low":
+		return 1
+	case "medium", "warning":
+		return 2
+	case "high", "error":
+		return 3
+	case "critical":
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ParseSeverity returns the severity with the given name, failing on unknown names
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(strings.ToLower(name))
+	if s.Rank() == 0 && s != SeverityInfo {
+		return "", fmt.Errorf("unknown severity %q, use info, low, medium, high or critical", name)
+	}
+	return s, nil
+}
+
+// SortFindings sorts the findings by decreasing severity, keeping the order of the findings of the same severity
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity.Rank() > findings[j].Severity.Rank()
+	})
+}
+
+// builtinFindings returns the findings of the checks vt runs on every query structure
+func (ql *queryList) builtinFindings(queries []QueryAnalysisResult) []Finding {
+	var findings []Finding
+	for _, q := range queries {
+		if ql.unboundedWrites[q.QueryStructure] {
+			findings = append(findings, Finding{
+				ID:             "unbounded-write",
+				Analyzer:       builtinAnalyzer,
+				Severity:       SeverityHigh,
+				Category:       CategoryAntiPattern,
+				Message:        fmt.Sprintf("%s without a WHERE clause", q.StatementType),
+				Evidence:       fmt.Sprintf("used %d times", q.UsageCount),
+				Recommendation: "modify the rows in batches, a write to every row of a sharded table runs on all shards",
+				LineNumber:     q.LineNumbers[0],
+				Query:          q.QueryStructure,
+			})
+		}
+		if len(q.FunctionFilters) > 0 {
+			filters := make([]string, 0, len(q.FunctionFilters))
+			for _, filter := range q.FunctionFilters {
+				filters = append(filters, filter.String())
+			}
+			findings = append(findings, Finding{
+				ID:             "function-filter",
+				Analyzer:       builtinAnalyzer,
+				Severity:       SeverityMedium,
+				Category:       CategoryAntiPattern,
+				Message:        "query filters on a function of a column",
+				Evidence:       "filters on " + strings.Join(filters, ", "),
+				Recommendation: "compare the column itself, such as created_at >= ? and created_at < ? instead of DATE(created_at) = ?, the function keeps the filter from using the indexes and the vindex of the column",
+				LineNumber:     q.LineNumbers[0],
+				Query:          q.QueryStructure,
+			})
+		}
+		if len(q.Targets) > 0 {
+			targets := make([]string, 0, len(q.Targets))
+			for target := range q.Targets {
+				targets = append(targets, target)
+			}
+			slices.Sort(targets)
+			findings = append(findings, Finding{
+				ID:             "explicit-target",
+				Analyzer:       builtinAnalyzer,
+				Severity:       SeverityMedium,
+				Category:       CategoryRisk,
+				Message:        "query targets a shard or a tablet type explicitly",
+				Evidence:       "targets " + strings.Join(targets, ", "),
+				Recommendation: "let vtgate route the query, explicit shard targets have to be rewritten when resharding",
+				LineNumber:     q.LineNumbers[0],
+				Query:          q.QueryStructure,
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestBuiltinFindings(t *testing.T) {
+	si := &schemaInfo{tables: make(map[string]columns)}
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+
+	queries := []string{
+		"delete from t where t.id = 1",
+		"delete from t",
+		"update t set t.x = 1",
+		"select * from ks[-80].t where t.id = 1",
+		"delete from t",
+		"select * from t where lower(t.x) = 'a'",
+	}
+	for i, query := range queries {
+		process(data.Query{Query: query, Line: i + 1, Type: typ.Query}, si, ql)
+	}
+	require.Empty(t, ql.failed)
+
+	values := make([]QueryAnalysisResult, 0, len(ql.queries))
+	for _, line := range []int{2, 3, 4, 6} {
+		for _, result := range ql.queries {
+			if result.LineNumbers[0] == line {
+				values = append(values, *result)
+			}
+		}
+	}
+	findings := ql.builtinFindings(values)
+	SortFindings(findings)
+
+	require.Len(t, findings, 4)
+	require.Equal(t, "unbounded-write", findings[0].ID)
+	require.Equal(t, SeverityHigh, findings[0].Severity)
+	require.Equal(t, "DELETE without a WHERE clause", findings[0].Message)
+	require.Equal(t, "used 2 times", findings[0].Evidence)
+	require.Equal(t, 2, findings[0].LineNumber)
+	require.Equal(t, "UPDATE without a WHERE clause", findings[1].Message)
+	require.Equal(t, Finding{
+		ID:             "explicit-target",
+		Analyzer:       "vt",
+		Severity:       SeverityMedium,
+		Category:       CategoryRisk,
+		Message:        "query targets a shard or a tablet type explicitly",
+		Evidence:       "targets ks:-80",
+		Recommendation: "let vtgate route the query, explicit shard targets have to be rewritten when resharding",
+		LineNumber:     4,
+		Query:          findings[2].Query,
+	}, findings[2])
+	require.Equal(t, "function-filter", findings[3].ID)
+	require.Equal(t, CategoryAntiPattern, findings[3].Category)
+	require.Equal(t, "filters on lower(t.x) =", findings[3].Evidence)
+	require.Equal(t, 6, findings[3].LineNumber)
+}
+
+func TestSeverityRank(t *testing.T) {
+	require.Greater(t, SeverityCritical.Rank(), SeverityHigh.Rank())
+	require.Equal(t, SeverityMedium.Rank(), Severity("warning").Rank())
+	require.Equal(t, SeverityHigh.Rank(), Severity("ERROR").Rank())
+
+	s, err := ParseSeverity("High")
+	require.NoError(t, err)
+	require.Equal(t, SeverityHigh, s)
+	_, err = ParseSeverity("urgent")
+	require.Error(t, err)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitessio/vt/go/internal/markdown"
+)
+
+// columnsHeader are the columns of the markdown and CSV outputs
+var columnsHeader = []string{"Query", "Statement Type", "Usage Count", "Tables", "Filter Columns", "Join Columns", "Grouping Columns"} //nolint:gochecknoglobals // this is instead of a const
+
+// writeMarkdownTo writes the query structures as a markdown table, the most used first,
+// followed by a table of the failed queries when there are some
+func (ql *queryList) writeMarkdownTo(w io.Writer) error {
+	res := ql.output()
+	if _, err := fmt.Fprintf(w, "## Query structures\n\n"); err != nil {
+		return err
+	}
+	table := markdown.Table(w, columnsHeader)
+	for _, query := range byUsage(res.Queries) {
+		row := queryRow(query)
+		for i, cell := range row {
+			row[i] = markdown.Cell(cell)
+		}
+		table.Append(row)
+	}
+	table.Render()
+
+	if len(res.Failed) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "\n## Failed queries\n\n"); err != nil {
+		return err
+	}
+	table = markdown.Table(w, []string{"Line", "Query", "Error"})
+	for _, failed := range res.Failed {
+		table.Append([]string{strconv.Itoa(failed.LineNumber), markdown.Cell(failed.Query), markdown.Cell(failed.Error)})
+	}
+	table.Render()
+	return nil
+}
+
+// writeCSVTo writes the query structures as CSV, the most used first. The failed queries and the findings are not written.
+func (ql *queryList) writeCSVTo(w io.Writer) error {
+	res := ql.output()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columnsHeader); err != nil {
+		return err
+	}
+	for _, query := range byUsage(res.Queries) {
+		if err := cw.Write(queryRow(query)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// byUsage returns the queries sorted by decreasing usage count, keeping the order of the output for the same count
+func byUsage(queries []QueryAnalysisResult) []QueryAnalysisResult {
+	sorted := append([]QueryAnalysisResult(nil), queries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].UsageCount > sorted[j].UsageCount
+	})
+	return sorted
+}
+
+func queryRow(query QueryAnalysisResult) []string {
+	return []string{
+		query.QueryStructure,
+		query.StatementType,
+		strconv.Itoa(query.UsageCount),
+		strings.Join(query.TableName, ", "),
+		joinStrings(query.FilterColumns),
+		joinStrings(query.JoinColumns),
+		joinStrings(query.GroupingColumns),
+	}
+}
+
+func joinStrings[T fmt.Stringer](values []T) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, v.String())
+	}
+	return strings.Join(strs, ", ")
+}
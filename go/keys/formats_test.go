@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysMarkdown(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "log.test")
+	require.NoError(t, os.WriteFile(fileName, []byte("delete from t;\nselect * from t where id = 1;\nselect * from t where id = 2 or id = 3;\nselect * from t where id = 4;\nselect from;\n"), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{fileName}, Format: FormatMarkdown})
+	require.NoError(t, err)
+
+	// ~ stands for the backticks, which can't be in a raw string
+	expected := `## Query structures
+
+| Query                                                                       | Statement Type | Usage Count | Tables | Filter Columns | Join Columns | Grouping Columns |
+|-----------------------------------------------------------------------------|----------------|-------------|--------|----------------|--------------|------------------|
+| SELECT * FROM ~t~ WHERE ~id~ = :_id /* INT64 */                             | SELECT         |           2 | t      | t.id =         |              |                  |
+| DELETE FROM ~t~                                                             | DELETE         |           1 | t      |                |              |                  |
+| SELECT * FROM ~t~ WHERE ~id~ = :_id /* INT64 */ OR ~id~ = :_id1 /* INT64 */ | SELECT         |           1 | t      |                |              |                  |
+
+## Failed queries
+
+| Line | Query        | Error                                   |
+|------|--------------|-----------------------------------------|
+|    5 | select from; | syntax error at position 12 near 'from' |
+`
+	require.Equal(t, strings.ReplaceAll(expected, "~", "`"), out.String())
+}
+
+func TestKeysCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "log.test")
+	require.NoError(t, os.WriteFile(fileName, []byte("select a, count(*) from t join u on t.id = u.t_id where u.b = 2 group by a;\nselect * from t where id = 1;\nselect * from t where id = 2;\n"), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{fileName}, Format: FormatCSV})
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, columnsHeader, records[0])
+	require.Equal(t, []string{"SELECT", "2", "t", "t.id =", "", ""}, records[1][1:])
+	require.Equal(t, []string{"SELECT", "1", "t, u", "u.b =", "t.id =, u.t_id =", ""}, records[2][1:])
+}
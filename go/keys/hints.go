@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+const vtHintPrefix = "/*vt+"
+
+// extractHints removes all the Vitess specific comment directives (/*vt+ ... */) from the statement,
+// so they don't end up in the query structure, and returns them as NAME=value strings.
+// Directives without a value, such as ALLOW_SCATTER, are returned as just the name.
+func extractHints(ast sqlparser.Statement) []string {
+	commented, ok := ast.(sqlparser.Commented)
+	if !ok {
+		return nil
+	}
+	comments := commented.GetParsedComments().GetComments()
+	if len(comments) == 0 {
+		return nil
+	}
+
+	var hints []string
+	var remaining sqlparser.Comments
+	for _, comment := range comments {
+		if !strings.HasPrefix(comment, vtHintPrefix) {
+			remaining = append(remaining, comment)
+			continue
+		}
+		// strip the comment markers and split the directives on whitespace
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(comment, vtHintPrefix), "*/"))
+		for _, field := range fields {
+			name, val, found := strings.Cut(field, "=")
+			name = strings.ToUpper(name)
+			if found {
+				name += "=" + val
+			}
+			hints = append(hints, name)
+		}
+	}
+
+	if len(hints) > 0 {
+		commented.SetComments(remaining)
+	}
+	return hints
+}
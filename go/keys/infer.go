@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+// inferSchema guesses the columns of the tables from the queries, for the logs that don't create their tables
+// when no schema is given. A column is attributed to a table when the query qualifies it with the table or its alias,
+// or when the query uses that table only; its type is guessed from the literals it is compared with or inserted as.
+// The inferred tables are not authoritative: the columns never seen alone remain possible columns of every table,
+// but those seen are attributed to their table in the queries joining tables without qualifying the columns.
+// Nothing is inferred when the log creates tables. It returns the number of tables inferred.
+func (s *schemaInfo) inferSchema(queries []data.Query, renames Renames) int {
+	parser := sqlparser.NewTestParser()
+	inferred := make(map[string]columns)
+	add := func(table, column string, typ querypb.Type) {
+		cols := inferred[table]
+		i := slices.IndexFunc(cols, func(c vindexes.Column) bool { return c.Name.EqualString(column) })
+		if i < 0 {
+			inferred[table] = append(cols, vindexes.Column{Name: sqlparser.NewIdentifierCI(column), Type: typ})
+			return
+		}
+		if cols[i].Type == querypb.Type_NULL_TYPE {
+			cols[i].Type = typ
+		}
+	}
+
+	for _, q := range queries {
+		if q.Type != typ.Query {
+			continue
+		}
+		ast, err := parser.Parse(q.Query)
+		if err != nil {
+			if quoted := quoteTargets(q.Query); quoted != q.Query {
+				ast, err = parser.Parse(quoted)
+			}
+		}
+		if err != nil {
+			continue
+		}
+		if _, ok := ast.(*sqlparser.CreateTable); ok {
+			return 0
+		}
+		_ = stripTargets(ast)
+		renames.rewrite(ast, false)
+		inferColumns(ast, add)
+	}
+
+	if s.inferred == nil {
+		s.inferred = make(map[string]bool)
+	}
+	for table, cols := range inferred {
+		if _, known := s.tables[table]; known {
+			continue
+		}
+		s.tables[table] = cols
+		s.inferred[table] = true
+	}
+	if len(inferred) > 0 {
+		log.Infof("the log creates no table, inferred the columns of %d tables from the queries", len(inferred))
+	}
+	return len(inferred)
+}
+
+// inferColumns calls add for the columns of the statement it can attribute to a table, see inferSchema
+func inferColumns(stmt sqlparser.Statement, add func(table, column string, typ querypb.Type)) {
+	// the names the tables are referred to by, the aliases of the select expressions and the CTEs are not columns
+	tables := make(map[string]string)
+	var tableNames []string
+	ctes := make(map[string]bool)
+	aliases := make(map[string]bool)
+	types := make(map[*sqlparser.ColName]querypb.Type)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.CommonTableExpr:
+			ctes[node.ID.String()] = true
+		case *sqlparser.AliasedExpr:
+			if !node.As.IsEmpty() {
+				aliases[node.As.Lowered()] = true
+			}
+		case *sqlparser.ComparisonExpr:
+			if col, ok := node.Left.(*sqlparser.ColName); ok {
+				types[col] = literalType(node.Right)
+			}
+			if col, ok := node.Right.(*sqlparser.ColName); ok {
+				types[col] = literalType(node.Left)
+			}
+		}
+		return true, nil
+	}, stmt)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return true, nil
+		}
+		tbl, ok := aliased.Expr.(sqlparser.TableName)
+		if !ok || ctes[tbl.Name.String()] {
+			return true, nil
+		}
+		name := tbl.Name.String()
+		if !slices.Contains(tableNames, name) {
+			tableNames = append(tableNames, name)
+		}
+		if aliased.As.IsEmpty() {
+			tables[name] = name
+		} else {
+			tables[aliased.As.String()] = name
+		}
+		return true, nil
+	}, stmt)
+
+	var single string
+	if len(tableNames) == 1 {
+		single = tableNames[0]
+	}
+	if ins, ok := stmt.(*sqlparser.Insert); ok && single != "" {
+		rows, _ := ins.Rows.(sqlparser.Values)
+		for i, col := range ins.Columns {
+			typ := querypb.Type_NULL_TYPE
+			if len(rows) > 0 && i < len(rows[0]) {
+				typ = literalType(rows[0][i])
+			}
+			add(single, col.String(), typ)
+		}
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		col, ok := node.(*sqlparser.ColName)
+		if !ok {
+			return true, nil
+		}
+		table := single
+		switch {
+		case !col.Qualifier.IsEmpty():
+			table = tables[col.Qualifier.Name.String()]
+		case aliases[col.Name.Lowered()]:
+			table = ""
+		}
+		if table != "" {
+			add(table, col.Name.String(), types[col])
+		}
+		return true, nil
+	}, stmt)
+}
+
+// literalType returns the type of a literal, NULL_TYPE for the other expressions
+func literalType(expr sqlparser.Expr) querypb.Type {
+	lit, ok := expr.(*sqlparser.Literal)
+	if !ok {
+		return querypb.Type_NULL_TYPE
+	}
+	switch lit.Type {
+	case sqlparser.StrVal:
+		return querypb.Type_VARCHAR
+	case sqlparser.IntVal:
+		return querypb.Type_INT64
+	case sqlparser.FloatVal:
+		return querypb.Type_FLOAT64
+	case sqlparser.DecimalVal:
+		return querypb.Type_DECIMAL
+	case sqlparser.HexNum, sqlparser.HexVal, sqlparser.BitNum:
+		return querypb.Type_VARBINARY
+	default:
+		return querypb.Type_NULL_TYPE
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestInferSchema(t *testing.T) {
+	join := "select id from orders join customer on customer_id = cid where region = 'eu'"
+	var queries []data.Query
+	for i, query := range []string{
+		"select region from customer where cid = 1 and region <> ''",
+		"select o.customer_id, o.status from orders as o where o.id = 2",
+		"insert into orders (id, customer_id, amount) values (3, 4, 9.99)",
+		"select status as s from orders order by s",
+		join,
+	} {
+		queries = append(queries, data.Query{Query: query, Line: i + 1, Type: typ.Query})
+	}
+
+	si := &schemaInfo{tables: make(map[string]columns)}
+	require.Equal(t, 2, si.inferSchema(queries, nil))
+	names := func(table string) []string {
+		return slice.Map(si.tables[table], func(c vindexes.Column) string { return c.Name.String() + " " + c.Type.String() })
+	}
+	require.Equal(t, []string{"region VARCHAR", "cid INT64"}, names("customer"))
+	// the alias of a select expression is not a column
+	require.Equal(t, []string{"customer_id INT64", "status NULL_TYPE", "id INT64", "amount DECIMAL"}, names("orders"))
+
+	// the unqualified columns of the join are attributed to the tables they were seen in
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+	process(data.Query{Query: join, Line: 5, Type: typ.Query}, si, ql)
+	require.Empty(t, ql.failed)
+	for _, result := range ql.queries {
+		require.Equal(t, []string{"customer.region ="}, stringsOf(result.FilterColumns))
+		require.Equal(t, []string{"orders.customer_id = customer.cid"}, stringsOf(result.JoinPredicates))
+		require.Empty(t, result.UnresolvedColumns)
+	}
+
+	// a column never seen alone is still looked for in all the tables
+	process(data.Query{Query: "select id from orders join customer on customer_id = cid where vip = 1", Line: 6, Type: typ.Query}, si, ql)
+	require.Empty(t, ql.failed)
+
+	// the tables of a log that creates them are not guessed
+	queries = append(queries, data.Query{Query: "create table customer (cid bigint, region varchar(10))", Line: 7, Type: typ.Query})
+	si = &schemaInfo{tables: make(map[string]columns)}
+	require.Zero(t, si.inferSchema(queries, nil))
+	require.Empty(t, si.tables)
+}
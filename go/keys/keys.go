@@ -17,12 +17,21 @@ limitations under the License.
 package keys
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"maps"
+	"math"
 	"os"
+	"runtime"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
@@ -33,173 +42,961 @@ import (
 	"github.com/vitessio/vt/go/typ"
 )
 
-func Run(fileName string) error {
-	return run(os.Stdout, fileName)
+// Config contains the options of a 'vt keys' run
+type Config struct {
+	// FileNames are the logs to analyse. Several logs, such as one per replica, are merged into a single analysis
+	// that records how many times each query was used in each of them. Identical logs are only read once.
+	FileNames []string
+
+	// Loader is used to read the queries from FileNames. Defaults to the mysqltest format.
+	Loader data.Loader
+
+	// OrderByTimestamp merges several logs in the order their queries were executed, instead of one after the other
+	OrderByTimestamp bool
+
+	// NormalizePlaceholders makes queries with literals and queries with `?` placeholders
+	// aggregate into the same query structure. See data.NormalizePlaceholders.
+	NormalizePlaceholders bool
+
+	// Filter selects the queries to analyse
+	Filter data.Filter
+
+	// Sample analyses a sample of the queries only. The counts in the output are scaled to estimate the whole log
+	// when a rate is given, while a sample limited to a number of queries is recorded in Output.MaxQueries.
+	Sample data.Sample
+
+	// Analyzers are user provided binaries that are run on the queries, their findings are added to the output
+	Analyzers []string
+
+	// Renames renames the tables of the queries before they are analysed, so a workload captured before
+	// a rename or a migration aggregates with the one captured after it
+	Renames Renames
+
+	// Format is FormatJSON, the default, FormatJSONL, FormatMarkdown or FormatCSV
+	Format string
+
+	// SchemaFile is a file with the CREATE TABLE statements of the tables, loaded before the queries.
+	// It is needed for the logs that only have the DML, the tables created by the logs are added to it.
+	SchemaFile string
+
+	// LiveSchema, when set, is the database the tables unknown to the logs and the schema file are read from
+	LiveSchema *LiveSchema
+
+	// MaxLiteralLength truncates the string and hexadecimal literals longer than this number of bytes,
+	// such as the blobs of INSERTs, see data.TruncateLiterals. They are kept whole when it is 0.
+	MaxLiteralLength int
+
+	// MergeInto is a previous JSON output of 'vt keys' that the queries of the logs are added to.
+	// The file is replaced by the merged output, which is not written to the output of the run.
+	MergeInto string
+
+	// Parallel is the number of queries analysed concurrently. The output doesn't depend on it.
+	// The queries are analysed one after the other when it is 0 or 1.
+	Parallel int
+
+	// Bucket, when set, counts the usage of every query structure per bucket of this duration,
+	// for the log formats with timestamps, so the traffic of the queries over time can be told apart
+	Bucket time.Duration
+
+	// TopValues, when set, records this number of the most used literal values of every filter column,
+	// and estimates the number of distinct values of the column, see ColumnValues
+	TopValues int
+
+	// Progress logs the number of queries analysed, the failures and the estimated time left periodically,
+	// for the long analyses of large logs
+	Progress bool
+}
+
+const (
+	// FormatJSON writes the output as a single JSON document, the format 'vt summarize' reads
+	FormatJSON = "json"
+	// FormatJSONL writes one JSON object per line, for processing large outputs with tools like jq
+	FormatJSONL = "jsonl"
+	// FormatMarkdown writes the query structures and the failed queries as markdown tables, for a quick look at the workload
+	FormatMarkdown = "markdown"
+	// FormatCSV writes the query structures as CSV, for spreadsheets
+	FormatCSV = "csv"
+)
+
+const (
+	// FileType identifies the JSON output of 'vt keys' among the files 'vt summarize' reads
+	FileType = "keys"
+	// OutputVersion is the version of the JSON output, it is increased when the output changes in a way
+	// older versions of vt can't read
+	OutputVersion = 1
+)
+
+// DefaultMaxLiteralLength is the default of Config.MaxLiteralLength: the blobs of the INSERTs are truncated,
+// the usual string literals are kept whole
+const DefaultMaxLiteralLength = 1024
+
+// DefaultConfig returns the config with the defaults of the command line of 'vt keys', the files and the loader
+// are left to set
+func DefaultConfig() Config {
+	return Config{
+		Format:           FormatJSON,
+		MaxLiteralLength: DefaultMaxLiteralLength,
+		Parallel:         runtime.NumCPU(),
+	}
+}
+
+// Formats lists the supported output formats
+var Formats = []string{FormatJSON, FormatJSONL, FormatMarkdown, FormatCSV} //nolint:gochecknoglobals // this is instead of a const
+
+func Run(cfg Config) error {
+	return run(context.Background(), os.Stdout, cfg)
+}
+
+// RunTo runs the keys analysis and writes the JSON output to out. When ctx is canceled, the analysis stops
+// and the output of the queries analysed so far is written before ctx.Err() is returned.
+func RunTo(ctx context.Context, out io.Writer, cfg Config) error {
+	return run(ctx, out, cfg)
+}
+
+func run(ctx context.Context, out io.Writer, cfg Config) error {
+	if err := cfg.Sample.Validate(); err != nil {
+		return err
+	}
+	switch cfg.Format {
+	case "", FormatJSON, FormatJSONL, FormatMarkdown, FormatCSV:
+	default:
+		last := len(Formats) - 1
+		return fmt.Errorf("unknown output format %q, use %s or %s", cfg.Format, strings.Join(Formats[:last], ", "), Formats[last])
+	}
+	if cfg.Bucket < 0 {
+		return fmt.Errorf("the bucket duration can't be negative: %s", cfg.Bucket)
+	}
+	si := newSchemaInfo()
+	ql := newQueryList(cfg)
+	if cfg.MergeInto != "" {
+		if ql.sampled() || (cfg.Format != "" && cfg.Format != FormatJSON) {
+			return fmt.Errorf("merging into %s works with the json format and without sampling", cfg.MergeInto)
+		}
+		if err := ql.loadPrevious(cfg.MergeInto); err != nil {
+			return err
+		}
+	}
+	if cfg.SchemaFile != "" {
+		if err := si.loadSchema(cfg.SchemaFile, cfg.Renames); err != nil {
+			return err
+		}
+	}
+	if cfg.LiveSchema != nil {
+		conn, err := cfg.LiveSchema.connect()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		si.fetchFrom(conn)
+	}
+	queries, err := loadQueries(cfg)
+	if err != nil {
+		return err
+	}
+
+	processed, total, err := analyseLog(ctx, queries, si, ql, cfg, cfg.SchemaFile == "" && cfg.LiveSchema == nil)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		if cfg.MergeInto != "" {
+			// merging a partial analysis would count its queries twice when the logs are analysed again
+			log.Warnf("analysis interrupted after %d of %d queries, %s is left untouched", processed, total, cfg.MergeInto)
+			return ctx.Err()
+		}
+		log.Warnf("analysis interrupted after %d of %d queries, writing the partial output", processed, total)
+		if err := ql.writeTo(out, cfg.Format); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+
+	if cfg.MergeInto != "" {
+		return ql.writeMerged(cfg.MergeInto)
+	}
+	return ql.writeTo(out, cfg.Format)
 }
 
-func run(out io.Writer, fileName string) error {
-	si := &schemaInfo{
+func newSchemaInfo() *schemaInfo {
+	return &schemaInfo{
 		tables: make(map[string]columns),
 	}
+}
+
+func newQueryList(cfg Config) *queryList {
 	ql := &queryList{
-		queries: make(map[string]*QueryAnalysisResult),
+		queries:   make(map[string]*QueryAnalysisResult),
+		renames:   cfg.Renames,
+		bucket:    cfg.Bucket,
+		topValues: cfg.TopValues,
+	}
+	if !cfg.Sample.IsEmpty() {
+		ql.sample = cfg.Sample
+	}
+	return ql
+}
+
+// analyseLog analyses the queries loaded from the logs into ql. The schema is inferred from the queries when
+// inferSchema is set. It returns how many of the queries to analyse were analysed before ctx was canceled.
+func analyseLog(ctx context.Context, queries []data.Query, si *schemaInfo, ql *queryList, cfg Config, inferSchema bool) (processed, total int, err error) {
+	queries = data.TruncateLiterals(queries, cfg.MaxLiteralLength)
+	if inferSchema {
+		si.inferSchema(queries, cfg.Renames)
+	}
+	queries = cfg.Filter.Apply(queries)
+	queries = cfg.Sample.Apply(queries)
+	if cfg.NormalizePlaceholders {
+		queries = data.NormalizePlaceholders(queries)
+	}
+	for _, analyzer := range cfg.Analyzers {
+		findings, err := runAnalyzer(analyzer, queries)
+		if err != nil {
+			return 0, 0, err
+		}
+		ql.findings = append(ql.findings, findings...)
 	}
-	queries, err := data.LoadQueries(fileName)
+
+	queries, err = analysedQueries(queries)
 	if err != nil {
-		return err
+		return 0, 0, err
+	}
+	var p *progress
+	if cfg.Progress {
+		p = newProgress(len(queries))
+	}
+	if cfg.Parallel > 1 {
+		processed = processParallel(ctx, queries, si, ql, cfg.Parallel, p)
+	} else {
+		for _, query := range queries {
+			if ctx.Err() != nil {
+				break
+			}
+			process(query, si, ql)
+			processed++
+			p.update(processed, len(ql.failed))
+		}
 	}
+	p.done(processed, len(ql.failed))
+	return processed, len(queries), nil
+}
+
+// bucketSize is the BucketSize of the output for the given bucket duration
+func bucketSize(bucket time.Duration) string {
+	if bucket == 0 {
+		return ""
+	}
+	return bucket.String()
+}
 
+// analysedQueries returns the queries to analyse: the statements of the log, without the commands of the
+// mysqltest format and the queries they tell to skip
+func analysedQueries(queries []data.Query) ([]data.Query, error) {
+	result := make([]data.Query, 0, len(queries))
 	skip := false
 	for _, query := range queries {
 		switch query.Type {
 		case typ.Skip, typ.Error, typ.VExplain:
 			skip = true
 		case typ.Unknown:
-			return fmt.Errorf("unknown command type: %s", query.Type)
-		case typ.Comment, typ.CommentWithCommand, typ.EmptyLine, typ.WaitForAuthoritative, typ.SkipIfBelowVersion:
+			return nil, fmt.Errorf("unknown command type: %s", query.Type)
+		case typ.Comment, typ.CommentWithCommand, typ.EmptyLine, typ.WaitForAuthoritative, typ.SkipIfBelowVersion, typ.AssertRowCount:
 			// no-op for keys
 		case typ.Query:
 			if skip {
 				skip = false
 				continue
 			}
-			process(query, si, ql)
+			result = append(result, query)
+		}
+	}
+	return result, nil
+}
+
+// loadQueries reads the queries of all the logs of the configuration and merges them
+func loadQueries(cfg Config) ([]data.Query, error) {
+	loader := cfg.Loader
+	if loader == nil {
+		loader = data.MySQLTestLoader{}
+	}
+	if len(cfg.FileNames) == 1 {
+		return loader.Load(cfg.FileNames[0])
+	}
+
+	var logs [][]data.Query
+	seen := make(map[uint64]string, len(cfg.FileNames))
+	for _, fileName := range cfg.FileNames {
+		queries, err := loader.Load(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", fileName, err)
+		}
+		sum := checksum(queries)
+		if first, found := seen[sum]; found {
+			log.Warnf("%s is identical to %s, skipping it", fileName, first)
+			continue
+		}
+		seen[sum] = fileName
+		for i := range queries {
+			queries[i].File = fileName
 		}
+		logs = append(logs, queries)
 	}
 
-	return ql.writeJSONTo(out)
+	if cfg.OrderByTimestamp {
+		return data.MergeByTimestamp(logs...), nil
+	}
+	return slices.Concat(logs...), nil
+}
+
+// checksum identifies the content of a log, to recognise a log that is passed twice
+func checksum(queries []data.Query) uint64 {
+	h := fnv.New64a()
+	for _, q := range queries {
+		fmt.Fprintf(h, "%d\x00%d\x00%s\x00%d\x00%d\x00", q.Type, q.Line, q.Query, q.Timestamp.UnixNano(), q.ConnectionID)
+	}
+	return h.Sum64()
 }
 
 func process(q data.Query, si *schemaInfo, ql *queryList) {
-	ast, bv, err := sqlparser.NewTestParser().Parse2(q.Query)
+	ql.add(q, analyse(q, si, ql.renames, ql.topValues > 0, ql.analysed), si)
+}
+
+// analysis is the result of the analysis of a query, which queryList.add adds to the list
+type analysis struct {
+	// failed is set when the query could not be parsed or analysed
+	failed *QueryFailedResult
+	// create is set for the CREATE TABLE statements, whose table is added to the schema
+	create *sqlparser.CreateTable
+
+	structure string
+	hints     []string
+	targets   []string
+	// keys holds what vexplain keys found about the query structure, it is nil when the structure was analysed already
+	keys           *QueryAnalysisResult
+	unboundedWrite bool
+	// values are the literal values the query compares its filter columns with, when they are recorded
+	values []columnValue
+}
+
+// analyse parses and analyses a query. The query structures for which analysed is true have their keys left out.
+// The values of the filters are recorded when withValues is set.
+// It only reads the schema and the renames, so queries can be analysed concurrently, see processParallel.
+func analyse(q data.Query, si *schemaInfo, renames Renames, withValues bool, analysed func(structure string) bool) analysis {
+	parser := sqlparser.NewTestParser()
+	ast, bv, err := parser.Parse2(q.Query)
 	if err != nil {
-		ql.failed = append(ql.failed, QueryFailedResult{
-			Query:      q.Query,
-			LineNumber: q.Line,
-			Error:      err.Error(),
-		})
-		return
+		// the parser only accepts shard and tablet type targets, like ks[-80].t, when they are quoted
+		if quoted := quoteTargets(q.Query); quoted != q.Query {
+			ast, bv, err = parser.Parse2(quoted)
+		}
+	}
+	if err != nil {
+		return analysis{failed: failure(q, err)}
 	}
 
 	switch ast := ast.(type) {
 	case *sqlparser.CreateTable:
-		si.handleCreateTable(ast)
+		renames.rewrite(ast, false)
+		return analysis{create: ast}
 	case sqlparser.Statement:
-		st, err := semantics.Analyze(ast, "ks", si)
+		targets := stripTargets(ast)
+		renames.rewrite(ast, false)
+		hints := extractHints(ast)
+		structure, err := queryStructure(ast, bv, si)
+		if err != nil {
+			return analysis{failed: failure(q, err)}
+		}
+		st, err := semantics.Analyze(ast, "ks", shardedSchema{si})
 		if err != nil {
-			ql.failed = append(ql.failed, QueryFailedResult{
-				Query:      q.Query,
-				LineNumber: q.Line,
-				Error:      err.Error(),
-			})
-			return
+			return analysis{failed: failure(q, err)}
 		}
 		ctx := &plancontext.PlanningContext{
 			ReservedVars: sqlparser.NewReservedVars("", bv),
 			SemTable:     st,
 		}
-		ql.processQuery(ctx, ast, q)
+		var values []columnValue
+		if withValues {
+			// the literals are replaced by bind variables when the query is normalized
+			values = filterValues(ctx, ast)
+		}
+		a := analyseQuery(ctx, ast, q, analysis{structure: structure, hints: hints, targets: targets}, analysed)
+		if a.failed == nil {
+			a.values = values
+		}
+		return a
+	}
+	return analysis{}
+}
+
+func failure(q data.Query, err error) *QueryFailedResult {
+	return &QueryFailedResult{
+		Query:      q.Query,
+		LineNumber: q.Line,
+		File:       q.File,
+		Error:      err.Error(),
 	}
 }
 
 // Output represents the output generated by 'vt keys'
 type Output struct {
+	// FileType is always FileType, and Version is OutputVersion when the file was written. The outputs of
+	// the versions of vt that didn't write these fields have neither.
+	FileType string `json:"fileType,omitempty"`
+	Version  int    `json:"version,omitempty"`
+
 	Queries []QueryAnalysisResult `json:"queries"`
+	Tables  []TableStats          `json:"tables,omitempty"`
 	Failed  []QueryFailedResult   `json:"failed,omitempty"`
+
+	// Findings are reported by the built-in checks and by the custom analyzers, sorted by decreasing severity
+	Findings []Finding `json:"findings,omitempty"`
+
+	// SampleRate is set when only a sample of the log was analysed. The usage counts, and the other counts of
+	// the queries, are then estimates for the whole log, while the line numbers are those of the sampled queries.
+	SampleRate float64 `json:"sampleRate,omitempty"`
+
+	// MaxQueries is set when the analysis stopped after this many queries of the log, or of its sample.
+	// The counts are those of the analysed queries, they are not scaled to the whole log.
+	MaxQueries int `json:"maxQueries,omitempty"`
+
+	// BucketSize is the duration of the buckets of the usage counts of the queries, such as 1h0m0s,
+	// when the analysis counted them per bucket
+	BucketSize string `json:"bucketSize,omitempty"`
+
+	// Values are the literal values of the filter columns, when the analysis recorded them
+	Values []ColumnValues `json:"values,omitempty"`
+}
+
+// CheckVersion returns an error when the output is not a 'vt keys' output, or one written by a newer version of vt
+func (o *Output) CheckVersion() error {
+	if o.FileType != "" && o.FileType != FileType {
+		return fmt.Errorf("the file type is %q instead of %q", o.FileType, FileType)
+	}
+	if o.Version > OutputVersion {
+		return fmt.Errorf("the keys output version %d is newer than the version %d this vt reads, upgrade vt", o.Version, OutputVersion)
+	}
+	return nil
+}
+
+// TableStats contains how many of the queries in the log read from and wrote to a table
+type TableStats struct {
+	Table  string `json:"table"`
+	Reads  int    `json:"reads"`
+	Writes int    `json:"writes"`
+	// QPS is the number of queries per second using the table over the time span of the log.
+	// It is only set when the log has timestamps.
+	QPS float64 `json:"qps,omitempty"`
 }
 
 type queryList struct {
 	queries map[string]*QueryAnalysisResult
 	failed  []QueryFailedResult
+
+	findings []Finding
+
+	renames Renames
+
+	// unboundedWrites are the query structures of the updates and deletes without a WHERE clause
+	unboundedWrites map[string]bool
+
+	// sample is set when only a sample of the log was analysed
+	sample data.Sample
+
+	// bucket is the duration of the buckets the usage of the queries is counted in, when set
+	bucket time.Duration
+
+	// topValues is the number of values recorded for every filter column in values, when set
+	topValues int
+	values    map[operators.Column]*valueSketch
+}
+
+// queryStructure is the structure of the query: the normalized statement as the analysis on the unsharded keyspace
+// leaves it. The analysis of the queries on a single table doesn't change them there, while the analysis on a sharded
+// keyspace expands their stars and qualifies their columns, and the structures would not match the earlier outputs.
+func queryStructure(ast sqlparser.Statement, known sqlparser.BindVars, si *schemaInfo) (string, error) {
+	stmt := sqlparser.Clone(ast)
+	if _, err := semantics.Analyze(stmt, "ks", si); err != nil {
+		return "", err
+	}
+	bv := make(map[string]*querypb.BindVariable)
+	if err := sqlparser.Normalize(stmt, sqlparser.NewReservedVars("", maps.Clone(known)), bv); err != nil {
+		return "", err
+	}
+	return sqlparser.CanonicalString(stmt), nil
 }
 
-func (ql *queryList) processQuery(ctx *plancontext.PlanningContext, ast sqlparser.Statement, q data.Query) {
+// analyseQuery normalizes the query and analyses its structure, given in a, unless it was analysed already
+func analyseQuery(ctx *plancontext.PlanningContext, ast sqlparser.Statement, q data.Query, a analysis, analysed func(string) bool) analysis {
 	bv := make(map[string]*querypb.BindVariable)
 	err := sqlparser.Normalize(ast, ctx.ReservedVars, bv)
 	if err != nil {
-		ql.failed = append(ql.failed, QueryFailedResult{
-			Query:      q.Query,
-			LineNumber: q.Line,
-			Error:      err.Error(),
-		})
-		return
+		return analysis{failed: failure(q, err)}
 	}
-	structure := sqlparser.CanonicalString(ast)
-	r, found := ql.queries[structure]
-	if found {
-		r.UsageCount++
-		r.LineNumbers = append(r.LineNumbers, q.Line)
-		return
+	a.unboundedWrite = isUnboundedWrite(ast)
+	if analysed(a.structure) {
+		return a
 	}
 
 	var tableNames []string
 	for _, t := range ctx.SemTable.Tables {
 		rtbl, ok := t.(*semantics.RealTable)
-		if !ok {
+		if !ok || rtbl.Table == nil {
+			// the references to a recursive CTE have no table, the tables the CTE reads are listed on their own
 			continue
 		}
 		tableNames = append(tableNames, rtbl.Table.Name.String())
 	}
 
 	result := operators.GetVExplainKeys(ctx, ast)
-	ql.queries[structure] = &QueryAnalysisResult{
-		QueryStructure:  structure,
-		StatementType:   result.StatementType,
-		UsageCount:      1,
-		LineNumbers:     []int{q.Line},
-		TableName:       tableNames,
-		GroupingColumns: result.GroupingColumns,
-		JoinColumns:     result.JoinColumns,
-		JoinPredicates:  result.JoinPredicates,
-		FilterColumns:   result.FilterColumns,
+	addDerivedKeys(ctx, ast, &result)
+	ordering, limit, offset := orderingColumns(ctx, ast)
+	a.keys = &QueryAnalysisResult{
+		QueryStructure:    a.structure,
+		StatementType:     result.StatementType,
+		TableName:         tableNames,
+		AffectedTables:    affectedTables(ctx, ast),
+		GroupingColumns:   result.GroupingColumns,
+		OrderingColumns:   ordering,
+		Limit:             limit,
+		Offset:            offset,
+		JoinColumns:       result.JoinColumns,
+		JoinPredicates:    result.JoinPredicates,
+		FilterColumns:     result.FilterColumns,
+		FunctionFilters:   functionFilterColumns(ctx, ast),
+		FullScan:          isFullScan(ast, tableNames),
+		UnresolvedColumns: unresolvedColumns(ctx.SemTable, ast),
 	}
+	return a
 }
 
-// writeJsonTo writes the query list, sorted by the first line number of the query, to the given writer.
-func (ql *queryList) writeJSONTo(w io.Writer) error {
+// analysed tells if the query structure is in the list already
+func (ql *queryList) analysed(structure string) bool {
+	_, found := ql.queries[structure]
+	return found
+}
+
+// add adds the analysis of the query to the list, or its table to the schema for a CREATE TABLE
+func (ql *queryList) add(q data.Query, a analysis, si *schemaInfo) {
+	switch {
+	case a.failed != nil:
+		ql.failed = append(ql.failed, *a.failed)
+		return
+	case a.create != nil:
+		si.handleCreateTable(a.create)
+		return
+	case a.structure == "":
+		return
+	}
+
+	r, found := ql.queries[a.structure]
+	if !found {
+		r = a.keys
+		ql.queries[a.structure] = r
+		if a.unboundedWrite {
+			if ql.unboundedWrites == nil {
+				ql.unboundedWrites = make(map[string]bool)
+			}
+			ql.unboundedWrites[a.structure] = true
+		}
+	}
+	r.UsageCount += q.Executions()
+	r.LineNumbers = append(r.LineNumbers, q.Line)
+	r.addTimestamp(q.Timestamp)
+	r.addBucket(q.Timestamp, ql.bucket, q.Executions())
+	r.addHints(a.hints, q.Executions())
+	r.addExecution(q.Execution, q.Executions())
+	r.addHostgroup(q.Hostgroup, q.Executions())
+	r.addUser(q.User, q.Executions())
+	r.addFile(q.File, q.Executions())
+	r.addTargets(a.targets, q.Executions())
+	ql.addValues(a.values, q.Executions())
+}
+
+// isUnboundedWrite tells if the statement is an update or a delete of all the rows of its tables
+func isUnboundedWrite(ast sqlparser.Statement) bool {
+	switch ast := ast.(type) {
+	case *sqlparser.Update:
+		return ast.Where == nil
+	case *sqlparser.Delete:
+		return ast.Where == nil
+	default:
+		return false
+	}
+}
+
+// sampled tells if only a sample of the log was analysed
+func (ql *queryList) sampled() bool {
+	return !ql.sample.IsEmpty()
+}
+
+// sampleRate is the rate the log was sampled at, 0 when the whole log was analysed or the sample has no rate
+func (ql *queryList) sampleRate() float64 {
+	if ql.sample.Rate == 1 {
+		return 0
+	}
+	return ql.sample.Rate
+}
+
+// output returns the query list, sorted by the first line number of the query, with the table statistics and the findings
+func (ql *queryList) output() Output {
 	values := make([]QueryAnalysisResult, 0, len(ql.queries))
+	scale := ql.sample.Scale()
 	for _, result := range ql.queries {
-		values = append(values, *result)
+		value := *result
+		if value.ID == "" {
+			value.ID = QueryID(value.QueryStructure)
+		}
+		if scale != 1 {
+			value.scale(scale)
+		}
+		values = append(values, value)
 	}
 
 	sort.Slice(values, func(i, j int) bool {
-		return values[i].LineNumbers[0] < values[j].LineNumbers[0]
+		if values[i].LineNumbers[0] != values[j].LineNumbers[0] {
+			return values[i].LineNumbers[0] < values[j].LineNumbers[0]
+		}
+		// queries of different logs can start on the same line
+		return values[i].QueryStructure < values[j].QueryStructure
 	})
 
-	res := Output{
-		Queries: values,
-		Failed:  ql.failed,
+	findings := append(ql.builtinFindings(values), ql.findings...)
+	SortFindings(findings)
+
+	return Output{
+		FileType:   FileType,
+		Version:    OutputVersion,
+		Queries:    values,
+		Tables:     tableStats(values),
+		Failed:     ql.failed,
+		Findings:   findings,
+		SampleRate: ql.sampleRate(),
+		MaxQueries: ql.sample.MaxQueries,
+		BucketSize: bucketSize(ql.bucket),
+		Values:     ql.columnValues(),
+	}
+}
+
+// writeTo writes the output in the given format
+func (ql *queryList) writeTo(w io.Writer, format string) error {
+	switch format {
+	case FormatJSONL:
+		return ql.writeJSONLinesTo(w)
+	case FormatMarkdown:
+		return ql.writeMarkdownTo(w)
+	case FormatCSV:
+		return ql.writeCSVTo(w)
+	default:
+		return ql.writeJSONTo(w)
 	}
+}
 
-	jsonData, err := json.MarshalIndent(res, "  ", "  ")
+// writeJSONTo writes the output as a single JSON document
+func (ql *queryList) writeJSONTo(w io.Writer) error {
+	jsonData, err := json.MarshalIndent(ql.output(), "  ", "  ")
 	if err != nil {
 		return err
 	}
 	_, err = w.Write(jsonData)
-	if err != nil {
-		return err
-	}
-
 	return err
 }
 
-// QueryAnalysisResult represents the result of analyzing a query in a query log. It contains the query structure, the number of
+// writeJSONLinesTo writes one JSON object per line: the query structures first, then the failed queries and the findings.
+// A failed query is the line with an error field, and a finding the one with an analyzer field.
+// The table statistics, which can be computed from the queries, are not written.
+func (ql *queryList) writeJSONLinesTo(w io.Writer) error {
+	res := ql.output()
+	enc := json.NewEncoder(w)
+	for _, query := range res.Queries {
+		if err := enc.Encode(query); err != nil {
+			return err
+		}
+	}
+	for _, failed := range res.Failed {
+		if err := enc.Encode(failed); err != nil {
+			return err
+		}
+	}
+	for _, finding := range res.Findings {
+		if err := enc.Encode(finding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryAnalysisResult represents the result of analyzing a query in a query log. It contains the ID and the query structure, the number of
 // times the query was used, the line numbers where the query was used, the table name, grouping columns, join columns,
-// filter columns, and the statement type.
+// filter columns, the statement type, and the vtgate query hints (/*vt+ ... */) used with it, counted per NAME=value.
+// Timestamps holds when the query was executed, for the log formats that record it.
+// Buckets counts the usage of the query per bucket, keyed by the start of the bucket, when the analysis was bucketed.
+// For INSERT, REPLACE, UPDATE and DELETE statements, AffectedTables are the tables the statement writes to,
+// the other tables of TableName being only read.
+// When the query log comes from vtgate, Observed holds how the executions of the query were actually routed.
+// When it comes from ProxySQL, Hostgroups counts the executions of the query per hostgroup.
+// Users counts the executions of the query per user, or per caller ID for vtgate, when the log records who sent it.
+// When several logs are analysed together, Files counts the usage of the query in each of them; the line numbers
+// are then those of the different logs.
+// Targets counts the usage of the query with explicit shard or tablet type targeting, such as ks:-80 or ks@replica,
+// which is written ks[-80].t or `ks@replica`.t in the queries.
+// OrderingColumns are the columns the query sorts its rows by, in the order of its ORDER BY, and Limit and Offset
+// tell if it has a LIMIT and an OFFSET: a sorted and limited query can read the rows in the order of an index.
+// FunctionFilters are the filters on a function of a column, such as DATE(created_at) = ?, which are not in FilterColumns:
+// the function keeps the filter from using the indexes and the vindex of the column.
+// FullScan is set for the full table scan candidates, the queries without a selective filter on their tables.
+// UnresolvedColumns are the columns that could not be attributed to a table, and are missing from the other fields;
+// the schema of the tables of the query, given with --schema or read from a live database, resolves them.
 type QueryAnalysisResult struct {
-	QueryStructure  string                    `json:"queryStructure"`
-	UsageCount      int                       `json:"usageCount"`
-	LineNumbers     []int                     `json:"lineNumbers"`
-	TableName       []string                  `json:"tableName,omitempty"`
-	GroupingColumns []operators.Column        `json:"groupingColumns,omitempty"`
-	JoinColumns     []operators.ColumnUse     `json:"joinColumns,omitempty"`
-	JoinPredicates  []operators.JoinPredicate `json:"joinPredicates,omitempty"`
-	FilterColumns   []operators.ColumnUse     `json:"filterColumns,omitempty"`
-	StatementType   string                    `json:"statementType"`
+	ID                string                    `json:"id,omitempty"`
+	QueryStructure    string                    `json:"queryStructure"`
+	UsageCount        int                       `json:"usageCount"`
+	LineNumbers       []int                     `json:"lineNumbers"`
+	Timestamps        []time.Time               `json:"timestamps,omitempty"`
+	Buckets           map[time.Time]int         `json:"buckets,omitempty"`
+	TableName         []string                  `json:"tableName,omitempty"`
+	AffectedTables    []string                  `json:"affectedTables,omitempty"`
+	GroupingColumns   []operators.Column        `json:"groupingColumns,omitempty"`
+	OrderingColumns   []operators.Column        `json:"orderingColumns,omitempty"`
+	Limit             bool                      `json:"limit,omitempty"`
+	Offset            bool                      `json:"offset,omitempty"`
+	JoinColumns       []operators.ColumnUse     `json:"joinColumns,omitempty"`
+	JoinPredicates    []operators.JoinPredicate `json:"joinPredicates,omitempty"`
+	FilterColumns     []operators.ColumnUse     `json:"filterColumns,omitempty"`
+	FunctionFilters   []FunctionColumnUse       `json:"functionFilters,omitempty"`
+	StatementType     string                    `json:"statementType"`
+	Hints             map[string]int            `json:"hints,omitempty"`
+	Observed          *ObservedExecution        `json:"observed,omitempty"`
+	Hostgroups        map[string]int            `json:"hostgroups,omitempty"`
+	Users             map[string]int            `json:"users,omitempty"`
+	Files             map[string]int            `json:"files,omitempty"`
+	Targets           map[string]int            `json:"targets,omitempty"`
+	FullScan          bool                      `json:"fullScan,omitempty"`
+	UnresolvedColumns []string                  `json:"unresolvedColumns,omitempty"`
+}
+
+// QueryID returns the ID of a query structure. It only depends on the structure, so the outputs of different runs,
+// and the other analyses of the same queries, can refer to the structures by their ID.
+func QueryID(structure string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(structure))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// ObservedExecution aggregates the execution information found in the query log for a query structure
+type ObservedExecution struct {
+	Executions   int `json:"executions"`
+	ShardQueries int `json:"shardQueries"`
+	// Scatter is the number of executions that sent queries to more than one shard
+	Scatter   int            `json:"scatter"`
+	PlanTypes map[string]int `json:"planTypes,omitempty"`
+}
+
+// scale multiplies the counts of the result, to estimate the counts of the whole log from those of a sample.
+// The maps of the result are replaced by scaled copies, so the result can be a copy of the one of the query list.
+func (r *QueryAnalysisResult) scale(scale float64) {
+	r.UsageCount = scaledCount(r.UsageCount, scale)
+	r.Buckets = scaledCounts(r.Buckets, scale)
+	r.Hints = scaledCounts(r.Hints, scale)
+	r.Hostgroups = scaledCounts(r.Hostgroups, scale)
+	r.Users = scaledCounts(r.Users, scale)
+	r.Files = scaledCounts(r.Files, scale)
+	r.Targets = scaledCounts(r.Targets, scale)
+	if r.Observed != nil {
+		observed := *r.Observed
+		observed.Executions = scaledCount(observed.Executions, scale)
+		observed.ShardQueries = scaledCount(observed.ShardQueries, scale)
+		observed.Scatter = scaledCount(observed.Scatter, scale)
+		observed.PlanTypes = scaledCounts(observed.PlanTypes, scale)
+		r.Observed = &observed
+	}
+}
+
+func scaledCount(count int, scale float64) int {
+	return int(math.Round(float64(count) * scale))
+}
+
+func scaledCounts[K comparable](counts map[K]int, scale float64) map[K]int {
+	if counts == nil {
+		return nil
+	}
+	result := make(map[K]int, len(counts))
+	for k, count := range counts {
+		result[k] = scaledCount(count, scale)
+	}
+	return result
+}
+
+// addHints records that the query was executed count times with the given vtgate query hints
+func (r *QueryAnalysisResult) addHints(hints []string, count int) {
+	if len(hints) == 0 {
+		return
+	}
+	if r.Hints == nil {
+		r.Hints = make(map[string]int)
+	}
+	for _, hint := range hints {
+		r.Hints[hint] += count
+	}
+}
+
+// addBucket records that the query was executed count times in the bucket of ts
+func (r *QueryAnalysisResult) addBucket(ts time.Time, bucket time.Duration, count int) {
+	if bucket == 0 || ts.IsZero() {
+		return
+	}
+	if r.Buckets == nil {
+		r.Buckets = make(map[time.Time]int)
+	}
+	r.Buckets[ts.UTC().Truncate(bucket)] += count
+}
+
+func (r *QueryAnalysisResult) addTimestamp(ts time.Time) {
+	if ts.IsZero() {
+		return
+	}
+	r.Timestamps = append(r.Timestamps, ts)
+}
+
+// addHostgroup records that the query was executed count times on the given ProxySQL hostgroup
+func (r *QueryAnalysisResult) addHostgroup(hostgroup string, count int) {
+	if hostgroup == "" {
+		return
+	}
+	if r.Hostgroups == nil {
+		r.Hostgroups = make(map[string]int)
+	}
+	r.Hostgroups[hostgroup] += count
+}
+
+// addUser records that the query was executed count times by the given user
+func (r *QueryAnalysisResult) addUser(user string, count int) {
+	if user == "" {
+		return
+	}
+	if r.Users == nil {
+		r.Users = make(map[string]int)
+	}
+	r.Users[user] += count
+}
+
+// addFile records that the query was used count times in the given log
+func (r *QueryAnalysisResult) addFile(file string, count int) {
+	if file == "" {
+		return
+	}
+	if r.Files == nil {
+		r.Files = make(map[string]int)
+	}
+	r.Files[file] += count
+}
+
+// addTargets records that the query was used count times with the given shard or tablet type targets
+func (r *QueryAnalysisResult) addTargets(targets []string, count int) {
+	if len(targets) == 0 {
+		return
+	}
+	if r.Targets == nil {
+		r.Targets = make(map[string]int)
+	}
+	for _, target := range targets {
+		r.Targets[target] += count
+	}
+}
+
+// addExecution records the observed executions of an entry of the log for this query structure,
+// the entry standing for count executions that were routed the same way
+func (r *QueryAnalysisResult) addExecution(exec *data.ExecutionInfo, count int) {
+	if exec == nil {
+		return
+	}
+	if r.Observed == nil {
+		r.Observed = &ObservedExecution{}
+	}
+	r.Observed.Executions += count
+	r.Observed.ShardQueries += exec.ShardQueries * count
+	if exec.ShardQueries > 1 {
+		r.Observed.Scatter += count
+	}
+	if exec.PlanType != "" {
+		if r.Observed.PlanTypes == nil {
+			r.Observed.PlanTypes = make(map[string]int)
+		}
+		r.Observed.PlanTypes[exec.PlanType] += count
+	}
+}
+
+// Reweight replaces the usage counts of the query structures found in counts, and recomputes the table statistics.
+// The counts are expected to come from another source than the query log, such as performance_schema,
+// so the queries per second of the tables, which are based on the timestamps of the log, are dropped.
+func (o *Output) Reweight(counts map[string]int) {
+	for i, q := range o.Queries {
+		if count, found := counts[q.QueryStructure]; found {
+			o.Queries[i].UsageCount = count
+		}
+	}
+	o.Tables = tableStats(o.Queries)
+	for i := range o.Tables {
+		o.Tables[i].QPS = 0
+	}
+}
+
+// tableStats counts the reads and writes of every table, sorted by table name.
+// Statements that are neither reads nor writes, such as SET, are not counted.
+func tableStats(queries []QueryAnalysisResult) []TableStats {
+	stats := make(map[string]*TableStats)
+	var first, last time.Time
+	for _, query := range queries {
+		for _, ts := range query.Timestamps {
+			if first.IsZero() || ts.Before(first) {
+				first = ts
+			}
+			if ts.After(last) {
+				last = ts
+			}
+		}
+
+		var write bool
+		switch query.StatementType {
+		case "SELECT":
+		case "INSERT", "REPLACE", "UPDATE", "DELETE":
+			write = true
+		default:
+			continue
+		}
+
+		tables := slices.Clone(query.TableName)
+		sort.Strings(tables)
+		for _, table := range slices.Compact(tables) {
+			ts, found := stats[table]
+			if !found {
+				ts = &TableStats{Table: table}
+				stats[table] = ts
+			}
+			// the other tables of a write, such as the source of an INSERT ... SELECT, are only read
+			if write && (len(query.AffectedTables) == 0 || slices.Contains(query.AffectedTables, table)) {
+				ts.Writes += query.UsageCount
+			} else {
+				ts.Reads += query.UsageCount
+			}
+		}
+	}
+
+	result := make([]TableStats, 0, len(stats))
+	seconds := last.Sub(first).Seconds()
+	for _, ts := range stats {
+		if seconds > 0 {
+			ts.QPS = float64(ts.Reads+ts.Writes) / seconds
+		}
+		result = append(result, *ts)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result
 }
 
 type QueryFailedResult struct {
 	Query      string `json:"query"`
 	LineNumber int    `json:"lineNumber"`
+	File       string `json:"file,omitempty"`
 	Error      string `json:"error"`
 }
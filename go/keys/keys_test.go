@@ -17,16 +17,24 @@ limitations under the License.
 package keys
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
 )
 
 func TestKeys(t *testing.T) {
 	sb := &strings.Builder{}
-	err := run(sb, "../../t/tpch_failing_queries.test")
+	err := run(context.Background(), sb, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}})
 	require.NoError(t, err)
 
 	out, err := os.ReadFile("../summarize/testdata/keys-log.json")
@@ -34,3 +42,382 @@ func TestKeys(t *testing.T) {
 
 	require.Equal(t, string(out), sb.String())
 }
+
+func TestQueryID(t *testing.T) {
+	output, err := Analyze(strings.NewReader("select * from t where id = 1;\nselect * from t where id = 2;\nselect * from u;\n"), Options{})
+	require.NoError(t, err)
+	require.Len(t, output.Queries, 2)
+	require.Equal(t, QueryID(output.Queries[0].QueryStructure), output.Queries[0].ID)
+	require.Len(t, output.Queries[0].ID, 16)
+	require.NotEqual(t, output.Queries[0].ID, output.Queries[1].ID)
+	// the ID doesn't depend on the run
+	require.Equal(t, "02fb785a1a5a96f2", QueryID("select 1"))
+}
+
+func TestKeysHints(t *testing.T) {
+	si := &schemaInfo{tables: make(map[string]columns)}
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+
+	queries := []string{
+		"select /*vt+ QUERY_TIMEOUT_MS=1000 PLANNER=gen4 */ * from t where id = 1",
+		"select /*vt+ QUERY_TIMEOUT_MS=2000 */ * from t where id = 2",
+		"select * from t where id = 3",
+	}
+	for i, query := range queries {
+		process(data.Query{Query: query, Line: i + 1, Type: typ.Query}, si, ql)
+	}
+
+	require.Empty(t, ql.failed)
+	require.Len(t, ql.queries, 1, "hints should not change the query structure")
+	for _, result := range ql.queries {
+		require.Equal(t, 3, result.UsageCount)
+		require.Equal(t, map[string]int{
+			"QUERY_TIMEOUT_MS=1000": 1,
+			"QUERY_TIMEOUT_MS=2000": 1,
+			"PLANNER=gen4":          1,
+		}, result.Hints)
+	}
+
+	// the hints of an entry standing for several executions are counted once per execution
+	process(data.Query{Query: "select /*vt+ PLANNER=gen4 */ * from t where id = 4", Line: 4, Type: typ.Query, Weight: 40}, si, ql)
+	for _, result := range ql.queries {
+		require.Equal(t, 43, result.UsageCount)
+		require.Equal(t, 41, result.Hints["PLANNER=gen4"])
+	}
+}
+
+func TestKeysObservedExecution(t *testing.T) {
+	si := &schemaInfo{tables: make(map[string]columns)}
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+
+	executions := []*data.ExecutionInfo{
+		{StmtType: "SELECT", PlanType: "Scatter", ShardQueries: 4},
+		{StmtType: "SELECT", PlanType: "Scatter", ShardQueries: 4},
+		{StmtType: "SELECT", ShardQueries: 1},
+	}
+	start := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	for i, exec := range executions {
+		ts := start.Add(time.Duration(i) * time.Second)
+		process(data.Query{Query: "select * from t where x = 1", Line: i + 1, Type: typ.Query, Execution: exec, Timestamp: ts}, si, ql)
+	}
+	process(data.Query{Query: "select * from t where x = 1", Line: 4, Type: typ.Query}, si, ql)
+	// an entry standing for several executions weighs as much as them
+	process(data.Query{Query: "select * from t where x = 1", Line: 5, Type: typ.Query, Weight: 3, Execution: executions[0]}, si, ql)
+
+	require.Len(t, ql.queries, 1)
+	for _, result := range ql.queries {
+		require.Equal(t, 7, result.UsageCount)
+		require.Equal(t, []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second)}, result.Timestamps)
+		require.Equal(t, &ObservedExecution{
+			Executions:   6,
+			ShardQueries: 21,
+			Scatter:      5,
+			PlanTypes:    map[string]int{"Scatter": 5},
+		}, result.Observed)
+	}
+}
+
+func TestKeysBuckets(t *testing.T) {
+	log := `{"Method": "Execute", "Start": "2024-11-05 10:15:30.000000", "StmtType": "SELECT", "SQL": "select * from t where id = 1"}
+{"Method": "Execute", "Start": "2024-11-05 10:59:59.999999", "StmtType": "SELECT", "SQL": "select * from t where id = 2"}
+{"Method": "Execute", "Start": "2024-11-05 11:00:00.000000", "StmtType": "SELECT", "SQL": "select * from t where id = 3"}
+{"Method": "Execute", "Start": "2024-11-05 02:00:00.000000", "StmtType": "DELETE", "SQL": "delete from t where created < now()"}
+{"Method": "Execute", "StmtType": "SELECT", "SQL": "select * from t where id = 4"}
+`
+	output, err := Analyze(strings.NewReader(log), Options{Loader: data.VtGateLogLoader{}, Bucket: time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, "1h0m0s", output.BucketSize)
+	require.Len(t, output.Queries, 2)
+	hour := func(h int) time.Time { return time.Date(2024, 11, 5, h, 0, 0, 0, time.UTC) }
+	// the queries without a timestamp are in no bucket
+	require.Equal(t, 4, output.Queries[0].UsageCount)
+	require.Equal(t, map[time.Time]int{hour(10): 2, hour(11): 1}, output.Queries[0].Buckets)
+	require.Equal(t, map[time.Time]int{hour(2): 1}, output.Queries[1].Buckets)
+
+	// the buckets are written with the start of the bucket as key
+	out, err := json.Marshal(output.Queries[1])
+	require.NoError(t, err)
+	require.Contains(t, string(out), `"buckets":{"2024-11-05T02:00:00Z":1}`)
+	var read QueryAnalysisResult
+	require.NoError(t, json.Unmarshal(out, &read))
+	require.Equal(t, output.Queries[1].Buckets, read.Buckets)
+
+	output, err = Analyze(strings.NewReader(log), Options{Loader: data.VtGateLogLoader{}})
+	require.NoError(t, err)
+	require.Empty(t, output.BucketSize)
+	require.Nil(t, output.Queries[0].Buckets)
+
+	_, err = Analyze(strings.NewReader(log), Options{Loader: data.VtGateLogLoader{}, Bucket: -time.Hour})
+	require.ErrorContains(t, err, "can't be negative")
+}
+
+func TestTableStats(t *testing.T) {
+	start := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	queries := []QueryAnalysisResult{{
+		UsageCount:    6,
+		TableName:     []string{"t", "u", "t"},
+		StatementType: "SELECT",
+		Timestamps:    []time.Time{start, start.Add(5 * time.Second)},
+	}, {
+		UsageCount:    2,
+		TableName:     []string{"t"},
+		StatementType: "UPDATE",
+		Timestamps:    []time.Time{start.Add(10 * time.Second)},
+	}, {
+		UsageCount:    3,
+		StatementType: "SET",
+	}}
+
+	require.Equal(t, []TableStats{
+		{Table: "t", Reads: 6, Writes: 2, QPS: 0.8},
+		{Table: "u", Reads: 6, QPS: 0.6},
+	}, tableStats(queries))
+}
+
+func TestReweight(t *testing.T) {
+	output := Output{Queries: []QueryAnalysisResult{{
+		QueryStructure: "select * from t",
+		UsageCount:     6,
+		TableName:      []string{"t"},
+		StatementType:  "SELECT",
+	}, {
+		QueryStructure: "update t set a = :1",
+		UsageCount:     2,
+		TableName:      []string{"t"},
+		StatementType:  "UPDATE",
+	}}, Tables: []TableStats{{Table: "t", Reads: 6, Writes: 2, QPS: 0.8}}}
+
+	output.Reweight(map[string]int{"select * from t": 1500, "select 1": 3})
+	require.Equal(t, 1500, output.Queries[0].UsageCount)
+	require.Equal(t, 2, output.Queries[1].UsageCount)
+	require.Equal(t, []TableStats{{Table: "t", Reads: 1500, Writes: 2}}, output.Tables)
+}
+
+func TestKeysSample(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "sample.test")
+	var sb strings.Builder
+	sb.WriteString("create table t (id int primary key, x int);\n")
+	for i := range 400 {
+		fmt.Fprintf(&sb, "select * from t where x = %d;\n", i)
+	}
+	require.NoError(t, os.WriteFile(fileName, []byte(sb.String()), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{fileName}, Sample: data.Sample{Rate: 0.25}})
+	require.NoError(t, err)
+
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Equal(t, 0.25, output.SampleRate)
+	require.Len(t, output.Queries, 1)
+	query := output.Queries[0]
+	require.Equal(t, float64(len(query.LineNumbers))*4, float64(query.UsageCount), "usage counts should be scaled")
+	require.InDelta(t, 400, query.UsageCount, 120)
+
+	out.Reset()
+	err = run(context.Background(), out, Config{FileNames: []string{fileName}, Sample: data.Sample{MaxQueries: 10}})
+	require.NoError(t, err)
+	output = Output{}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Zero(t, output.SampleRate)
+	require.Equal(t, 10, output.MaxQueries)
+	require.Equal(t, 10, output.Queries[0].UsageCount, "the counts of a limited sample are not scaled")
+}
+
+func TestSampledOutput(t *testing.T) {
+	ql := &queryList{
+		queries: map[string]*QueryAnalysisResult{"SELECT 1": {
+			QueryStructure: "SELECT 1",
+			UsageCount:     5,
+			LineNumbers:    []int{1},
+			Hints:          map[string]int{"PLANNER=gen4": 2},
+			Users:          map[string]int{"app": 5},
+			Observed:       &ObservedExecution{Executions: 5, ShardQueries: 10, Scatter: 3, PlanTypes: map[string]int{"Scatter": 3}},
+		}},
+		sample: data.Sample{Rate: 0.5},
+	}
+
+	for range 2 {
+		query := ql.output().Queries[0]
+		require.Equal(t, 10, query.UsageCount)
+		require.Equal(t, map[string]int{"PLANNER=gen4": 4}, query.Hints)
+		require.Equal(t, map[string]int{"app": 10}, query.Users)
+		require.Equal(t, &ObservedExecution{Executions: 10, ShardQueries: 20, Scatter: 6, PlanTypes: map[string]int{"Scatter": 6}}, query.Observed)
+	}
+	require.Equal(t, 5, ql.queries["SELECT 1"].UsageCount, "the analysis itself is not scaled")
+	require.Equal(t, 5, ql.queries["SELECT 1"].Observed.Executions)
+}
+
+func TestKeysBlobLiterals(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "blobs.test")
+	blob := strings.Repeat("ab", 1<<19)
+	log := "create table t (id int primary key, data blob);\n" +
+		"insert into t (id, data) values (1, _binary'" + blob + "');\n" +
+		"insert into t (id, data) values (2, _binary'" + blob + "') on duplicate;\n"
+	require.NoError(t, os.WriteFile(fileName, []byte(log), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{fileName}, MaxLiteralLength: 16})
+	require.NoError(t, err)
+	require.Less(t, out.Len(), 10000, "the blobs should be truncated")
+
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Len(t, output.Queries, 1)
+	require.Len(t, output.Failed, 1)
+	require.Contains(t, output.Failed[0].Query, "' /* 1048576 bytes */) on duplicate")
+}
+
+func TestKeysAnalyzer(t *testing.T) {
+	analyzer := filepath.Join(t.TempDir(), "no-inserts")
+	script := `#!/bin/sh
+while IFS= read -r line; do
+  case "$line" in
+    *'"query":"INSERT'*|*'"query":"insert'*) echo '{"severity":"warning","message":"this log should be read only"}' ;;
+  esac
+done
+`
+	require.NoError(t, os.WriteFile(analyzer, []byte(script), 0o700))
+
+	sb := &strings.Builder{}
+	err := run(context.Background(), sb, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}, Analyzers: []string{analyzer}})
+	require.NoError(t, err)
+
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &output))
+	require.Len(t, output.Findings, 8)
+	for _, finding := range output.Findings {
+		require.Equal(t, Finding{Analyzer: "no-inserts", Severity: "warning", Message: "this log should be read only"}, finding)
+	}
+
+	failing := filepath.Join(t.TempDir(), "failing")
+	require.NoError(t, os.WriteFile(failing, []byte("#!/bin/sh\necho oops >&2\nexit 3\n"), 0o700))
+	err = run(context.Background(), sb, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}, Analyzers: []string{failing}})
+	require.ErrorContains(t, err, "analyzer failing failed: exit status 3: oops")
+}
+
+func TestKeysProxySQLDigest(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "digest.tsv")
+	export := "hostgroup\tusername\tdigest_text\tcount_star\n" +
+		"10\torders\tselect * from t where id = ?\t1200\n" +
+		"20\treports\tselect * from t where id = ?\t300\n" +
+		"10\torders\tselect * from t where x in (?,...)\t7\n"
+	require.NoError(t, os.WriteFile(fileName, []byte(export), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{fileName}, Loader: data.ProxySQLDigestLoader{}})
+	require.NoError(t, err)
+
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Empty(t, output.Failed)
+	require.Len(t, output.Queries, 2)
+	require.Equal(t, 1500, output.Queries[0].UsageCount)
+	require.Equal(t, map[string]int{"10": 1200, "20": 300}, output.Queries[0].Hostgroups)
+	require.Equal(t, map[string]int{"orders": 1200, "reports": 300}, output.Queries[0].Users)
+	require.Equal(t, 7, output.Queries[1].UsageCount)
+	require.Equal(t, map[string]int{"10": 7}, output.Queries[1].Hostgroups)
+	require.Equal(t, map[string]int{"orders": 7}, output.Queries[1].Users)
+}
+
+func TestKeysMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	replica1 := filepath.Join(dir, "replica1.test")
+	replica2 := filepath.Join(dir, "replica2.test")
+	duplicate := filepath.Join(dir, "duplicate.test")
+	require.NoError(t, os.WriteFile(replica1, []byte("select * from t where id = 1;\nselect * from t where id = 2;\n"), 0o600))
+	require.NoError(t, os.WriteFile(replica2, []byte("select * from t where id = 3;\nselect * from u where x = 1;\nselect from;\n"), 0o600))
+	require.NoError(t, os.WriteFile(duplicate, []byte("select * from t where id = 1;\nselect * from t where id = 2;\n"), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{replica1, replica2, duplicate}})
+	require.NoError(t, err)
+
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Len(t, output.Queries, 2)
+	require.Equal(t, 3, output.Queries[0].UsageCount, "the duplicate file should be skipped")
+	require.Equal(t, map[string]int{replica1: 2, replica2: 1}, output.Queries[0].Files)
+	require.Equal(t, map[string]int{replica2: 1}, output.Queries[1].Files)
+	require.Len(t, output.Failed, 1)
+	require.Equal(t, replica2, output.Failed[0].File)
+	require.Equal(t, 3, output.Failed[0].LineNumber)
+}
+
+func TestKeysSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.sql")
+	log := filepath.Join(dir, "dml.test")
+	dump := "/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+		"DROP TABLE IF EXISTS `orders`;\n" +
+		"CREATE TABLE `orders` (\n  `id` bigint NOT NULL,\n  `customer_id` bigint,\n  `status` varchar(10),\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB;\n" +
+		"CREATE TABLE `customer` (\n  `cid` bigint NOT NULL,\n  `region` varchar(10),\n  PRIMARY KEY (`cid`)\n) ENGINE=InnoDB;\n"
+	require.NoError(t, os.WriteFile(schema, []byte(dump), 0o600))
+	// the unqualified columns can only be bound to their table with the schema
+	require.NoError(t, os.WriteFile(log, []byte("select id from orders join customer on customer_id = cid where region = 'eu';\n"), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{log}})
+	require.NoError(t, err)
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Len(t, output.Queries, 1)
+	require.Empty(t, output.Queries[0].FilterColumns)
+	require.Empty(t, output.Queries[0].JoinPredicates)
+	require.Equal(t, []string{"cid", "customer_id", "id", "region"}, output.Queries[0].UnresolvedColumns)
+
+	out.Reset()
+	err = run(context.Background(), out, Config{FileNames: []string{log}, SchemaFile: schema})
+	require.NoError(t, err)
+	output = Output{}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &output))
+	require.Empty(t, output.Failed)
+	require.Len(t, output.Queries, 1)
+	require.Equal(t, "customer.region =", output.Queries[0].FilterColumns[0].String())
+	require.Equal(t, "orders.customer_id = customer.cid", output.Queries[0].JoinPredicates[0].String())
+	require.Empty(t, output.Queries[0].UnresolvedColumns)
+
+	empty := filepath.Join(dir, "empty.sql")
+	require.NoError(t, os.WriteFile(empty, []byte("DROP TABLE IF EXISTS `orders`;\n"), 0o600))
+	err = run(context.Background(), out, Config{FileNames: []string{log}, SchemaFile: empty})
+	require.ErrorContains(t, err, "no CREATE TABLE statement")
+}
+
+func TestKeysInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sb := &strings.Builder{}
+	err := run(ctx, sb, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}})
+	require.ErrorIs(t, err, context.Canceled)
+
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &output), "the partial output should be valid JSON")
+	require.Empty(t, output.Queries)
+}
+
+func TestKeysJSONLines(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "log.test")
+	require.NoError(t, os.WriteFile(fileName, []byte("select * from t where id = 1;\ndelete from t;\nselect * from t where id = 2;\nselect from;\n"), 0o600))
+
+	out := &strings.Builder{}
+	err := run(context.Background(), out, Config{FileNames: []string{fileName}, Format: FormatJSONL})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4, "two query structures, a failed query and a finding")
+	var query QueryAnalysisResult
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &query))
+	require.Equal(t, 2, query.UsageCount)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &query))
+	require.Equal(t, "DELETE", query.StatementType)
+	var failed QueryFailedResult
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &failed))
+	require.Equal(t, 4, failed.LineNumber)
+	var finding Finding
+	require.NoError(t, json.Unmarshal([]byte(lines[3]), &finding))
+	require.Equal(t, "unbounded-write", finding.ID)
+
+	err = run(context.Background(), out, Config{FileNames: []string{fileName}, Format: "yaml"})
+	require.EqualError(t, err, `unknown output format "yaml", use json, jsonl, markdown or csv`)
+}
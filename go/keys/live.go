@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/sqlerror"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type (
+	// LiveSchema is the database the schema of the tables is read from, for the tables
+	// the logs and the schema file don't create. A table is read the first time a query uses it.
+	LiveSchema struct {
+		Host     string
+		Port     int
+		Socket   string
+		User     string
+		Password string
+		// Database is the database of the tables, it defaults to the database of the connection
+		Database string
+	}
+
+	executor interface {
+		ExecuteFetch(query string, maxrows int, wantfields bool) (*sqltypes.Result, error)
+	}
+)
+
+func (l *LiveSchema) connect() (*mysql.Conn, error) {
+	conn, err := mysql.Connect(context.Background(), &mysql.ConnParams{
+		Host:       l.Host,
+		Port:       l.Port,
+		UnixSocket: l.Socket,
+		Uname:      l.User,
+		Pass:       l.Password,
+		DbName:     l.Database,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the database of the schema: %w", err)
+	}
+	return conn, nil
+}
+
+// showCreateTable reads the CREATE TABLE statement of a table. It returns nil and no error
+// when the table doesn't exist or is a view, the queries on it are analysed without its columns.
+func showCreateTable(conn executor, table string) (*sqlparser.CreateTable, error) {
+	rs, err := conn.ExecuteFetch("show create table "+sqlparser.String(sqlparser.NewIdentifierCS(table)), 1, false)
+	if err != nil {
+		var sqlErr *sqlerror.SQLError
+		if errors.As(err, &sqlErr) && sqlErr.Number() == sqlerror.ERNoSuchTable {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(rs.Rows) != 1 || len(rs.Rows[0]) < 2 {
+		return nil, fmt.Errorf("unexpected result of SHOW CREATE TABLE %s", table)
+	}
+	ast, err := sqlparser.NewTestParser().Parse(rs.Rows[0][1].ToString())
+	if err != nil {
+		return nil, fmt.Errorf("parsing the schema of table %s: %w", table, err)
+	}
+	create, ok := ast.(*sqlparser.CreateTable)
+	if !ok {
+		return nil, nil
+	}
+	return create, nil
+}
+
+// fetchFrom makes the schema read the tables it doesn't know from the database of conn
+func (s *schemaInfo) fetchFrom(conn executor) {
+	s.fetched = make(map[string]bool)
+	s.fetch = func(table string) (*sqlparser.CreateTable, error) {
+		return showCreateTable(conn, table)
+	}
+}
+
+// fetchTable reads the schema of a table the first time it is asked for, and tells if it is known now
+func (s *schemaInfo) fetchTable(table string) bool {
+	if s.fetch == nil || s.fetched[table] {
+		return false
+	}
+	s.fetched[table] = true
+	create, err := s.fetch(table)
+	if err != nil {
+		log.Warnf("reading the schema of table %s: %v", table, err)
+		return false
+	}
+	if create == nil {
+		log.Debugf("table %s is not in the database of the schema", table)
+		return false
+	}
+	// SHOW CREATE TABLE names the table like the query does, whatever its case in the database
+	create.Table.Name = sqlparser.NewIdentifierCS(table)
+	s.handleCreateTable(create)
+	return true
+}
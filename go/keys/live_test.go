@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql/sqlerror"
+	"vitess.io/vitess/go/sqltypes"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+// fakeDatabase answers SHOW CREATE TABLE for its tables, and counts the statements it runs
+type fakeDatabase struct {
+	tables  map[string]string
+	queries []string
+}
+
+func (f *fakeDatabase) ExecuteFetch(query string, _ int, _ bool) (*sqltypes.Result, error) {
+	f.queries = append(f.queries, query)
+	for name, create := range f.tables {
+		if query == "show create table "+name {
+			return sqltypes.MakeTestResult(sqltypes.MakeTestFields("Table|Create Table", "varchar|varchar"), name+"|"+create), nil
+		}
+	}
+	return nil, sqlerror.NewSQLError(sqlerror.ERNoSuchTable, sqlerror.SSUnknownTable, "Table doesn't exist")
+}
+
+func TestLiveSchema(t *testing.T) {
+	db := &fakeDatabase{tables: map[string]string{
+		"orders":   "CREATE TABLE `orders` (\n  `id` bigint NOT NULL,\n  `customer_id` bigint,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB",
+		"customer": "CREATE TABLE `customer` (\n  `cid` bigint NOT NULL,\n  `region` varchar(10),\n  PRIMARY KEY (`cid`)\n) ENGINE=InnoDB",
+	}}
+	si := &schemaInfo{tables: make(map[string]columns)}
+	si.fetchFrom(db)
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+
+	// the unqualified columns can only be bound to their table with the schema
+	process(data.Query{Query: "select id from orders join customer on customer_id = cid where region = 'eu'", Line: 1, Type: typ.Query}, si, ql)
+	process(data.Query{Query: "select id from orders where customer_id = 3", Line: 2, Type: typ.Query}, si, ql)
+	process(data.Query{Query: "select a from missing where b = 1", Line: 3, Type: typ.Query}, si, ql)
+	process(data.Query{Query: "select a from missing where b = 2", Line: 4, Type: typ.Query}, si, ql)
+	require.Empty(t, ql.failed)
+
+	result := ql.queries["SELECT `id` FROM `orders` JOIN `customer` ON `customer_id` = `cid` WHERE `region` = :_region /* VARCHAR */"]
+	require.NotNil(t, result)
+	require.Equal(t, []string{"customer.region ="}, stringsOf(result.FilterColumns))
+	require.Equal(t, []string{"orders.customer_id = customer.cid"}, stringsOf(result.JoinPredicates))
+
+	// each table is only read once, the missing ones too
+	require.Equal(t, []string{"show create table orders", "show create table customer", "show create table missing"}, db.queries)
+}
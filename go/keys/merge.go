@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// loadPrevious adds the queries, the failed queries and the findings of the custom analyzers of a previous
+// 'vt keys' output to the list, so the queries of the new logs are counted on top of them. The built-in findings
+// are computed again from the merged queries. A missing file is taken as an empty output.
+func (ql *queryList) loadPrevious(fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var previous Output
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return fmt.Errorf("%s is not a 'vt keys' JSON output: %w", fileName, err)
+	}
+	if err := previous.CheckVersion(); err != nil {
+		return fmt.Errorf("can't merge into %s: %w", fileName, err)
+	}
+	if previous.SampleRate > 0 {
+		return fmt.Errorf("can't merge into %s, its usage counts are estimated from a sample", fileName)
+	}
+	if previous.MaxQueries > 0 {
+		return fmt.Errorf("can't merge into %s, its usage counts are those of the first %d queries of a log", fileName, previous.MaxQueries)
+	}
+	if previous.BucketSize != "" && previous.BucketSize != bucketSize(ql.bucket) {
+		return fmt.Errorf("can't merge into %s, its usage counts are bucketed by %s", fileName, previous.BucketSize)
+	}
+
+	parser := sqlparser.NewTestParser()
+	for i := range previous.Queries {
+		q := &previous.Queries[i]
+		ql.queries[q.QueryStructure] = q
+		if ast, err := parser.Parse(q.QueryStructure); err == nil && isUnboundedWrite(ast) {
+			if ql.unboundedWrites == nil {
+				ql.unboundedWrites = make(map[string]bool)
+			}
+			ql.unboundedWrites[q.QueryStructure] = true
+		}
+	}
+	ql.failed = previous.Failed
+	ql.loadValues(previous.Values)
+	for _, finding := range previous.Findings {
+		if finding.Analyzer != builtinAnalyzer {
+			ql.findings = append(ql.findings, finding)
+		}
+	}
+	return nil
+}
+
+// writeMerged replaces the file with the merged output. The output is written to a temporary file first,
+// so the cumulative statistics are not lost if writing fails.
+func (ql *queryList) writeMerged(fileName string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := ql.writeJSONTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fileName)
+}
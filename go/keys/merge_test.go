@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+)
+
+func TestMergeInto(t *testing.T) {
+	fileName := "../../t/tpch_failing_queries.test"
+	single := &strings.Builder{}
+	require.NoError(t, run(context.Background(), single, Config{FileNames: []string{fileName}}))
+	var expected Output
+	require.NoError(t, json.Unmarshal([]byte(single.String()), &expected))
+
+	// the first run creates the file, the second one adds its counts
+	merged := filepath.Join(t.TempDir(), "keys.json")
+	for range 2 {
+		out := &strings.Builder{}
+		require.NoError(t, run(context.Background(), out, Config{FileNames: []string{fileName}, MergeInto: merged}))
+		require.Empty(t, out.String())
+	}
+
+	content, err := os.ReadFile(merged)
+	require.NoError(t, err)
+	var output Output
+	require.NoError(t, json.Unmarshal(content, &output))
+
+	require.Len(t, output.Queries, len(expected.Queries))
+	for i, query := range output.Queries {
+		require.Equal(t, expected.Queries[i].QueryStructure, query.QueryStructure)
+		require.Equal(t, 2*expected.Queries[i].UsageCount, query.UsageCount)
+		require.Equal(t, append(expected.Queries[i].LineNumbers, expected.Queries[i].LineNumbers...), query.LineNumbers)
+	}
+	require.Len(t, output.Failed, 2*len(expected.Failed))
+	for i, table := range output.Tables {
+		require.Equal(t, 2*expected.Tables[i].Reads, table.Reads)
+	}
+}
+
+func TestMergeIntoFindings(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "writes.test")
+	require.NoError(t, os.WriteFile(fileName, []byte("delete from t;\nupdate t set x = 1 where id = 2;\n"), 0o600))
+
+	merged := filepath.Join(dir, "keys.json")
+	for range 2 {
+		require.NoError(t, run(context.Background(), &strings.Builder{}, Config{FileNames: []string{fileName}, MergeInto: merged}))
+	}
+	content, err := os.ReadFile(merged)
+	require.NoError(t, err)
+	var output Output
+	require.NoError(t, json.Unmarshal(content, &output))
+
+	// the built-in findings are computed again from the merged queries instead of being accumulated
+	require.Len(t, output.Findings, 1)
+	require.Equal(t, "unbounded-write", output.Findings[0].ID)
+	require.Equal(t, "used 2 times", output.Findings[0].Evidence)
+}
+
+func TestMergeIntoErrors(t *testing.T) {
+	dir := t.TempDir()
+	fileName := "../../t/tpch_failing_queries.test"
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("select 1"), 0o600))
+	err := run(context.Background(), &strings.Builder{}, Config{FileNames: []string{fileName}, MergeInto: invalid})
+	require.ErrorContains(t, err, "is not a 'vt keys' JSON output")
+
+	sampled := filepath.Join(dir, "sampled.json")
+	require.NoError(t, os.WriteFile(sampled, []byte(`{"queries": [], "sampleRate": 0.5}`), 0o600))
+	err = run(context.Background(), &strings.Builder{}, Config{FileNames: []string{fileName}, MergeInto: sampled})
+	require.ErrorContains(t, err, "estimated from a sample")
+
+	err = run(context.Background(), &strings.Builder{}, Config{FileNames: []string{fileName}, MergeInto: sampled, Sample: data.Sample{Rate: 0.5}})
+	require.ErrorContains(t, err, "without sampling")
+
+	bucketed := filepath.Join(dir, "bucketed.json")
+	require.NoError(t, os.WriteFile(bucketed, []byte(`{"queries": [], "bucketSize": "1h0m0s"}`), 0o600))
+	err = run(context.Background(), &strings.Builder{}, Config{FileNames: []string{fileName}, MergeInto: bucketed, Bucket: time.Minute})
+	require.ErrorContains(t, err, "bucketed by 1h0m0s")
+	require.NoError(t, run(context.Background(), &strings.Builder{}, Config{FileNames: []string{fileName}, MergeInto: bucketed, Bucket: time.Hour}))
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"slices"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// orderingColumns returns the columns of the ORDER BY clauses of the query, in the order they sort the rows,
+// and whether the query has a LIMIT and an OFFSET. The columns of the derived tables are followed to
+// the columns of their tables, the expressions other than columns are left out.
+func orderingColumns(ctx *plancontext.PlanningContext, stmt sqlparser.Statement) (ordering []operators.Column, limit, offset bool) {
+	_ = sqlparser.VisitSQLNode(stmt, func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Order:
+			col, ok := node.Expr.(*sqlparser.ColName)
+			if !ok {
+				return true, nil
+			}
+			if c, ok := physicalColumn(ctx, col); ok && !slices.Contains(ordering, c) {
+				ordering = append(ordering, c)
+			}
+		case *sqlparser.Limit:
+			limit = limit || node.Rowcount != nil
+			offset = offset || node.Offset != nil
+		}
+		return true, nil
+	})
+	return ordering, limit, offset
+}
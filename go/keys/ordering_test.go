@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestOrderingColumns(t *testing.T) {
+	tests := []struct {
+		query         string
+		ordering      []string
+		limit, offset bool
+	}{{
+		query: "select a from t where id = 1",
+	}, {
+		query:    "select a, b from t order by b desc, a",
+		ordering: []string{"t.b", "t.a"},
+	}, {
+		query:    "select id from t order by a limit 10",
+		ordering: []string{"t.a"},
+		limit:    true,
+	}, {
+		query:    "select id from t order by a limit 20, 10",
+		ordering: []string{"t.a"},
+		limit:    true,
+		offset:   true,
+	}, {
+		// the ordinals and the aliases are rewritten to the columns they stand for
+		query:    "select a as x, b from t order by x, 2",
+		ordering: []string{"t.a", "t.b"},
+	}, {
+		query:    "select x.a from (select a from t order by id limit 5) as x order by x.a",
+		ordering: []string{"t.id", "t.a"},
+		limit:    true,
+	}, {
+		// an expression can't be read from an index
+		query: "select a from t order by a + b",
+	}, {
+		query:    "delete from t where a = 1 order by id limit 100",
+		ordering: []string{"t.id"},
+		limit:    true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			si := &schemaInfo{tables: make(map[string]columns)}
+			ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+			process(data.Query{Query: "create table t (id bigint primary key, a int, b int)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: tt.query, Line: 1, Type: typ.Query}, si, ql)
+			require.Empty(t, ql.failed)
+			require.Len(t, ql.queries, 1)
+			for _, result := range ql.queries {
+				require.Equal(t, tt.ordering, stringsOf(result.OrderingColumns))
+				require.Equal(t, tt.limit, result.Limit)
+				require.Equal(t, tt.offset, result.Offset)
+			}
+		})
+	}
+}
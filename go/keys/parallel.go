@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"sync"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/data"
+)
+
+// parallelChunkSize is the number of queries analysed concurrently before their results are added to the query list
+const parallelChunkSize = 1000
+
+// processParallel analyses the queries with the given number of workers. The results are added to the query list
+// in the order of the log, so the output is the same as the one of a sequential analysis. The DDL statements change
+// the schema the next queries are analysed with: they are processed on their own, once the queries before them are added.
+// It returns the number of queries processed, which is less than len(queries) when ctx is canceled.
+// The progress, when not nil, is updated after every chunk.
+func processParallel(ctx context.Context, queries []data.Query, si *schemaInfo, ql *queryList, workers int, p *progress) int {
+	start := 0
+	for start < len(queries) {
+		if ctx.Err() != nil {
+			return start
+		}
+		if isDDL(queries[start]) {
+			process(queries[start], si, ql)
+			start++
+			p.update(start, len(ql.failed))
+			continue
+		}
+		end := start + 1
+		for end < len(queries) && end-start < parallelChunkSize && !isDDL(queries[end]) {
+			end++
+		}
+		ql.processChunk(queries[start:end], si, workers)
+		start = end
+		p.update(start, len(ql.failed))
+	}
+	return start
+}
+
+// processChunk analyses queries that don't change the schema concurrently, and adds them to the list in order
+func (ql *queryList) processChunk(queries []data.Query, si *schemaInfo, workers int) {
+	// a query structure is analysed once, by the first worker that finds it,
+	// which is not necessarily the one analysing its first query
+	var claimed sync.Map
+	analysed := func(structure string) bool {
+		if ql.analysed(structure) {
+			return true
+		}
+		_, loaded := claimed.LoadOrStore(structure, true)
+		return loaded
+	}
+
+	analyses := make([]analysis, len(queries))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				analyses[i] = analyse(queries[i], si, ql.renames, ql.topValues > 0, analysed)
+			}
+		}()
+	}
+	for i := range queries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	structures := make(map[string]*QueryAnalysisResult)
+	for _, a := range analyses {
+		if a.keys != nil {
+			structures[a.structure] = a.keys
+		}
+	}
+	for i, a := range analyses {
+		if a.keys == nil {
+			a.keys = structures[a.structure]
+		}
+		ql.add(queries[i], a, si)
+	}
+}
+
+// isDDL tells if the query changes the schema
+func isDDL(q data.Query) bool {
+	return sqlparser.Preview(q.Query) == sqlparser.StmtDDL
+}
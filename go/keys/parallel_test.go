@@ -0,0 +1,43 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelMatchesSequential(t *testing.T) {
+	files, err := filepath.Glob("../../t/*.test")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			sequential := &strings.Builder{}
+			require.NoError(t, run(context.Background(), sequential, Config{FileNames: []string{file}}))
+
+			parallel := &strings.Builder{}
+			require.NoError(t, run(context.Background(), parallel, Config{FileNames: []string{file}, Parallel: 4}))
+			require.Equal(t, sequential.String(), parallel.String())
+		})
+	}
+}
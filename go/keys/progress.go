@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// progressInterval is how often the progress of the analysis is logged
+const progressInterval = 10 * time.Second
+
+// progress logs how many queries were analysed so far, how many failed, and when the analysis should end
+type progress struct {
+	total    int
+	start    time.Time
+	last     time.Time
+	interval time.Duration
+
+	// now and logf are replaced by the tests
+	now  func() time.Time
+	logf func(format string, args ...any)
+}
+
+func newProgress(total int) *progress {
+	start := time.Now()
+	return &progress{
+		total:    total,
+		start:    start,
+		last:     start,
+		interval: progressInterval,
+		now:      time.Now,
+		logf:     log.Infof,
+	}
+}
+
+// update logs the progress when the previous line is older than the interval. A nil progress logs nothing.
+func (p *progress) update(processed, failed int) {
+	if p == nil {
+		return
+	}
+	now := p.now()
+	if now.Sub(p.last) < p.interval || processed == 0 {
+		return
+	}
+	p.last = now
+	elapsed := now.Sub(p.start)
+	eta := time.Duration(float64(elapsed) / float64(processed) * float64(p.total-processed))
+	p.logf("analysed %d of %d queries (%.1f%%), %d failed, %s left", processed, p.total,
+		float64(processed)/float64(p.total)*100, failed, eta.Round(time.Second))
+}
+
+// done logs the end of the analysis
+func (p *progress) done(processed, failed int) {
+	if p == nil {
+		return
+	}
+	p.logf("analysed %d queries in %s, %d failed", processed, p.now().Sub(p.start).Round(time.Millisecond), failed)
+}
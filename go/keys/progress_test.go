@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	now := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	var lines []string
+	p := &progress{
+		total:    100,
+		start:    now,
+		last:     now,
+		interval: 10 * time.Second,
+		now:      func() time.Time { return now },
+		logf: func(format string, args ...any) {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		},
+	}
+
+	now = now.Add(5 * time.Second)
+	p.update(10, 0)
+	require.Empty(t, lines, "the interval has not elapsed")
+
+	now = now.Add(5 * time.Second)
+	p.update(25, 1)
+	now = now.Add(time.Second)
+	p.update(26, 1)
+	now = now.Add(20 * time.Second)
+	p.update(100, 3)
+	p.done(100, 3)
+	require.Equal(t, []string{
+		"analysed 25 of 100 queries (25.0%), 1 failed, 30s left",
+		"analysed 100 of 100 queries (100.0%), 3 failed, 0s left",
+		"analysed 100 queries in 31s, 3 failed",
+	}, lines)
+
+	var none *progress
+	none.update(1, 0)
+	none.done(1, 0)
+}
+
+func TestKeysProgress(t *testing.T) {
+	sb := &strings.Builder{}
+	err := run(context.Background(), sb, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}, Progress: true})
+	require.NoError(t, err)
+	// the progress is logged, the output is unchanged
+	expected := &strings.Builder{}
+	require.NoError(t, run(context.Background(), expected, Config{FileNames: []string{"../../t/tpch_failing_queries.test"}}))
+	require.Equal(t, expected.String(), sb.String())
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+)
+
+// Renames maps the tables of a workload captured before a rename or a migration to their new names, so it can
+// be compared with the workload captured after it. It is read from a JSON object such as
+// {"old_db.old_table": "new_ks.new_table", "customers": "customer"}. A mapping without a database matches
+// the table in any database, and a table used without a database matches the mappings of its name in any
+// database. The names are matched case-insensitively.
+type Renames map[string]string
+
+func ReadRenames(fileName string) (Renames, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(b, &mapping); err != nil {
+		return nil, fmt.Errorf("reading rename file %s: %w", fileName, err)
+	}
+	renames := make(Renames, len(mapping))
+	for from, to := range mapping {
+		if from == "" || to == "" || strings.Count(from, ".") > 1 || strings.Count(to, ".") > 1 {
+			return nil, fmt.Errorf("rename file %s: %q -> %q is not a [database.]table to [keyspace.]table mapping", fileName, from, to)
+		}
+		renames[strings.ToLower(from)] = to
+	}
+	return renames, nil
+}
+
+// lookup returns the new keyspace and name of a table, the keyspace is empty when the mapping doesn't name one
+func (r Renames) lookup(qualifier, name string) (keyspace, table string, found bool) {
+	name = strings.ToLower(name)
+	var to string
+	if qualifier != "" {
+		to, found = r[strings.ToLower(qualifier)+"."+name]
+	}
+	if !found {
+		to, found = r[name]
+	}
+	if !found && qualifier == "" {
+		// the smallest database name wins, so the result doesn't depend on the map order
+		var from string
+		for key, value := range r {
+			if strings.HasSuffix(key, "."+name) && (from == "" || key < from) {
+				from, to, found = key, value, true
+			}
+		}
+	}
+	if !found {
+		return "", "", false
+	}
+	if ks, tbl, ok := strings.Cut(to, "."); ok {
+		return ks, tbl, true
+	}
+	return "", to, true
+}
+
+// Rewrite renames the tables of the query, and the tables qualifying its columns
+func (r Renames) Rewrite(ast sqlparser.Statement) {
+	r.rewrite(ast, true)
+}
+
+// rewrite renames the tables of the query. The keyspace of the new name is only set when qualified is true,
+// the keys analysis drops it since it analyses all the tables as one keyspace.
+func (r Renames) rewrite(ast sqlparser.Statement, qualified bool) {
+	if len(r) == 0 {
+		return
+	}
+	_ = sqlparser.Rewrite(ast, func(cursor *sqlparser.Cursor) bool {
+		tbl, ok := cursor.Node().(sqlparser.TableName)
+		if !ok || tbl.Name.IsEmpty() {
+			return true
+		}
+		keyspace, name, found := r.lookup(tbl.Qualifier.String(), tbl.Name.String())
+		if !found {
+			return true
+		}
+		tbl.Name = sqlparser.NewIdentifierCS(name)
+		switch {
+		case !qualified:
+			tbl.Qualifier = sqlparser.NewIdentifierCS("")
+		case keyspace != "":
+			tbl.Qualifier = sqlparser.NewIdentifierCS(keyspace)
+		}
+		cursor.Replace(tbl)
+		return true
+	}, nil)
+}
+
+// ApplyTo renames the tables and columns of a keys output. The query structures are left as they are:
+// they can't be parsed back without losing their bind variable types. Run 'vt keys' with the renames
+// to rename the tables of the query structures too.
+func (r Renames) ApplyTo(output *Output) {
+	if len(r) == 0 {
+		return
+	}
+	for i := range output.Queries {
+		q := &output.Queries[i]
+		for j, table := range q.TableName {
+			q.TableName[j] = r.table(table)
+		}
+		for j, table := range q.AffectedTables {
+			q.AffectedTables[j] = r.table(table)
+		}
+		for j := range q.GroupingColumns {
+			r.column(&q.GroupingColumns[j])
+		}
+		for j := range q.JoinColumns {
+			r.column(&q.JoinColumns[j].Column)
+		}
+		for j := range q.FilterColumns {
+			r.column(&q.FilterColumns[j].Column)
+		}
+		for j := range q.JoinPredicates {
+			r.column(&q.JoinPredicates[j].LHS)
+			r.column(&q.JoinPredicates[j].RHS)
+		}
+	}
+
+	// two tables can be renamed to the same one
+	stats := make([]TableStats, 0, len(output.Tables))
+	index := make(map[string]int, len(output.Tables))
+	for _, ts := range output.Tables {
+		ts.Table = r.table(ts.Table)
+		if i, found := index[ts.Table]; found {
+			stats[i].Reads += ts.Reads
+			stats[i].Writes += ts.Writes
+			stats[i].QPS += ts.QPS
+			continue
+		}
+		index[ts.Table] = len(stats)
+		stats = append(stats, ts)
+	}
+	output.Tables = stats
+}
+
+// table returns the new name of a table of the keys output, which has no database qualifiers
+func (r Renames) table(name string) string {
+	if _, table, found := r.lookup("", name); found {
+		return table
+	}
+	return name
+}
+
+func (r Renames) column(col *operators.Column) {
+	col.Table = r.table(col.Table)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestReadRenames(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "renames.json")
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"Old_DB.Orders": "commerce.order", "customers": "customer"}`), 0o600))
+
+	renames, err := ReadRenames(fileName)
+	require.NoError(t, err)
+	require.Equal(t, Renames{"old_db.orders": "commerce.order", "customers": "customer"}, renames)
+
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"a.b.c": "d"}`), 0o600))
+	_, err = ReadRenames(fileName)
+	require.ErrorContains(t, err, "is not a [database.]table to [keyspace.]table mapping")
+}
+
+func TestRenamesRewrite(t *testing.T) {
+	renames := Renames{"old_db.orders": "commerce.order", "customers": "customer", "other.orders": "archive.orders"}
+	parser := sqlparser.NewTestParser()
+
+	tests := map[string]string{
+		"select * from old_db.orders where old_db.orders.id = 1":        "select * from commerce.`order` where commerce.`order`.id = 1",
+		"select * from orders join Customers as c on orders.cid = c.id": "select * from commerce.`order` join customer as c on commerce.`order`.cid = c.id",
+		"select * from other.orders":                                    "select * from archive.orders",
+		"select * from sales.customers, products where products.id = 1": "select * from sales.customer, products where products.id = 1",
+	}
+	for query, expected := range tests {
+		ast, err := parser.Parse(query)
+		require.NoError(t, err)
+		renames.Rewrite(ast)
+		require.Equal(t, expected, sqlparser.String(ast), query)
+	}
+}
+
+func TestKeysRenames(t *testing.T) {
+	si := &schemaInfo{tables: make(map[string]columns)}
+	ql := &queryList{
+		queries: make(map[string]*QueryAnalysisResult),
+		renames: Renames{"old_db.orders": "commerce.order_line"},
+	}
+
+	queries := []string{
+		"create table orders (id bigint, cid bigint, primary key (id))",
+		"select * from old_db.orders where id = 1",
+		"select * from order_line where id = 2",
+		"select * from ks[-80].orders where id = 3",
+	}
+	for i, query := range queries {
+		process(data.Query{Query: query, Line: i + 1, Type: typ.Query}, si, ql)
+	}
+
+	require.Empty(t, ql.failed)
+	require.Contains(t, si.tables, "order_line")
+	require.Len(t, ql.queries, 1, "the old and the new table should aggregate together")
+	for _, result := range ql.queries {
+		require.Equal(t, 3, result.UsageCount)
+		require.Equal(t, []string{"order_line"}, result.TableName)
+		require.Equal(t, map[string]int{"ks:-80": 1}, result.Targets)
+	}
+}
+
+func TestRenamesApplyTo(t *testing.T) {
+	output := &Output{
+		Queries: []QueryAnalysisResult{{
+			QueryStructure: "select * from `orders` join `items` on `orders`.`id` = `items`.`oid`",
+			TableName:      []string{"orders", "items"},
+			FilterColumns:  []operators.ColumnUse{{Column: operators.Column{Table: "orders", Name: "id"}, Uses: sqlparser.EqualOp}},
+			JoinPredicates: []operators.JoinPredicate{{
+				LHS:  operators.Column{Table: "orders", Name: "id"},
+				RHS:  operators.Column{Table: "items", Name: "oid"},
+				Uses: sqlparser.EqualOp,
+			}},
+		}},
+		Tables: []TableStats{{Table: "items", Reads: 2}, {Table: "order_line", Reads: 1, Writes: 1}, {Table: "orders", Reads: 3}},
+	}
+
+	Renames{"old_db.orders": "commerce.order_line"}.ApplyTo(output)
+	require.Equal(t, []string{"order_line", "items"}, output.Queries[0].TableName)
+	require.Equal(t, "order_line", output.Queries[0].FilterColumns[0].Column.Table)
+	require.Equal(t, "order_line", output.Queries[0].JoinPredicates[0].LHS.Table)
+	require.Equal(t, "items", output.Queries[0].JoinPredicates[0].RHS.Table)
+	require.Equal(t, []TableStats{{Table: "items", Reads: 2}, {Table: "order_line", Reads: 4, Writes: 1}}, output.Tables)
+}
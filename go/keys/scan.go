@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"slices"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// isFullScan tells if a query reads or modifies the rows of its tables without a selective filter: no predicate
+// compares a column with a value, or only with a negation, such as `col != 1` or `col NOT IN (...)`, which match most rows.
+// Such queries read every row of their tables, and are scattered on all the shards of a sharded keyspace.
+func isFullScan(ast sqlparser.Statement, tableNames []string) bool {
+	switch ast.(type) {
+	case *sqlparser.Select, *sqlparser.Union, *sqlparser.Update, *sqlparser.Delete:
+	default:
+		return false
+	}
+	if !slices.ContainsFunc(tableNames, func(name string) bool { return name != "dual" }) {
+		return false
+	}
+
+	filtered := false
+	_ = sqlparser.VisitSQLNode(ast, func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Where:
+			filtered = filtered || isSelective(node.Expr)
+		case *sqlparser.JoinCondition:
+			filtered = filtered || isSelective(node.On)
+		}
+		return !filtered, nil
+	})
+	return !filtered
+}
+
+// isSelective tells if a predicate only matches the rows with some values of a column
+func isSelective(expr sqlparser.Expr) bool {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		return isSelective(expr.Left) || isSelective(expr.Right)
+	case *sqlparser.OrExpr:
+		return isSelective(expr.Left) && isSelective(expr.Right)
+	case *sqlparser.ComparisonExpr:
+		switch expr.Operator {
+		case sqlparser.NotEqualOp, sqlparser.NotInOp, sqlparser.NotLikeOp, sqlparser.NotRegexpOp:
+			return false
+		}
+		return isColumnValue(expr.Left, expr.Right) || isColumnValue(expr.Right, expr.Left)
+	case *sqlparser.BetweenExpr:
+		return expr.IsBetween && isColumnValue(expr.Left, expr.From) && isColumnValue(expr.Left, expr.To)
+	case *sqlparser.IsExpr:
+		_, isCol := expr.Left.(*sqlparser.ColName)
+		return isCol && expr.Right == sqlparser.IsNullOp
+	default:
+		return false
+	}
+}
+
+// isColumnValue tells if col is a column and value an expression that doesn't read any column, such as a literal
+func isColumnValue(col, value sqlparser.Expr) bool {
+	if _, ok := col.(*sqlparser.ColName); !ok {
+		return false
+	}
+	return readsNoColumn(value)
+}
+
+// readsNoColumn tells if the expression doesn't read any column, such as a literal or a bind variable
+func readsNoColumn(expr sqlparser.Expr) bool {
+	readsColumn := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node.(type) {
+		case *sqlparser.ColName, *sqlparser.Subquery:
+			readsColumn = true
+		}
+		return !readsColumn, nil
+	}, expr)
+	return !readsColumn
+}
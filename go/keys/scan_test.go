@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestFullScan(t *testing.T) {
+	tests := []struct {
+		query    string
+		fullScan bool
+	}{
+		{query: "select a from t where id = 1"},
+		{query: "select a from t where b between 1 and 3"},
+		{query: "select count(*) from t", fullScan: true},
+		{query: "select a from t where b != 2 and a not in (1, 2)", fullScan: true},
+		{query: "select t.a from t join u on t.id = u.t_id", fullScan: true},
+		{query: "select t.a from t join u on t.id = u.t_id where u.c = 1"},
+		{query: "update t set a = 1", fullScan: true},
+		{query: "delete from t where id = 4"},
+		{query: "insert into t (id, a) values (1, 2)"},
+		{query: "select 1 from dual"},
+		{query: "select x.a from (select a, b from t) as x where x.b = 1"},
+		{query: "select a from t where id = 1 or b in (2, 3)"},
+		{query: "select a from t where id = 1 or b > a", fullScan: true},
+		{query: "select a from t where b not between 1 and 3", fullScan: true},
+		{query: "select a from t where id in (select t_id from u where c = 1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			si := &schemaInfo{tables: make(map[string]columns)}
+			ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+			process(data.Query{Query: "create table t (id bigint primary key, a int, b int)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: "create table u (id bigint primary key, t_id bigint, c int)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: tt.query, Line: 1, Type: typ.Query}, si, ql)
+			require.Empty(t, ql.failed)
+			require.Len(t, ql.queries, 1)
+			for _, result := range ql.queries {
+				require.Equal(t, tt.fullScan, result.FullScan)
+			}
+		})
+	}
+}
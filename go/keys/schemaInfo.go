@@ -18,7 +18,10 @@ package keys
 
 import (
 	"fmt"
+	"os"
+	"sync"
 
+	log "github.com/sirupsen/logrus"
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/vt/key"
 	"vitess.io/vitess/go/vt/proto/topodata"
@@ -35,6 +38,17 @@ type (
 	schemaInfo struct {
 		ksName string
 		tables map[string]columns
+
+		// mu serializes the lookups of the tables, the queries being analysed by concurrent workers
+		// when a table fetched from a live database is added to tables
+		mu sync.Mutex
+
+		// fetch reads the tables that are not in tables from a live database, fetched lists the tables it was asked for
+		fetch   func(table string) (*sqlparser.CreateTable, error)
+		fetched map[string]bool
+
+		// inferred are the tables whose columns were guessed from the queries, see inferSchema
+		inferred map[string]bool
 	}
 
 	columns []vindexes.Column
@@ -49,6 +63,48 @@ func (s *schemaInfo) handleCreateTable(create *sqlparser.CreateTable) {
 		})
 	}
 	s.tables[create.Table.Name.String()] = columns
+	delete(s.inferred, create.Table.Name.String())
+}
+
+// loadSchema adds the tables of a schema file, such as the output of `mysqldump --no-data`, so the queries of logs
+// that don't create their tables can be analysed. The statements other than CREATE TABLE are ignored,
+// and so are those that don't parse. The table renames are applied to the tables of the file too.
+func (s *schemaInfo) loadSchema(fileName string, renames Renames) error {
+	sql, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	return s.parseSchema(string(sql), "schema file "+fileName, renames)
+}
+
+// parseSchema adds the tables of the CREATE TABLE statements of sql, source names where they come from in the logs
+func (s *schemaInfo) parseSchema(sql, source string, renames Renames) error {
+	parser := sqlparser.NewTestParser()
+	pieces, err := parser.SplitStatementToPieces(sql)
+	if err != nil {
+		return fmt.Errorf("splitting the statements of %s: %w", source, err)
+	}
+
+	tables := 0
+	for _, piece := range pieces {
+		ast, err := parser.Parse(piece)
+		if err != nil {
+			log.Debugf("skipping a statement of %s: %v", source, err)
+			continue
+		}
+		create, ok := ast.(*sqlparser.CreateTable)
+		if !ok {
+			continue
+		}
+		renames.rewrite(create, false)
+		s.handleCreateTable(create)
+		tables++
+	}
+	if tables == 0 {
+		return fmt.Errorf("no CREATE TABLE statement in %s", source)
+	}
+	log.Infof("loaded %d tables from %s", tables, source)
+	return nil
 }
 
 func (s *schemaInfo) FindTableOrVindex(tablename sqlparser.TableName) (*vindexes.Table, vindexes.Vindex, string, topodata.TabletType, key.Destination, error) {
@@ -56,7 +112,12 @@ func (s *schemaInfo) FindTableOrVindex(tablename sqlparser.TableName) (*vindexes
 		return nil, nil, "", topodata.TabletType_REPLICA, nil, fmt.Errorf("unknown keyspace %s", tablename.Qualifier.String())
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	columns, found := s.tables[tablename.Name.String()]
+	if !found && s.fetchTable(tablename.Name.String()) {
+		columns, found = s.tables[tablename.Name.String()]
+	}
 	if !found {
 		return &vindexes.Table{
 			Name:                    tablename.Name,
@@ -69,10 +130,24 @@ func (s *schemaInfo) FindTableOrVindex(tablename sqlparser.TableName) (*vindexes
 		Name:                    tablename.Name,
 		Keyspace:                &vindexes.Keyspace{Name: s.ksName},
 		Columns:                 columns,
-		ColumnListAuthoritative: true,
+		ColumnListAuthoritative: !s.inferred[tablename.Name.String()],
 	}, nil, s.ksName, topodata.TabletType_REPLICA, nil, nil
 }
 
+// shardedSchema is the schema seen from a sharded keyspace. The analysis of the queries on a single table of the
+// unsharded keyspace takes a shortcut, which doesn't bind the columns to their table, and nothing is found about them.
+type shardedSchema struct {
+	*schemaInfo
+}
+
+func (s shardedSchema) FindTableOrVindex(tablename sqlparser.TableName) (*vindexes.Table, vindexes.Vindex, string, topodata.TabletType, key.Destination, error) {
+	table, vindex, keyspace, tabletType, dest, err := s.schemaInfo.FindTableOrVindex(tablename)
+	if table != nil {
+		table.Keyspace.Sharded = true
+	}
+	return table, vindex, keyspace, tabletType, dest, err
+}
+
 func (s *schemaInfo) ConnCollation() collations.ID {
 	return collations.CollationBinaryID
 }
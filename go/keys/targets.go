@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// unquotedTarget matches the table qualifiers that target a shard or a tablet type without backticks,
+// such as ks[-80].t or ks@replica.t, which the parser only accepts when quoted
+var unquotedTarget = regexp.MustCompile("(^|[^\\w`.@])([A-Za-z_]\\w*(?:\\[[-0-9A-Fa-f]*\\](?:@(?i:primary|replica|rdonly))?|@(?i:primary|replica|rdonly)))\\.") //nolint:gochecknoglobals // this is instead of a const
+
+// quoteTargets quotes the shard and tablet type targeting qualifiers of the query, so the query can be parsed
+func quoteTargets(query string) string {
+	return unquotedTarget.ReplaceAllString(query, "$1`$2`.")
+}
+
+// splitTarget splits a table qualifier into its keyspace and the Vitess target it names, such as ks:-80,
+// ks@replica or ks:-80@replica. The target is empty when the qualifier is a plain keyspace name.
+func splitTarget(qualifier string) (keyspace, target string) {
+	keyspace, tabletType := qualifier, ""
+	if i := strings.LastIndexByte(qualifier, '@'); i >= 0 {
+		keyspace, tabletType = qualifier[:i], strings.ToLower(qualifier[i+1:])
+		if !slices.Contains([]string{"primary", "replica", "rdonly"}, tabletType) {
+			return qualifier, ""
+		}
+	}
+
+	var shard string
+	switch {
+	case strings.HasSuffix(keyspace, "]"):
+		i := strings.IndexByte(keyspace, '[')
+		if i < 0 {
+			return qualifier, ""
+		}
+		keyspace, shard = keyspace[:i], keyspace[i+1:len(keyspace)-1]
+	case strings.Contains(keyspace, ":"):
+		keyspace, shard, _ = strings.Cut(keyspace, ":")
+	}
+	if shard == "" && tabletType == "" {
+		return qualifier, ""
+	}
+
+	target = keyspace
+	if shard != "" {
+		target += ":" + shard
+	}
+	if tabletType != "" {
+		target += "@" + tabletType
+	}
+	return keyspace, target
+}
+
+// stripTargets removes the shard and tablet type targeting from the table qualifiers of the query,
+// so the query is analysed, and aggregated, like the same query without targeting. It returns the targets it found.
+func stripTargets(ast sqlparser.Statement) []string {
+	var targets []string
+	_ = sqlparser.Rewrite(ast, func(cursor *sqlparser.Cursor) bool {
+		tbl, ok := cursor.Node().(sqlparser.TableName)
+		if !ok || tbl.Qualifier.IsEmpty() {
+			return true
+		}
+		_, target := splitTarget(tbl.Qualifier.String())
+		if target == "" {
+			return true
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+		tbl.Qualifier = sqlparser.NewIdentifierCS("")
+		cursor.Replace(tbl)
+		return true
+	}, nil)
+	return targets
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestSplitTarget(t *testing.T) {
+	tests := []struct {
+		qualifier, keyspace, target string
+	}{
+		{"ks", "ks", ""},
+		{"ks:-80", "ks", "ks:-80"},
+		{"ks[-80]", "ks", "ks:-80"},
+		{"ks@replica", "ks", "ks@replica"},
+		{"ks@PRIMARY", "ks", "ks@primary"},
+		{"ks[80-]@rdonly", "ks", "ks:80-@rdonly"},
+		{"ks:80-@replica", "ks", "ks:80-@replica"},
+		{"ks@unknown", "ks@unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.qualifier, func(t *testing.T) {
+			keyspace, target := splitTarget(tt.qualifier)
+			require.Equal(t, tt.keyspace, keyspace)
+			require.Equal(t, tt.target, target)
+		})
+	}
+}
+
+func TestQuoteTargets(t *testing.T) {
+	require.Equal(t, "select * from `ks[-80]`.t", quoteTargets("select * from ks[-80].t"))
+	require.Equal(t, "select * from `ks@replica`.t join `ks[80-]@rdonly`.u", quoteTargets("select * from ks@replica.t join ks[80-]@rdonly.u"))
+	require.Equal(t, "select * from `ks@replica`.t", quoteTargets("select * from `ks@replica`.t"))
+	require.Equal(t, "select * from t where a = @replica.x", quoteTargets("select * from t where a = @replica.x"))
+}
+
+func TestKeysTargets(t *testing.T) {
+	si := &schemaInfo{tables: make(map[string]columns)}
+	ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+
+	queries := []string{
+		"select * from ks[-80].t where t.id = 1",
+		"select * from `ks@replica`.t where t.id = 2",
+		"select * from t where t.id = 3",
+		"select * from ks[80-]@replica.t where ks[80-]@replica.t.id = 4",
+	}
+	for i, query := range queries {
+		process(data.Query{Query: query, Line: i + 1, Type: typ.Query}, si, ql)
+	}
+
+	require.Empty(t, ql.failed)
+	require.Len(t, ql.queries, 1, "targeting should not change the query structure")
+	for _, result := range ql.queries {
+		require.Equal(t, 4, result.UsageCount)
+		require.Equal(t, []string{"t"}, result.TableName)
+		require.Equal(t, map[string]int{"ks:-80": 1, "ks@replica": 1, "ks:80-@replica": 1}, result.Targets)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"slices"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+// unresolvedColumns returns the columns of the query the semantic analysis could not attribute to a table, sorted.
+// They are the unqualified columns of the queries joining tables whose columns are unknown: any of the tables could have them.
+func unresolvedColumns(st *semantics.SemTable, ast sqlparser.Statement) []string {
+	var unresolved []string
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		col, ok := node.(*sqlparser.ColName)
+		if !ok || st.RecursiveDeps(col).NumberOfTables() > 0 {
+			return true, nil
+		}
+		name := col.Name.String()
+		if !col.Qualifier.IsEmpty() {
+			name = col.Qualifier.Name.String() + "." + name
+		}
+		if !slices.Contains(unresolved, name) {
+			unresolved = append(unresolved, name)
+		}
+		return true, nil
+	}, ast)
+	slices.Sort(unresolved)
+	return unresolved
+}
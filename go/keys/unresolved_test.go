@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestUnresolvedColumns(t *testing.T) {
+	tests := []struct {
+		query      string
+		unresolved []string
+	}{{
+		// the columns of a single table are attributed to it, even when the table is unknown
+		query: "select id from orders where region = 'eu'",
+	}, {
+		query:      "select o.id from orders o join customer c on o.customer_id = c.cid where region = 'eu' and o.status = 1",
+		unresolved: []string{"region"},
+	}, {
+		// the columns of t are known, those it doesn't have are in the other table
+		query: "select id from t join orders on a = customer_id",
+	}, {
+		query:      "update orders join customer on customer_id = cid set region = 'x' where `status` = 1",
+		unresolved: []string{"cid", "customer_id", "region", "status"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			si := &schemaInfo{tables: make(map[string]columns)}
+			ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+			process(data.Query{Query: "create table t (id bigint primary key, a int)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: tt.query, Line: 1, Type: typ.Query}, si, ql)
+			require.Empty(t, ql.failed)
+			require.Len(t, ql.queries, 1)
+			for _, result := range ql.queries {
+				require.Equal(t, tt.unresolved, result.UnresolvedColumns)
+			}
+		})
+	}
+}
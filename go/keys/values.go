@@ -0,0 +1,289 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+const (
+	// valueCountersPerTopValue is how many counters the top values sketch keeps for every value it reports,
+	// the more counters the more accurate the counts of the reported values
+	valueCountersPerTopValue = 10
+
+	// hllPrecision is the number of bits of the hash that select the register of the distinct values sketch,
+	// it estimates the number of distinct values with a standard error of about 3%
+	hllPrecision = 10
+	hllRegisters = 1 << hllPrecision
+)
+
+type (
+	// ColumnValues describes the literal values the queries compare a filter column with for equality or IN,
+	// which tells how good a sharding key the column is: a column with many evenly used values spreads the rows
+	// over the shards, while a column with a few values, such as a status, can't.
+	ColumnValues struct {
+		Column operators.Column `json:"column"`
+		// Uses is the number of values the queries compared the column with
+		Uses int `json:"uses"`
+		// Distinct is the estimated number of distinct values
+		Distinct int `json:"distinct"`
+		// TopValues are the most used values, the most used first. Their counts are estimates when the column has
+		// more distinct values than the sketch keeps counters for.
+		TopValues []ValueCount `json:"topValues"`
+		// Sketch holds the state of the distinct values estimation, so outputs can be merged
+		Sketch []byte `json:"sketch"`
+	}
+
+	// ValueCount is how many times the queries used a literal value, written as in SQL, such as 'active' or 42
+	ValueCount struct {
+		Value string `json:"value"`
+		Count int    `json:"count"`
+	}
+
+	// columnValue is a literal value a query compares a column with
+	columnValue struct {
+		column operators.Column
+		value  string
+	}
+
+	// valueSketch is the bounded state recorded for the values of a column: the counters of a space-saving
+	// top-k sketch, and the registers of a HyperLogLog sketch estimating the number of distinct values
+	valueSketch struct {
+		uses int
+		// top is the number of values reported
+		top       int
+		counters  map[string]int
+		registers []byte
+	}
+)
+
+// filterValues returns the literal values the WHERE and ON clauses of the query compare a column with,
+// with = or IN. The other comparisons don't tell how the rows are distributed.
+func filterValues(ctx *plancontext.PlanningContext, ast sqlparser.Statement) []columnValue {
+	var values []columnValue
+	add := func(expr sqlparser.Expr, literals ...sqlparser.Expr) {
+		col, ok := expr.(*sqlparser.ColName)
+		if !ok {
+			return
+		}
+		c, ok := physicalColumn(ctx, col)
+		if !ok {
+			return
+		}
+		for _, literal := range literals {
+			if literal, ok := literal.(*sqlparser.Literal); ok {
+				values = append(values, columnValue{column: c, value: sqlparser.String(literal)})
+			}
+		}
+	}
+	visitPredicates := func(expr sqlparser.Expr) {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch node := node.(type) {
+			case *sqlparser.ComparisonExpr:
+				switch node.Operator {
+				case sqlparser.EqualOp:
+					add(node.Left, node.Right)
+					add(node.Right, node.Left)
+				case sqlparser.InOp:
+					if tuple, ok := node.Right.(sqlparser.ValTuple); ok {
+						add(node.Left, tuple...)
+					}
+				}
+			case *sqlparser.Subquery:
+				// the predicates of the subqueries are visited with their own WHERE clause
+				return false, nil
+			}
+			return true, nil
+		}, expr)
+	}
+	_ = sqlparser.VisitSQLNode(ast, func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Where:
+			if node.Type == sqlparser.WhereClause {
+				visitPredicates(node.Expr)
+			}
+		case *sqlparser.JoinCondition:
+			visitPredicates(node.On)
+		}
+		return true, nil
+	})
+	return values
+}
+
+func newValueSketch(top int) *valueSketch {
+	return &valueSketch{
+		top:       top,
+		counters:  make(map[string]int),
+		registers: make([]byte, hllRegisters),
+	}
+}
+
+// capacity is the number of values the sketch counts
+func (s *valueSketch) capacity() int {
+	return max(s.top*valueCountersPerTopValue, 1)
+}
+
+// add records count uses of the value. When all the counters are taken, the least used value is replaced,
+// and the new value inherits its count, which overestimates the count of the new value but never underestimates it.
+func (s *valueSketch) add(value string, count int) {
+	s.uses += count
+	s.addDistinct(value)
+	if _, found := s.counters[value]; !found && len(s.counters) >= s.capacity() {
+		least, min := "", math.MaxInt
+		for v, c := range s.counters {
+			if c < min || (c == min && v < least) {
+				least, min = v, c
+			}
+		}
+		delete(s.counters, least)
+		count += min
+	}
+	s.counters[value] += count
+}
+
+func (s *valueSketch) addDistinct(value string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	hash := mix64(h.Sum64())
+	register := hash >> (64 - hllPrecision)
+	// the rank is the position of the first 1 bit after the register bits, the sentinel bit bounds it
+	rank := byte(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > s.registers[register] {
+		s.registers[register] = rank
+	}
+}
+
+// distinct estimates the number of distinct values, counting them by linear counting while few registers are set
+func (s *valueSketch) distinct() int {
+	m := float64(hllRegisters)
+	var sum float64
+	zeros := 0
+	for _, r := range s.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
+
+// topValues returns the most used values, the most used first, with their counts scaled by the given factor
+func (s *valueSketch) topValues(scale float64) []ValueCount {
+	values := make([]ValueCount, 0, len(s.counters))
+	for value, count := range s.counters {
+		values = append(values, ValueCount{Value: value, Count: int(math.Round(float64(count) * scale))})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	if len(values) > s.top {
+		values = values[:s.top]
+	}
+	return values
+}
+
+// merge adds the values of a previous output to the sketch. Only its top values are known,
+// their counts are added to the counters.
+func (s *valueSketch) merge(previous ColumnValues) {
+	for _, value := range previous.TopValues {
+		if _, found := s.counters[value.Value]; found || len(s.counters) < s.capacity() {
+			s.counters[value.Value] += value.Count
+		}
+	}
+	s.uses += previous.Uses
+	if len(previous.Sketch) == hllRegisters {
+		for i, r := range previous.Sketch {
+			s.registers[i] = max(s.registers[i], r)
+		}
+	}
+}
+
+// mix64 is the finalizer of MurmurHash3, which spreads the bits of the FNV hash over the whole word
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// addValues records the values the query compares its filter columns with
+func (ql *queryList) addValues(values []columnValue, count int) {
+	for _, v := range values {
+		sketch, found := ql.values[v.column]
+		if !found {
+			if ql.values == nil {
+				ql.values = make(map[operators.Column]*valueSketch)
+			}
+			sketch = newValueSketch(ql.topValues)
+			ql.values[v.column] = sketch
+		}
+		sketch.add(v.value, count)
+	}
+}
+
+// loadValues restores the values of the filter columns of a previous output
+func (ql *queryList) loadValues(previous []ColumnValues) {
+	for _, values := range previous {
+		if ql.values == nil {
+			ql.values = make(map[operators.Column]*valueSketch)
+		}
+		sketch := newValueSketch(max(ql.topValues, len(values.TopValues)))
+		sketch.merge(values)
+		ql.values[values.Column] = sketch
+	}
+}
+
+// columnValues returns the values of the filter columns, sorted by column
+func (ql *queryList) columnValues() []ColumnValues {
+	if len(ql.values) == 0 {
+		return nil
+	}
+	scale := ql.sample.Scale()
+	result := make([]ColumnValues, 0, len(ql.values))
+	for column, sketch := range ql.values {
+		result = append(result, ColumnValues{
+			Column:    column,
+			Uses:      scaledCount(sketch.uses, scale),
+			Distinct:  sketch.distinct(),
+			TopValues: sketch.topValues(scale),
+			Sketch:    sketch.registers,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Column.Table != result[j].Column.Table {
+			return result[i].Column.Table < result[j].Column.Table
+		}
+		return result[i].Column.Name < result[j].Column.Name
+	})
+	return result
+}
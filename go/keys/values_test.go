@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+)
+
+func TestFilterValues(t *testing.T) {
+	log := "create table orders (id bigint primary key, state varchar(10), customer_id bigint);\n" +
+		"select * from orders where state = 'active' and customer_id = 1;\n" +
+		"select * from orders where 'active' = state and customer_id = 2;\n" +
+		"select * from orders where state in ('done', 'active') and customer_id > 3;\n" +
+		"select * from orders o join orders p on o.id = p.id and p.customer_id = 7 where o.state = 'done';\n" +
+		"select * from orders where id = (select max(id) from orders where customer_id = 1);\n" +
+		"select * from orders where state = ?;\n"
+	output, err := Analyze(strings.NewReader(log), Options{TopValues: 2})
+	require.NoError(t, err)
+	require.Empty(t, output.Failed)
+	require.Len(t, output.Values, 2)
+
+	customer := output.Values[0]
+	require.Equal(t, operators.Column{Table: "orders", Name: "customer_id"}, customer.Column)
+	require.Equal(t, 4, customer.Uses)
+	require.Equal(t, 3, customer.Distinct)
+	require.Equal(t, []ValueCount{{Value: "1", Count: 2}, {Value: "2", Count: 1}}, customer.TopValues)
+
+	state := output.Values[1]
+	require.Equal(t, operators.Column{Table: "orders", Name: "state"}, state.Column)
+	require.Equal(t, 5, state.Uses)
+	require.Equal(t, 2, state.Distinct)
+	require.Equal(t, []ValueCount{{Value: "'active'", Count: 3}, {Value: "'done'", Count: 2}}, state.TopValues)
+
+	output, err = Analyze(strings.NewReader(log), Options{})
+	require.NoError(t, err)
+	require.Empty(t, output.Values)
+}
+
+func TestValueSketch(t *testing.T) {
+	sketch := newValueSketch(3)
+	for i := range 20000 {
+		sketch.add(strconv.Itoa(i), 1)
+		// the heavy hitters are found among many values used once
+		if i%10 == 0 {
+			sketch.add("'hot'", 2)
+			sketch.add("'warm'", 1)
+		}
+	}
+	require.Equal(t, 26000, sketch.uses)
+	require.InEpsilon(t, 20002, sketch.distinct(), 0.06)
+	top := sketch.topValues(1)
+	require.Len(t, top, 3)
+	require.Equal(t, "'hot'", top[0].Value)
+	require.GreaterOrEqual(t, top[0].Count, 4000)
+	require.Equal(t, "'warm'", top[1].Value)
+	require.GreaterOrEqual(t, top[1].Count, 2000)
+
+	small := newValueSketch(3)
+	for i := range 50 {
+		small.add(strconv.Itoa(i%5), 1)
+	}
+	require.Equal(t, 5, small.distinct())
+}
+
+func TestValuesMerge(t *testing.T) {
+	ql := &queryList{topValues: 2}
+	ql.addValues([]columnValue{{column: operators.Column{Table: "t", Name: "c"}, value: "1"}, {column: operators.Column{Table: "t", Name: "c"}, value: "2"}}, 1)
+	data, err := json.Marshal(ql.columnValues())
+	require.NoError(t, err)
+
+	var previous []ColumnValues
+	require.NoError(t, json.Unmarshal(data, &previous))
+	merged := &queryList{topValues: 2}
+	merged.loadValues(previous)
+	merged.addValues([]columnValue{{column: operators.Column{Table: "t", Name: "c"}, value: "2"}, {column: operators.Column{Table: "t", Name: "c"}, value: "3"}}, 1)
+	values := merged.columnValues()
+	require.Len(t, values, 1)
+	require.Equal(t, 4, values[0].Uses)
+	require.Equal(t, 3, values[0].Distinct)
+	require.Equal(t, []ValueCount{{Value: "2", Count: 2}, {Value: "1", Count: 1}}, values[0].TopValues)
+}
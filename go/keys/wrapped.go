@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// FunctionColumnUse is a filter comparing the result of a function of a column with a value, such as
+// DATE(created_at) = ? or LOWER(email) = ?. Such a filter can't use the indexes of the column, nor its vindex
+// to route the query. It is written as "date(t.created_at) =" in JSON.
+type FunctionColumnUse struct {
+	Function string
+	Column   operators.Column
+	Uses     sqlparser.ComparisonExprOperator
+}
+
+func (fu FunctionColumnUse) String() string {
+	col := fu.Column.Name
+	if fu.Column.Table != "" {
+		col = fu.Column.Table + "." + col
+	}
+	return fmt.Sprintf("%s(%s) %s", fu.Function, col, fu.Uses.JSONString())
+}
+
+func (fu FunctionColumnUse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fu.String())
+}
+
+func (fu *FunctionColumnUse) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	open, end := strings.Index(s, "("), strings.LastIndex(s, ")")
+	if open <= 0 || end < open {
+		return fmt.Errorf("invalid FunctionColumnUse format: %s", s)
+	}
+	fu.Function = s[:open]
+	if err := fu.Column.UnmarshalJSON([]byte(`"` + s[open+1:end] + `"`)); err != nil {
+		return fmt.Errorf("failed to unmarshal column: %w", err)
+	}
+	var err error
+	fu.Uses, err = sqlparser.ComparisonExprOperatorFromJson(strings.TrimSpace(s[end+1:]))
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal operator: %w", err)
+	}
+	return nil
+}
+
+// functionFilterColumns returns the filters of the WHERE and ON clauses of the query that compare a function
+// of a single column with a value. A BETWEEN is recorded as a >= and a <= comparison, like vexplain keys does.
+func functionFilterColumns(ctx *plancontext.PlanningContext, ast sqlparser.Statement) []FunctionColumnUse {
+	var uses []FunctionColumnUse
+	add := func(fn sqlparser.Expr, op sqlparser.ComparisonExprOperator) {
+		name, col, ok := functionColumn(fn)
+		if !ok {
+			return
+		}
+		c, ok := physicalColumn(ctx, col)
+		if !ok {
+			return
+		}
+		use := FunctionColumnUse{Function: name, Column: c, Uses: op}
+		if !slices.Contains(uses, use) {
+			uses = append(uses, use)
+		}
+	}
+	visitPredicates := func(expr sqlparser.Expr) {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch node := node.(type) {
+			case *sqlparser.ComparisonExpr:
+				if readsNoColumn(node.Right) {
+					add(node.Left, node.Operator)
+				} else if op, ok := node.Operator.SwitchSides(); ok && readsNoColumn(node.Left) {
+					add(node.Right, op)
+				}
+			case *sqlparser.BetweenExpr:
+				if node.IsBetween && readsNoColumn(node.From) && readsNoColumn(node.To) {
+					add(node.Left, sqlparser.GreaterEqualOp)
+					add(node.Left, sqlparser.LessEqualOp)
+				}
+			case *sqlparser.Subquery:
+				// the predicates of the subqueries are visited with their own WHERE clause
+				return false, nil
+			}
+			return true, nil
+		}, expr)
+	}
+	_ = sqlparser.VisitSQLNode(ast, func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Where:
+			if node.Type == sqlparser.WhereClause {
+				visitPredicates(node.Expr)
+			}
+		case *sqlparser.JoinCondition:
+			visitPredicates(node.On)
+		}
+		return true, nil
+	})
+	return uses
+}
+
+// functionColumn returns the name of the function and the column, when expr is a function of a single column,
+// such as DATE(created_at) or SUBSTRING(phone, 1, 2). The aggregations are left out.
+func functionColumn(expr sqlparser.Expr) (string, *sqlparser.ColName, bool) {
+	if _, ok := expr.(sqlparser.Callable); !ok {
+		return "", nil, false
+	}
+	if _, ok := expr.(sqlparser.AggrFunc); ok {
+		return "", nil, false
+	}
+	var col *sqlparser.ColName
+	single := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ColName:
+			if col != nil && !col.Equal(node) {
+				single = false
+			}
+			col = node
+		case *sqlparser.Subquery:
+			single = false
+		}
+		return single, nil
+	}, expr)
+	if col == nil || !single {
+		return "", nil, false
+	}
+	name, _, _ := strings.Cut(sqlparser.String(expr), "(")
+	return strings.ToLower(name), col, true
+}
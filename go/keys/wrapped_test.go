@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keys
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/typ"
+)
+
+func TestFunctionFilters(t *testing.T) {
+	tests := []struct {
+		query   string
+		filters []string
+	}{
+		{query: "select id from t where date(created) = '2024-01-01'", filters: []string{"date(t.created) ="}},
+		{query: "select id from t where lower(email) = 'a@b.c' and id > 3", filters: []string{"lower(t.email) ="}},
+		{query: "select id from t where 5 < year(created)", filters: []string{"year(t.created) gt"}},
+		{query: "select id from t where substr(email, 1, 2) in ('ab', 'cd')", filters: []string{"substr(t.email) in"}},
+		{query: "select id from t where date(created) between '2024-01-01' and '2024-02-01'", filters: []string{"date(t.created) ge", "date(t.created) le"}},
+		{query: "select t.id from t join u on lower(t.email) = u.email"},
+		{query: "select id from t where email = 'a@b.c'"},
+		{query: "select lower(email) from t where id = 1"},
+		{query: "select email, count(*) from t group by email having count(*) > 1"},
+		{query: "select x.id from (select id, created from t) as x where date(x.created) = '2024-01-01'", filters: []string{"date(t.created) ="}},
+		{query: "select id from u where t_id in (select id from t where lower(email) = 'a@b.c')", filters: []string{"lower(t.email) ="}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			si := &schemaInfo{tables: make(map[string]columns)}
+			ql := &queryList{queries: make(map[string]*QueryAnalysisResult)}
+			process(data.Query{Query: "create table t (id bigint primary key, email varchar(64), created datetime)", Type: typ.Query}, si, ql)
+			process(data.Query{Query: "create table u (id bigint primary key, t_id bigint, email varchar(64))", Type: typ.Query}, si, ql)
+			process(data.Query{Query: tt.query, Line: 1, Type: typ.Query}, si, ql)
+			require.Empty(t, ql.failed)
+			require.Len(t, ql.queries, 1)
+			for _, result := range ql.queries {
+				require.Equal(t, tt.filters, stringsOf(result.FunctionFilters))
+			}
+		})
+	}
+}
+
+func TestFunctionColumnUseJSON(t *testing.T) {
+	for _, s := range []string{`"date(t.created) ="`, `"lower(email) not in"`} {
+		var use FunctionColumnUse
+		require.NoError(t, json.Unmarshal([]byte(s), &use))
+		out, err := json.Marshal(use)
+		require.NoError(t, err)
+		require.Equal(t, s, string(out))
+	}
+
+	var use FunctionColumnUse
+	require.ErrorContains(t, json.Unmarshal([]byte(`"t.created ="`), &use), "invalid FunctionColumnUse format")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// Config contains the options of a 'vt probe' run
+type Config struct {
+	// VTGate is the host:port of the MySQL protocol listener of the vtgate
+	VTGate   string
+	User     string
+	Password string
+}
+
+type (
+	// Capabilities is the content of a capabilities file: the Vitess version of a cluster and the features
+	// the other commands need, so they can adapt to the cluster instead of failing midway.
+	Capabilities struct {
+		// Version is the Vitess version, such as 21.0.0, and MajorVersion its major number
+		Version      string `json:"version"`
+		MajorVersion int    `json:"majorVersion"`
+		// Planner is the default query planner of the version
+		Planner       string `json:"planner"`
+		VExplainTrace bool   `json:"vexplainTrace"`
+		VExplainKeys  bool   `json:"vexplainKeys"`
+		// AtomicTransactions is set when the vtgate commits multi-shard transactions with two-phase commit
+		AtomicTransactions bool `json:"atomicTransactions"`
+	}
+
+	// executor runs the probing queries, it is implemented by *mysql.Conn
+	executor interface {
+		ExecuteFetch(query string, maxrows int, wantfields bool) (*sqltypes.Result, error)
+	}
+)
+
+// vitessVersion matches the version comment of a vtgate, such as "Version: 21.0.0-SNAPSHOT (Git revision ...)"
+var vitessVersion = regexp.MustCompile(`^Version: ((\d+)\.(\d+)\.(\d+)\S*)`) //nolint:gochecknoglobals // this is instead of a const
+
+func Run(cfg Config) error {
+	return run(os.Stdout, cfg)
+}
+
+func run(out io.Writer, cfg Config) error {
+	host, portStr, err := net.SplitHostPort(cfg.VTGate)
+	if err != nil {
+		return fmt.Errorf("--vtgate must be host:port: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("--vtgate has an invalid port %q", portStr)
+	}
+
+	conn, err := mysql.Connect(context.Background(), &mysql.ConnParams{
+		Host:  host,
+		Port:  port,
+		Uname: cfg.User,
+		Pass:  cfg.Password,
+	})
+	if err != nil {
+		return fmt.Errorf("connecting to the vtgate: %w", err)
+	}
+	defer conn.Close()
+
+	caps, err := probe(conn)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(caps)
+}
+
+// probe finds the version of the vtgate, then tries the features, since they can be disabled by flags
+func probe(conn executor) (*Capabilities, error) {
+	match, err := readVersionComment(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &Capabilities{Version: strings.Join(match[2:], ".")}
+	caps.MajorVersion, _ = strconv.Atoi(match[2])
+	// Gen4 is the default planner since v14, and the only one since v17
+	caps.Planner = "Gen4"
+	if caps.MajorVersion < 14 {
+		caps.Planner = "V3"
+	}
+
+	_, err = conn.ExecuteFetch("vexplain trace select 1 from dual", 10, false)
+	caps.VExplainTrace = err == nil
+	_, err = conn.ExecuteFetch("vexplain keys select 1 from dual", 10, false)
+	caps.VExplainKeys = err == nil
+
+	rs, err := conn.ExecuteFetch("select @@transaction_mode", 1, false)
+	if err == nil && len(rs.Rows) == 1 {
+		caps.AtomicTransactions = strings.EqualFold(rs.Rows[0][0].ToString(), "TWOPC")
+	}
+	return caps, nil
+}
+
+// ReadVersion returns the exact Vitess version of the vtgate conn is connected to, such as 21.0.0-SNAPSHOT
+func ReadVersion(conn executor) (string, error) {
+	match, err := readVersionComment(conn)
+	if err != nil {
+		return "", err
+	}
+	return match[1], nil
+}
+
+// readVersionComment returns the match of vitessVersion in the version comment of the vtgate
+func readVersionComment(conn executor) ([]string, error) {
+	rs, err := conn.ExecuteFetch("select @@version_comment", 1, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading the version: %w", err)
+	}
+	if len(rs.Rows) != 1 {
+		return nil, errors.New("reading the version: no version comment")
+	}
+	comment := rs.Rows[0][0].ToString()
+	match := vitessVersion.FindStringSubmatch(comment)
+	if match == nil {
+		return nil, fmt.Errorf("the endpoint is not a vtgate, its version is %q", comment)
+	}
+	return match, nil
+}
+
+// ReadCapabilities reads a capabilities file written by 'vt probe'
+func ReadCapabilities(fileName string) (*Capabilities, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var caps Capabilities
+	if err := json.Unmarshal(b, &caps); err != nil {
+		return nil, fmt.Errorf("reading capabilities file %s: %w", fileName, err)
+	}
+	return &caps, nil
+}
+
+// SupportsVExplain tells if the cluster supports the given vexplain type, such as trace or keys
+func (c *Capabilities) SupportsVExplain(typ string) bool {
+	switch strings.ToLower(typ) {
+	case "trace":
+		return c.VExplainTrace
+	case "keys":
+		return c.VExplainKeys
+	default:
+		return true
+	}
+}
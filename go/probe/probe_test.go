@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// fakeExecutor returns the result registered for a query, or fails it when none is
+type fakeExecutor map[string]*sqltypes.Result
+
+func (f fakeExecutor) ExecuteFetch(query string, _ int, _ bool) (*sqltypes.Result, error) {
+	if rs, found := f[query]; found {
+		return rs, nil
+	}
+	return nil, fmt.Errorf("syntax error at position 9 near %q", query)
+}
+
+func TestProbe(t *testing.T) {
+	conn := fakeExecutor{
+		"select @@version_comment": sqltypes.MakeTestResult(sqltypes.MakeTestFields("@@version_comment", "varchar"),
+			"Version: 20.0.2 (Git revision 2592c5932b3036647868299b6df76f8ef28dfbd3 branch 'HEAD') built on Wed Sep 11 08:12:14 UTC 2024 by runner@fv-az1152-369 using go1.22.7 linux/amd64"),
+		"vexplain trace select 1 from dual": sqltypes.MakeTestResult(sqltypes.MakeTestFields("Trace", "varchar"), "{}"),
+		"select @@transaction_mode":         sqltypes.MakeTestResult(sqltypes.MakeTestFields("@@transaction_mode", "varchar"), "TWOPC"),
+	}
+
+	caps, err := probe(conn)
+	require.NoError(t, err)
+	require.Equal(t, &Capabilities{
+		Version:            "20.0.2",
+		MajorVersion:       20,
+		Planner:            "Gen4",
+		VExplainTrace:      true,
+		AtomicTransactions: true,
+	}, caps)
+	require.True(t, caps.SupportsVExplain("TRACE"))
+	require.False(t, caps.SupportsVExplain("keys"))
+	require.True(t, caps.SupportsVExplain("plan"))
+
+	conn["select @@version_comment"] = sqltypes.MakeTestResult(sqltypes.MakeTestFields("@@version_comment", "varchar"), "MySQL Community Server - GPL")
+	_, err = probe(conn)
+	require.ErrorContains(t, err, "the endpoint is not a vtgate")
+}
+
+func TestReadVersion(t *testing.T) {
+	conn := fakeExecutor{
+		"select @@version_comment": sqltypes.MakeTestResult(sqltypes.MakeTestFields("@@version_comment", "varchar"),
+			"Version: 21.0.0-SNAPSHOT (Git revision 0282feba4bdc branch 'main') built on Thu Oct 31 22:51:46 UTC 2024 by runner using go1.23.2 linux/amd64"),
+	}
+	version, err := ReadVersion(conn)
+	require.NoError(t, err)
+	require.Equal(t, "21.0.0-SNAPSHOT", version)
+
+	_, err = ReadVersion(fakeExecutor{})
+	require.ErrorContains(t, err, "reading the version")
+}
+
+func TestReadCapabilities(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "capabilities.json")
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"version": "21.0.0", "majorVersion": 21, "vexplainTrace": true, "vexplainKeys": true}`), 0o600))
+	caps, err := ReadCapabilities(fileName)
+	require.NoError(t, err)
+	require.Equal(t, &Capabilities{Version: "21.0.0", MajorVersion: 21, VExplainTrace: true, VExplainKeys: true}, caps)
+
+	_, err = ReadCapabilities(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// maxUncoveredQueries is the number of uncovered query structures listed by the test coverage
+const maxUncoveredQueries = 10
+
+// TestCoverage is how much of a production workload a test suite exercises: the query structures of
+// the keys file that the test files run too, weighted by their usage in production
+type TestCoverage struct {
+	Queries, CoveredQueries int
+	Uses, CoveredUses       int
+	// Uncovered are the query structures the test files don't run, the most used first
+	Uncovered []keys.QueryAnalysisResult
+}
+
+// testedStructures runs the keys analysis on the test files, and returns the query structures they run
+func testedStructures(fileNames []string, renames keys.Renames) (map[string]bool, error) {
+	var out bytes.Buffer
+	if err := keys.RunTo(context.Background(), &out, keys.Config{FileNames: fileNames, Renames: renames}); err != nil {
+		return nil, fmt.Errorf("analysing the test files: %w", err)
+	}
+	var output keys.Output
+	if err := json.Unmarshal(out.Bytes(), &output); err != nil {
+		return nil, err
+	}
+	tested := make(map[string]bool, len(output.Queries))
+	for _, query := range output.Queries {
+		tested[query.QueryStructure] = true
+	}
+	return tested, nil
+}
+
+// checkTestCoverage compares the query structures of the production workload with those of the test suite
+func checkTestCoverage(queries *keys.Output, tested map[string]bool) TestCoverage {
+	var coverage TestCoverage
+	for _, query := range queries.Queries {
+		coverage.Queries++
+		coverage.Uses += query.UsageCount
+		if tested[query.QueryStructure] {
+			coverage.CoveredQueries++
+			coverage.CoveredUses += query.UsageCount
+			continue
+		}
+		coverage.Uncovered = append(coverage.Uncovered, query)
+	}
+	sort.SliceStable(coverage.Uncovered, func(i, j int) bool {
+		return coverage.Uncovered[i].UsageCount > coverage.Uncovered[j].UsageCount
+	})
+	return coverage
+}
+
+func printTestCoverage(out io.Writer, coverage TestCoverage) {
+	if coverage.Uses == 0 {
+		fmt.Fprintln(out, "No query to check the test coverage of")
+		return
+	}
+
+	fmt.Fprintf(out, "Test coverage: %.2f%% of the query uses (%d of %d) and %d of the %d query structures are run by the tests\n",
+		float64(coverage.CoveredUses)/float64(coverage.Uses)*100, coverage.CoveredUses, coverage.Uses, coverage.CoveredQueries, coverage.Queries)
+	if len(coverage.Uncovered) == 0 {
+		return
+	}
+
+	uncovered := coverage.Uncovered
+	if len(uncovered) > maxUncoveredQueries {
+		uncovered = uncovered[:maxUncoveredQueries]
+	}
+	fmt.Fprintf(out, "The most used query structures without tests:\n")
+	table := createTableWriter(out, []string{"Query", "Usage Count", "Usage %"})
+	for _, query := range uncovered {
+		table.Append([]string{
+			query.QueryStructure,
+			strconv.Itoa(query.UsageCount),
+			fmt.Sprintf("%.2f%%", float64(query.UsageCount)/float64(coverage.Uses)*100),
+		})
+	}
+	table.Render()
+	if more := len(coverage.Uncovered) - len(uncovered); more > 0 {
+		fmt.Fprintf(out, "and %d more\n", more)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestTestCoverage(t *testing.T) {
+	dir := t.TempDir()
+	schema := "create table orders (id bigint primary key, customer_id bigint, total int);\n"
+	testFile := filepath.Join(dir, "orders.test")
+	require.NoError(t, os.WriteFile(testFile, []byte(schema+
+		"select * from orders where id = 5;\n"+
+		"insert into orders (id, customer_id, total) values (1, 2, 3);\n"), 0o600))
+	productionLog := schema +
+		strings.Repeat("select * from orders where id = 7;\n", 6) +
+		strings.Repeat("select total from orders where customer_id = 3;\n", 3) +
+		"delete from orders where id = 1;\n"
+
+	productionFile := filepath.Join(dir, "production.test")
+	require.NoError(t, os.WriteFile(productionFile, []byte(productionLog), 0o600))
+
+	tested, err := testedStructures([]string{testFile}, nil)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, keys.RunTo(context.Background(), &out, keys.Config{FileNames: []string{productionFile}}))
+	var production keys.Output
+	require.NoError(t, json.Unmarshal(out.Bytes(), &production))
+
+	coverage := checkTestCoverage(&production, tested)
+	require.Equal(t, 3, coverage.Queries)
+	require.Equal(t, 1, coverage.CoveredQueries)
+	require.Equal(t, 10, coverage.Uses)
+	require.Equal(t, 6, coverage.CoveredUses)
+	require.Len(t, coverage.Uncovered, 2)
+	require.Equal(t, 3, coverage.Uncovered[0].UsageCount)
+
+	sb := &strings.Builder{}
+	printTestCoverage(sb, coverage)
+	require.Equal(t, strings.ReplaceAll(`Test coverage: 60.00% of the query uses (6 of 10) and 1 of the 3 query structures are run by the tests
+The most used query structures without tests:
++------------------------------------------------------------------------------+-------------+---------+
+|                                    Query                                     | Usage Count | Usage % |
++------------------------------------------------------------------------------+-------------+---------+
+| SELECT 'total' FROM 'orders' WHERE 'customer_id' = :_customer_id /* INT64 */ |           3 | 30.00%  |
+| DELETE FROM 'orders' WHERE 'id' = :_id /* INT64 */                           |           1 | 10.00%  |
++------------------------------------------------------------------------------+-------------+---------+
+`, "'", "`"), sb.String())
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/vitessio/vt/go/dbinfo"
+)
+
+// autoIncrementWarning is the share of the range of an auto-increment column above which its table is reported
+const autoIncrementWarning = 0.75
+
+// AutoIncrementUsage is how much of the range of its auto-increment column a table used
+type AutoIncrementUsage struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Type   string `json:"type"`
+	// Next is the next value of the column and Max the largest value of its type
+	Next uint64 `json:"next"`
+	Max  uint64 `json:"max"`
+}
+
+func (u AutoIncrementUsage) Share() float64 {
+	return float64(u.Next) / float64(u.Max)
+}
+
+func readDBInfo(fileName string) (*dbinfo.Info, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var info dbinfo.Info
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("reading dbinfo file %s: %w", fileName, err)
+	}
+	return &info, nil
+}
+
+// checkAutoIncrements returns the tables whose auto-increment column used more than autoIncrementWarning of its range,
+// the closest to exhaustion first
+func checkAutoIncrements(info *dbinfo.Info) []AutoIncrementUsage {
+	var result []AutoIncrementUsage
+	for _, table := range info.Tables {
+		col, found := table.AutoIncrementColumn()
+		if !found || table.AutoIncrement == 0 {
+			continue
+		}
+		maxValue, ok := col.MaxValue()
+		if !ok {
+			continue
+		}
+		usage := AutoIncrementUsage{Table: table.Name, Column: col.Name, Type: col.Type, Next: table.AutoIncrement, Max: maxValue}
+		if usage.Share() >= autoIncrementWarning {
+			result = append(result, usage)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Share() > result[j].Share()
+	})
+	return result
+}
+
+// partitionedTables returns the tables of the dbinfo file that are partitioned
+func partitionedTables(info *dbinfo.Info) []dbinfo.TableInfo {
+	var partitioned []dbinfo.TableInfo
+	for _, table := range info.Tables {
+		if table.Partitioning != nil {
+			partitioned = append(partitioned, table)
+		}
+	}
+	return partitioned
+}
+
+// printTableRisks reports the tables that need attention before sharding: the tables about to exhaust
+// their auto-increment column, and the partitioned tables, whose partitioning has to fit the sharding scheme
+func printTableRisks(out io.Writer, info *dbinfo.Info) {
+	if usages := checkAutoIncrements(info); len(usages) > 0 {
+		fmt.Fprintf(out, "Tables that used more than %.0f%% of the range of their auto-increment column:\n", autoIncrementWarning*100)
+		table := createTableWriter(out, []string{"Table", "Column", "Type", "Next Value", "Used"})
+		for _, usage := range usages {
+			table.Append([]string{
+				usage.Table,
+				usage.Column,
+				usage.Type,
+				strconv.FormatUint(usage.Next, 10),
+				fmt.Sprintf("%.2f%%", usage.Share()*100),
+			})
+		}
+		table.Render()
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if partitioned := partitionedTables(info); len(partitioned) > 0 {
+		fmt.Fprintln(out, "Partitioned tables, their partitioning has to be compatible with the sharding scheme:")
+		table := createTableWriter(out, []string{"Table", "Method", "Expression", "Partitions"})
+		for _, t := range partitioned {
+			table.Append([]string{t.Name, t.Partitioning.Method, t.Partitioning.Expression, strconv.Itoa(t.Partitioning.Partitions)})
+		}
+		table.Render()
+		_, _ = fmt.Fprintln(out)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitessio/vt/go/dbinfo"
+)
+
+func TestPrintTableRisks(t *testing.T) {
+	info := &dbinfo.Info{Tables: []dbinfo.TableInfo{{
+		Name:          "events",
+		AutoIncrement: 3_900_000_000,
+		Columns:       []dbinfo.ColumnInfo{{Name: "id", Type: "int unsigned", AutoIncrement: true}},
+	}, {
+		Name:          "flags",
+		AutoIncrement: 120,
+		Columns:       []dbinfo.ColumnInfo{{Name: "id", Type: "tinyint", AutoIncrement: true}},
+	}, {
+		Name:          "customer",
+		AutoIncrement: 1001,
+		Columns:       []dbinfo.ColumnInfo{{Name: "id", Type: "bigint", AutoIncrement: true}},
+	}, {
+		Name:         "orders",
+		Partitioning: &dbinfo.Partitioning{Method: "RANGE", Expression: "year(`created`)", Partitions: 4},
+	}}}
+
+	sb := &strings.Builder{}
+	printTableRisks(sb, info)
+	assert.Equal(t, `Tables that used more than 75% of the range of their auto-increment column:
++--------+--------+--------------+------------+--------+
+| Table  | Column |     Type     | Next Value |  Used  |
++--------+--------+--------------+------------+--------+
+| flags  | id     | tinyint      |        120 | 94.49% |
+| events | id     | int unsigned | 3900000000 | 90.80% |
++--------+--------+--------------+------------+--------+
+
+Partitioned tables, their partitioning has to be compatible with the sharding scheme:
++--------+--------+-----------------+------------+
+| Table  | Method |   Expression    | Partitions |
++--------+--------+-----------------+------------+
+| orders | RANGE  | year(`+"`created`"+`) |          4 |
++--------+--------+-----------------+------------+
+
+`, sb.String())
+
+	sb.Reset()
+	printTableRisks(sb, &dbinfo.Info{Tables: info.Tables[2:3]})
+	assert.Empty(t, sb.String())
+}
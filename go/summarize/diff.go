@@ -0,0 +1,351 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+const (
+	// DefaultDiffThreshold is the relative change, in percent, of the share of queries using a table
+	// above which the table is reported in a changelog
+	DefaultDiffThreshold = 10
+
+	// maxNewHotQueries is the number of new query structures listed in a changelog
+	maxNewHotQueries = 10
+)
+
+// printChangelog compares two keys files, typically of the same workload a week apart, and prints what changed
+// as a list that can be read in a review meeting: new frequent queries, tables whose share of the queries shifted
+// by more than threshold percent, new failures and new findings. It returns the new findings, so a CI job can fail on them.
+func printChangelog(out io.Writer, termWidth int, oldFile, newFile readingSummary, threshold float64) []keys.Finding {
+	oldQueries, newQueries := oldFile.AnalysedQueries, newFile.AnalysedQueries
+	fmt.Fprintf(out, "Changes from %s to %s\n\n", oldFile.Name, newFile.Name)
+
+	hot := newHotQueries(oldQueries, newQueries)
+	if len(hot) == 0 {
+		fmt.Fprintln(out, "No new queries.")
+	} else {
+		fmt.Fprintln(out, "New hot queries:")
+		for _, q := range hot {
+			fmt.Fprintf(out, "- %d uses: %s\n", q.UsageCount, limitQueryLength(q.QueryStructure, termWidth))
+		}
+	}
+	fmt.Fprintln(out)
+
+	printTableUsageShifts(out, tableUsageShifts(oldQueries, newQueries, threshold), threshold)
+	fmt.Fprintln(out)
+
+	failures := newFailures(oldQueries, newQueries)
+	if len(failures) == 0 {
+		fmt.Fprintln(out, "No new failures.")
+	} else {
+		fmt.Fprintln(out, "New failures:")
+		for _, failure := range failures {
+			fmt.Fprintf(out, "- line %d: %s\n  %s\n", failure.LineNumber, limitQueryLength(failure.Query, termWidth), failure.Error)
+		}
+	}
+	fmt.Fprintln(out)
+
+	findings := newFindings(oldQueries, newQueries)
+	if len(findings) == 0 {
+		fmt.Fprintln(out, "No new findings.")
+		return nil
+	}
+	fmt.Fprintln(out, "New findings:")
+	for _, finding := range findings {
+		fmt.Fprintf(out, "- %s: %s (%s)\n", finding.Severity, finding.Message, finding.Analyzer)
+		if finding.Query != "" {
+			fmt.Fprintf(out, "  line %d: %s\n", finding.LineNumber, limitQueryLength(finding.Query, termWidth))
+		}
+	}
+	return findings
+}
+
+// printKeysComparison compares the query structures of two keys files, typically captured before and after a release:
+// the query structures only one of them has, and the ones whose share of the uses changed by more than threshold percent
+func printKeysComparison(out io.Writer, termWidth int, oldFile, newFile readingSummary, threshold float64) {
+	oldQueries, newQueries := oldFile.AnalysedQueries, newFile.AnalysedQueries
+	fmt.Fprintf(out, "Comparing keys files %s and %s\n\n", oldFile.Name, newFile.Name)
+
+	changes := queryUsageChanges(oldQueries, newQueries)
+	var added, removed, shifted []QueryUsageChange
+	for _, change := range changes {
+		switch {
+		case change.OldShare == 0 && change.NewShare == 0:
+			// a query structure without uses, in both files
+		case change.OldShare == 0:
+			added = append(added, change)
+		case change.NewShare == 0:
+			removed = append(removed, change)
+		case math.Abs(change.Change) > threshold:
+			shifted = append(shifted, change)
+		}
+	}
+
+	if len(added) == 0 {
+		fmt.Fprintln(out, "No new query structures.")
+	} else {
+		fmt.Fprintf(out, "New query structures (%d):\n", len(added))
+		for _, change := range added {
+			fmt.Fprintf(out, "- %.2f%% of uses: %s\n", change.NewShare, limitQueryLength(change.QueryStructure, termWidth))
+		}
+	}
+	fmt.Fprintln(out)
+
+	if len(removed) == 0 {
+		fmt.Fprintln(out, "No removed query structures.")
+	} else {
+		fmt.Fprintf(out, "Removed query structures (%d):\n", len(removed))
+		for _, change := range removed {
+			fmt.Fprintf(out, "- %.2f%% of uses: %s\n", change.OldShare, limitQueryLength(change.QueryStructure, termWidth))
+		}
+	}
+	fmt.Fprintln(out)
+
+	if len(shifted) == 0 {
+		fmt.Fprintf(out, "No query structure usage shifted by more than %.0f%%.\n", threshold)
+	} else {
+		fmt.Fprintf(out, "Query structures with a usage shift above %.0f%%:\n", threshold)
+		for _, change := range shifted {
+			fmt.Fprintf(out, "- %.2f%% -> %.2f%% of uses (%+.2f%%): %s\n",
+				change.OldShare, change.NewShare, change.Change, limitQueryLength(change.QueryStructure, termWidth))
+		}
+	}
+	fmt.Fprintln(out)
+
+	printTableUsageShifts(out, tableUsageShifts(oldQueries, newQueries, threshold), threshold)
+}
+
+func printTableUsageShifts(out io.Writer, shifts []TableUsageShift, threshold float64) {
+	if len(shifts) == 0 {
+		fmt.Fprintf(out, "No table usage shifted by more than %.0f%%.\n", threshold)
+		return
+	}
+	fmt.Fprintf(out, "Tables with a usage shift above %.0f%%:\n", threshold)
+	for _, shift := range shifts {
+		switch {
+		case shift.OldShare == 0:
+			fmt.Fprintf(out, "- %s: new table, used by %.2f%% of queries\n", shift.Table, shift.NewShare)
+		case shift.NewShare == 0:
+			fmt.Fprintf(out, "- %s: no longer used, was used by %.2f%% of queries\n", shift.Table, shift.OldShare)
+		default:
+			fmt.Fprintf(out, "- %s: %.2f%% -> %.2f%% of queries (%+.2f%%)\n", shift.Table, shift.OldShare, shift.NewShare, shift.Change)
+		}
+	}
+}
+
+// QueryUsageChange is the change of the share of the uses of a query structure between two keys files
+type QueryUsageChange struct {
+	QueryStructure string
+	// OldShare and NewShare are the percentages of the query uses that are uses of the query structure,
+	// 0 when the file doesn't have it
+	OldShare, NewShare float64
+	// Change is the relative change of the share, in percent
+	Change float64
+}
+
+// queryUsageChanges returns the share of the uses of every query structure of the two files,
+// the largest shares of the new file first, then the query structures only the old file has, the largest first
+func queryUsageChanges(oldQueries, newQueries *keys.Output) []QueryUsageChange {
+	oldShares, newShares := queryShares(oldQueries), queryShares(newQueries)
+
+	result := make([]QueryUsageChange, 0, len(newShares))
+	for query, newShare := range newShares {
+		oldShare, found := oldShares[query]
+		change := math.Inf(1)
+		if found && oldShare > 0 {
+			change = (newShare - oldShare) / oldShare * 100
+		}
+		result = append(result, QueryUsageChange{QueryStructure: query, OldShare: oldShare, NewShare: newShare, Change: change})
+	}
+	for query, oldShare := range oldShares {
+		if _, found := newShares[query]; !found {
+			result = append(result, QueryUsageChange{QueryStructure: query, OldShare: oldShare, Change: -100})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.NewShare != b.NewShare {
+			return a.NewShare > b.NewShare
+		}
+		if a.OldShare != b.OldShare {
+			return a.OldShare > b.OldShare
+		}
+		return a.QueryStructure < b.QueryStructure
+	})
+	return result
+}
+
+// queryShares returns, for each query structure, the percentage of the query uses that are its uses
+func queryShares(queries *keys.Output) map[string]float64 {
+	var total int
+	for _, q := range queries.Queries {
+		total += q.UsageCount
+	}
+	shares := make(map[string]float64, len(queries.Queries))
+	for _, q := range queries.Queries {
+		var share float64
+		if total > 0 {
+			share = float64(q.UsageCount) / float64(total) * 100
+		}
+		shares[q.QueryStructure] += share
+	}
+	return shares
+}
+
+// TableUsageShift is the change of the share of queries using a table between two keys files
+type TableUsageShift struct {
+	Table string
+	// OldShare and NewShare are the percentages of queries using the table
+	OldShare, NewShare float64
+	// Change is the relative change of the share, in percent
+	Change float64
+}
+
+// newHotQueries returns the most used query structures of the new file that are not in the old one
+func newHotQueries(oldQueries, newQueries *keys.Output) []keys.QueryAnalysisResult {
+	known := make(map[string]bool, len(oldQueries.Queries))
+	for _, q := range oldQueries.Queries {
+		known[q.QueryStructure] = true
+	}
+
+	var result []keys.QueryAnalysisResult
+	for _, q := range newQueries.Queries {
+		if !known[q.QueryStructure] {
+			result = append(result, q)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UsageCount > result[j].UsageCount
+	})
+	if len(result) > maxNewHotQueries {
+		result = result[:maxNewHotQueries]
+	}
+	return result
+}
+
+// tableUsageShifts compares the share of queries using each table. Comparing shares instead of query counts
+// makes logs of different lengths comparable.
+func tableUsageShifts(oldQueries, newQueries *keys.Output, threshold float64) []TableUsageShift {
+	oldShares, newShares := tableShares(oldQueries), tableShares(newQueries)
+
+	var result []TableUsageShift
+	for table, newShare := range newShares {
+		oldShare := oldShares[table]
+		change := math.Inf(1)
+		if oldShare > 0 {
+			change = (newShare - oldShare) / oldShare * 100
+		}
+		if math.Abs(change) > threshold {
+			result = append(result, TableUsageShift{Table: table, OldShare: oldShare, NewShare: newShare, Change: change})
+		}
+	}
+	for table, oldShare := range oldShares {
+		if _, found := newShares[table]; !found {
+			result = append(result, TableUsageShift{Table: table, OldShare: oldShare, Change: -100})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result
+}
+
+// tableShares returns, for each table, the percentage of the queries using it
+func tableShares(queries *keys.Output) map[string]float64 {
+	var total int
+	counts := make(map[string]int)
+	for _, q := range queries.Queries {
+		total += q.UsageCount
+		seen := make(map[string]bool, len(q.TableName))
+		for _, table := range q.TableName {
+			if !seen[table] {
+				seen[table] = true
+				counts[table] += q.UsageCount
+			}
+		}
+	}
+
+	shares := make(map[string]float64, len(counts))
+	for table, count := range counts {
+		shares[table] = float64(count) / float64(total) * 100
+	}
+	return shares
+}
+
+// newFailures returns the failed queries of the new file that didn't fail in the old one
+func newFailures(oldQueries, newQueries *keys.Output) []keys.QueryFailedResult {
+	known := make(map[string]bool, len(oldQueries.Failed))
+	for _, failure := range oldQueries.Failed {
+		known[failure.Query] = true
+	}
+
+	var result []keys.QueryFailedResult
+	for _, failure := range newQueries.Failed {
+		if !known[failure.Query] {
+			result = append(result, failure)
+		}
+	}
+	return result
+}
+
+// newFindings returns the findings of the new file that the old one doesn't have, sorted by decreasing severity.
+// Findings are matched on what they report, not on their line numbers, which change from one log to the other.
+func newFindings(oldQueries, newQueries *keys.Output) []keys.Finding {
+	type findingKey struct {
+		id, analyzer, message, query string
+	}
+	keyOf := func(f keys.Finding) findingKey {
+		return findingKey{id: f.ID, analyzer: f.Analyzer, message: f.Message, query: f.Query}
+	}
+
+	known := make(map[findingKey]bool, len(oldQueries.Findings))
+	for _, finding := range oldQueries.Findings {
+		known[keyOf(finding)] = true
+	}
+
+	var result []keys.Finding
+	for _, finding := range newQueries.Findings {
+		key := keyOf(finding)
+		if !known[key] {
+			// the same finding reported on several lines is listed once
+			known[key] = true
+			result = append(result, finding)
+		}
+	}
+	keys.SortFindings(result)
+	return result
+}
+
+// findingsAtLeast counts the findings of the given severity or above
+func findingsAtLeast(findings []keys.Finding, severity keys.Severity) int {
+	var count int
+	for _, finding := range findings {
+		if finding.Severity.Rank() >= severity.Rank() {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestPrintChangelog(t *testing.T) {
+	oldFile := readingSummary{
+		Name: "old.json",
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{
+				{QueryStructure: "select * from t", UsageCount: 50, TableName: []string{"t"}},
+				{QueryStructure: "select * from u", UsageCount: 45, TableName: []string{"u"}},
+				{QueryStructure: "select * from v", UsageCount: 5, TableName: []string{"v"}},
+			},
+			Failed: []keys.QueryFailedResult{{Query: "select nope", LineNumber: 3, Error: "syntax error"}},
+			Findings: []keys.Finding{
+				{ID: "explicit-target", Analyzer: "vt", Severity: keys.SeverityMedium, Message: "query targets a shard", Query: "select * from t", LineNumber: 1},
+			},
+		},
+	}
+	newFile := readingSummary{
+		Name: "new.json",
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{
+				{QueryStructure: "select * from t", UsageCount: 100, TableName: []string{"t"}},
+				{QueryStructure: "select * from u", UsageCount: 95, TableName: []string{"u"}},
+				{QueryStructure: "select * from w where id = :1", UsageCount: 5, TableName: []string{"w"}},
+				{QueryStructure: "select * from t join w", UsageCount: 0, TableName: []string{"t", "w"}},
+			},
+			Failed: []keys.QueryFailedResult{
+				{Query: "select nope", LineNumber: 3, Error: "syntax error"},
+				{Query: "select oops", LineNumber: 9, Error: "syntax error"},
+			},
+			Findings: []keys.Finding{
+				{ID: "explicit-target", Analyzer: "vt", Severity: keys.SeverityMedium, Message: "query targets a shard", Query: "select * from t", LineNumber: 4},
+				{Analyzer: "lint", Severity: "warning", Message: "select *", Query: "select * from u", LineNumber: 2},
+				{ID: "unbounded-write", Analyzer: "vt", Severity: keys.SeverityHigh, Message: "DELETE without a WHERE clause", Query: "delete from w", LineNumber: 7},
+				{ID: "unbounded-write", Analyzer: "vt", Severity: keys.SeverityHigh, Message: "DELETE without a WHERE clause", Query: "delete from w", LineNumber: 8},
+			},
+		},
+	}
+
+	sb := &strings.Builder{}
+	findings := printChangelog(sb, 80, oldFile, newFile, DefaultDiffThreshold)
+	assert.Equal(t, `Changes from old.json to new.json
+
+New hot queries:
+- 5 uses: select * from w where id = :1
+- 0 uses: select * from t join w
+
+Tables with a usage shift above 10%:
+- v: no longer used, was used by 5.00% of queries
+- w: new table, used by 2.50% of queries
+
+New failures:
+- line 9: select oops
+  syntax error
+
+New findings:
+- high: DELETE without a WHERE clause (vt)
+  line 7: delete from w
+- warning: select * (lint)
+  line 2: select * from u
+`, sb.String())
+	assert.Len(t, findings, 2)
+	assert.Equal(t, 1, findingsAtLeast(findings, keys.SeverityHigh))
+	assert.Equal(t, 2, findingsAtLeast(findings, keys.SeverityMedium))
+
+	sb.Reset()
+	findings = printChangelog(sb, 80, oldFile, oldFile, DefaultDiffThreshold)
+	assert.Equal(t, `Changes from old.json to old.json
+
+No new queries.
+
+No table usage shifted by more than 10%.
+
+No new failures.
+
+No new findings.
+`, sb.String())
+	assert.Empty(t, findings)
+}
+
+func TestPrintKeysComparison(t *testing.T) {
+	oldFile := readingSummary{
+		Name: "before.json",
+		AnalysedQueries: &keys.Output{Queries: []keys.QueryAnalysisResult{
+			{QueryStructure: "select * from t", UsageCount: 50, TableName: []string{"t"}},
+			{QueryStructure: "select * from u", UsageCount: 40, TableName: []string{"u"}},
+			{QueryStructure: "select * from v", UsageCount: 10, TableName: []string{"v"}},
+		}},
+	}
+	newFile := readingSummary{
+		Name: "after.json",
+		AnalysedQueries: &keys.Output{Queries: []keys.QueryAnalysisResult{
+			{QueryStructure: "select * from t", UsageCount: 300, TableName: []string{"t"}},
+			{QueryStructure: "select * from u", UsageCount: 140, TableName: []string{"u"}},
+			{QueryStructure: "select * from w where id = :1", UsageCount: 60, TableName: []string{"w"}},
+		}},
+	}
+
+	sb := &strings.Builder{}
+	printKeysComparison(sb, 80, oldFile, newFile, DefaultDiffThreshold)
+	assert.Equal(t, `Comparing keys files before.json and after.json
+
+New query structures (1):
+- 12.00% of uses: select * from w where id = :1
+
+Removed query structures (1):
+- 10.00% of uses: select * from v
+
+Query structures with a usage shift above 10%:
+- 50.00% -> 60.00% of uses (+20.00%): select * from t
+- 40.00% -> 28.00% of uses (-30.00%): select * from u
+
+Tables with a usage shift above 10%:
+- t: 50.00% -> 60.00% of queries (+20.00%)
+- u: 40.00% -> 28.00% of queries (-30.00%)
+- v: no longer used, was used by 10.00% of queries
+- w: new table, used by 12.00% of queries
+`, sb.String())
+
+	sb.Reset()
+	printKeysComparison(sb, 80, oldFile, oldFile, DefaultDiffThreshold)
+	assert.Equal(t, `Comparing keys files before.json and before.json
+
+No new query structures.
+
+No removed query structures.
+
+No query structure usage shifted by more than 10%.
+
+No table usage shifted by more than 10%.
+`, sb.String())
+}
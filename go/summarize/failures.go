@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// maxFailureSamples is the number of queries listed as samples of every type of failure
+const maxFailureSamples = 3
+
+//nolint:gochecknoglobals // these are instead of consts
+var (
+	failureQuoted = regexp.MustCompile("'[^']*'|`[^`]*`|\"[^\"]*\"")
+	failureNumber = regexp.MustCompile(`\b\d+\b`)
+)
+
+// FailureTypeSummary is the queries of a keys file that failed to parse or to be analysed with the same kind of error
+type FailureTypeSummary struct {
+	// Type is the error with the names and numbers it mentions replaced by placeholders, see failureType
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+	// Percentage is the share of the workload, failed queries and uses of the analysed ones, that failed with this error
+	Percentage float64  `json:"percentage"`
+	Samples    []string `json:"samples"`
+}
+
+// summarizeFailureTypes groups the failed queries by the type of their error, the most common first,
+// with the number of failed queries and the number of the failed queries and query uses of the workload
+func summarizeFailureTypes(queries *keys.Output) (result []FailureTypeSummary, failed, total int) {
+	failed = len(queries.Failed)
+	total = failed
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+	}
+
+	types := make(map[string]*FailureTypeSummary)
+	for _, failure := range queries.Failed {
+		name := failureType(failure.Error)
+		summary, found := types[name]
+		if !found {
+			summary = &FailureTypeSummary{Type: name}
+			types[name] = summary
+		}
+		summary.Count++
+		if len(summary.Samples) < maxFailureSamples {
+			summary.Samples = append(summary.Samples, failure.Query)
+		}
+	}
+
+	result = make([]FailureTypeSummary, 0, len(types))
+	for _, summary := range types {
+		summary.Percentage = float64(summary.Count) / float64(total) * 100
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Type < result[j].Type
+	})
+	return result, failed, total
+}
+
+// failureType returns the kind of an error, so the queries failing for the same reason on different tables,
+// columns or positions are counted together: all the syntax errors are one type, and the other errors
+// have the quoted names they mention replaced by ? and their numbers by N
+func failureType(err string) string {
+	if strings.HasPrefix(err, "syntax error") {
+		return "syntax error"
+	}
+	return failureNumber.ReplaceAllString(failureQuoted.ReplaceAllString(err, "?"), "N")
+}
+
+func printFailureTypes(out io.Writer, types []FailureTypeSummary, failed, total int) {
+	fmt.Fprintf(out, "%.2f%% of the workload could not be analysed (%d of %d queries), by type of error:\n",
+		float64(failed)/float64(total)*100, failed, total)
+	table := createTableWriter(out, []string{"Error Type", "Queries", "%", "Sample Query"})
+	for _, t := range types {
+		table.Append([]string{t.Type, strconv.Itoa(t.Count), fmt.Sprintf("%.2f%%", t.Percentage), t.Samples[0]})
+	}
+	table.Render()
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestSummarizeFailureTypes(t *testing.T) {
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{QueryStructure: "select * from t", UsageCount: 14}},
+		Failed: []keys.QueryFailedResult{
+			{Query: "select from", Error: "syntax error at position 12"},
+			{Query: "selec 1", Error: "syntax error at position 6 near 'selec'"},
+			{Query: "select x from t", Error: "VT03019: column 'x' not found"},
+			{Query: "select y from u", Error: "VT03019: column `y` not found"},
+			{Query: "select 1 from v", Error: "VT05004: table 'v' does not exist"},
+			{Query: "select z from t", Error: "VT03019: column 'z' not found"},
+			{Query: "select w from t", Error: "VT03019: column 'w' not found"},
+		},
+	}
+
+	types, failed, total := summarizeFailureTypes(queries)
+	require.Equal(t, 7, failed)
+	require.Equal(t, 21, total)
+	require.Equal(t, []FailureTypeSummary{{
+		Type:       "VT03019: column ? not found",
+		Count:      4,
+		Percentage: 4.0 / 21 * 100,
+		Samples:    []string{"select x from t", "select y from u", "select z from t"},
+	}, {
+		Type:       "syntax error",
+		Count:      2,
+		Percentage: 2.0 / 21 * 100,
+		Samples:    []string{"select from", "selec 1"},
+	}, {
+		Type:       "VT05004: table ? does not exist",
+		Count:      1,
+		Percentage: 1.0 / 21 * 100,
+		Samples:    []string{"select 1 from v"},
+	}}, types)
+
+	sb := &strings.Builder{}
+	printFailureTypes(sb, types, failed, total)
+	assert.Equal(t, `33.33% of the workload could not be analysed (7 of 21 queries), by type of error:
++---------------------------------+---------+--------+-----------------+
+|           Error Type            | Queries |   %    |  Sample Query   |
++---------------------------------+---------+--------+-----------------+
+| VT03019: column ? not found     |       4 | 19.05% | select x from t |
+| syntax error                    |       2 | 9.52%  | select from     |
+| VT05004: table ? does not exist |       1 | 4.76%  | select 1 from v |
++---------------------------------+---------+--------+-----------------+
+`, sb.String())
+}
+
+func TestFailureType(t *testing.T) {
+	assert.Equal(t, "VT12001: unsupported: N derived tables", failureType("VT12001: unsupported: 3 derived tables"))
+	assert.Equal(t, "VT09015: schema tracking required", failureType("VT09015: schema tracking required"))
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultHotMetric ranks the query statistics by their total latency, the most time-consuming queries first
+const DefaultHotMetric = "total-latency"
+
+//nolint:gochecknoglobals // this is instead of a const
+var hotMetrics = map[string]func(QueryWeight) float64{
+	"executions":    func(w QueryWeight) float64 { return float64(w.Executions) },
+	"usage-count":   func(w QueryWeight) float64 { return float64(w.Executions) },
+	"total-latency": func(w QueryWeight) float64 { return w.TotalLatency },
+	"avg-latency":   QueryWeight.AvgLatency,
+	"rows-examined": func(w QueryWeight) float64 { return float64(w.RowsExamined) },
+	"avg-rows-examined": func(w QueryWeight) float64 {
+		if w.Executions == 0 {
+			return 0
+		}
+		return float64(w.RowsExamined) / float64(w.Executions)
+	},
+}
+
+type (
+	// HotMetric scores the query statistics of a dbinfo file to rank them, and the hot queries of the keys file
+	// they weight. It is a sum of terms, each a product of numbers and metrics, such as usage-count*avg-rows-examined
+	// or 2*total-latency+0.001*rows-examined. usage-count is another name of executions: the execution count
+	// replaces the usage count of the keys file.
+	HotMetric struct {
+		expression string
+		terms      []metricTerm
+	}
+
+	metricTerm struct {
+		weight float64
+		// factors multiply the weight, divisors divide it
+		factors, divisors []string
+	}
+)
+
+// ParseHotMetric reads a hot metric expression, or the weighted scoring file the expression names when it ends with .json.
+// A scoring file maps metrics to their weights, {"total-latency": 1, "rows-examined": 0.001} being total-latency+0.001*rows-examined.
+func ParseHotMetric(expression string) (HotMetric, error) {
+	if strings.HasSuffix(expression, ".json") {
+		return readHotMetric(expression)
+	}
+	metric := HotMetric{expression: expression}
+	for _, text := range strings.Split(strings.ReplaceAll(expression, " ", ""), "+") {
+		term := metricTerm{weight: 1}
+		for i, operand := range splitOperands(text) {
+			divide := strings.HasPrefix(operand, "/")
+			name := strings.TrimLeft(operand, "*/")
+			if number, err := strconv.ParseFloat(name, 64); err == nil {
+				if divide {
+					number = 1 / number
+				}
+				term.weight *= number
+				continue
+			}
+			if _, found := hotMetrics[name]; !found {
+				return HotMetric{}, fmt.Errorf("unknown hot metric %q in %q, use %s", name, expression, strings.Join(hotMetricNames(), ", "))
+			}
+			if divide && i > 0 {
+				term.divisors = append(term.divisors, name)
+			} else {
+				term.factors = append(term.factors, name)
+			}
+		}
+		metric.terms = append(metric.terms, term)
+	}
+	return metric, nil
+}
+
+// splitOperands splits a term such as 2*executions/avg-latency into 2, *executions and /avg-latency
+func splitOperands(term string) []string {
+	var operands []string
+	start := 0
+	for i := 1; i < len(term); i++ {
+		if term[i] == '*' || term[i] == '/' {
+			operands = append(operands, term[start:i])
+			start = i
+		}
+	}
+	return append(operands, term[start:])
+}
+
+func readHotMetric(fileName string) (HotMetric, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return HotMetric{}, err
+	}
+	var weights map[string]float64
+	if err := json.Unmarshal(b, &weights); err != nil {
+		return HotMetric{}, fmt.Errorf("reading hot metric %s: %w", fileName, err)
+	}
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	terms := make([]string, 0, len(names))
+	for _, name := range names {
+		terms = append(terms, strconv.FormatFloat(weights[name], 'g', -1, 64)+"*"+name)
+	}
+	if len(terms) == 0 {
+		return HotMetric{}, fmt.Errorf("reading hot metric %s: no metric is weighted", fileName)
+	}
+	return ParseHotMetric(strings.Join(terms, "+"))
+}
+
+func hotMetricNames() []string {
+	names := make([]string, 0, len(hotMetrics))
+	for name := range hotMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the expression of the metric
+func (m HotMetric) String() string {
+	return m.expression
+}
+
+// Score returns the value of the metric for the statistics of a query
+func (m HotMetric) Score(w QueryWeight) float64 {
+	var score float64
+	for _, term := range m.terms {
+		value := term.weight
+		for _, name := range term.factors {
+			value *= hotMetrics[name](w)
+		}
+		for _, name := range term.divisors {
+			divisor := hotMetrics[name](w)
+			if divisor == 0 {
+				value = 0
+				break
+			}
+			value /= divisor
+		}
+		score += value
+	}
+	return score
+}
+
+// rankHotQueries sorts the hot queries by decreasing score of their query statistics, keeping the order of the queries
+// of the same score. The queries without statistics are scored with their usage count as their execution count.
+func rankHotQueries(queries []hotQuery, weights []QueryWeight, metric HotMetric) {
+	matched := make(map[string]QueryWeight, len(weights))
+	for _, w := range weights {
+		if w.Matched {
+			matched[w.Query] = w
+		}
+	}
+	for i, q := range queries {
+		w, found := matched[q.Query]
+		if !found {
+			w = QueryWeight{Query: q.Query, Executions: q.UsageCount}
+		}
+		queries[i].Score = metric.Score(w)
+	}
+	sort.SliceStable(queries, func(i, j int) bool {
+		return queries[i].Score > queries[j].Score
+	})
+}
+
+// rankQueryWeights sorts the query statistics by decreasing score, keeping the order of the weights of the same score
+func rankQueryWeights(weights []QueryWeight, metric HotMetric) {
+	sort.SliceStable(weights, func(i, j int) bool {
+		return metric.Score(weights[i]) > metric.Score(weights[j])
+	})
+}
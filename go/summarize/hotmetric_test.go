@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotMetric(t *testing.T) {
+	w := QueryWeight{Query: "q", Executions: 10, TotalLatency: 50, RowsExamined: 400}
+	tests := []struct {
+		expression string
+		score      float64
+	}{
+		{expression: "total-latency", score: 50},
+		{expression: "usage-count*avg-rows-examined", score: 400},
+		{expression: "2*total-latency + 0.5*rows-examined", score: 300},
+		{expression: "rows-examined/executions", score: 40},
+		{expression: "avg-latency/2", score: 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			metric, err := ParseHotMetric(tt.expression)
+			require.NoError(t, err)
+			require.InDelta(t, tt.score, metric.Score(w), 0.0001)
+		})
+	}
+
+	// a metric divided by a zero metric scores 0
+	metric, err := ParseHotMetric("total-latency/rows-examined")
+	require.NoError(t, err)
+	require.Zero(t, metric.Score(QueryWeight{TotalLatency: 10}))
+
+	_, err = ParseHotMetric("usage-count*cpu")
+	require.EqualError(t, err, `unknown hot metric "cpu" in "usage-count*cpu", use avg-latency, avg-rows-examined, executions, rows-examined, total-latency, usage-count`)
+	_, err = ParseHotMetric("executions+")
+	require.Error(t, err)
+}
+
+func TestHotMetricFile(t *testing.T) {
+	fileName := t.TempDir() + "/scoring.json"
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"total-latency": 1, "rows-examined": 0.001}`), 0o600))
+	metric, err := ParseHotMetric(fileName)
+	require.NoError(t, err)
+	require.Equal(t, "0.001*rows-examined+1*total-latency", metric.String())
+	require.InDelta(t, 10.5, metric.Score(QueryWeight{TotalLatency: 10, RowsExamined: 500}), 0.0001)
+
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"cpu": 1}`), 0o600))
+	_, err = ParseHotMetric(fileName)
+	require.ErrorContains(t, err, `unknown hot metric "cpu"`)
+}
+
+func TestRankQueryWeights(t *testing.T) {
+	weights := []QueryWeight{
+		{Query: "slow", Executions: 1, TotalLatency: 100, RowsExamined: 10},
+		{Query: "scan", Executions: 2, TotalLatency: 10, RowsExamined: 100000},
+		{Query: "fast", Executions: 50, TotalLatency: 5, RowsExamined: 50},
+	}
+	metric, err := ParseHotMetric("usage-count*avg-rows-examined")
+	require.NoError(t, err)
+	rankQueryWeights(weights, metric)
+	require.Equal(t, "scan", weights[0].Query)
+	require.Equal(t, "fast", weights[1].Query)
+	require.Equal(t, "slow", weights[2].Query)
+
+	var out bytes.Buffer
+	printQueryWeights(&out, 200, weights, metric)
+	require.Contains(t, out.String(), "ranked by usage-count*avg-rows-examined:")
+	require.Contains(t, out.String(), "|   Score    |")
+	require.Contains(t, out.String(), "100000.000")
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+const (
+	graphSize   = 560
+	graphRadius = 220
+)
+
+//go:embed report.html
+var reportTemplate string
+
+type (
+	// queryGraph is the SVG drawing of the tables joined by the queries, the tables on a circle
+	// and the edges as wide as the joins are used
+	queryGraph struct {
+		Size  int
+		Nodes []graphNode
+		Edges []graphEdge
+	}
+
+	graphNode struct {
+		Name string
+		X, Y float64
+	}
+
+	graphEdge struct {
+		X1, Y1, X2, Y2 float64
+		Width          float64
+		Title          string
+	}
+)
+
+// printHTMLReport writes a self-contained HTML report of a keys file, which can be shared without vt
+func printHTMLReport(out io.Writer, file readingSummary, limits ReportLimits, inputs keysInputs) error {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"join":      func(s []string) string { return strings.Join(s, ", ") },
+		"percentOf": percentOf,
+		"percent":   func(share float64) string { return fmt.Sprintf("%.2f%%", share*100) },
+		"add":       func(a, b int) int { return a + b },
+	}).Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(out, newKeysReport(file, limits, inputs))
+}
+
+// newQueryGraph draws the tables joined by the queries, it returns nil when the queries join no tables
+func newQueryGraph(queries *keys.Output) *queryGraph {
+	type pair struct{ a, b string }
+	usage := make(map[pair]int)
+	for _, query := range queries.Queries {
+		seen := make(map[pair]bool)
+		for _, predicate := range query.JoinPredicates {
+			a, b := predicate.LHS.Table, predicate.RHS.Table
+			if a == b {
+				continue
+			}
+			if b < a {
+				a, b = b, a
+			}
+			if p := (pair{a, b}); !seen[p] {
+				seen[p] = true
+				usage[p] += query.UsageCount
+			}
+		}
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+
+	var names []string
+	maxUsage := 0
+	for p, count := range usage {
+		names = append(names, p.a, p.b)
+		maxUsage = max(maxUsage, count)
+	}
+	sort.Strings(names)
+	names = compactStrings(names)
+
+	graph := &queryGraph{Size: graphSize}
+	positions := make(map[string]graphNode, len(names))
+	center := float64(graphSize) / 2
+	for i, name := range names {
+		angle := 2*math.Pi*float64(i)/float64(len(names)) - math.Pi/2
+		node := graphNode{Name: name, X: round1(center + graphRadius*math.Cos(angle)), Y: round1(center + graphRadius*math.Sin(angle))}
+		positions[name] = node
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	pairs := make([]pair, 0, len(usage))
+	for p := range usage {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+	for _, p := range pairs {
+		a, b := positions[p.a], positions[p.b]
+		graph.Edges = append(graph.Edges, graphEdge{
+			X1: a.X, Y1: a.Y, X2: b.X, Y2: b.Y,
+			Width: round1(1 + 7*float64(usage[p])/float64(maxUsage)),
+			Title: fmt.Sprintf("%s - %s: used %d times", p.a, p.b, usage[p]),
+		})
+	}
+	return graph
+}
+
+func compactStrings(sorted []string) []string {
+	result := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
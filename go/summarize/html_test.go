@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestPrintHTMLReport(t *testing.T) {
+	file := reportTestFile()
+
+	sb := &strings.Builder{}
+	require.NoError(t, printHTMLReport(sb, file, ReportLimits{}, keysInputs{}))
+	report := sb.String()
+	require.Contains(t, report, "<h1>Summary from trace file keys.json</h1>")
+	require.Contains(t, report, "estimated from a 50.00% sample")
+	require.Contains(t, report, "<td class=\"num\">3</td><td class=\"num\">75.00%</td>")
+	require.Contains(t, report, "<h2 id=\"table-t\">Table: t used in 4 queries</h2>\n<p>Joined with <a href=\"#table-u\">u</a></p>")
+	require.Contains(t, report, "<summary>2 most used query structures</summary>")
+	require.Contains(t, report, "<tr><td><a href=\"#table-u\">u</a></td>")
+	require.Contains(t, report, "<title>t - u: used 3 times</title>")
+	require.Contains(t, report, "<h2>Full scan candidates</h2>")
+	// the query tables are sorted, searched and paged by the script of the report
+	require.Contains(t, report, "<table class=\"queries\">\n<tr><th>Query</th><th>Tables</th><th>Usage Count</th></tr>")
+	require.Contains(t, report, `document.querySelectorAll("table.queries")`)
+	require.Contains(t, report, `search.placeholder = "Search the queries";`)
+	// the queries are escaped, the only script is the one of the report
+	require.Contains(t, report, "select &lt;script&gt;")
+	require.Equal(t, 1, strings.Count(report, "<script>"))
+}
+
+func TestQueryGraph(t *testing.T) {
+	join := func(a, b string) operators.JoinPredicate {
+		return operators.JoinPredicate{LHS: operators.Column{Table: a, Name: "id"}, RHS: operators.Column{Table: b, Name: "id"}}
+	}
+	graph := newQueryGraph(&keys.Output{Queries: []keys.QueryAnalysisResult{{
+		UsageCount:     4,
+		JoinPredicates: []operators.JoinPredicate{join("u", "t"), join("t", "u")},
+	}, {
+		UsageCount:     2,
+		JoinPredicates: []operators.JoinPredicate{join("t", "v"), join("v", "v")},
+	}}})
+
+	require.NotNil(t, graph)
+	require.Equal(t, []graphNode{{Name: "t", X: 280, Y: 60}, {Name: "u", X: 470.5, Y: 390}, {Name: "v", X: 89.5, Y: 390}}, graph.Nodes)
+	require.Len(t, graph.Edges, 2)
+	// a query joining the same tables twice counts once
+	require.Equal(t, "t - u: used 4 times", graph.Edges[0].Title)
+	require.InDelta(t, 8, graph.Edges[0].Width, 0.01)
+	require.Equal(t, "t - v: used 2 times", graph.Edges[1].Title)
+	require.InDelta(t, 4.5, graph.Edges[1].Width, 0.01)
+
+	require.Nil(t, newQueryGraph(&keys.Output{Queries: []keys.QueryAnalysisResult{{UsageCount: 1}}}))
+}
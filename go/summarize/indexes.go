@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+// UnindexedFilter is a column the queries filter on that is not the first column of any index of its table
+type UnindexedFilter struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	// Uses is the number of query uses filtering on the column
+	Uses int `json:"uses"`
+	// Rows is the estimated number of rows of the table
+	Rows int `json:"rows"`
+}
+
+// IndexSuggestion is an index that would serve the filters and joins of queries that no index of the dbinfo file serves
+type IndexSuggestion struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	// Uses is the number of query uses the index would serve
+	Uses int `json:"uses"`
+	// Rows is the estimated number of rows of the table
+	Rows int `json:"rows"`
+}
+
+// maxIndexNameLength is the longest identifier MySQL accepts
+const maxIndexNameLength = 64
+
+// checkIndexes returns the filter columns of the queries that no index of the dbinfo file can be used for.
+// Only the leading column of an index is considered, the other columns need a filter on the previous ones.
+// Tables missing from the dbinfo file are not checked. The columns are sorted by uses, the most used first.
+func checkIndexes(queries *keys.Output, info *dbinfo.Info) []UnindexedFilter {
+	tables := make(map[string]dbinfo.TableInfo, len(info.Tables))
+	for _, table := range info.Tables {
+		tables[strings.ToLower(table.Name)] = table
+	}
+
+	uses := make(map[[2]string]int)
+	for _, query := range queries.Queries {
+		// a query filtering twice on the same column is counted once
+		seen := make(map[[2]string]bool)
+		for _, filter := range query.FilterColumns {
+			key := [2]string{strings.ToLower(filter.Column.Table), strings.ToLower(filter.Column.Name)}
+			if !seen[key] {
+				seen[key] = true
+				uses[key] += query.UsageCount
+			}
+		}
+	}
+
+	var result []UnindexedFilter
+	for key, count := range uses {
+		table, found := tables[key[0]]
+		if !found || isIndexed(table, key[1]) {
+			continue
+		}
+		result = append(result, UnindexedFilter{Table: table.Name, Column: key[1], Uses: count, Rows: table.Rows})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Uses != result[j].Uses {
+			return result[i].Uses > result[j].Uses
+		}
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].Column < result[j].Column
+	})
+	return result
+}
+
+func isIndexed(table dbinfo.TableInfo, column string) bool {
+	for _, index := range table.Indexes {
+		if len(index.Columns) > 0 && strings.EqualFold(index.Columns[0], column) {
+			return true
+		}
+	}
+	return false
+}
+
+func printUnindexedFilters(out io.Writer, filters []UnindexedFilter) {
+	if len(filters) == 0 {
+		fmt.Fprintln(out, "All the filter columns are indexed")
+		return
+	}
+
+	fmt.Fprintf(out, "%d filter columns are not the first column of an index:\n", len(filters))
+	table := createTableWriter(out, []string{"Table", "Column", "Filter Uses", "Table Rows"})
+	for _, filter := range filters {
+		table.Append([]string{filter.Table, filter.Column, strconv.Itoa(filter.Uses), strconv.Itoa(filter.Rows)})
+	}
+	table.Render()
+}
+
+// suggestIndexes returns the indexes to add for the queries whose filter and join columns of a table
+// are none of them the first column of an index of the dbinfo file.
+// The suggested index has the columns compared with equality or IN, in name order, then one of the range columns.
+// Queries needing the same index are counted together, and the indexes are sorted by uses, the most used first.
+func suggestIndexes(queries *keys.Output, info *dbinfo.Info) []IndexSuggestion {
+	tables := make(map[string]dbinfo.TableInfo, len(info.Tables))
+	for _, table := range info.Tables {
+		tables[strings.ToLower(table.Name)] = table
+	}
+
+	suggestions := make(map[string]*IndexSuggestion)
+	for _, query := range queries.Queries {
+		equalities := make(map[string][]string)
+		ranges := make(map[string][]string)
+		add := func(uses []operators.ColumnUse) {
+			for _, use := range uses {
+				table := strings.ToLower(use.Column.Table)
+				switch use.Uses {
+				case sqlparser.EqualOp, sqlparser.NullSafeEqualOp, sqlparser.InOp:
+					equalities[table] = append(equalities[table], use.Column.Name)
+				case sqlparser.LessThanOp, sqlparser.LessEqualOp, sqlparser.GreaterThanOp, sqlparser.GreaterEqualOp:
+					ranges[table] = append(ranges[table], use.Column.Name)
+				}
+			}
+		}
+		add(query.FilterColumns)
+		add(query.JoinColumns)
+
+		for name, table := range tables {
+			columns := compactColumns(equalities[name])
+			if len(ranges[name]) > 0 {
+				rangeColumns := compactColumns(ranges[name])
+				if !slices.ContainsFunc(columns, func(c string) bool { return strings.EqualFold(c, rangeColumns[0]) }) {
+					columns = append(columns, rangeColumns[0])
+				}
+			}
+			if len(columns) == 0 || slices.ContainsFunc(columns, func(c string) bool { return isIndexed(table, c) }) {
+				continue
+			}
+
+			key := name + "(" + strings.ToLower(strings.Join(columns, ",")) + ")"
+			suggestion, found := suggestions[key]
+			if !found {
+				suggestion = &IndexSuggestion{Table: table.Name, Columns: columns, Rows: table.Rows}
+				suggestions[key] = suggestion
+			}
+			suggestion.Uses += query.UsageCount
+		}
+	}
+
+	result := make([]IndexSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		result = append(result, *suggestion)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Uses != result[j].Uses {
+			return result[i].Uses > result[j].Uses
+		}
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return strings.Join(result[i].Columns, ",") < strings.Join(result[j].Columns, ",")
+	})
+	return result
+}
+
+// compactColumns sorts the column names and removes the ones repeated with a different case
+func compactColumns(columns []string) []string {
+	columns = slices.Clone(columns)
+	slices.SortFunc(columns, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+	return slices.CompactFunc(columns, strings.EqualFold)
+}
+
+// Statement returns the CREATE INDEX statement adding the suggested index
+func (s IndexSuggestion) Statement() string {
+	name := strings.ToLower("idx_" + s.Table + "_" + strings.Join(s.Columns, "_"))
+	if len(name) > maxIndexNameLength {
+		name = name[:maxIndexNameLength]
+	}
+	columns := make([]string, 0, len(s.Columns))
+	for _, column := range s.Columns {
+		columns = append(columns, sqlparser.String(sqlparser.NewIdentifierCI(column)))
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);",
+		sqlparser.String(sqlparser.NewIdentifierCI(name)),
+		sqlparser.String(sqlparser.NewIdentifierCS(s.Table)),
+		strings.Join(columns, ", "))
+}
+
+func printIndexSuggestions(out io.Writer, suggestions []IndexSuggestion) {
+	if len(suggestions) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "Suggested indexes, the most used first:")
+	for _, suggestion := range suggestions {
+		fmt.Fprintf(out, "%s -- %d uses, %d rows\n", suggestion.Statement(), suggestion.Uses, suggestion.Rows)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestCheckIndexes(t *testing.T) {
+	filter := func(table, column string) operators.ColumnUse {
+		return operators.ColumnUse{Column: operators.Column{Table: table, Name: column}, Uses: sqlparser.EqualOp}
+	}
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{
+			QueryStructure: "select * from orders where id = :1",
+			UsageCount:     10,
+			FilterColumns:  []operators.ColumnUse{filter("orders", "id")},
+		}, {
+			QueryStructure: "select * from orders where status = :1 and created > :2 and status != :3",
+			UsageCount:     6,
+			FilterColumns:  []operators.ColumnUse{filter("orders", "status"), filter("orders", "created"), filter("orders", "status")},
+		}, {
+			QueryStructure: "select * from orders join customer on orders.customer_id = customer.id where customer.email = :1",
+			UsageCount:     3,
+			FilterColumns:  []operators.ColumnUse{filter("customer", "email"), filter("Orders", "Customer_ID")},
+		}, {
+			QueryStructure: "select * from unknown where x = :1",
+			UsageCount:     100,
+			FilterColumns:  []operators.ColumnUse{filter("unknown", "x")},
+		}},
+	}
+	info := &dbinfo.Info{Tables: []dbinfo.TableInfo{{
+		Name: "orders",
+		Rows: 250000,
+		Indexes: []dbinfo.IndexInfo{
+			{Name: "PRIMARY", Columns: []string{"id"}, Unique: true},
+			{Name: "idx_customer_created", Columns: []string{"customer_id", "created"}},
+		},
+	}, {
+		Name: "customer",
+		Rows: 1000,
+	}}}
+
+	filters := checkIndexes(queries, info)
+	require.Equal(t, []UnindexedFilter{
+		{Table: "orders", Column: "created", Uses: 6, Rows: 250000},
+		{Table: "orders", Column: "status", Uses: 6, Rows: 250000},
+		{Table: "customer", Column: "email", Uses: 3, Rows: 1000},
+	}, filters)
+
+	sb := &strings.Builder{}
+	printUnindexedFilters(sb, filters)
+	assert.Equal(t, `3 filter columns are not the first column of an index:
++----------+---------+-------------+------------+
+|  Table   | Column  | Filter Uses | Table Rows |
++----------+---------+-------------+------------+
+| orders   | created |           6 |     250000 |
+| orders   | status  |           6 |     250000 |
+| customer | email   |           3 |       1000 |
++----------+---------+-------------+------------+
+`, sb.String())
+
+	sb.Reset()
+	printUnindexedFilters(sb, nil)
+	assert.Equal(t, "All the filter columns are indexed\n", sb.String())
+}
+
+func TestSuggestIndexes(t *testing.T) {
+	use := func(table, column string, op sqlparser.ComparisonExprOperator) operators.ColumnUse {
+		return operators.ColumnUse{Column: operators.Column{Table: table, Name: column}, Uses: op}
+	}
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{
+			QueryStructure: "select * from orders where status = :1 and created > :2 and region in ::3",
+			UsageCount:     6,
+			FilterColumns: []operators.ColumnUse{
+				use("orders", "status", sqlparser.EqualOp),
+				use("orders", "created", sqlparser.GreaterThanOp),
+				use("orders", "region", sqlparser.InOp),
+			},
+		}, {
+			QueryStructure: "select * from orders where Region in ::1 and Status = :2",
+			UsageCount:     2,
+			FilterColumns:  []operators.ColumnUse{use("orders", "Status", sqlparser.EqualOp), use("orders", "Region", sqlparser.InOp)},
+		}, {
+			QueryStructure: "select * from orders where region = :1 and status = :2 and created <= :3",
+			UsageCount:     4,
+			FilterColumns: []operators.ColumnUse{
+				use("orders", "region", sqlparser.EqualOp),
+				use("orders", "status", sqlparser.EqualOp),
+				use("orders", "created", sqlparser.LessEqualOp),
+			},
+		}, {
+			QueryStructure: "select * from orders join order_line on orders.id = order_line.order_id where orders.id = :1",
+			UsageCount:     10,
+			FilterColumns:  []operators.ColumnUse{use("orders", "id", sqlparser.EqualOp)},
+			JoinColumns:    []operators.ColumnUse{use("orders", "id", sqlparser.EqualOp), use("order_line", "order_id", sqlparser.EqualOp)},
+		}, {
+			QueryStructure: "select * from orders where note like :1",
+			UsageCount:     3,
+			FilterColumns:  []operators.ColumnUse{use("orders", "note", sqlparser.LikeOp)},
+		}},
+	}
+	info := &dbinfo.Info{Tables: []dbinfo.TableInfo{{
+		Name:    "orders",
+		Rows:    250000,
+		Indexes: []dbinfo.IndexInfo{{Name: "PRIMARY", Columns: []string{"id"}, Unique: true}},
+	}, {
+		Name: "order_line",
+		Rows: 900000,
+	}}}
+
+	suggestions := suggestIndexes(queries, info)
+	require.Equal(t, []IndexSuggestion{
+		{Table: "order_line", Columns: []string{"order_id"}, Uses: 10, Rows: 900000},
+		{Table: "orders", Columns: []string{"region", "status", "created"}, Uses: 10, Rows: 250000},
+		{Table: "orders", Columns: []string{"Region", "Status"}, Uses: 2, Rows: 250000},
+	}, suggestions)
+
+	sb := &strings.Builder{}
+	printIndexSuggestions(sb, suggestions)
+	assert.Equal(t, strings.ReplaceAll(`Suggested indexes, the most used first:
+CREATE INDEX idx_order_line_order_id ON order_line (order_id); -- 10 uses, 900000 rows
+CREATE INDEX idx_orders_region_status_created ON orders (region, ~status~, created); -- 10 uses, 250000 rows
+CREATE INDEX idx_orders_region_status ON orders (Region, ~Status~); -- 2 uses, 250000 rows
+`, "~", "`"), sb.String())
+
+	long := IndexSuggestion{Table: "t", Columns: []string{strings.Repeat("a", 40), strings.Repeat("b", 40)}}
+	require.Len(t, strings.Fields(long.Statement())[2], maxIndexNameLength)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// DefaultLatencyThreshold is the percentage by which a query must be slower on Vitess to be listed as a regression
+const DefaultLatencyThreshold = 20
+
+type (
+	// QueryLatency represents the latencies of a query signature in a 'vt tester --latency-file' output
+	QueryLatency struct {
+		Query      string             `json:"query"`
+		LineNumber int                `json:"lineNumber"`
+		Samples    int                `json:"samples"`
+		MySQL      LatencyPercentiles `json:"mysql"`
+		Vitess     LatencyPercentiles `json:"vitess"`
+	}
+
+	// LatencyPercentiles are in milliseconds
+	LatencyPercentiles struct {
+		P50 float64 `json:"p50"`
+		P95 float64 `json:"p95"`
+		P99 float64 `json:"p99"`
+	}
+)
+
+// Ratio is how many times slower the median latency of the query is on Vitess than on MySQL
+func (l QueryLatency) Ratio() float64 {
+	if l.MySQL.P50 == 0 {
+		return 0
+	}
+	return l.Vitess.P50 / l.MySQL.P50
+}
+
+func printLatencySummary(out io.Writer, termWidth int, file readingSummary, threshold float64) {
+	latencies := file.Latencies
+	fmt.Fprintf(out, "Latency comparison from file %s, in milliseconds\n", file.Name)
+	table := createTableWriter(out, []string{"Query", "Samples", "MySQL p50", "MySQL p95", "MySQL p99", "Vitess p50", "Vitess p95", "Vitess p99", "Ratio"})
+	for _, l := range latencies {
+		table.Append([]string{
+			limitQueryLength(l.Query, termWidth/2),
+			strconv.Itoa(l.Samples),
+			fmt.Sprintf("%.3f", l.MySQL.P50),
+			fmt.Sprintf("%.3f", l.MySQL.P95),
+			fmt.Sprintf("%.3f", l.MySQL.P99),
+			fmt.Sprintf("%.3f", l.Vitess.P50),
+			fmt.Sprintf("%.3f", l.Vitess.P95),
+			fmt.Sprintf("%.3f", l.Vitess.P99),
+			fmt.Sprintf("%.2fx", l.Ratio()),
+		})
+	}
+	table.Render()
+	fmt.Fprintln(out)
+
+	regressions := latencyRegressions(latencies, threshold)
+	if len(regressions) == 0 {
+		fmt.Fprintf(out, "No query is more than %.0f%% slower on Vitess.\n", threshold)
+		return
+	}
+	fmt.Fprintf(out, "Queries more than %.0f%% slower on Vitess (median latency):\n", threshold)
+	for _, l := range regressions {
+		fmt.Fprintf(out, "- %+.0f%% (%.3fms -> %.3fms): %s\n", (l.Ratio()-1)*100, l.MySQL.P50, l.Vitess.P50, limitQueryLength(l.Query, termWidth))
+	}
+}
+
+// latencyRegressions returns the queries whose median latency is more than threshold percent
+// higher on Vitess than on MySQL, the slowest first
+func latencyRegressions(latencies []QueryLatency, threshold float64) []QueryLatency {
+	var result []QueryLatency
+	for _, l := range latencies {
+		if l.MySQL.P50 > 0 && (l.Ratio()-1)*100 > threshold {
+			result = append(result, l)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Ratio() > result[j].Ratio()
+	})
+	return result
+}
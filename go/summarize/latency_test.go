@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeLatencies(t *testing.T) {
+	file, err := readTraceFile("testdata/latency-log.json", false)
+	require.NoError(t, err)
+	require.Len(t, file.Latencies, 3)
+	require.Nil(t, file.AnalysedQueries)
+
+	sb := &bytes.Buffer{}
+	printLatencySummary(sb, 200, file, DefaultLatencyThreshold)
+	expected := "Latency comparison from file testdata/latency-log.json, in milliseconds\n" +
+		"+--------------------------------------------------------------+---------+-----------+-----------+-----------+------------+------------+------------+-------+\n" +
+		"|                            Query                             | Samples | MySQL p50 | MySQL p95 | MySQL p99 | Vitess p50 | Vitess p95 | Vitess p99 | Ratio |\n" +
+		"+--------------------------------------------------------------+---------+-----------+-----------+-----------+------------+------------+------------+-------+\n" +
+		"| SELECT * FROM `orders` WHERE `id` = :_id /* INT64 */         |      10 |     0.200 |     0.350 |     0.500 |      0.600 |      0.900 |      1.200 | 3.00x |\n" +
+		"| SELECT count(*) FROM `orders`                                |       5 |     4.000 |     4.500 |     4.500 |      4.400 |      5.000 |      5.000 | 1.10x |\n" +
+		"| SELECT * FROM `customer` WHERE `name` = :_name /* VARCHAR */ |      10 |     1.000 |     1.500 |     2.000 |      1.500 |      2.000 |      3.000 | 1.50x |\n" +
+		"+--------------------------------------------------------------+---------+-----------+-----------+-----------+------------+------------+------------+-------+\n" +
+		"\n" +
+		"Queries more than 20% slower on Vitess (median latency):\n" +
+		"- +200% (0.200ms -> 0.600ms): SELECT * FROM `orders` WHERE `id` = :_id /* INT64 */\n" +
+		"- +50% (1.000ms -> 1.500ms): SELECT * FROM `customer` WHERE `name` = :_name /* VARCHAR */\n"
+	require.Equal(t, expected, sb.String())
+
+	sb.Reset()
+	printLatencySummary(sb, 200, file, 500)
+	require.Contains(t, sb.String(), "No query is more than 500% slower on Vitess.\n")
+}
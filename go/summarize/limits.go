@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"sort"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// ReportLimits shorten the sections of the markdown, JSON, HTML and PDF summaries of large workloads.
+// The items left out of a section are counted, and the markdown and HTML formats end the section with "and X more...".
+type ReportLimits struct {
+	// TopQueries is the number of queries listed in every section of queries, and of items in the other sections
+	// that are not lists of tables, such as the findings or the users. The hot queries and the queries of every table
+	// are limited to maxHotQueries when it is 0, the uncovered queries to maxUncoveredQueries and the query statistics
+	// to queryStatsLimit.
+	TopQueries int
+	// TopTables is the number of tables listed in the tables overview, in the table sections and in the other
+	// sections listing tables, the most used ones
+	TopTables int
+	// MinUsagePercentage leaves out the queries and the tables used by less than this percentage of the query uses
+	MinUsagePercentage float64
+}
+
+// omitted counts the items the limits left out of every section of a report
+type omitted struct {
+	Tables, HotQueries, TableSummaries, FullScans, FunctionFilters, Failures int
+
+	Findings, FailureTypes, Hints, Users, Observed, Targets, BatchQueries, Values int
+	ShardingKeys, TenancyViolations, ShardingKeyCoverage, Uncovered               int
+	QueryWeights, AutoIncrements, PartitionedTables, UnindexedFilters             int
+	IndexSuggestions, UnusedTables, UnusedColumns                                 int
+}
+
+// applyLimits shortens the sections of the report, total is the number of query uses of the keys file
+func (r *keysReport) applyLimits(limits ReportLimits, total int) {
+	share := func(uses int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(uses) / float64(total) * 100
+	}
+	kept := func(uses int) bool {
+		return share(uses) >= limits.MinUsagePercentage
+	}
+	topQueries := limits.TopQueries
+
+	r.Tables, r.Omitted.Tables = limitSection(r.Tables, limits.TopTables,
+		func(t keys.TableStats) int { return t.Reads + t.Writes }, kept)
+	r.TableSummaries, r.Omitted.TableSummaries = limitSection(r.TableSummaries, limits.TopTables,
+		func(t reportTable) int { return t.QueryCount }, kept)
+
+	hotTop := topQueries
+	if hotTop == 0 {
+		hotTop = maxHotQueries
+	}
+	// the hot queries are already ranked, by their usage count or by the hot metric
+	for i := range r.TableSummaries {
+		t := &r.TableSummaries[i]
+		t.Queries, t.OmittedQueries = limitRanked(t.Queries, hotTop, func(q hotQuery) int { return q.UsageCount }, kept)
+	}
+	r.HotQueries, r.Omitted.HotQueries = limitRanked(r.HotQueries, hotTop,
+		func(q hotQuery) int { return q.UsageCount }, kept)
+	r.FullScans, r.Omitted.FullScans = limitSection(r.FullScans, topQueries,
+		func(s FullScanSummary) int { return s.UsageCount }, kept)
+	r.FunctionFilters, r.Omitted.FunctionFilters = limitSection(r.FunctionFilters, topQueries,
+		func(f FunctionFilterSummary) int { return f.UsageCount }, kept)
+	// the failed queries have no usage count
+	r.Failures, r.Omitted.Failures = limitSection(r.Failures, topQueries,
+		func(FailuresSummary) int { return 0 }, keptAll)
+	r.FailureTypes, r.Omitted.FailureTypes = limitSection(r.FailureTypes, topQueries,
+		func(t FailureTypeSummary) int { return t.Count }, kept)
+	// the findings count queries, not query uses
+	r.Findings, r.Omitted.Findings = limitSection(r.Findings, topQueries,
+		func(f FindingSummary) int { return f.Count }, keptAll)
+	r.Hints, r.Omitted.Hints = limitSection(r.Hints, topQueries,
+		func(h HintSummary) int { return h.UsageCount }, kept)
+	r.Users, r.Omitted.Users = limitSection(r.Users, topQueries,
+		func(u UserSummary) int { return u.UsageCount }, kept)
+	r.Observed, r.Omitted.Observed = limitSection(r.Observed, topQueries,
+		func(o ObservedSummary) int { return o.Executions }, kept)
+	r.Targets, r.Omitted.Targets = limitSection(r.Targets, topQueries,
+		func(t TargetSummary) int { return t.Uses }, kept)
+	if r.Traffic != nil {
+		r.Traffic.BatchQueries, r.Omitted.BatchQueries = limitSection(r.Traffic.BatchQueries, topQueries,
+			func(q BatchQuerySummary) int { return q.UsageCount }, kept)
+	}
+	r.Values, r.Omitted.Values = limitSection(r.Values, topQueries,
+		func(v reportValues) int { return v.Uses }, kept)
+	// the recommendations have no uses, the most confident ones are kept
+	r.ShardingKeys, r.Omitted.ShardingKeys = limitSection(r.ShardingKeys, limits.TopTables,
+		func(s ShardingKeyRecommendation) int { return int(s.Confidence) }, keptAll)
+	r.limitInputs(limits, kept)
+}
+
+// limitInputs shortens the sections of the files given along the keys file
+func (r *keysReport) limitInputs(limits ReportLimits, kept func(int) bool) {
+	topQueries := limits.TopQueries
+	if r.Tenancy != nil {
+		r.Tenancy.Violations, r.Omitted.TenancyViolations = limitSection(r.Tenancy.Violations, topQueries,
+			func(v TenancyViolation) int { return v.UsageCount }, kept)
+	}
+	if r.ShardingKeyCoverage != nil {
+		r.ShardingKeyCoverage.Tables, r.Omitted.ShardingKeyCoverage = limitSection(r.ShardingKeyCoverage.Tables, limits.TopTables,
+			func(c ShardingKeyCoverage) int { return c.Uses }, kept)
+	}
+	if r.TestCoverage != nil {
+		top := topQueries
+		if top == 0 {
+			top = maxUncoveredQueries
+		}
+		r.TestCoverage.Uncovered, r.Omitted.Uncovered = limitRanked(r.TestCoverage.Uncovered, top,
+			func(q hotQuery) int { return q.UsageCount }, kept)
+	}
+	if d := r.Database; d != nil {
+		top := topQueries
+		if top == 0 {
+			top = queryStatsLimit
+		}
+		// the query statistics are ranked by the hot metric or by their total latency
+		d.QueryWeights, r.Omitted.QueryWeights = limitRanked(d.QueryWeights, top,
+			func(w reportQueryWeight) int { return w.Executions }, keptAll)
+		// the auto-increments are ranked by the share of their range they used
+		d.AutoIncrements, r.Omitted.AutoIncrements = limitRanked(d.AutoIncrements, limits.TopTables,
+			func(AutoIncrementUsage) int { return 0 }, keptAll)
+		d.PartitionedTables, r.Omitted.PartitionedTables = limitRanked(d.PartitionedTables, limits.TopTables,
+			func(reportPartitioning) int { return 0 }, keptAll)
+		d.UnindexedFilters, r.Omitted.UnindexedFilters = limitSection(d.UnindexedFilters, topQueries,
+			func(f UnindexedFilter) int { return f.Uses }, kept)
+		d.IndexSuggestions, r.Omitted.IndexSuggestions = limitSection(d.IndexSuggestions, topQueries,
+			func(s reportIndexSuggestion) int { return s.Uses }, kept)
+		// the unused tables and columns have no uses, the largest tables are kept
+		d.UnusedTables, r.Omitted.UnusedTables = limitSection(d.UnusedTables, limits.TopTables,
+			func(t UnusedTable) int { return t.Rows }, keptAll)
+		d.UnusedColumns, r.Omitted.UnusedColumns = limitSection(d.UnusedColumns, limits.TopTables,
+			func(c UnusedColumns) int { return len(c.Columns) }, keptAll)
+	}
+}
+
+// keptAll keeps the items of the sections whose items have no query uses to compare with the minimum usage
+func keptAll(int) bool { return true }
+
+// limitRanked is limitSection for the items already ranked: it keeps the top first items with enough uses
+func limitRanked[T any](items []T, top int, uses func(T) int, kept func(int) bool) ([]T, int) {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if top > 0 && len(result) == top {
+			break
+		}
+		if kept(uses(item)) {
+			result = append(result, item)
+		}
+	}
+	if len(result) == len(items) {
+		return items, 0
+	}
+	return result, len(items) - len(result)
+}
+
+// limitSection keeps the items with enough uses, and the top most used of them when top is set,
+// in the order they were in. It returns the kept items and the number of items left out.
+func limitSection[T any](items []T, top int, uses func(T) int, kept func(int) bool) ([]T, int) {
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		if kept(uses(item)) {
+			indexes = append(indexes, i)
+		}
+	}
+	if top > 0 && len(indexes) > top {
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return uses(items[indexes[i]]) > uses(items[indexes[j]])
+		})
+		indexes = indexes[:top]
+		sort.Ints(indexes)
+	}
+	if len(indexes) == len(items) {
+		return items, 0
+	}
+	result := make([]T, 0, len(indexes))
+	for _, i := range indexes {
+		result = append(result, items[i])
+	}
+	return result, len(items) - len(result)
+}
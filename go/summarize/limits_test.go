@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitSection(t *testing.T) {
+	uses := func(i int) int { return i }
+	all := func(int) bool { return true }
+
+	// the most used items are kept, in their order
+	kept, omitted := limitSection([]int{3, 9, 1, 7, 5}, 3, uses, all)
+	require.Equal(t, []int{9, 7, 5}, kept)
+	require.Equal(t, 2, omitted)
+
+	kept, omitted = limitSection([]int{3, 9, 1, 7, 5}, 0, uses, func(n int) bool { return n >= 5 })
+	require.Equal(t, []int{9, 7, 5}, kept)
+	require.Equal(t, 2, omitted)
+
+	kept, omitted = limitSection([]int{3, 9}, 5, uses, all)
+	require.Equal(t, []int{3, 9}, kept)
+	require.Zero(t, omitted)
+}
+
+func TestReportLimits(t *testing.T) {
+	report := newKeysReport(reportTestFile(), ReportLimits{TopTables: 1, MinUsagePercentage: 50}, keysInputs{})
+	require.Len(t, report.Tables, 1)
+	require.Equal(t, "t", report.Tables[0].Table)
+	require.Len(t, report.TableSummaries, 1)
+	require.Equal(t, "t", report.TableSummaries[0].Table)
+	// the query used by 25% of the query uses is left out
+	require.Len(t, report.HotQueries, 1)
+	require.Empty(t, report.FullScans)
+	require.Equal(t, omitted{Tables: 1, HotQueries: 1, TableSummaries: 1, FullScans: 1, FailureTypes: 1, ShardingKeys: 1}, report.Omitted)
+
+	// the sections of the files given along the keys file are limited too
+	inputs := keysInputs{tenancy: TenancyConfig{"t": "tenant_id"}, tested: map[string]bool{}}
+	report = newKeysReport(reportTestFile(), ReportLimits{TopQueries: 1}, inputs)
+	require.Len(t, report.Tenancy.Violations, 1)
+	require.Equal(t, 2, report.Tenancy.Queries)
+	require.Len(t, report.TestCoverage.Uncovered, 1)
+	require.Equal(t, 1, report.Omitted.TenancyViolations)
+	require.Equal(t, 1, report.Omitted.Uncovered)
+	sb := &strings.Builder{}
+	printMarkdownReport(sb, reportTestFile(), ReportLimits{TopQueries: 1}, inputs)
+	require.Contains(t, sb.String(), "2 query structures, used 4 times, do not filter on the tenancy column.")
+
+	sb.Reset()
+	printMarkdownReport(sb, reportTestFile(), ReportLimits{TopQueries: 1}, keysInputs{})
+	require.Contains(t, sb.String(), "| SELECT         |           3 | 75.00% |\n\nand 1 more...\n")
+
+	sb.Reset()
+	require.NoError(t, printHTMLReport(sb, reportTestFile(), ReportLimits{TopQueries: 1}, keysInputs{}))
+	require.Contains(t, sb.String(), `<p class="more">and 1 more...</p>`)
+}
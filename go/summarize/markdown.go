@@ -0,0 +1,429 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/vitessio/vt/go/internal/markdown"
+)
+
+// printMarkdownReport writes the summary of a keys file as markdown, to paste in an issue or a document
+func printMarkdownReport(out io.Writer, file readingSummary, limits ReportLimits, inputs keysInputs) {
+	report := newKeysReport(file, limits, inputs)
+	fmt.Fprintf(out, "# Summary from trace file %s\n", report.Name)
+	if note := report.SampleNote(); note != "" {
+		fmt.Fprintf(out, "\n%s\n", note)
+	}
+
+	if len(report.Tables) > 0 {
+		fmt.Fprint(out, "\n## Tables\n\n")
+		table := markdown.Table(out, []string{"Table", "Reads", "Writes"})
+		for _, t := range report.Tables {
+			table.Append([]string{t.Table, strconv.Itoa(t.Reads), strconv.Itoa(t.Writes)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Tables)
+	}
+
+	if len(report.HotQueries) > 0 {
+		fmt.Fprint(out, "\n## Hot queries\n\n")
+		columns := []string{"Query", "Statement Type", "Usage Count", "%"}
+		if report.HotMetric != "" {
+			fmt.Fprintf(out, "Ranked by %s.\n\n", report.HotMetric)
+			columns = append(columns, "Score")
+		}
+		table := markdown.Table(out, columns)
+		for _, q := range report.HotQueries {
+			row := []string{markdown.Cell(q.Query), q.StatementType, strconv.Itoa(q.UsageCount), fmt.Sprintf("%.2f%%", q.Percentage)}
+			if report.HotMetric != "" {
+				row = append(row, fmt.Sprintf("%.3f", q.Score))
+			}
+			table.Append(row)
+		}
+		table.Render()
+		printMore(out, report.Omitted.HotQueries)
+	}
+
+	for _, summary := range report.TableSummaries {
+		fmt.Fprintf(out, "\n## Table: %s used in %d queries\n", summary.Table, summary.QueryCount)
+		if len(summary.Columns) > 0 {
+			fmt.Fprintln(out)
+			table := markdown.Table(out, []string{"Column", "Filter %", "Grouping %", "Join %"})
+			for _, c := range summary.Columns {
+				table.Append([]string{c.Name, fmt.Sprintf("%.2f%%", c.Filter), fmt.Sprintf("%.2f%%", c.Grouping), fmt.Sprintf("%.2f%%", c.Join)})
+			}
+			table.Render()
+		}
+		if len(summary.JoinPredicates) > 0 {
+			fmt.Fprint(out, "\nJoin predicates:\n\n")
+			for _, predicate := range summary.JoinPredicates {
+				fmt.Fprintf(out, "- %s\n", predicate)
+			}
+		}
+	}
+	printMore(out, report.Omitted.TableSummaries)
+	printMarkdownUsage(out, report)
+
+	if len(report.FullScans) > 0 {
+		fmt.Fprint(out, "\n## Full scan candidates\n\n")
+		table := markdown.Table(out, []string{"Query", "Tables", "Usage Count"})
+		for _, scan := range report.FullScans {
+			table.Append([]string{markdown.Cell(scan.QueryStructure), strings.Join(scan.Tables, ", "), strconv.Itoa(scan.UsageCount)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.FullScans)
+	}
+
+	if len(report.FunctionFilters) > 0 {
+		fmt.Fprint(out, "\n## Filters on a function of a column\n\n")
+		table := markdown.Table(out, []string{"Query", "Filters", "Usage Count"})
+		for _, filter := range report.FunctionFilters {
+			table.Append([]string{markdown.Cell(filter.QueryStructure), markdown.Cell(strings.Join(filter.Filters, ", ")), strconv.Itoa(filter.UsageCount)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.FunctionFilters)
+	}
+
+	if len(report.Values) > 0 {
+		fmt.Fprint(out, "\n## Values of the filter columns\n\nA good sharding key has many distinct values and no value used much more than the others.\n\n")
+		table := markdown.Table(out, []string{"Column", "Uses", "Distinct Values", "Top Value %", "Top Values"})
+		for _, v := range report.Values {
+			var top []string
+			for _, value := range v.TopValues {
+				top = append(top, fmt.Sprintf("%s (%d)", value.Value, value.Count))
+			}
+			var share string
+			if len(v.TopValues) > 0 && v.Uses > 0 {
+				share = fmt.Sprintf("%.2f%%", float64(v.TopValues[0].Count)/float64(v.Uses)*100)
+			}
+			table.Append([]string{v.Column, strconv.Itoa(v.Uses), strconv.Itoa(v.Distinct), share, markdown.Cell(strings.Join(top, ", "))})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Values)
+	}
+
+	if len(report.Settings) > 0 {
+		fmt.Fprint(out, "\n## Recommended settings\n\n")
+		table := markdown.Table(out, []string{"Component", "Setting", "Advice", "Rationale"})
+		for _, a := range report.Settings {
+			table.Append([]string{a.Component, a.Setting, markdown.Cell(a.Advice), markdown.Cell(a.Rationale)})
+		}
+		table.Render()
+	}
+
+	if len(report.Findings) > 0 {
+		fmt.Fprint(out, "\n## Findings\n\n")
+		table := markdown.Table(out, []string{"Severity", "Category", "Analyzer", "Finding", "Count"})
+		for _, finding := range report.Findings {
+			table.Append([]string{string(finding.Severity), string(finding.Category), finding.Analyzer, markdown.Cell(finding.Message), strconv.Itoa(finding.Count)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Findings)
+	}
+
+	if len(report.FailureTypes) > 0 {
+		fmt.Fprintf(out, "\n## Failures by type of error\n\n%.2f%% of the workload could not be analysed.\n\n", report.FailedPercentage)
+		table := markdown.Table(out, []string{"Error Type", "Queries", "%", "Sample Query"})
+		for _, t := range report.FailureTypes {
+			table.Append([]string{markdown.Cell(t.Type), strconv.Itoa(t.Count), fmt.Sprintf("%.2f%%", t.Percentage), markdown.Cell(t.Samples[0])})
+		}
+		table.Render()
+		printMore(out, report.Omitted.FailureTypes)
+	}
+
+	if len(report.Failures) > 0 {
+		fmt.Fprintf(out, "\n## The %d following queries have failed\n\n", len(report.Failures))
+		table := markdown.Table(out, []string{"Query", "Error"})
+		for _, failure := range report.Failures {
+			table.Append([]string{markdown.Cell(failure.Query), markdown.Cell(failure.Error)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Failures)
+	}
+
+	if len(report.ShardingKeys) > 0 {
+		fmt.Fprint(out, "\n## Sharding key recommendations\n\n")
+		table := markdown.Table(out, []string{"Table", "Sharding Key", "Confidence", "Reasons"})
+		for _, r := range report.ShardingKeys {
+			table.Append([]string{r.Table, r.Column, fmt.Sprintf("%.0f%%", r.Confidence), markdown.Cell(strings.Join(r.Reasons, "; "))})
+		}
+		table.Render()
+		printMore(out, report.Omitted.ShardingKeys)
+	}
+	printMarkdownInputs(out, report)
+}
+
+// printMarkdownUsage writes who runs the queries of the workload, when, and how vtgate routed them
+func printMarkdownUsage(out io.Writer, report keysReport) {
+	if len(report.Hints) > 0 {
+		fmt.Fprint(out, "\n## Query hints\n\n")
+		table := markdown.Table(out, []string{"Hint", "Value", "Usage Count", "% of queries"})
+		for _, hint := range report.Hints {
+			table.Append([]string{hint.Name, markdown.Cell(hint.Value), strconv.Itoa(hint.UsageCount), fmt.Sprintf("%.2f%%", hint.Percentage)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Hints)
+	}
+
+	if len(report.Users) > 0 {
+		fmt.Fprint(out, "\n## Usage per user\n\n")
+		table := markdown.Table(out, []string{"User", "Usage Count", "% of queries", "Query Structures", "Most Used Query"})
+		for _, user := range report.Users {
+			table.Append([]string{
+				markdown.Cell(user.User),
+				strconv.Itoa(user.UsageCount),
+				fmt.Sprintf("%.2f%%", user.Percentage),
+				strconv.Itoa(user.QueryStructures),
+				markdown.Cell(user.MostUsedQuery),
+			})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Users)
+	}
+
+	if len(report.Observed) > 0 {
+		executions, scatter := report.ObservedExecutions, report.ObservedScatter
+		fmt.Fprintf(out, "\n## Observed routing\n\nObserved scatter rate: %.2f%% of %d logged executions.\n\n", float64(scatter)/float64(executions)*100, executions)
+		table := markdown.Table(out, []string{"Query", "Executions", "Avg Shard Queries", "Scatter %", "Plan Types"})
+		for _, o := range report.Observed {
+			table.Append([]string{
+				markdown.Cell(o.QueryStructure),
+				strconv.Itoa(o.Executions),
+				fmt.Sprintf("%.2f", o.AvgShardQueries),
+				fmt.Sprintf("%.2f%%", o.ScatterPercentage),
+				o.PlanTypes,
+			})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Observed)
+	}
+
+	if traffic := report.Traffic; traffic != nil {
+		fmt.Fprintf(out, "\n## Traffic per bucket of %s, in %s\n\n", traffic.BucketSize, traffic.Location)
+		table := markdown.Table(out, []string{"Bucket", "Usage Count", "Query Structures"})
+		for _, bucket := range traffic.Buckets {
+			table.Append([]string{bucket.Start.Format(bucketTimeFormat), strconv.Itoa(bucket.UsageCount), strconv.Itoa(bucket.QueryStructures)})
+		}
+		table.Render()
+		if len(traffic.BatchQueries) > 0 {
+			fmt.Fprintf(out, "\nQuery structures run in %d or fewer of the %d buckets with traffic, such as batch jobs:\n\n", len(traffic.Buckets)/4, len(traffic.Buckets))
+			table := markdown.Table(out, []string{"Query", "Usage Count", "Buckets"})
+			for _, query := range traffic.BatchQueries {
+				table.Append([]string{markdown.Cell(query.QueryStructure), strconv.Itoa(query.UsageCount), strconv.Itoa(query.Buckets)})
+			}
+			table.Render()
+			printMore(out, report.Omitted.BatchQueries)
+		}
+	}
+
+	if len(report.Targets) > 0 {
+		fmt.Fprint(out, "\n## Explicit shard or tablet type targeting\n\nThese queries complicate resharding.\n\n")
+		table := markdown.Table(out, []string{"Target", "Uses"})
+		for _, target := range report.Targets {
+			table.Append([]string{markdown.Cell(target.Target), strconv.Itoa(target.Uses)})
+		}
+		table.Render()
+		printMore(out, report.Omitted.Targets)
+	}
+}
+
+// printMarkdownInputs writes the sections of the files given along the keys file
+func printMarkdownInputs(out io.Writer, report keysReport) {
+	if tenancy := report.Tenancy; tenancy != nil {
+		fmt.Fprint(out, "\n## Tenancy\n\n")
+		if tenancy.Queries == 0 {
+			fmt.Fprintln(out, "All queries filter on the tenancy columns.")
+		} else {
+			fmt.Fprintf(out, "%d query structures, used %d times, do not filter on the tenancy column.\n\n", tenancy.Queries, tenancy.Uses)
+			table := markdown.Table(out, []string{"Table", "Tenancy Column", "Statement", "Usage Count", "Query"})
+			for _, violation := range tenancy.Violations {
+				table.Append([]string{
+					violation.Table,
+					violation.TenancyColumn,
+					violation.StatementType,
+					strconv.Itoa(violation.UsageCount),
+					markdown.Cell(violation.QueryStructure),
+				})
+			}
+			table.Render()
+			printMore(out, report.Omitted.TenancyViolations)
+		}
+	}
+
+	if coverage := report.ShardingKeyCoverage; coverage != nil {
+		fmt.Fprint(out, "\n## Sharding key coverage\n\n")
+		if coverage.Uses == 0 {
+			fmt.Fprintln(out, "No query uses the tables of the sharding keys.")
+		} else {
+			fmt.Fprintf(out, "%.2f%% of the query uses of the tables (%d of %d) constrain their sharding key.\n\n",
+				float64(coverage.Covered)/float64(coverage.Uses)*100, coverage.Covered, coverage.Uses)
+			table := markdown.Table(out, []string{"Table", "Sharding Key", "Query Uses", "Covered", "Coverage %"})
+			for _, c := range coverage.Tables {
+				table.Append([]string{c.Table, c.ShardingKey, strconv.Itoa(c.Uses), strconv.Itoa(c.Covered), percentOf(c.Covered, c.Uses)})
+			}
+			table.Render()
+			printMore(out, report.Omitted.ShardingKeyCoverage)
+		}
+	}
+
+	if coverage := report.TestCoverage; coverage != nil {
+		fmt.Fprint(out, "\n## Test coverage\n\n")
+		if coverage.Uses == 0 {
+			fmt.Fprintln(out, "No query to check the test coverage of.")
+		} else {
+			fmt.Fprintf(out, "%.2f%% of the query uses (%d of %d) and %d of the %d query structures are run by the tests.\n",
+				float64(coverage.CoveredUses)/float64(coverage.Uses)*100, coverage.CoveredUses, coverage.Uses, coverage.CoveredQueries, coverage.Queries)
+			if len(coverage.Uncovered) > 0 {
+				fmt.Fprint(out, "\nThe most used query structures without tests:\n\n")
+				table := markdown.Table(out, []string{"Query", "Usage Count", "Usage %"})
+				for _, query := range coverage.Uncovered {
+					table.Append([]string{markdown.Cell(query.Query), strconv.Itoa(query.UsageCount), fmt.Sprintf("%.2f%%", query.Percentage)})
+				}
+				table.Render()
+				printMore(out, report.Omitted.Uncovered)
+			}
+		}
+	}
+
+	if report.Database != nil {
+		printMarkdownDatabase(out, report.Database, report.Omitted)
+	}
+}
+
+// printMarkdownDatabase writes the sections summarized with the dbinfo file
+func printMarkdownDatabase(out io.Writer, database *reportDatabase, omitted omitted) {
+	if len(database.QueryWeights) > 0 {
+		var matched int
+		for _, w := range database.QueryWeights {
+			if w.Matched {
+				matched++
+			}
+		}
+		fmt.Fprint(out, "\n## Query statistics from performance_schema\n\n")
+		fmt.Fprintf(out, "%d of the %d query structures match a query of the keys file and are weighted by their execution counts", matched, len(database.QueryWeights))
+		columns := []string{"Query", "In Keys File", "Executions", "Total Latency (ms)", "Avg Latency (ms)", "Rows Examined"}
+		if database.HotMetric != "" {
+			fmt.Fprintf(out, ", ranked by %s", database.HotMetric)
+			columns = append(columns, "Score")
+		}
+		fmt.Fprint(out, ".\n\n")
+		table := markdown.Table(out, columns)
+		for _, w := range database.QueryWeights {
+			inKeys := "no"
+			if w.Matched {
+				inKeys = "yes"
+			}
+			row := []string{
+				markdown.Cell(w.Query),
+				inKeys,
+				strconv.Itoa(w.Executions),
+				fmt.Sprintf("%.3f", w.TotalLatency),
+				fmt.Sprintf("%.3f", w.AvgLatency),
+				strconv.Itoa(w.RowsExamined),
+			}
+			if database.HotMetric != "" {
+				row = append(row, fmt.Sprintf("%.3f", w.Score))
+			}
+			table.Append(row)
+		}
+		table.Render()
+		printMore(out, omitted.QueryWeights)
+	}
+
+	if len(database.AutoIncrements) > 0 {
+		fmt.Fprintf(out, "\n## Tables that used more than %.0f%% of the range of their auto-increment column\n\n", autoIncrementWarning*100)
+		table := markdown.Table(out, []string{"Table", "Column", "Type", "Next Value", "Used"})
+		for _, usage := range database.AutoIncrements {
+			table.Append([]string{usage.Table, usage.Column, usage.Type, strconv.FormatUint(usage.Next, 10), fmt.Sprintf("%.2f%%", usage.Share()*100)})
+		}
+		table.Render()
+		printMore(out, omitted.AutoIncrements)
+	}
+
+	if len(database.PartitionedTables) > 0 {
+		fmt.Fprint(out, "\n## Partitioned tables\n\nTheir partitioning has to be compatible with the sharding scheme.\n\n")
+		table := markdown.Table(out, []string{"Table", "Method", "Expression", "Partitions"})
+		for _, t := range database.PartitionedTables {
+			table.Append([]string{t.Table, t.Method, markdown.Cell(t.Expression), strconv.Itoa(t.Partitions)})
+		}
+		table.Render()
+		printMore(out, omitted.PartitionedTables)
+	}
+
+	fmt.Fprint(out, "\n## Indexes\n\n")
+	if len(database.UnindexedFilters) == 0 {
+		fmt.Fprintln(out, "All the filter columns are indexed.")
+	} else {
+		fmt.Fprintf(out, "%d filter columns are not the first column of an index.\n\n", len(database.UnindexedFilters)+omitted.UnindexedFilters)
+		table := markdown.Table(out, []string{"Table", "Column", "Filter Uses", "Table Rows"})
+		for _, filter := range database.UnindexedFilters {
+			table.Append([]string{filter.Table, filter.Column, strconv.Itoa(filter.Uses), strconv.Itoa(filter.Rows)})
+		}
+		table.Render()
+		printMore(out, omitted.UnindexedFilters)
+	}
+	if len(database.IndexSuggestions) > 0 {
+		fmt.Fprint(out, "\nSuggested indexes, the most used first:\n\n")
+		table := markdown.Table(out, []string{"Statement", "Uses", "Table Rows"})
+		for _, suggestion := range database.IndexSuggestions {
+			table.Append([]string{markdown.Cell(suggestion.Statement), strconv.Itoa(suggestion.Uses), strconv.Itoa(suggestion.Rows)})
+		}
+		table.Render()
+		printMore(out, omitted.IndexSuggestions)
+	}
+
+	fmt.Fprint(out, "\n## Unused tables and columns\n\n")
+	if len(database.UnusedTables) == 0 {
+		fmt.Fprintln(out, "All the tables are used by the queries.")
+	} else {
+		fmt.Fprintf(out, "%d tables are not used by any query, they can be sharded in any way or dropped.\n\n", len(database.UnusedTables)+omitted.UnusedTables)
+		table := markdown.Table(out, []string{"Table", "Table Rows"})
+		for _, unused := range database.UnusedTables {
+			table.Append([]string{unused.Table, strconv.Itoa(unused.Rows)})
+		}
+		table.Render()
+		printMore(out, omitted.UnusedTables)
+	}
+	if len(database.UnusedColumns) > 0 {
+		fmt.Fprint(out, "\nColumns no query filters, joins, groups or orders on (columns only read in the select list are not known):\n\n")
+		table := markdown.Table(out, []string{"Table", "Columns"})
+		for _, unused := range database.UnusedColumns {
+			table.Append([]string{unused.Table, strings.Join(unused.Columns, ", ")})
+		}
+		table.Render()
+		printMore(out, omitted.UnusedColumns)
+	}
+}
+
+// printMore ends a section the limits shortened
+func printMore(out io.Writer, omitted int) {
+	if omitted > 0 {
+		fmt.Fprintf(out, "\nand %d more...\n", omitted)
+	}
+}
+
+// percentOf is part as a percentage of whole, or - when whole is 0
+func percentOf(part, whole int) string {
+	if whole == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(part)/float64(whole)*100)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintMarkdownReport(t *testing.T) {
+	sb := &strings.Builder{}
+	printMarkdownReport(sb, reportTestFile(), ReportLimits{}, keysInputs{})
+	// ~ stands for the backticks of the query structures
+	expected := `# Summary from trace file keys.json
+
+Usage counts are estimated from a 50.00% sample of the query log
+
+## Tables
+
+| Table | Reads | Writes |
+|-------|-------|--------|
+| t     |     4 |      0 |
+| u     |     1 |      0 |
+
+## Hot queries
+
+| Query                                                                  | Statement Type | Usage Count | %      |
+|------------------------------------------------------------------------|----------------|-------------|--------|
+| SELECT * FROM ~t~ JOIN ~u~ ON ~t~.~id~ = ~u~.~t_id~ WHERE ~t~.~a~ < :a | SELECT         |           3 | 75.00% |
+| SELECT * FROM ~t~                                                      | SELECT         |           1 | 25.00% |
+
+## Table: t used in 4 queries
+
+| Column | Filter % | Grouping % | Join % |
+|--------|----------|------------|--------|
+| a      | 75.00%   | 0.00%      | 0.00%  |
+
+Join predicates:
+
+- t.id = u.t_id
+
+## Table: u used in 3 queries
+
+Join predicates:
+
+- t.id = u.t_id
+
+## Usage per user
+
+| User | Usage Count | % of queries | Query Structures | Most Used Query                                                        |
+|------|-------------|--------------|------------------|------------------------------------------------------------------------|
+| app  |           3 | 75.00%       |                1 | SELECT * FROM ~t~ JOIN ~u~ ON ~t~.~id~ = ~u~.~t_id~ WHERE ~t~.~a~ < :a |
+
+## Traffic per bucket of 1h0m0s, in UTC
+
+| Bucket              | Usage Count | Query Structures |
+|---------------------|-------------|------------------|
+| 2024-10-01 08:00:00 |           3 |                1 |
+
+## Full scan candidates
+
+| Query             | Tables | Usage Count |
+|-------------------|--------|-------------|
+| SELECT * FROM ~t~ | t      |           1 |
+
+## Values of the filter columns
+
+A good sharding key has many distinct values and no value used much more than the others.
+
+| Column | Uses | Distinct Values | Top Value % | Top Values   |
+|--------|------|-----------------|-------------|--------------|
+| t.a    |    3 |               2 | 66.67%      | 1 (2), 2 (1) |
+
+## Recommended settings
+
+| Component | Setting               | Advice                                                                                                               | Rationale                                                                                                                    |
+|-----------|-----------------------|----------------------------------------------------------------------------------------------------------------------|------------------------------------------------------------------------------------------------------------------------------|
+| session   | SET workload = 'olap' | run these queries in OLAP sessions, or raise --queryserver-config-max-result-size on vttablet, 10000 rows by default | 25.00% of query uses (1 of 4) read whole tables without a LIMIT, OLTP sessions fail when the result is larger than the limit |
+
+## Failures by type of error
+
+20.00% of the workload could not be analysed.
+
+| Error Type   | Queries | %      | Sample Query    |
+|--------------|---------|--------|-----------------|
+| syntax error |       1 | 20.00% | select <script> |
+
+## The 1 following queries have failed
+
+| Query           | Error        |
+|-----------------|--------------|
+| select <script> | syntax error |
+
+## Sharding key recommendations
+
+| Table | Sharding Key | Confidence | Reasons                                                                         |
+|-------|--------------|------------|---------------------------------------------------------------------------------|
+| t     | id           | 22%        | constrained by 0.00% of the query uses; 75.00% of the query uses join to u.t_id |
+| u     | t_id         | 30%        | constrained by 0.00% of the query uses; 100.00% of the query uses join to t.id  |
+`
+	require.Equal(t, strings.ReplaceAll(expected, "~", "`"), sb.String())
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// traceMatrix compares the queries of a baseline trace file with several other trace files, such as the same
+// workload traced with candidate vschemas. A configuration is better for a query when it is cheaper on the
+// metrics in this order: route calls, rows sent, rows in memory and shards queried.
+type traceMatrix struct {
+	names []string
+	// best and worst are the number of queries each file is the best and the worst configuration of
+	best, worst []int
+	// totals are the metrics of each file, summed over the compared queries
+	totals         []QuerySummary
+	totalQueries   int
+	sameEverywhere int
+}
+
+// compareTraceMatrix prints the metrics of every query of the first file that all the files traced, one column per file,
+// and which files are the best and the worst configuration of the query. It ends with how often each file was the best.
+func compareTraceMatrix(out io.Writer, termWidth int, highLighter Highlighter, files []readingSummary) {
+	summaries := make([]map[string]QuerySummary, len(files))
+	m := &traceMatrix{
+		best:   make([]int, len(files)),
+		worst:  make([]int, len(files)),
+		totals: make([]QuerySummary, len(files)),
+	}
+	for i, file := range files {
+		summaries[i] = summarizeTraces(file)
+		m.names = append(m.names, file.Name)
+	}
+
+	for _, q := range files[0].TracedQueries {
+		var row []QuerySummary
+		for _, summary := range summaries {
+			s, found := summary[q.Query]
+			if !found {
+				break
+			}
+			row = append(row, s)
+		}
+		if len(row) != len(files) {
+			continue
+		}
+		m.add(out, termWidth, highLighter, row)
+	}
+	m.printSummary(out)
+}
+
+// add prints the comparison of a query, row holds its summary in every file
+func (m *traceMatrix) add(out io.Writer, termWidth int, highLighter Highlighter, row []QuerySummary) {
+	m.totalQueries++
+	for i, s := range row {
+		m.totals[i].RouteCalls += s.RouteCalls
+		m.totals[i].RowsSent += s.RowsSent
+		m.totals[i].RowsInMemory += s.RowsInMemory
+		m.totals[i].ShardsQueried += s.ShardsQueried
+	}
+
+	best, worst := m.extremes(row)
+	same := len(best) == len(row)
+	printQuery(out, termWidth, highLighter, row[0].Q, !same)
+	m.renderMetrics(out, row)
+	if same {
+		m.sameEverywhere++
+		fmt.Fprintln(out, "All the files cost the same")
+	} else {
+		for _, i := range best {
+			m.best[i]++
+		}
+		for _, i := range worst {
+			m.worst[i]++
+		}
+		fmt.Fprintf(out, "Best: %s, worst: %s\n", m.join(best), m.join(worst))
+	}
+	fmt.Fprintln(out)
+}
+
+// extremes returns the indexes of the cheapest and of the most expensive summaries of the row
+func (m *traceMatrix) extremes(row []QuerySummary) (best, worst []int) {
+	for i := range row {
+		switch {
+		case len(best) == 0 || compareCost(row[i], row[best[0]]) < 0:
+			best = []int{i}
+		case compareCost(row[i], row[best[0]]) == 0:
+			best = append(best, i)
+		}
+		switch {
+		case len(worst) == 0 || compareCost(row[i], row[worst[0]]) > 0:
+			worst = []int{i}
+		case compareCost(row[i], row[worst[0]]) == 0:
+			worst = append(worst, i)
+		}
+	}
+	return best, worst
+}
+
+func compareCost(a, b QuerySummary) int {
+	return cmp.Or(
+		cmp.Compare(a.RouteCalls, b.RouteCalls),
+		cmp.Compare(a.RowsSent, b.RowsSent),
+		cmp.Compare(a.RowsInMemory, b.RowsInMemory),
+		cmp.Compare(a.ShardsQueried, b.ShardsQueried),
+	)
+}
+
+func (m *traceMatrix) join(indexes []int) string {
+	names := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		names = append(names, m.names[i])
+	}
+	return strings.Join(names, ", ")
+}
+
+func (m *traceMatrix) renderMetrics(out io.Writer, row []QuerySummary) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader(append([]string{"Metric"}, m.names...))
+	table.SetAutoFormatHeaders(false)
+	metrics := []struct {
+		name  string
+		value func(QuerySummary) int
+	}{
+		{"Route Calls", func(s QuerySummary) int { return s.RouteCalls }},
+		{"Rows Sent", func(s QuerySummary) int { return s.RowsSent }},
+		{"Rows In Memory", func(s QuerySummary) int { return s.RowsInMemory }},
+		{"Shards Queried", func(s QuerySummary) int { return s.ShardsQueried }},
+	}
+	for _, metric := range metrics {
+		line := []string{metric.name}
+		for _, s := range row {
+			line = append(line, strconv.Itoa(metric.value(s)))
+		}
+		table.Append(line)
+	}
+	table.Render()
+}
+
+func (m *traceMatrix) printSummary(out io.Writer) {
+	fmt.Fprintln(out, "Summary:")
+	fmt.Fprintf(out, "- %d queries compared, %d of them cost the same in all the files\n", m.totalQueries, m.sameEverywhere)
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"File", "Best For", "Worst For", "Route Calls", "Rows Sent", "Rows In Memory", "Shards Queried"})
+	table.SetAutoFormatHeaders(false)
+	for i, name := range m.names {
+		total := m.totals[i]
+		table.Append([]string{
+			name,
+			strconv.Itoa(m.best[i]),
+			strconv.Itoa(m.worst[i]),
+			strconv.Itoa(total.RouteCalls),
+			strconv.Itoa(total.RowsSent),
+			strconv.Itoa(total.RowsInMemory),
+			strconv.Itoa(total.ShardsQueried),
+		})
+	}
+	table.Render()
+	// a file is only named when no other file is the best configuration of as many queries
+	best, tied := 0, false
+	for i, count := range m.best[1:] {
+		switch {
+		case count > m.best[best]:
+			best, tied = i+1, false
+		case count == m.best[best]:
+			tied = true
+		}
+	}
+	if m.best[best] > 0 && !tied {
+		fmt.Fprintf(out, "- %s is the best configuration of the most queries\n", m.names[best])
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareTraceMatrix(t *testing.T) {
+	baseline, candidate1, candidate2 := tf1(), tf2(), tf1()
+	baseline.Name, candidate1.Name, candidate2.Name = "baseline", "vschema1", "vschema2"
+	// the queries missing from a file are not compared
+	baseline.TracedQueries = append(baseline.TracedQueries, TracedQuery{Query: "select 1", LineNumber: "3"}, TracedQuery{Query: "select 2", LineNumber: "4"})
+	candidate1.TracedQueries = append(candidate1.TracedQueries, TracedQuery{Query: "select 2", LineNumber: "3"})
+	candidate2.TracedQueries = append(candidate2.TracedQueries, TracedQuery{Query: "select 2", LineNumber: "3"})
+	candidate2.TracedQueries[1].Trace.Inputs[0].Inputs[1].NoOfCalls = 5
+
+	sb := &strings.Builder{}
+	compareTraceMatrix(sb, 80, noHighlight, []readingSummary{baseline, candidate1, candidate2})
+	assert.Equal(t, `Query: select * from music
+Line # 1 (significant)
++----------------+----------+----------+----------+
+|     Metric     | baseline | vschema1 | vschema2 |
++----------------+----------+----------+----------+
+| Route Calls    |        1 |        1 |        1 |
+| Rows Sent      |       16 |       16 |       16 |
+| Rows In Memory |        0 |        0 |        0 |
+| Shards Queried |        8 |        7 |        8 |
++----------------+----------+----------+----------+
+Best: vschema1, worst: baseline, vschema2
+
+Query: select tbl.foo, tbl2.bar from tbl join tbl2 on tbl.id = tbl2.id order ...
+Line # 2 (significant)
++----------------+----------+----------+----------+
+|     Metric     | baseline | vschema1 | vschema2 |
++----------------+----------+----------+----------+
+| Route Calls    |       11 |        1 |        6 |
+| Rows Sent      |       20 |       16 |       15 |
+| Rows In Memory |       16 |        0 |       16 |
+| Shards Queried |       18 |        8 |       18 |
++----------------+----------+----------+----------+
+Best: vschema1, worst: baseline
+
+Query: select 2
+Line # 4
++----------------+----------+----------+----------+
+|     Metric     | baseline | vschema1 | vschema2 |
++----------------+----------+----------+----------+
+| Route Calls    |        0 |        0 |        0 |
+| Rows Sent      |        0 |        0 |        0 |
+| Rows In Memory |        0 |        0 |        0 |
+| Shards Queried |        0 |        0 |        0 |
++----------------+----------+----------+----------+
+All the files cost the same
+
+Summary:
+- 3 queries compared, 1 of them cost the same in all the files
++----------+----------+-----------+-------------+-----------+----------------+----------------+
+|   File   | Best For | Worst For | Route Calls | Rows Sent | Rows In Memory | Shards Queried |
++----------+----------+-----------+-------------+-----------+----------------+----------------+
+| baseline |        0 |         2 |          12 |        36 |             16 |             26 |
+| vschema1 |        2 |         0 |           2 |        32 |              0 |             15 |
+| vschema2 |        0 |         1 |           7 |        31 |             16 |             26 |
++----------+----------+-----------+-------------+-----------+----------------+----------------+
+- vschema1 is the best configuration of the most queries
+`, sb.String())
+}
@@ -0,0 +1,474 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The PDF report is laid out on A4 pages, in points. It only uses the standard fonts every PDF reader has,
+// so no font is embedded, and the tables are drawn as text in the monospaced font to keep their columns aligned.
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 40
+
+	pdfTitleSize   = 14
+	pdfHeadingSize = 11
+	pdfTextSize    = 9
+	pdfTableSize   = 7
+
+	// pdfTableChars is the number of characters of the monospaced font that fit in a line of a table,
+	// every character being 0.6 times the font size wide
+	pdfTableChars = (pdfPageWidth - 2*pdfMargin) * 10 / (6 * pdfTableSize)
+	// pdfTextChars is an estimate of the number of characters of the proportional font that fit in a line of text
+	pdfTextChars = (pdfPageWidth - 2*pdfMargin) * 2 / pdfTextSize
+	// pdfQueryChars is the length queries are cut to in the tables
+	pdfQueryChars = 60
+)
+
+type (
+	// pdfDocument lays out the lines of the PDF report, starting a new page when the current one is full
+	pdfDocument struct {
+		title string
+		pages [][]pdfLine
+		// y is where the next line goes on the current page, from the bottom of the page
+		y float64
+	}
+
+	pdfLine struct {
+		font string
+		size float64
+		x, y float64
+		text string
+	}
+)
+
+// The font resources of the pages
+const (
+	pdfRegular   = "F1"
+	pdfBold      = "F2"
+	pdfMonospace = "F3"
+)
+
+// printPDFReport writes the summary of a keys file as a PDF document, for migration proposals and the people
+// who don't read markdown. It has the sections of the markdown report, the recommended sharding keys,
+// and the sections of the files given along the keys file.
+func printPDFReport(out io.Writer, file readingSummary, limits ReportLimits, inputs keysInputs) error {
+	report := newKeysReport(file, limits, inputs)
+	doc := &pdfDocument{title: "Summary from trace file " + report.Name}
+	doc.line(pdfBold, pdfTitleSize, doc.title)
+	if note := report.SampleNote(); note != "" {
+		doc.text(note)
+	}
+
+	if len(report.Tables) > 0 {
+		doc.heading("Tables")
+		rows := make([][]string, 0, len(report.Tables))
+		for _, t := range report.Tables {
+			rows = append(rows, []string{t.Table, strconv.Itoa(t.Reads), strconv.Itoa(t.Writes)})
+		}
+		doc.table([]string{"Table", "Reads", "Writes"}, rows)
+		doc.more(report.Omitted.Tables)
+	}
+
+	if len(report.HotQueries) > 0 {
+		doc.heading("Hot queries")
+		columns := []string{"Query", "Statement Type", "Usage Count", "%"}
+		if report.HotMetric != "" {
+			doc.text("Ranked by " + report.HotMetric + ".")
+			columns = append(columns, "Score")
+		}
+		rows := make([][]string, 0, len(report.HotQueries))
+		for _, q := range report.HotQueries {
+			row := []string{pdfCell(q.Query), q.StatementType, strconv.Itoa(q.UsageCount), fmt.Sprintf("%.2f%%", q.Percentage)}
+			if report.HotMetric != "" {
+				row = append(row, fmt.Sprintf("%.3f", q.Score))
+			}
+			rows = append(rows, row)
+		}
+		doc.table(columns, rows)
+		doc.more(report.Omitted.HotQueries)
+	}
+
+	if len(report.ShardingKeys) > 0 {
+		doc.heading("Sharding key recommendations")
+		rows := make([][]string, 0, len(report.ShardingKeys))
+		for _, r := range report.ShardingKeys {
+			rows = append(rows, []string{r.Table, r.Column, fmt.Sprintf("%.0f", r.Confidence), pdfCell(strings.Join(r.Reasons, ", "))})
+		}
+		doc.table([]string{"Table", "Column", "Confidence", "Reasons"}, rows)
+		doc.more(report.Omitted.ShardingKeys)
+	}
+
+	for _, summary := range report.TableSummaries {
+		doc.heading(fmt.Sprintf("Table: %s used in %d queries", summary.Table, summary.QueryCount))
+		if len(summary.Columns) > 0 {
+			rows := make([][]string, 0, len(summary.Columns))
+			for _, c := range summary.Columns {
+				rows = append(rows, []string{c.Name, fmt.Sprintf("%.2f%%", c.Filter), fmt.Sprintf("%.2f%%", c.Grouping), fmt.Sprintf("%.2f%%", c.Join)})
+			}
+			doc.table([]string{"Column", "Filter %", "Grouping %", "Join %"}, rows)
+		}
+		if len(summary.JoinPredicates) > 0 {
+			doc.text("Join predicates: " + strings.Join(summary.JoinPredicates, ", "))
+		}
+	}
+	doc.more(report.Omitted.TableSummaries)
+
+	if len(report.FullScans) > 0 {
+		doc.heading("Full scan candidates")
+		rows := make([][]string, 0, len(report.FullScans))
+		for _, scan := range report.FullScans {
+			rows = append(rows, []string{pdfCell(scan.QueryStructure), strings.Join(scan.Tables, ", "), strconv.Itoa(scan.UsageCount)})
+		}
+		doc.table([]string{"Query", "Tables", "Usage Count"}, rows)
+		doc.more(report.Omitted.FullScans)
+	}
+
+	if len(report.FunctionFilters) > 0 {
+		doc.heading("Filters on a function of a column")
+		rows := make([][]string, 0, len(report.FunctionFilters))
+		for _, filter := range report.FunctionFilters {
+			rows = append(rows, []string{pdfCell(filter.QueryStructure), pdfCell(strings.Join(filter.Filters, ", ")), strconv.Itoa(filter.UsageCount)})
+		}
+		doc.table([]string{"Query", "Filters", "Usage Count"}, rows)
+		doc.more(report.Omitted.FunctionFilters)
+	}
+
+	if len(report.Findings) > 0 {
+		doc.heading("Findings")
+		rows := make([][]string, 0, len(report.Findings))
+		for _, finding := range report.Findings {
+			rows = append(rows, []string{string(finding.Severity), string(finding.Category), finding.Analyzer, pdfCell(finding.Message), strconv.Itoa(finding.Count)})
+		}
+		doc.table([]string{"Severity", "Category", "Analyzer", "Finding", "Count"}, rows)
+		doc.more(report.Omitted.Findings)
+	}
+
+	if len(report.FailureTypes) > 0 {
+		doc.heading("Failures by type of error")
+		doc.text(fmt.Sprintf("%.2f%% of the workload could not be analysed.", report.FailedPercentage))
+		rows := make([][]string, 0, len(report.FailureTypes))
+		for _, t := range report.FailureTypes {
+			rows = append(rows, []string{pdfCell(t.Type), strconv.Itoa(t.Count), fmt.Sprintf("%.2f%%", t.Percentage), pdfCell(t.Samples[0])})
+		}
+		doc.table([]string{"Error Type", "Queries", "%", "Sample Query"}, rows)
+		doc.more(report.Omitted.FailureTypes)
+	}
+
+	if len(report.Failures) > 0 {
+		doc.heading(fmt.Sprintf("The %d following queries have failed", len(report.Failures)))
+		rows := make([][]string, 0, len(report.Failures))
+		for _, failure := range report.Failures {
+			rows = append(rows, []string{pdfCell(failure.Query), pdfCell(failure.Error)})
+		}
+		doc.table([]string{"Query", "Error"}, rows)
+		doc.more(report.Omitted.Failures)
+	}
+	doc.inputs(report)
+
+	_, err := out.Write(doc.bytes())
+	return err
+}
+
+// inputs writes the sections of the files given along the keys file
+func (d *pdfDocument) inputs(report keysReport) {
+	if tenancy := report.Tenancy; tenancy != nil {
+		d.heading("Tenancy")
+		if tenancy.Queries == 0 {
+			d.text("All queries filter on the tenancy columns.")
+		} else {
+			d.text(fmt.Sprintf("%d query structures, used %d times, do not filter on the tenancy column.", tenancy.Queries, tenancy.Uses))
+			rows := make([][]string, 0, len(tenancy.Violations))
+			for _, v := range tenancy.Violations {
+				rows = append(rows, []string{v.Table, v.TenancyColumn, v.StatementType, strconv.Itoa(v.UsageCount), pdfCell(v.QueryStructure)})
+			}
+			d.table([]string{"Table", "Tenancy Column", "Statement", "Usage Count", "Query"}, rows)
+			d.more(report.Omitted.TenancyViolations)
+		}
+	}
+
+	if coverage := report.ShardingKeyCoverage; coverage != nil {
+		d.heading("Sharding key coverage")
+		if coverage.Uses == 0 {
+			d.text("No query uses the tables of the sharding keys.")
+		} else {
+			d.text(fmt.Sprintf("%.2f%% of the query uses of the tables (%d of %d) constrain their sharding key.",
+				float64(coverage.Covered)/float64(coverage.Uses)*100, coverage.Covered, coverage.Uses))
+			rows := make([][]string, 0, len(coverage.Tables))
+			for _, c := range coverage.Tables {
+				rows = append(rows, []string{c.Table, c.ShardingKey, strconv.Itoa(c.Uses), strconv.Itoa(c.Covered), percentOf(c.Covered, c.Uses)})
+			}
+			d.table([]string{"Table", "Sharding Key", "Query Uses", "Covered", "Coverage %"}, rows)
+			d.more(report.Omitted.ShardingKeyCoverage)
+		}
+	}
+
+	if coverage := report.TestCoverage; coverage != nil {
+		d.heading("Test coverage")
+		if coverage.Uses == 0 {
+			d.text("No query to check the test coverage of.")
+		} else {
+			d.text(fmt.Sprintf("%.2f%% of the query uses (%d of %d) and %d of the %d query structures are run by the tests.",
+				float64(coverage.CoveredUses)/float64(coverage.Uses)*100, coverage.CoveredUses, coverage.Uses, coverage.CoveredQueries, coverage.Queries))
+			if len(coverage.Uncovered) > 0 {
+				rows := make([][]string, 0, len(coverage.Uncovered))
+				for _, q := range coverage.Uncovered {
+					rows = append(rows, []string{pdfCell(q.Query), strconv.Itoa(q.UsageCount), fmt.Sprintf("%.2f%%", q.Percentage)})
+				}
+				d.table([]string{"Query without tests", "Usage Count", "Usage %"}, rows)
+				d.more(report.Omitted.Uncovered)
+			}
+		}
+	}
+
+	if report.Database != nil {
+		d.database(report.Database, report.Omitted)
+	}
+}
+
+// database writes the sections summarized with the dbinfo file
+func (d *pdfDocument) database(database *reportDatabase, omitted omitted) {
+	if len(database.QueryWeights) > 0 {
+		d.heading("Query statistics from performance_schema")
+		columns := []string{"Query", "In Keys File", "Executions", "Total Latency (ms)", "Avg Latency (ms)", "Rows Examined"}
+		if database.HotMetric != "" {
+			d.text("Ranked by " + database.HotMetric + ".")
+			columns = append(columns, "Score")
+		}
+		rows := make([][]string, 0, len(database.QueryWeights))
+		for _, w := range database.QueryWeights {
+			inKeys := "no"
+			if w.Matched {
+				inKeys = "yes"
+			}
+			row := []string{pdfCell(w.Query), inKeys, strconv.Itoa(w.Executions), fmt.Sprintf("%.3f", w.TotalLatency),
+				fmt.Sprintf("%.3f", w.AvgLatency), strconv.Itoa(w.RowsExamined)}
+			if database.HotMetric != "" {
+				row = append(row, fmt.Sprintf("%.3f", w.Score))
+			}
+			rows = append(rows, row)
+		}
+		d.table(columns, rows)
+		d.more(omitted.QueryWeights)
+	}
+
+	if len(database.AutoIncrements) > 0 {
+		d.heading(fmt.Sprintf("Tables that used more than %.0f%% of the range of their auto-increment column", autoIncrementWarning*100))
+		rows := make([][]string, 0, len(database.AutoIncrements))
+		for _, usage := range database.AutoIncrements {
+			rows = append(rows, []string{usage.Table, usage.Column, usage.Type, strconv.FormatUint(usage.Next, 10), fmt.Sprintf("%.2f%%", usage.Share()*100)})
+		}
+		d.table([]string{"Table", "Column", "Type", "Next Value", "Used"}, rows)
+		d.more(omitted.AutoIncrements)
+	}
+
+	if len(database.PartitionedTables) > 0 {
+		d.heading("Partitioned tables")
+		d.text("Their partitioning has to be compatible with the sharding scheme.")
+		rows := make([][]string, 0, len(database.PartitionedTables))
+		for _, t := range database.PartitionedTables {
+			rows = append(rows, []string{t.Table, t.Method, pdfCell(t.Expression), strconv.Itoa(t.Partitions)})
+		}
+		d.table([]string{"Table", "Method", "Expression", "Partitions"}, rows)
+		d.more(omitted.PartitionedTables)
+	}
+
+	d.heading("Indexes")
+	if len(database.UnindexedFilters) == 0 {
+		d.text("All the filter columns are indexed.")
+	} else {
+		d.text(fmt.Sprintf("%d filter columns are not the first column of an index.", len(database.UnindexedFilters)+omitted.UnindexedFilters))
+		rows := make([][]string, 0, len(database.UnindexedFilters))
+		for _, filter := range database.UnindexedFilters {
+			rows = append(rows, []string{filter.Table, filter.Column, strconv.Itoa(filter.Uses), strconv.Itoa(filter.Rows)})
+		}
+		d.table([]string{"Table", "Column", "Filter Uses", "Table Rows"}, rows)
+		d.more(omitted.UnindexedFilters)
+	}
+	if len(database.IndexSuggestions) > 0 {
+		d.text("Suggested indexes, the most used first:")
+		rows := make([][]string, 0, len(database.IndexSuggestions))
+		for _, suggestion := range database.IndexSuggestions {
+			rows = append(rows, []string{pdfCell(suggestion.Statement), strconv.Itoa(suggestion.Uses), strconv.Itoa(suggestion.Rows)})
+		}
+		d.table([]string{"Statement", "Uses", "Table Rows"}, rows)
+		d.more(omitted.IndexSuggestions)
+	}
+
+	d.heading("Unused tables and columns")
+	if len(database.UnusedTables) == 0 {
+		d.text("All the tables are used by the queries.")
+	} else {
+		d.text(fmt.Sprintf("%d tables are not used by any query, they can be sharded in any way or dropped.", len(database.UnusedTables)+omitted.UnusedTables))
+		rows := make([][]string, 0, len(database.UnusedTables))
+		for _, unused := range database.UnusedTables {
+			rows = append(rows, []string{unused.Table, strconv.Itoa(unused.Rows)})
+		}
+		d.table([]string{"Table", "Table Rows"}, rows)
+		d.more(omitted.UnusedTables)
+	}
+	if len(database.UnusedColumns) > 0 {
+		d.text("Columns no query filters, joins, groups or orders on (columns only read in the select list are not known):")
+		rows := make([][]string, 0, len(database.UnusedColumns))
+		for _, unused := range database.UnusedColumns {
+			rows = append(rows, []string{unused.Table, pdfCell(strings.Join(unused.Columns, ", "))})
+		}
+		d.table([]string{"Table", "Columns"}, rows)
+		d.more(omitted.UnusedColumns)
+	}
+}
+
+// pdfCell keeps a value on a single line, cut so the tables fit in the width of the page
+func pdfCell(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > pdfQueryChars {
+		value = string(runes[:pdfQueryChars-3]) + "..."
+	}
+	return value
+}
+
+func (d *pdfDocument) heading(text string) {
+	d.space(pdfHeadingSize)
+	d.line(pdfBold, pdfHeadingSize, text)
+}
+
+// text writes a paragraph, wrapped at the width of the page
+func (d *pdfDocument) text(text string) {
+	d.space(pdfTextSize / 2)
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > pdfTextChars {
+			d.line(pdfRegular, pdfTextSize, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	d.line(pdfRegular, pdfTextSize, line)
+}
+
+func (d *pdfDocument) more(omitted int) {
+	if omitted > 0 {
+		d.text(fmt.Sprintf("and %d more...", omitted))
+	}
+}
+
+// table draws the table as the text tables of the terminal, longer lines are cut at the width of the page
+func (d *pdfDocument) table(cols []string, rows [][]string) {
+	sb := &strings.Builder{}
+	table := createTableWriter(sb, cols)
+	table.AppendBulk(rows)
+	table.Render()
+	d.space(pdfTableSize / 2)
+	for _, line := range strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n") {
+		if runes := []rune(line); len(runes) > pdfTableChars {
+			line = string(runes[:pdfTableChars])
+		}
+		d.line(pdfMonospace, pdfTableSize, line)
+	}
+}
+
+// space leaves some room before the next line, unless it is the first one of the page
+func (d *pdfDocument) space(size float64) {
+	if len(d.pages) > 0 && d.y < pdfPageHeight-pdfMargin {
+		d.y -= size
+	}
+}
+
+// line adds a line below the previous one, on a new page when it doesn't fit on the current one
+func (d *pdfDocument) line(font string, size float64, text string) {
+	height := size * 1.3
+	if len(d.pages) == 0 || d.y-height < pdfMargin {
+		d.pages = append(d.pages, nil)
+		d.y = pdfPageHeight - pdfMargin
+	}
+	d.y -= height
+	page := len(d.pages) - 1
+	d.pages[page] = append(d.pages[page], pdfLine{font: font, size: size, x: pdfMargin, y: d.y, text: text})
+}
+
+// bytes writes the PDF file: the catalog, the page tree, the fonts, then every page with its content stream,
+// and the cross-reference table of the offsets of these objects
+func (d *pdfDocument) bytes() []byte {
+	buf := &bytes.Buffer{}
+	var offsets []int
+	object := func(content string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	// the page objects come after the catalog, the page tree, the info dictionary and the three fonts
+	const firstPage = 7
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (vt) >>", pdfString(d.title)))
+	for _, font := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	}
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /%s 4 0 R /%s 5 0 R /%s 6 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pdfRegular, pdfBold, pdfMonospace, firstPage+2*i+1))
+		content := &bytes.Buffer{}
+		for _, line := range page {
+			fmt.Fprintf(content, "BT /%s %g Tf %g %.2f Td (%s) Tj ET\n", line.font, line.size, line.x, line.y, pdfString(line.text))
+		}
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfString escapes a text for a PDF string. The characters the fonts have no glyph for in the
+// WinAnsi encoding, which matches Latin-1 for the accented letters, are replaced by a question mark.
+func pdfString(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			sb.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintPDFReport(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, printPDFReport(buf, reportTestFile(), ReportLimits{}, keysInputs{}))
+	pdf := buf.Bytes()
+	requireValidPDF(t, pdf)
+
+	s := string(pdf)
+	require.Contains(t, s, "/Title (Summary from trace file keys.json)")
+	require.Contains(t, s, "(Summary from trace file keys.json) Tj")
+	require.Contains(t, s, "(Hot queries) Tj")
+	require.Contains(t, s, "(Sharding key recommendations) Tj")
+	require.Contains(t, s, "(Table: t used in 4 queries) Tj")
+	require.Contains(t, s, "/Count 1 >>")
+}
+
+func TestPDFDocumentPages(t *testing.T) {
+	doc := &pdfDocument{title: "pages"}
+	for i := range 100 {
+		doc.text(fmt.Sprintf("line %d", i))
+	}
+	// every line and the space before it take 16.2 points, 47 lines fit in the 762 points of a page
+	require.Len(t, doc.pages, 3)
+	pdf := doc.bytes()
+	requireValidPDF(t, pdf)
+	require.Contains(t, string(pdf), "/Kids [7 0 R 9 0 R 11 0 R] /Count 3 >>")
+
+	// the first line of a page has no space before it
+	assert.InDelta(t, pdfPageHeight-pdfMargin-pdfTextSize*1.3, doc.pages[1][0].y, 0.001)
+}
+
+func TestPDFString(t *testing.T) {
+	assert.Equal(t, `f\(x\) = \\ caf\351 ? ?`, pdfString("f(x) = \\ café ✓ \t"))
+}
+
+// requireValidPDF checks that the cross-reference table points at the objects and that the lengths of the streams are right
+func requireValidPDF(t *testing.T, pdf []byte) {
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, start)
+	xref, err := strconv.Atoi(string(start[1]))
+	require.NoError(t, err)
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	require.Equal(t, "xref", lines[0])
+	size, err := strconv.Atoi(strings.Fields(lines[1])[1])
+	require.NoError(t, err)
+	for i := 1; i < size; i++ {
+		offset, err := strconv.Atoi(lines[2+i][:10])
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i))), "object %d", i)
+	}
+
+	for _, match := range regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(pdf, -1) {
+		length, err := strconv.Atoi(string(pdf[match[2]:match[3]]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(pdf[match[1]+length:], []byte("endstream")))
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+// queryStatsLimit is the number of query structures listed in the query statistics, the most time-consuming ones
+const queryStatsLimit = 20
+
+// QueryWeight is what performance_schema measured for a query structure of a keys file.
+// Digests that match no query structure are kept, with their digest text as the query.
+type QueryWeight struct {
+	Query   string `json:"query"`
+	Matched bool   `json:"matched"`
+	// Executions, TotalLatency (in milliseconds) and RowsExamined add up the digests of the query
+	Executions   int     `json:"executions"`
+	TotalLatency float64 `json:"totalLatency"`
+	RowsExamined int     `json:"rowsExamined"`
+}
+
+func (w QueryWeight) AvgLatency() float64 {
+	if w.Executions == 0 {
+		return 0
+	}
+	return w.TotalLatency / float64(w.Executions)
+}
+
+//nolint:gochecknoglobals // these are instead of consts
+var (
+	fingerprintComments  = regexp.MustCompile(`/\*.*?\*/`)
+	fingerprintValues    = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|::?\w+|\b\d+(?:\.\d+)?\b|\(\s*\.\.\.\s*\)`)
+	fingerprintLists     = regexp.MustCompile(`\(\s*\?(?:\s*,\s*(?:\?|\.\.\.))*\s*\)|\?(?:\s*,\s*\?)+`)
+	fingerprintQualifier = regexp.MustCompile(`\b\w+\s*\.\s*(\w+|\*)`)
+	fingerprintNoise     = regexp.MustCompile(`\s+(?:as|asc|outer)\b`)
+	fingerprintSpaces    = regexp.MustCompile(`\s*([(),=<>!*+/-])\s*|\s+`)
+)
+
+// queryFingerprint reduces a query structure of a keys file, or a digest text of performance_schema, to a form
+// in which both compare equal: lowercase, without quotes, comments, column qualifiers nor values, and with all
+// the lists of values collapsed. It is a best effort, the two are printed by different tools.
+func queryFingerprint(query string) string {
+	s := strings.ToLower(strings.ReplaceAll(query, "`", ""))
+	s = fingerprintComments.ReplaceAllString(s, " ")
+	s = strings.ReplaceAll(s, "<>", "!=")
+	s = fingerprintValues.ReplaceAllString(s, "?")
+	// the rows of a multi-row insert become a list of lists
+	for collapsed := ""; collapsed != s; {
+		collapsed, s = s, fingerprintLists.ReplaceAllString(s, "?")
+	}
+	s = fingerprintQualifier.ReplaceAllString(s, "$1")
+	s = fingerprintNoise.ReplaceAllString(s, "")
+	s = fingerprintSpaces.ReplaceAllStringFunc(s, func(m string) string {
+		if t := strings.TrimSpace(m); t != "" {
+			return t
+		}
+		return " "
+	})
+	return strings.TrimSpace(s)
+}
+
+// weightQueries matches the query digests of a dbinfo file with the query structures of a keys file, and replaces
+// the usage counts of the matched structures with their execution counts. The weights are returned
+// the most time-consuming first.
+func weightQueries(queries *keys.Output, stats []dbinfo.QueryStat) []QueryWeight {
+	structures := make(map[string]string, len(queries.Queries))
+	for _, query := range queries.Queries {
+		structures[queryFingerprint(query.QueryStructure)] = query.QueryStructure
+	}
+
+	weights := make(map[string]*QueryWeight)
+	for _, stat := range stats {
+		structure, matched := structures[queryFingerprint(stat.DigestText)]
+		if !matched {
+			structure = stat.DigestText
+		}
+		w, found := weights[structure]
+		if !found {
+			w = &QueryWeight{Query: structure, Matched: matched}
+			weights[structure] = w
+		}
+		w.Executions += stat.Executions
+		w.TotalLatency += stat.TotalLatency
+		w.RowsExamined += stat.RowsExamined
+	}
+
+	counts := make(map[string]int)
+	result := make([]QueryWeight, 0, len(weights))
+	for _, w := range weights {
+		if w.Matched {
+			counts[w.Query] = w.Executions
+		}
+		result = append(result, *w)
+	}
+	if len(counts) > 0 {
+		queries.Reweight(counts)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalLatency != result[j].TotalLatency {
+			return result[i].TotalLatency > result[j].TotalLatency
+		}
+		return result[i].Query < result[j].Query
+	})
+	return result
+}
+
+func printQueryWeights(out io.Writer, termWidth int, weights []QueryWeight, metric HotMetric) {
+	var matched int
+	for _, w := range weights {
+		if w.Matched {
+			matched++
+		}
+	}
+	fmt.Fprintf(out, "Query statistics from performance_schema, %d of the %d query structures match a query of the keys file "+
+		"and are weighted by their execution counts", matched, len(weights))
+	columns := []string{"Query", "In Keys File", "Executions", "Total Latency (ms)", "Avg Latency (ms)", "Rows Examined"}
+	if metric.expression != "" {
+		fmt.Fprintf(out, ", ranked by %s", metric)
+		columns = append(columns, "Score")
+	}
+	fmt.Fprintln(out, ":")
+	table := createTableWriter(out, columns)
+	for i, w := range weights {
+		if i == queryStatsLimit {
+			break
+		}
+		inKeys := "no"
+		if w.Matched {
+			inKeys = "yes"
+		}
+		row := []string{
+			limitQueryLength(w.Query, termWidth/2),
+			inKeys,
+			strconv.Itoa(w.Executions),
+			fmt.Sprintf("%.3f", w.TotalLatency),
+			fmt.Sprintf("%.3f", w.AvgLatency()),
+			strconv.Itoa(w.RowsExamined),
+		}
+		if metric.expression != "" {
+			row = append(row, fmt.Sprintf("%.3f", metric.Score(w)))
+		}
+		table.Append(row)
+	}
+	table.Render()
+	_, _ = fmt.Fprintln(out)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestQueryFingerprint(t *testing.T) {
+	tests := []struct {
+		structure, digest string
+	}{{
+		structure: "SELECT `a`, count(*) AS `c` FROM `t` WHERE `id` = :_id /* INT64 */ AND `b` IN ::1 GROUP BY `a` ORDER BY `t`.`a` ASC LIMIT :2 /* INT64 */",
+		digest:    "SELECT `a` , COUNT ( * ) `c` FROM `t` WHERE `id` = ? AND `b` IN (...) GROUP BY `a` ORDER BY `a` LIMIT ?",
+	}, {
+		structure: "INSERT INTO `t`(`a`, `b`) VALUES (:1 /* INT64 */, :2 /* VARCHAR */), (:3 /* INT64 */, :4 /* VARCHAR */)",
+		digest:    "INSERT INTO `t` ( `a` , `b` ) VALUES (...) /* , ... */",
+	}, {
+		structure: "UPDATE `t` SET `a` = :1 /* INT64 */ WHERE `b` != :_b /* VARCHAR */",
+		digest:    "UPDATE `t` SET `a` = ? WHERE `b` <> ?",
+	}, {
+		structure: "SELECT `a` FROM `t` LEFT JOIN `u` ON `t`.`id` = `u`.`t_id`",
+		digest:    "SELECT `a` FROM `t` LEFT OUTER JOIN `u` ON `t` . `id` = `u` . `t_id`",
+	}}
+	for _, test := range tests {
+		require.Equal(t, queryFingerprint(test.structure), queryFingerprint(test.digest), test.digest)
+	}
+	require.NotEqual(t, queryFingerprint("SELECT `a` FROM `t1`"), queryFingerprint("SELECT `a` FROM `t2`"))
+}
+
+func TestWeightQueries(t *testing.T) {
+	output := &keys.Output{Queries: []keys.QueryAnalysisResult{{
+		QueryStructure: "SELECT * FROM `orders` WHERE `customer_id` = :_customer_id /* INT64 */",
+		UsageCount:     3,
+		TableName:      []string{"orders"},
+		StatementType:  "SELECT",
+	}, {
+		QueryStructure: "DELETE FROM `orders` WHERE `id` = :_id /* INT64 */",
+		UsageCount:     1,
+		TableName:      []string{"orders"},
+		StatementType:  "DELETE",
+	}}}
+	weights := weightQueries(output, []dbinfo.QueryStat{{
+		DigestText:   "SELECT * FROM `orders` WHERE `customer_id` = ?",
+		Executions:   2000,
+		TotalLatency: 500,
+		RowsExamined: 8000,
+	}, {
+		DigestText:   "SELECT * FROM `shop`.`orders` WHERE `customer_id` = ?",
+		Executions:   500,
+		TotalLatency: 100,
+	}, {
+		DigestText:   "SELECT `email` FROM `customer` WHERE `id` = ?",
+		Executions:   10000,
+		TotalLatency: 1000,
+	}})
+
+	require.Equal(t, []QueryWeight{{
+		Query:        "SELECT `email` FROM `customer` WHERE `id` = ?",
+		Executions:   10000,
+		TotalLatency: 1000,
+	}, {
+		Query:        "SELECT * FROM `orders` WHERE `customer_id` = :_customer_id /* INT64 */",
+		Matched:      true,
+		Executions:   2500,
+		TotalLatency: 600,
+		RowsExamined: 8000,
+	}}, weights)
+	require.Equal(t, 2500, output.Queries[0].UsageCount)
+	require.Equal(t, 1, output.Queries[1].UsageCount)
+	require.Equal(t, []keys.TableStats{{Table: "orders", Reads: 2500, Writes: 1}}, output.Tables)
+
+	var out bytes.Buffer
+	printQueryWeights(&out, 200, weights, HotMetric{})
+	require.Contains(t, out.String(), "1 of the 2 query structures match a query of the keys file")
+}
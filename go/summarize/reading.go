@@ -17,62 +17,132 @@ limitations under the License.
 package summarize
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/vitessio/vt/go/keys"
 )
 
-func readTraceFile(fileName string) readingSummary {
+// maxConcurrentReads is the number of files read at the same time
+const maxConcurrentReads = 4
+
+// readTraceFiles reads all the files concurrently, returning them in the same order as the file names.
+// All the errors are collected and returned together. Unless strict is set, the complete entries of
+// truncated or corrupted files are kept, see readingSummary.Incomplete.
+func readTraceFiles(fileNames []string, strict bool) ([]readingSummary, error) {
+	results := make([]readingSummary, len(fileNames))
+	errs := make([]error, len(fileNames))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentReads)
+	for i, fileName := range fileNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = readTraceFile(fileName, strict)
+		}()
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+func readTraceFile(fileName string, strict bool) (readingSummary, error) {
 	// Open the JSON file
 	file, err := os.Open(fileName)
 	if err != nil {
-		exit("Error opening file: " + err.Error())
+		return readingSummary{}, fmt.Errorf("error opening file: %w", err)
 	}
 	defer file.Close()
 
-	decoder, val := getDecoderAndDelim(file)
+	decoder, val, err := getDecoderAndDelim(file)
+	if err != nil {
+		return readingSummary{}, fmt.Errorf("error reading %s: %w", fileName, err)
+	}
 
 	// Determine the type based on the first delimiter of the JSON file
+	var summary readingSummary
+	var entries int
 	switch val {
 	case json.Delim('['):
-		return readTracedQueryFile(decoder, fileName)
+		summary, err = readTracedQueryFile(decoder, fileName)
+		entries = len(summary.TracedQueries)
 	case json.Delim('{'):
-		return readAnalysedQueryFile(decoder, fileName)
+		summary, err = readAnalysedQueryFile(file, decoder, fileName)
+		if summary.AnalysedQueries != nil {
+			entries = len(summary.AnalysedQueries.Queries) + len(summary.AnalysedQueries.Failed)
+		}
+		entries += len(summary.Latencies)
+	default:
+		return readingSummary{}, fmt.Errorf("unknown file format of %s", fileName)
 	}
-
-	exit("Unknown file format")
-	panic("unreachable")
+	if err == nil {
+		return summary, nil
+	}
+	if strict || entries == 0 {
+		return readingSummary{}, fmt.Errorf("error reading json of %s: %w", fileName, err)
+	}
+	summary.Incomplete = &incompleteFile{Entries: entries, Err: err}
+	return summary, nil
 }
 
-func getDecoderAndDelim(file *os.File) (*json.Decoder, json.Delim) {
-	// Create a decoder
-	decoder := json.NewDecoder(file)
-
-	// Read the opening bracket
-	val, err := decoder.Token()
-	if err != nil {
-		exit("Error reading json: " + err.Error())
+// getDecoderAndDelim peeks at the first JSON delimiter of the file, and returns a decoder
+// that reads the file from its beginning, so the file is only read once
+func getDecoderAndDelim(file io.Reader) (*json.Decoder, json.Delim, error) {
+	r := bufio.NewReader(file)
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading json: %w", err)
+		}
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			_, _ = r.ReadByte()
+			continue
+		case '[', '{':
+			return json.NewDecoder(r), json.Delim(b[0]), nil
+		default:
+			return nil, 0, fmt.Errorf("error reading json: unexpected character %q", b[0])
+		}
 	}
+}
 
-	// Reset the file pointer to the beginning
-	_, err = file.Seek(0, io.SeekStart)
+// decodeArray decodes a JSON array one element at a time, calling add for each of them,
+// so the elements before a truncation or a corruption are not lost
+func decodeArray[T any](decoder *json.Decoder, add func(T)) error {
+	tok, err := decoder.Token()
 	if err != nil {
-		exit("Error rewinding file: " + err.Error())
+		return err
 	}
-	decoder = json.NewDecoder(file)
-	return decoder, val.(json.Delim)
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected an array, got %v", tok)
+	}
+	for decoder.More() {
+		var elem T
+		if err := decoder.Decode(&elem); err != nil {
+			return err
+		}
+		add(elem)
+	}
+	_, err = decoder.Token()
+	return err
 }
 
-func readTracedQueryFile(decoder *json.Decoder, fileName string) readingSummary {
+// readTracedQueryFile returns the queries read before any error, along with the error
+func readTracedQueryFile(decoder *json.Decoder, fileName string) (readingSummary, error) {
 	var tracedQueries []TracedQuery
-	err := decoder.Decode(&tracedQueries)
-	if err != nil {
-		exit("Error reading json: " + err.Error())
-	}
+	err := decodeArray(decoder, func(q TracedQuery) {
+		tracedQueries = append(tracedQueries, q)
+	})
 
 	sort.Slice(tracedQueries, func(i, j int) bool {
 		a, err := strconv.Atoi(tracedQueries[i].LineNumber)
@@ -86,21 +156,102 @@ func readTracedQueryFile(decoder *json.Decoder, fileName string) readingSummary
 		return a < b
 	})
 
-	return readingSummary{
+	summary := readingSummary{
 		Name:          fileName,
 		TracedQueries: tracedQueries,
 	}
+	for _, q := range tracedQueries {
+		if q.VitessVersion != "" {
+			summary.VitessVersion = q.VitessVersion
+			break
+		}
+	}
+	return summary, err
 }
 
-func readAnalysedQueryFile(decoder *json.Decoder, fileName string) readingSummary {
-	var output keys.Output
-	err := decoder.Decode(&output)
-	if err != nil {
-		exit("Error reading json: " + err.Error())
+// analysedQueryFile is a keys output or, when it has no fileType and has latencies, a 'vt tester --latency-file' output
+type analysedQueryFile struct {
+	keys.Output
+	Latencies []QueryLatency `json:"latencies"`
+}
+
+// readAnalysedQueryFile returns the keys output read before any error, along with the error.
+// A truncated or corrupted file is read again with recoverAnalysedQueryFile, so it keeps its first queries.
+func readAnalysedQueryFile(file io.ReadSeeker, decoder *json.Decoder, fileName string) (readingSummary, error) {
+	var contents analysedQueryFile
+	err := decoder.Decode(&contents)
+	var syntaxErr *json.SyntaxError
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &syntaxErr) {
+		if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+			return readingSummary{}, errors.Join(err, seekErr)
+		}
+		contents, err = recoverAnalysedQueryFile(json.NewDecoder(bufio.NewReader(file)))
+	}
+
+	// the keys outputs written before the fileType field have none, they are told from the latency files by their fields
+	if contents.FileType != "" && contents.FileType != keys.FileType {
+		return readingSummary{}, fmt.Errorf("unknown file type %v", contents.FileType)
+	}
+	if contents.FileType == "" && contents.Latencies != nil {
+		return readingSummary{Name: fileName, Latencies: contents.Latencies}, err
+	}
+	if versionErr := contents.CheckVersion(); versionErr != nil {
+		return readingSummary{}, errors.Join(err, versionErr)
+	}
+	return readingSummary{Name: fileName, AnalysedQueries: &contents.Output}, err
+}
+
+// recoverAnalysedQueryFile reads a truncated or corrupted keys output one field at a time, and its arrays
+// of queries, failures and latencies one element at a time. It returns what was read before the problem, along with the error.
+func recoverAnalysedQueryFile(decoder *json.Decoder) (analysedQueryFile, error) {
+	var contents analysedQueryFile
+	// the other fields are decoded together once they are all read
+	others := make(map[string]json.RawMessage)
+	err := decodeFields(decoder, func(name string) error {
+		switch name {
+		case "queries":
+			return decodeArray(decoder, func(q keys.QueryAnalysisResult) { contents.Queries = append(contents.Queries, q) })
+		case "failed":
+			return decodeArray(decoder, func(f keys.QueryFailedResult) { contents.Failed = append(contents.Failed, f) })
+		case "latencies":
+			return decodeArray(decoder, func(l QueryLatency) { contents.Latencies = append(contents.Latencies, l) })
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		others[name] = value
+		return nil
+	})
+	raw, jsonErr := json.Marshal(others)
+	if jsonErr == nil {
+		jsonErr = json.Unmarshal(raw, &contents)
 	}
+	return contents, errors.Join(err, jsonErr)
+}
 
-	return readingSummary{
-		Name:            fileName,
-		AnalysedQueries: &output,
+// decodeFields decodes a JSON object one field at a time, calling decode to read the value of each of them
+func decodeFields(decoder *json.Decoder, decode func(name string) error) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected an object, got %v", tok)
+	}
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		name, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected a field name, got %v", tok)
+		}
+		if err := decode(name); err != nil {
+			return err
+		}
 	}
+	_, err = decoder.Token()
+	return err
 }
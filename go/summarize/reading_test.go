@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -52,8 +53,99 @@ func TestGetDecoderAndDelim(t *testing.T) {
 			f := initFile(t, tt.wantDelim)
 			defer f.Close()
 
-			_, delim := getDecoderAndDelim(f)
+			_, delim, err := getDecoderAndDelim(f)
+			require.NoError(t, err)
 			require.Equal(t, json.Delim(tt.wantDelim), delim)
 		})
 	}
 }
+
+func TestReadTraceFiles(t *testing.T) {
+	files := []string{"testdata/keys-log.json", "testdata/trace-log.json", "testdata/keys-log.json"}
+	summaries, err := readTraceFiles(files, false)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	for i, summary := range summaries {
+		require.Equal(t, files[i], summary.Name)
+	}
+	require.NotNil(t, summaries[0].AnalysedQueries)
+	require.NotEmpty(t, summaries[1].TracedQueries)
+
+	invalid := initFile(t, 'x')
+	defer invalid.Close()
+	_, err = readTraceFiles([]string{"testdata/keys-log.json", "does-not-exist.json", invalid.Name()}, false)
+	require.ErrorContains(t, err, "does-not-exist.json")
+	require.ErrorContains(t, err, "unexpected character 'x'")
+}
+
+func TestReadTruncatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	truncate := func(source string, keep float64) string {
+		content, err := os.ReadFile(source)
+		require.NoError(t, err)
+		name := filepath.Join(dir, filepath.Base(source))
+		require.NoError(t, os.WriteFile(name, content[:int(float64(len(content))*keep)], 0o600))
+		return name
+	}
+
+	complete, err := readTraceFile("testdata/keys-log.json", false)
+	require.NoError(t, err)
+	require.Nil(t, complete.Incomplete)
+
+	keysFile := truncate("testdata/keys-log.json", 0.5)
+	summary, err := readTraceFile(keysFile, false)
+	require.NoError(t, err)
+	require.NotNil(t, summary.Incomplete)
+	require.NotEmpty(t, summary.AnalysedQueries.Queries)
+	require.Less(t, len(summary.AnalysedQueries.Queries), len(complete.AnalysedQueries.Queries))
+	require.Equal(t, complete.AnalysedQueries.Queries[:len(summary.AnalysedQueries.Queries)], summary.AnalysedQueries.Queries)
+	require.Equal(t, len(summary.AnalysedQueries.Queries), summary.Incomplete.Entries)
+
+	traceFile := truncate("testdata/trace-log.json", 0.5)
+	summary, err = readTraceFile(traceFile, false)
+	require.NoError(t, err)
+	require.NotNil(t, summary.Incomplete)
+	require.NotEmpty(t, summary.TracedQueries)
+
+	_, err = readTraceFile(traceFile, true)
+	require.ErrorContains(t, err, "error reading json of "+traceFile)
+
+	_, err = readTraceFile(truncate("testdata/trace-log.json", 0.001), false)
+	require.Error(t, err, "nothing can be recovered")
+
+	// the fields before the truncation are kept along with the complete queries
+	truncated := filepath.Join(dir, "sampled.json")
+	require.NoError(t, os.WriteFile(truncated, []byte(`{"fileType": "keys", "sampleRate": 0.5, "queries": [`+
+		`{"queryStructure": "SELECT 1", "usageCount": 1, "lineNumbers": [1], "statementType": "SELECT"}, {"queryStructure": "SEL`), 0o600))
+	summary, err = readTraceFile(truncated, false)
+	require.NoError(t, err)
+	require.NotNil(t, summary.Incomplete)
+	require.InDelta(t, 0.5, summary.AnalysedQueries.SampleRate, 0.001)
+	require.Len(t, summary.AnalysedQueries.Queries, 1)
+}
+
+func TestReadKeysFileType(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		fileName := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+		return fileName
+	}
+
+	// the outputs written before the fileType field are read like the new ones
+	for _, content := range []string{
+		`{"queries": [{"queryStructure": "SELECT 1", "usageCount": 1, "lineNumbers": [1], "statementType": "SELECT"}]}`,
+		`{"fileType": "keys", "version": 1, "queries": [{"queryStructure": "SELECT 1", "usageCount": 1, "lineNumbers": [1], "statementType": "SELECT"}]}`,
+	} {
+		summary, err := readTraceFile(write("keys.json", content), true)
+		require.NoError(t, err)
+		require.NotNil(t, summary.AnalysedQueries)
+		require.Len(t, summary.AnalysedQueries.Queries, 1)
+	}
+
+	_, err := readTraceFile(write("other.json", `{"fileType": "transactions", "latencies": []}`), true)
+	require.ErrorContains(t, err, `unknown file type transactions`)
+
+	_, err = readTraceFile(write("newer.json", `{"fileType": "keys", "version": 99, "queries": []}`), true)
+	require.ErrorContains(t, err, "the keys output version 99 is newer than the version 1 this vt reads")
+}
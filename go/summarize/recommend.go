@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+const (
+	// minShardingKeyDistinct is the number of distinct values below which a column is a poor sharding key:
+	// its rows can't be spread over more shards than it has values, and a frequent value makes a hot shard
+	minShardingKeyDistinct = 100
+	// lowCardinalityPenalty is the factor the confidence of a low cardinality sharding key is multiplied by
+	lowCardinalityPenalty = 0.5
+	// joinWeight is the weight of the joins in the confidence of a sharding key, the filters weigh the rest.
+	// Joining tables on their sharding keys keeps the joins on a single shard.
+	joinWeight = 0.3
+)
+
+// ShardingKeyRecommendation is the column proposed to shard a table by, with how confident the recommendation is
+type ShardingKeyRecommendation struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	// Confidence goes from 0 to 100. It weighs the share of the query uses of the table that constrain the column,
+	// and the share of those joining on it, and it is halved when the column has few distinct values.
+	Confidence float64 `json:"confidence"`
+	// Reasons tell what the recommendation is based on, such as "constrained by 82.00% of the query uses"
+	Reasons []string `json:"reasons"`
+}
+
+// shardingCandidate is a column the queries filter or join on with an equality
+type shardingCandidate struct {
+	table, column string
+	// joins are the columns of other tables the column is joined to with an equality, with the uses of these joins
+	joins map[string]int
+	// joinUses is the number of query uses joining on the column
+	joinUses int
+}
+
+// recommendShardingKeys proposes a sharding key for every table with a column the queries filter or join on
+// with an equality. The candidate constraining the most query uses of the table wins, as checkShardingKeys counts them.
+// The distinct values of the candidates are taken from the dbinfo file when given, and else from the values of the keys file.
+// The recommendations are sorted by table name.
+func recommendShardingKeys(queries *keys.Output, info *dbinfo.Info) []ShardingKeyRecommendation {
+	candidates := make(map[[2]string]*shardingCandidate)
+	candidate := func(column operators.Column) *shardingCandidate {
+		key := [2]string{strings.ToLower(column.Table), strings.ToLower(column.Name)}
+		c, found := candidates[key]
+		if !found {
+			c = &shardingCandidate{table: column.Table, column: column.Name, joins: make(map[string]int)}
+			candidates[key] = c
+		}
+		return c
+	}
+	for _, query := range queries.Queries {
+		if query.StatementType == "INSERT" {
+			continue
+		}
+		for _, filter := range query.FilterColumns {
+			switch filter.Uses {
+			case sqlparser.EqualOp, sqlparser.InOp, sqlparser.NullSafeEqualOp:
+				candidate(filter.Column)
+			}
+		}
+		// a query joining twice on the same column is counted once
+		joined := make(map[*shardingCandidate]bool)
+		for _, predicate := range query.JoinPredicates {
+			if predicate.Uses != sqlparser.EqualOp || strings.EqualFold(predicate.LHS.Table, predicate.RHS.Table) {
+				continue
+			}
+			lhs, rhs := candidate(predicate.LHS), candidate(predicate.RHS)
+			lhs.joins[predicate.RHS.String()] += query.UsageCount
+			rhs.joins[predicate.LHS.String()] += query.UsageCount
+			joined[lhs], joined[rhs] = true, true
+		}
+		for c := range joined {
+			c.joinUses += query.UsageCount
+		}
+	}
+
+	best := make(map[string]ShardingKeyRecommendation)
+	for _, c := range candidates {
+		coverage := checkShardingKeys(queries, ShardingKeys{c.table: c.column})[0]
+		if coverage.Uses == 0 {
+			continue
+		}
+		share := float64(coverage.Covered) / float64(coverage.Uses) * 100
+		joinShare := min(float64(c.joinUses)/float64(coverage.Uses)*100, 100)
+		recommendation := ShardingKeyRecommendation{
+			Table:      c.table,
+			Column:     c.column,
+			Confidence: (1-joinWeight)*share + joinWeight*joinShare,
+			Reasons:    []string{fmt.Sprintf("constrained by %.2f%% of the query uses", share)},
+		}
+		if joins := c.joinedColumns(); len(joins) > 0 {
+			recommendation.Reasons = append(recommendation.Reasons,
+				fmt.Sprintf("%.2f%% of the query uses join to %s", joinShare, strings.Join(joins, ", ")))
+		}
+		if distinct, source, known := columnDistinct(queries, info, c.table, c.column); known {
+			if distinct < minShardingKeyDistinct {
+				recommendation.Confidence *= lowCardinalityPenalty
+				recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf("low cardinality, %d distinct values %s", distinct, source))
+			} else {
+				recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf("high cardinality, %d distinct values %s", distinct, source))
+			}
+		}
+
+		table := strings.ToLower(c.table)
+		current, found := best[table]
+		if !found || recommendation.Confidence > current.Confidence ||
+			(recommendation.Confidence == current.Confidence && recommendation.Column < current.Column) {
+			best[table] = recommendation
+		}
+	}
+
+	result := make([]ShardingKeyRecommendation, 0, len(best))
+	for _, recommendation := range best {
+		result = append(result, recommendation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result
+}
+
+// joinedColumns returns the columns the candidate is joined to, the most used joins first
+func (c *shardingCandidate) joinedColumns() []string {
+	columns := make([]string, 0, len(c.joins))
+	for column := range c.joins {
+		columns = append(columns, column)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if c.joins[columns[i]] != c.joins[columns[j]] {
+			return c.joins[columns[i]] > c.joins[columns[j]]
+		}
+		return columns[i] < columns[j]
+	})
+	return columns
+}
+
+// columnDistinct returns the number of distinct values of a column, and where it comes from. The sampled cardinality
+// of the dbinfo file is preferred, then the cardinality of an index starting with the column, then the values of the keys file.
+func columnDistinct(queries *keys.Output, info *dbinfo.Info, table, column string) (distinct int, source string, known bool) {
+	if info != nil {
+		for _, t := range info.Tables {
+			if !strings.EqualFold(t.Name, table) {
+				continue
+			}
+			for _, c := range t.Columns {
+				if strings.EqualFold(c.Name, column) && c.Sample != nil {
+					return c.Sample.Distinct, fmt.Sprintf("in a sample of %d rows", c.Sample.Rows), true
+				}
+			}
+			for _, index := range t.Indexes {
+				if len(index.Columns) > 0 && strings.EqualFold(index.Columns[0], column) && index.Cardinality > 0 {
+					return index.Cardinality, "in index " + index.Name, true
+				}
+			}
+		}
+	}
+	for _, values := range queries.Values {
+		if strings.EqualFold(values.Column.Table, table) && strings.EqualFold(values.Column.Name, column) {
+			return values.Distinct, "in the query log", true
+		}
+	}
+	return 0, "", false
+}
+
+func printShardingKeyRecommendations(out io.Writer, recommendations []ShardingKeyRecommendation) {
+	if len(recommendations) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Sharding key recommendations:")
+	table := createTableWriter(out, []string{"Table", "Sharding Key", "Confidence", "Reasons"})
+	for _, r := range recommendations {
+		table.Append([]string{r.Table, r.Column, fmt.Sprintf("%.0f%%", r.Confidence), strings.Join(r.Reasons, "; ")})
+	}
+	table.Render()
+	_, _ = fmt.Fprintln(out)
+}
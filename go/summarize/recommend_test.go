@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestRecommendShardingKeys(t *testing.T) {
+	column := func(table, name string) operators.Column {
+		return operators.Column{Table: table, Name: name}
+	}
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{
+			QueryStructure: "select * from orders where customer_id = :1",
+			UsageCount:     6,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{{Column: column("orders", "customer_id"), Uses: sqlparser.EqualOp}},
+		}, {
+			QueryStructure: "select * from orders where id = :1",
+			UsageCount:     2,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{{Column: column("orders", "id"), Uses: sqlparser.EqualOp}},
+		}, {
+			QueryStructure: "select * from orders join customer on orders.customer_id = customer.id where customer.id = :1",
+			UsageCount:     2,
+			TableName:      []string{"orders", "customer"},
+			FilterColumns:  []operators.ColumnUse{{Column: column("customer", "id"), Uses: sqlparser.EqualOp}},
+			JoinPredicates: []operators.JoinPredicate{{LHS: column("orders", "customer_id"), RHS: column("customer", "id"), Uses: sqlparser.EqualOp}},
+		}, {
+			// inserts are routed by the values they insert
+			QueryStructure: "insert into orders (id, customer_id) values (:1, :2)",
+			StatementType:  "INSERT",
+			UsageCount:     100,
+			TableName:      []string{"orders"},
+		}},
+		Values: []keys.ColumnValues{{Column: column("customer", "id"), Uses: 2, Distinct: 500}},
+	}
+
+	recommendations := recommendShardingKeys(queries, nil)
+	require.Len(t, recommendations, 2)
+	require.Equal(t, "customer", recommendations[0].Table)
+	require.Equal(t, "id", recommendations[0].Column)
+	require.InDelta(t, 100, recommendations[0].Confidence, 0.01)
+	require.Equal(t, []string{
+		"constrained by 100.00% of the query uses",
+		"100.00% of the query uses join to orders.customer_id",
+		"high cardinality, 500 distinct values in the query log",
+	}, recommendations[0].Reasons)
+	// 80% of the uses constrain orders.customer_id, 20% join on it
+	require.Equal(t, "orders", recommendations[1].Table)
+	require.Equal(t, "customer_id", recommendations[1].Column)
+	require.InDelta(t, 62, recommendations[1].Confidence, 0.01)
+
+	// the sampled cardinality of the dbinfo file shows orders.customer_id has few distinct values
+	info := &dbinfo.Info{Tables: []dbinfo.TableInfo{{
+		Name: "orders",
+		Columns: []dbinfo.ColumnInfo{
+			{Name: "id", Type: "bigint"},
+			{Name: "customer_id", Type: "bigint", Sample: &dbinfo.CardinalitySample{Rows: 1000, Distinct: 40}},
+		},
+	}}}
+	recommendations = recommendShardingKeys(queries, info)
+	require.Equal(t, "customer_id", recommendations[1].Column)
+	require.InDelta(t, 31, recommendations[1].Confidence, 0.01)
+	require.Equal(t, "low cardinality, 40 distinct values in a sample of 1000 rows", recommendations[1].Reasons[2])
+
+	sb := &strings.Builder{}
+	printShardingKeyRecommendations(sb, recommendations)
+	require.Contains(t, sb.String(), "Sharding key recommendations:")
+	require.Contains(t, sb.String(), "| orders   | customer_id  | 31%        |")
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+const (
+	// ReportFileType identifies the JSON summary of a keys file
+	ReportFileType = "summary"
+	// ReportVersion is the version of the JSON summary, it is increased when fields are renamed or removed,
+	// or change meaning. New fields don't change the version, so readers should ignore the fields they don't know.
+	ReportVersion = 1
+
+	// maxHotQueries is the number of the most used query structures listed by the reports
+	maxHotQueries = 20
+)
+
+type (
+	// keysReport is the summary of a keys file written by the markdown, JSON, HTML and PDF formats
+	keysReport struct {
+		FileType string `json:"fileType"`
+		Version  int    `json:"version"`
+		Name     string `json:"name"`
+		// SamplePercentage is the share of the query log the usage counts are estimated from, 0 when it was not sampled.
+		// MaxQueries is set when the analysis stopped after this many queries, whose counts are not scaled.
+		SamplePercentage float64           `json:"samplePercentage,omitempty"`
+		MaxQueries       int               `json:"maxQueries,omitempty"`
+		Tables           []keys.TableStats `json:"tables,omitempty"`
+		HotQueries       []hotQuery        `json:"hotQueries,omitempty"`
+		// HotMetric is the metric the hot queries are ranked by, they are ranked by their usage count when it is empty
+		HotMetric       string                  `json:"hotMetric,omitempty"`
+		TableSummaries  []reportTable           `json:"tableSummaries,omitempty"`
+		Graph           *queryGraph             `json:"-"`
+		FullScans       []FullScanSummary       `json:"fullScans,omitempty"`
+		FunctionFilters []FunctionFilterSummary `json:"functionFilters,omitempty"`
+		Findings        []FindingSummary        `json:"findings,omitempty"`
+		// FailedPercentage is the share of the workload that could not be analysed, see summarizeFailureTypes
+		FailedPercentage float64              `json:"failedPercentage,omitempty"`
+		FailureTypes     []FailureTypeSummary `json:"failureTypes,omitempty"`
+		Failures         []FailuresSummary    `json:"failures,omitempty"`
+
+		// Hints to Settings are only written by the markdown and JSON formats
+		Hints    []HintSummary     `json:"hints,omitempty"`
+		Users    []UserSummary     `json:"users,omitempty"`
+		Observed []ObservedSummary `json:"observed,omitempty"`
+		// ObservedExecutions and ObservedScatter add up all the observed query structures, before the limits of the report
+		ObservedExecutions int             `json:"observedExecutions,omitempty"`
+		ObservedScatter    int             `json:"observedScatter,omitempty"`
+		Targets            []TargetSummary `json:"targets,omitempty"`
+		Traffic            *reportTraffic  `json:"traffic,omitempty"`
+		Values             []reportValues  `json:"values,omitempty"`
+		Settings           []SettingAdvice `json:"settings,omitempty"`
+		// ShardingKeys are recommended with the dbinfo file too when it is given, the PDF format writes them too
+		ShardingKeys []ShardingKeyRecommendation `json:"shardingKeys,omitempty"`
+		// Tenancy, ShardingKeyCoverage, TestCoverage and Database are only set when their files are given,
+		// every format writes them
+		Tenancy             *reportTenancy             `json:"tenancy,omitempty"`
+		ShardingKeyCoverage *reportShardingKeyCoverage `json:"shardingKeyCoverage,omitempty"`
+		TestCoverage        *reportTestCoverage        `json:"testCoverage,omitempty"`
+		Database            *reportDatabase            `json:"database,omitempty"`
+
+		Omitted omitted `json:"-"`
+	}
+
+	hotQuery struct {
+		// ID is the stable ID of the query structure, see keys.QueryID
+		ID            string  `json:"id,omitempty"`
+		Query         string  `json:"query"`
+		StatementType string  `json:"statementType"`
+		UsageCount    int     `json:"usageCount"`
+		Percentage    float64 `json:"percentage"`
+		// Score is the value of the hot metric for the query, when the hot queries are ranked by one
+		Score float64 `json:"score,omitempty"`
+
+		tables []string
+	}
+
+	reportTable struct {
+		Table          string         `json:"table"`
+		QueryCount     int            `json:"queryCount"`
+		Columns        []reportColumn `json:"columns,omitempty"`
+		JoinPredicates []string       `json:"joinPredicates,omitempty"`
+		// JoinedTables and Queries, the query structures using the table the most used first, are only listed by the HTML report
+		JoinedTables   []string   `json:"-"`
+		Queries        []hotQuery `json:"-"`
+		OmittedQueries int        `json:"-"`
+	}
+
+	// reportColumn is the share of the queries of a table that filter, group or join on a column, in percent
+	reportColumn struct {
+		Name     string  `json:"name"`
+		Filter   float64 `json:"filter"`
+		Grouping float64 `json:"grouping"`
+		Join     float64 `json:"join"`
+	}
+
+	// reportTraffic are the buckets of the traffic, whose start times are in the time zone of Location
+	reportTraffic struct {
+		BucketSize   string              `json:"bucketSize"`
+		Location     string              `json:"location"`
+		Buckets      []BucketSummary     `json:"buckets"`
+		BatchQueries []BatchQuerySummary `json:"batchQueries,omitempty"`
+	}
+
+	// reportValues are the values the queries compare a column with, without the sketch of keys.ColumnValues
+	reportValues struct {
+		Column    string            `json:"column"`
+		Uses      int               `json:"uses"`
+		Distinct  int               `json:"distinct"`
+		TopValues []keys.ValueCount `json:"topValues"`
+	}
+
+	// reportTenancy are the query structures that don't filter on the tenancy column of their table, see checkTenancy
+	reportTenancy struct {
+		// Queries and Uses count all the violations, before the limits of the report
+		Queries    int                `json:"queries"`
+		Uses       int                `json:"uses"`
+		Violations []TenancyViolation `json:"violations,omitempty"`
+	}
+
+	// reportShardingKeyCoverage adds up the sharding key coverage of the tables, see checkShardingKeys
+	reportShardingKeyCoverage struct {
+		Uses    int                   `json:"uses"`
+		Covered int                   `json:"covered"`
+		Tables  []ShardingKeyCoverage `json:"tables,omitempty"`
+	}
+
+	// reportTestCoverage is the test coverage of the workload, listing the most used uncovered queries
+	reportTestCoverage struct {
+		Queries        int        `json:"queries"`
+		CoveredQueries int        `json:"coveredQueries"`
+		Uses           int        `json:"uses"`
+		CoveredUses    int        `json:"coveredUses"`
+		Uncovered      []hotQuery `json:"uncovered,omitempty"`
+	}
+
+	// reportDatabase are the sections summarized with the dbinfo file
+	reportDatabase struct {
+		// QueryWeights are the first query statistics, ranked by HotMetric, or by their total latency when it is empty
+		HotMetric         string                  `json:"hotMetric,omitempty"`
+		QueryWeights      []reportQueryWeight     `json:"queryWeights,omitempty"`
+		AutoIncrements    []AutoIncrementUsage    `json:"autoIncrements,omitempty"`
+		PartitionedTables []reportPartitioning    `json:"partitionedTables,omitempty"`
+		UnindexedFilters  []UnindexedFilter       `json:"unindexedFilters,omitempty"`
+		IndexSuggestions  []reportIndexSuggestion `json:"indexSuggestions,omitempty"`
+		UnusedTables      []UnusedTable           `json:"unusedTables,omitempty"`
+		UnusedColumns     []UnusedColumns         `json:"unusedColumns,omitempty"`
+	}
+
+	reportQueryWeight struct {
+		QueryWeight
+		AvgLatency float64 `json:"avgLatency"`
+		Score      float64 `json:"score,omitempty"`
+	}
+
+	reportPartitioning struct {
+		Table string `json:"table"`
+		dbinfo.Partitioning
+	}
+
+	reportIndexSuggestion struct {
+		IndexSuggestion
+		Statement string `json:"statement"`
+	}
+)
+
+// newKeysReport summarizes a keys file, and the files given along it, for the markdown, JSON, HTML and PDF formats
+func newKeysReport(file readingSummary, limits ReportLimits, inputs keysInputs) keysReport {
+	queries := file.AnalysedQueries
+	tableSummaries, failures := summarizeQueries(queries)
+	all := hotQueries(queries)
+	var hotMetric string
+	if inputs.hotMetric.expression != "" && inputs.info != nil {
+		rankHotQueries(all, inputs.weights, inputs.hotMetric)
+		hotMetric = inputs.hotMetric.String()
+	}
+	report := keysReport{
+		FileType:         ReportFileType,
+		Version:          ReportVersion,
+		Name:             file.Name,
+		SamplePercentage: queries.SampleRate * 100,
+		MaxQueries:       queries.MaxQueries,
+		Tables:           queries.Tables,
+		HotQueries:       all,
+		HotMetric:        hotMetric,
+		Graph:            newQueryGraph(queries),
+		Findings:         summarizeFindings(queries),
+		Failures:         failures,
+		Hints:            summarizeHints(queries),
+		Users:            summarizeUsers(queries),
+		Observed:         summarizeObserved(queries),
+		Settings:         adviseSettings(queries),
+		ShardingKeys:     recommendShardingKeys(queries, inputs.info),
+	}
+	for _, o := range report.Observed {
+		report.ObservedExecutions += o.Executions
+		report.ObservedScatter += o.Scatter
+	}
+	report.Targets, _, _ = summarizeTargets(queries)
+	if buckets := summarizeBuckets(queries); len(buckets) > 0 {
+		for i := range buckets {
+			buckets[i].Start = buckets[i].Start.In(inputs.timeLocation())
+		}
+		report.Traffic = &reportTraffic{
+			BucketSize:   queries.BucketSize,
+			Location:     inputs.timeLocation().String(),
+			Buckets:      buckets,
+			BatchQueries: summarizeBatchQueries(queries, len(buckets)),
+		}
+	}
+	for _, values := range sortedColumnValues(queries.Values) {
+		report.Values = append(report.Values, reportValues{
+			Column:    values.Column.Table + "." + values.Column.Name,
+			Uses:      values.Uses,
+			Distinct:  values.Distinct,
+			TopValues: values.TopValues,
+		})
+	}
+	if types, failed, total := summarizeFailureTypes(queries); len(types) > 0 {
+		report.FailureTypes = types
+		report.FailedPercentage = float64(failed) / float64(total) * 100
+	}
+	report.FullScans, _, _ = summarizeFullScans(queries)
+	report.FunctionFilters, _, _ = summarizeFunctionFilters(queries)
+	for _, summary := range tableSummaries {
+		t := reportTable{Table: summary.Table, QueryCount: summary.QueryCount}
+		for name, usage := range summary.GetColumns() {
+			t.Columns = append(t.Columns, reportColumn{
+				Name:     name,
+				Filter:   usage.FilterPercentage,
+				Grouping: usage.GroupingPercentage,
+				Join:     usage.JoinPercentage,
+			})
+		}
+		for _, predicate := range summary.JoinPredicates {
+			t.JoinPredicates = append(t.JoinPredicates, predicate.String())
+			for _, other := range []string{predicate.LHS.Table, predicate.RHS.Table} {
+				if other != summary.Table && !slices.Contains(t.JoinedTables, other) {
+					t.JoinedTables = append(t.JoinedTables, other)
+				}
+			}
+		}
+		slices.Sort(t.JoinedTables)
+		for _, query := range all {
+			if slices.Contains(query.tables, summary.Table) {
+				t.Queries = append(t.Queries, query)
+			}
+		}
+		report.TableSummaries = append(report.TableSummaries, t)
+	}
+	report.addInputs(queries, inputs)
+	var total int
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+	}
+	report.applyLimits(limits, total)
+	return report
+}
+
+// addInputs adds the sections of the files given along the keys file
+func (r *keysReport) addInputs(queries *keys.Output, inputs keysInputs) {
+	if inputs.tenancy != nil {
+		tenancy := &reportTenancy{Violations: checkTenancy(queries, inputs.tenancy)}
+		for _, violation := range tenancy.Violations {
+			tenancy.Queries++
+			tenancy.Uses += violation.UsageCount
+		}
+		r.Tenancy = tenancy
+	}
+	if inputs.shardingKeys != nil {
+		coverage := &reportShardingKeyCoverage{Tables: checkShardingKeys(queries, inputs.shardingKeys)}
+		for _, c := range coverage.Tables {
+			coverage.Uses += c.Uses
+			coverage.Covered += c.Covered
+		}
+		r.ShardingKeyCoverage = coverage
+	}
+	if inputs.tested != nil {
+		tests := checkTestCoverage(queries, inputs.tested)
+		coverage := &reportTestCoverage{
+			Queries:        tests.Queries,
+			CoveredQueries: tests.CoveredQueries,
+			Uses:           tests.Uses,
+			CoveredUses:    tests.CoveredUses,
+		}
+		for _, query := range tests.Uncovered {
+			coverage.Uncovered = append(coverage.Uncovered, hotQuery{
+				ID:            query.ID,
+				Query:         query.QueryStructure,
+				StatementType: query.StatementType,
+				UsageCount:    query.UsageCount,
+				Percentage:    float64(query.UsageCount) / float64(tests.Uses) * 100,
+			})
+		}
+		r.TestCoverage = coverage
+	}
+	if info := inputs.info; info != nil {
+		database := &reportDatabase{
+			HotMetric:        inputs.hotMetric.String(),
+			AutoIncrements:   checkAutoIncrements(info),
+			UnindexedFilters: checkIndexes(queries, info),
+		}
+		for _, w := range inputs.weights {
+			weight := reportQueryWeight{QueryWeight: w, AvgLatency: w.AvgLatency()}
+			if database.HotMetric != "" {
+				weight.Score = inputs.hotMetric.Score(w)
+			}
+			database.QueryWeights = append(database.QueryWeights, weight)
+		}
+		for _, table := range partitionedTables(info) {
+			database.PartitionedTables = append(database.PartitionedTables, reportPartitioning{Table: table.Name, Partitioning: *table.Partitioning})
+		}
+		for _, suggestion := range suggestIndexes(queries, info) {
+			database.IndexSuggestions = append(database.IndexSuggestions, reportIndexSuggestion{IndexSuggestion: suggestion, Statement: suggestion.Statement()})
+		}
+		database.UnusedTables, database.UnusedColumns = checkUnused(queries, info)
+		r.Database = database
+	}
+}
+
+// SampleNote tells how the usage counts relate to the query log when it was sampled, see sampleNote
+func (r keysReport) SampleNote() string {
+	return sampleNote(r.SamplePercentage, r.MaxQueries)
+}
+
+// hotQueries returns the query structures, the most used first
+func hotQueries(queries *keys.Output) []hotQuery {
+	var total int
+	result := make([]hotQuery, 0, len(queries.Queries))
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		result = append(result, hotQuery{
+			ID:            query.ID,
+			Query:         query.QueryStructure,
+			StatementType: query.StatementType,
+			UsageCount:    query.UsageCount,
+			tables:        query.TableName,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UsageCount > result[j].UsageCount
+	})
+	for i := range result {
+		result[i].Percentage = float64(result[i].UsageCount) / float64(total) * 100
+	}
+	return result
+}
+
+// printReport writes the summary of a keys file in the markdown, JSON, HTML or PDF format
+func printReport(out io.Writer, format string, file readingSummary, limits ReportLimits, inputs keysInputs) error {
+	switch format {
+	case FormatMarkdown:
+		printMarkdownReport(out, file, limits, inputs)
+		return nil
+	case FormatJSON:
+		return printJSONReport(out, file, limits, inputs)
+	case FormatPDF:
+		return printPDFReport(out, file, limits, inputs)
+	default:
+		return printHTMLReport(out, file, limits, inputs)
+	}
+}
+
+// printJSONReport writes the summary of a keys file as JSON, for other tools to read
+func printJSONReport(out io.Writer, file readingSummary, limits ReportLimits, inputs keysInputs) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newKeysReport(file, limits, inputs))
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+func reportTestFile() readingSummary {
+	return readingSummary{Name: "keys.json", AnalysedQueries: &keys.Output{
+		SampleRate: 0.5,
+		BucketSize: "1h0m0s",
+		Tables:     []keys.TableStats{{Table: "t", Reads: 4}, {Table: "u", Reads: 1}},
+		Queries: []keys.QueryAnalysisResult{{
+			ID:             "0123456789abcdef",
+			QueryStructure: "SELECT * FROM `t` JOIN `u` ON `t`.`id` = `u`.`t_id` WHERE `t`.`a` < :a",
+			StatementType:  "SELECT",
+			UsageCount:     3,
+			TableName:      []string{"t", "u"},
+			FilterColumns:  []operators.ColumnUse{{Column: operators.Column{Table: "t", Name: "a"}, Uses: 1}},
+			JoinPredicates: []operators.JoinPredicate{{
+				LHS: operators.Column{Table: "t", Name: "id"}, RHS: operators.Column{Table: "u", Name: "t_id"}, Uses: 0,
+			}},
+			LineNumbers: []int{1},
+			Users:       map[string]int{"app": 3},
+			Buckets:     map[time.Time]int{time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC): 3},
+		}, {
+			QueryStructure: "SELECT * FROM `t`",
+			StatementType:  "SELECT",
+			UsageCount:     1,
+			TableName:      []string{"t"},
+			FullScan:       true,
+			LineNumbers:    []int{2},
+		}},
+		Values: []keys.ColumnValues{{
+			Column:    operators.Column{Table: "t", Name: "a"},
+			Uses:      3,
+			Distinct:  2,
+			TopValues: []keys.ValueCount{{Value: "1", Count: 2}, {Value: "2", Count: 1}},
+			Sketch:    []byte{1, 2, 3},
+		}},
+		Failed: []keys.QueryFailedResult{{Query: "select <script>", Error: "syntax error"}},
+	}}
+}
+
+func TestPrintJSONReport(t *testing.T) {
+	sb := &strings.Builder{}
+	require.NoError(t, printJSONReport(sb, reportTestFile(), ReportLimits{}, keysInputs{}))
+
+	var report keysReport
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &report))
+	require.Equal(t, ReportFileType, report.FileType)
+	require.Equal(t, ReportVersion, report.Version)
+	require.Equal(t, "keys.json", report.Name)
+	require.InDelta(t, 50, report.SamplePercentage, 0.01)
+	require.Len(t, report.HotQueries, 2)
+	require.Equal(t, "0123456789abcdef", report.HotQueries[0].ID)
+	require.Equal(t, hotQuery{Query: "SELECT * FROM `t`", StatementType: "SELECT", UsageCount: 1, Percentage: 25}, report.HotQueries[1])
+	require.Equal(t, []reportColumn{{Name: "a", Filter: 75}}, report.TableSummaries[0].Columns)
+	require.Equal(t, []string{"t.id = u.t_id"}, report.TableSummaries[0].JoinPredicates)
+	require.Equal(t, []FailuresSummary{{Query: "select <script>", Error: "syntax error"}}, report.Failures)
+	require.Equal(t, []UserSummary{{User: "app", UsageCount: 3, Percentage: 75, QueryStructures: 1, MostUsedQuery: report.HotQueries[0].Query}}, report.Users)
+	require.NotNil(t, report.Traffic)
+	require.Equal(t, "1h0m0s", report.Traffic.BucketSize)
+	require.Equal(t, "UTC", report.Traffic.Location)
+	require.Equal(t, []BucketSummary{{Start: time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC), UsageCount: 3, QueryStructures: 1}}, report.Traffic.Buckets)
+	require.Equal(t, []reportValues{{Column: "t.a", Uses: 3, Distinct: 2, TopValues: []keys.ValueCount{{Value: "1", Count: 2}, {Value: "2", Count: 1}}}}, report.Values)
+	// the graph is only drawn by the HTML report, and the sketches are only needed to merge keys files
+	require.NotContains(t, sb.String(), "Graph")
+	require.NotContains(t, sb.String(), "sketch")
+}
+
+func TestReportInputs(t *testing.T) {
+	file := reportTestFile()
+	info := &dbinfo.Info{
+		Tables: []dbinfo.TableInfo{{
+			Name:    "t",
+			Rows:    1000,
+			Columns: []dbinfo.ColumnInfo{{Name: "id", Type: "bigint"}, {Name: "a", Type: "int"}, {Name: "b", Type: "int"}},
+		}, {
+			Name:    "u",
+			Columns: []dbinfo.ColumnInfo{{Name: "t_id", Type: "bigint"}},
+			Indexes: []dbinfo.IndexInfo{{Name: "t_id", Columns: []string{"t_id"}}},
+		}, {
+			Name:         "v",
+			Rows:         10,
+			Partitioning: &dbinfo.Partitioning{Method: "HASH", Expression: "id", Partitions: 4},
+		}},
+		QueryStats: []dbinfo.QueryStat{{DigestText: "SELECT * FROM `t`", Executions: 5, TotalLatency: 10, RowsExamined: 5000}},
+	}
+	inputs := keysInputs{
+		info:         info,
+		weights:      weightQueries(file.AnalysedQueries, info.QueryStats),
+		tenancy:      TenancyConfig{"t": "tenant_id"},
+		shardingKeys: ShardingKeys{"t": "a"},
+		tested:       map[string]bool{"SELECT * FROM `t`": true},
+	}
+
+	sb := &strings.Builder{}
+	require.NoError(t, printJSONReport(sb, file, ReportLimits{}, inputs))
+	var report keysReport
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &report))
+	require.NotNil(t, report.Tenancy)
+	require.Len(t, report.Tenancy.Violations, 2)
+	// the range filter on t.a doesn't route the queries to a shard
+	require.Equal(t, &reportShardingKeyCoverage{Uses: 8, Tables: []ShardingKeyCoverage{{Table: "t", ShardingKey: "a", Uses: 8}}},
+		report.ShardingKeyCoverage)
+	require.NotNil(t, report.TestCoverage)
+	require.Equal(t, 2, report.TestCoverage.Queries)
+	require.Equal(t, 1, report.TestCoverage.CoveredQueries)
+	require.Len(t, report.TestCoverage.Uncovered, 1)
+	require.Equal(t, "0123456789abcdef", report.TestCoverage.Uncovered[0].ID)
+
+	database := report.Database
+	require.NotNil(t, database)
+	require.Len(t, database.QueryWeights, 1)
+	require.True(t, database.QueryWeights[0].Matched)
+	require.InDelta(t, 2, database.QueryWeights[0].AvgLatency, 0.001)
+	require.Equal(t, []reportPartitioning{{Table: "v", Partitioning: *info.Tables[2].Partitioning}}, database.PartitionedTables)
+	require.Equal(t, []UnindexedFilter{{Table: "t", Column: "a", Uses: 3, Rows: 1000}}, database.UnindexedFilters)
+	require.NotEmpty(t, database.IndexSuggestions)
+	require.Equal(t, database.IndexSuggestions[0].IndexSuggestion.Statement(), database.IndexSuggestions[0].Statement)
+	require.Equal(t, []UnusedTable{{Table: "v", Rows: 10}}, database.UnusedTables)
+	require.Equal(t, []UnusedColumns{{Table: "t", Columns: []string{"b"}}}, database.UnusedColumns)
+
+	sb.Reset()
+	printMarkdownReport(sb, file, ReportLimits{}, inputs)
+	for _, section := range []string{"## Tenancy", "## Sharding key coverage", "## Test coverage", "## Query statistics from performance_schema",
+		"## Partitioned tables", "## Indexes", "## Unused tables and columns"} {
+		require.Contains(t, sb.String(), "\n"+section+"\n")
+	}
+
+	// the HTML and PDF reports have these sections too
+	sb.Reset()
+	require.NoError(t, printHTMLReport(sb, file, ReportLimits{}, inputs))
+	for _, section := range []string{"Tenancy", "Sharding key coverage", "Test coverage", "Query statistics from performance_schema",
+		"Partitioned tables", "Indexes", "Unused tables and columns"} {
+		require.Contains(t, sb.String(), "<h2>"+section+"</h2>")
+	}
+	require.Contains(t, sb.String(), "<td><code>CREATE INDEX idx_t_a ON t (a);</code>")
+	buf := &bytes.Buffer{}
+	require.NoError(t, printPDFReport(buf, file, ReportLimits{}, inputs))
+	requireValidPDF(t, buf.Bytes())
+	for _, section := range []string{"Tenancy", "Test coverage", "Indexes", "Unused tables and columns"} {
+		require.Contains(t, buf.String(), "("+section+") Tj")
+	}
+
+	// the hot metric ranks the hot queries, the queries without statistics are scored with their usage count
+	metric, err := ParseHotMetric("rows-examined+executions")
+	require.NoError(t, err)
+	inputs.hotMetric = metric
+	ranked := newKeysReport(file, ReportLimits{}, inputs)
+	require.Equal(t, "rows-examined+executions", ranked.HotMetric)
+	require.Equal(t, []string{"SELECT * FROM `t`", file.AnalysedQueries.Queries[0].QueryStructure},
+		[]string{ranked.HotQueries[0].Query, ranked.HotQueries[1].Query})
+	require.InDelta(t, 5005, ranked.HotQueries[0].Score, 0.001)
+	require.InDelta(t, 3, ranked.HotQueries[1].Score, 0.001)
+	sb.Reset()
+	printMarkdownReport(sb, file, ReportLimits{}, inputs)
+	require.Contains(t, sb.String(), "## Hot queries\n\nRanked by rows-examined+executions.\n")
+
+	// the sections are left out when their files are not given
+	sb.Reset()
+	require.NoError(t, printJSONReport(sb, file, ReportLimits{}, keysInputs{}))
+	require.NotContains(t, sb.String(), "tenancy")
+	require.NotContains(t, sb.String(), "database")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// SettingAdvice is a vtgate, vttablet or session setting recommended for the workload, with the part of the
+// workload that calls for it
+type SettingAdvice struct {
+	// Component is vtgate, vttablet or session
+	Component string `json:"component"`
+	Setting   string `json:"setting"`
+	Advice    string `json:"advice"`
+	Rationale string `json:"rationale"`
+}
+
+// adviseSettings recommends the settings the workload depends on, from what the queries of the keys file do:
+// the scatter queries sorting or grouping their rows, the selects reading whole tables, the queries targeting
+// replicas and those setting their own timeout
+func adviseSettings(queries *keys.Output) []SettingAdvice {
+	var total, inMemory, unlimited, replicas, timeouts int
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		scatter := query.FullScan || (query.Observed != nil && query.Observed.Scatter > 0)
+		if scatter && (len(query.GroupingColumns) > 0 || (len(query.OrderingColumns) > 0 && !query.Limit)) {
+			inMemory += query.UsageCount
+		}
+		if query.FullScan && !query.Limit && query.StatementType == "SELECT" {
+			unlimited += query.UsageCount
+		}
+		for target, count := range query.Targets {
+			if strings.HasSuffix(target, "@replica") || strings.HasSuffix(target, "@rdonly") {
+				replicas += count
+			}
+		}
+		for hint, count := range query.Hints {
+			if strings.HasPrefix(hint, "QUERY_TIMEOUT_MS=") {
+				timeouts += count
+			}
+		}
+	}
+	share := func(uses int) string {
+		return fmt.Sprintf("%.2f%% of query uses (%d of %d)", float64(uses)/float64(total)*100, uses, total)
+	}
+
+	var advice []SettingAdvice
+	if inMemory > 0 {
+		advice = append(advice, SettingAdvice{
+			Component: "vtgate",
+			Setting:   "--max_memory_rows",
+			Advice:    "raise it above the number of rows these queries read, it is 300000 by default",
+			Rationale: share(inMemory) + " group or sort the rows of all the shards, which vtgate does in memory",
+		})
+	}
+	if unlimited > 0 {
+		advice = append(advice, SettingAdvice{
+			Component: "session",
+			Setting:   "SET workload = 'olap'",
+			Advice:    "run these queries in OLAP sessions, or raise --queryserver-config-max-result-size on vttablet, 10000 rows by default",
+			Rationale: share(unlimited) + " read whole tables without a LIMIT, OLTP sessions fail when the result is larger than the limit",
+		})
+	}
+	if replicas > 0 {
+		advice = append(advice, SettingAdvice{
+			Component: "vtgate",
+			Setting:   "--tablet_types_to_wait",
+			Advice:    "list the REPLICA and RDONLY tablet types, so vtgate waits for them before serving",
+			Rationale: share(replicas) + " target replica tablets explicitly",
+		})
+	}
+	if timeouts > 0 && timeouts < total {
+		advice = append(advice, SettingAdvice{
+			Component: "vtgate",
+			Setting:   "--query-timeout",
+			Advice:    "set a default timeout in milliseconds, the other queries have none",
+			Rationale: share(timeouts) + " set their own timeout with the QUERY_TIMEOUT_MS hint",
+		})
+	}
+	return advice
+}
+
+func renderSettingsTable(out io.Writer, advice []SettingAdvice) {
+	table := createTableWriter(out, []string{"Component", "Setting", "Advice", "Rationale"})
+	for _, a := range advice {
+		table.Append([]string{a.Component, a.Setting, a.Advice, a.Rationale})
+	}
+	table.Render()
+}
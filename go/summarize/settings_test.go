@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestAdviseSettings(t *testing.T) {
+	queries := &keys.Output{Queries: []keys.QueryAnalysisResult{{
+		QueryStructure:  "SELECT `c`, count(*) FROM `t` GROUP BY `c`",
+		StatementType:   "SELECT",
+		UsageCount:      2,
+		GroupingColumns: []operators.Column{{Table: "t", Name: "c"}},
+		FullScan:        true,
+	}, {
+		QueryStructure: "SELECT * FROM `t` WHERE `id` = :id",
+		StatementType:  "SELECT",
+		UsageCount:     4,
+		Targets:        map[string]int{"ks@replica": 3},
+		Hints:          map[string]int{"QUERY_TIMEOUT_MS=100": 1},
+	}, {
+		QueryStructure:  "SELECT * FROM `t` ORDER BY `c` ASC LIMIT :1",
+		StatementType:   "SELECT",
+		UsageCount:      4,
+		OrderingColumns: []operators.Column{{Table: "t", Name: "c"}},
+		Limit:           true,
+		FullScan:        true,
+	}}}
+
+	advice := adviseSettings(queries)
+	require.Len(t, advice, 4)
+	require.Equal(t, "--max_memory_rows", advice[0].Setting)
+	require.Equal(t, "20.00% of query uses (2 of 10) group or sort the rows of all the shards, which vtgate does in memory", advice[0].Rationale)
+	require.Equal(t, "SET workload = 'olap'", advice[1].Setting)
+	require.Contains(t, advice[1].Rationale, "(2 of 10)")
+	require.Equal(t, "--tablet_types_to_wait", advice[2].Setting)
+	require.Contains(t, advice[2].Rationale, "(3 of 10)")
+	require.Equal(t, "--query-timeout", advice[3].Setting)
+	require.Contains(t, advice[3].Rationale, "(1 of 10)")
+
+	// point queries need no setting
+	require.Empty(t, adviseSettings(&keys.Output{Queries: []keys.QueryAnalysisResult{{
+		QueryStructure: "SELECT * FROM `t` WHERE `id` = :id",
+		StatementType:  "SELECT",
+		UsageCount:     4,
+	}}}))
+}
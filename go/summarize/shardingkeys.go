@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// ShardingKeys maps a table name to the column it would be sharded by.
+// It is read from a JSON object such as {"orders": "customer_id", "customer": "id"}.
+type ShardingKeys map[string]string
+
+// ShardingKeyCoverage is how many of the uses of the queries of a table constrain its sharding key.
+// Those queries can be routed to the shards holding the rows, the others are scattered on all shards.
+type ShardingKeyCoverage struct {
+	Table       string `json:"table"`
+	ShardingKey string `json:"shardingKey"`
+	// Uses is the number of uses of the queries reading or modifying the table, the inserts being left out
+	Uses    int `json:"uses"`
+	Covered int `json:"covered"`
+}
+
+func readShardingKeys(fileName string) (ShardingKeys, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var cfg ShardingKeys
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("reading sharding keys %s: %w", fileName, err)
+	}
+	return cfg, nil
+}
+
+// checkShardingKeys returns the sharding key coverage of the tables of the config, sorted by table name.
+// A query constrains the sharding key of a table when it filters the key with an equality or IN predicate,
+// or joins it with equality to a column of another table it filters that way.
+// Inserts are left out, they are routed by the values they insert.
+func checkShardingKeys(queries *keys.Output, cfg ShardingKeys) []ShardingKeyCoverage {
+	coverage := make(map[string]*ShardingKeyCoverage)
+	for table, column := range cfg {
+		coverage[strings.ToLower(table)] = &ShardingKeyCoverage{Table: table, ShardingKey: column}
+	}
+	for _, query := range queries.Queries {
+		if query.StatementType == "INSERT" {
+			continue
+		}
+		var seen []string
+		for _, table := range query.TableName {
+			c, found := coverage[strings.ToLower(table)]
+			if !found || slices.Contains(seen, c.Table) {
+				continue
+			}
+			// a table referenced several times by the query is only counted once
+			seen = append(seen, c.Table)
+			c.Uses += query.UsageCount
+			if constrainsShardingKey(query, table, c.ShardingKey) {
+				c.Covered += query.UsageCount
+			}
+		}
+	}
+
+	result := make([]ShardingKeyCoverage, 0, len(coverage))
+	for _, c := range coverage {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result
+}
+
+func constrainsShardingKey(query keys.QueryAnalysisResult, table, column string) bool {
+	if filtersOnColumn(query, table, column) {
+		return true
+	}
+	for _, predicate := range query.JoinPredicates {
+		if predicate.Uses != sqlparser.EqualOp {
+			continue
+		}
+		lhs, rhs := predicate.LHS, predicate.RHS
+		if !strings.EqualFold(lhs.Table, table) || !strings.EqualFold(lhs.Name, column) {
+			lhs, rhs = rhs, lhs
+		}
+		if strings.EqualFold(lhs.Table, table) && strings.EqualFold(lhs.Name, column) && filtersOnColumn(query, rhs.Table, rhs.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func printShardingKeyCoverage(out io.Writer, coverage []ShardingKeyCoverage) {
+	var uses, covered int
+	for _, c := range coverage {
+		uses += c.Uses
+		covered += c.Covered
+	}
+	if uses == 0 {
+		fmt.Fprintln(out, "No query uses the tables of the sharding keys")
+		return
+	}
+
+	fmt.Fprintf(out, "Sharding key coverage: %.2f%% of the query uses of the tables (%d of %d) constrain their sharding key\n",
+		float64(covered)/float64(uses)*100, covered, uses)
+	table := createTableWriter(out, []string{"Table", "Sharding Key", "Query Uses", "Covered", "Coverage %"})
+	for _, c := range coverage {
+		percentage := "-"
+		if c.Uses > 0 {
+			percentage = fmt.Sprintf("%.2f%%", float64(c.Covered)/float64(c.Uses)*100)
+		}
+		table.Append([]string{c.Table, c.ShardingKey, strconv.Itoa(c.Uses), strconv.Itoa(c.Covered), percentage})
+	}
+	table.Render()
+}
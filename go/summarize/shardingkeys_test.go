@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestCheckShardingKeys(t *testing.T) {
+	column := func(table, name string) operators.Column {
+		return operators.Column{Table: table, Name: name}
+	}
+	filter := func(table, name string, op sqlparser.ComparisonExprOperator) operators.ColumnUse {
+		return operators.ColumnUse{Column: column(table, name), Uses: op}
+	}
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{
+			QueryStructure: "select * from orders where customer_id = :1",
+			UsageCount:     6,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{filter("orders", "customer_id", sqlparser.EqualOp)},
+			StatementType:  "SELECT",
+		}, {
+			QueryStructure: "select * from orders where id = :1",
+			UsageCount:     2,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{filter("orders", "id", sqlparser.EqualOp)},
+			StatementType:  "SELECT",
+		}, {
+			// the sharding key of orders is joined to the filtered key of customer
+			QueryStructure: "select * from customer join orders on orders.customer_id = customer.id where customer.id in ::1",
+			UsageCount:     3,
+			TableName:      []string{"customer", "orders"},
+			FilterColumns:  []operators.ColumnUse{filter("customer", "id", sqlparser.InOp)},
+			JoinPredicates: []operators.JoinPredicate{{LHS: column("orders", "customer_id"), RHS: column("customer", "id"), Uses: sqlparser.EqualOp}},
+			StatementType:  "SELECT",
+		}, {
+			QueryStructure: "update customer set name = :1 where id > :2",
+			UsageCount:     1,
+			TableName:      []string{"customer"},
+			FilterColumns:  []operators.ColumnUse{filter("customer", "id", sqlparser.GreaterThanOp)},
+			StatementType:  "UPDATE",
+		}, {
+			QueryStructure: "insert into orders (customer_id, id) values (:1, :2)",
+			UsageCount:     5,
+			TableName:      []string{"orders"},
+			StatementType:  "INSERT",
+		}},
+	}
+
+	coverage := checkShardingKeys(queries, ShardingKeys{"orders": "customer_id", "customer": "ID", "product": "id"})
+	require.Equal(t, []ShardingKeyCoverage{
+		{Table: "customer", ShardingKey: "ID", Uses: 4, Covered: 3},
+		{Table: "orders", ShardingKey: "customer_id", Uses: 11, Covered: 9},
+		{Table: "product", ShardingKey: "id"},
+	}, coverage)
+
+	sb := &strings.Builder{}
+	printShardingKeyCoverage(sb, coverage)
+	assert.Equal(t, `Sharding key coverage: 80.00% of the query uses of the tables (12 of 15) constrain their sharding key
++----------+--------------+------------+---------+------------+
+|  Table   | Sharding Key | Query Uses | Covered | Coverage % |
++----------+--------------+------------+---------+------------+
+| customer | ID           |          4 |       3 | 75.00%     |
+| orders   | customer_id  |         11 |       9 | 81.82%     |
+| product  | id           |          0 |       0 | -          |
++----------+--------------+------------+---------+------------+
+`, sb.String())
+
+	sb.Reset()
+	printShardingKeyCoverage(sb, checkShardingKeys(&keys.Output{}, ShardingKeys{"orders": "customer_id"}))
+	assert.Equal(t, "No query uses the tables of the sharding keys\n", sb.String())
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// traceIndex holds the metrics of the queries of a trace file, keyed by the hash of their text.
+// Neither the traces nor the text of the queries are kept, so the index of a large file stays small.
+type traceIndex struct {
+	summaries map[uint64]QuerySummary
+	version   string
+}
+
+// queryHash identifies a query in a traceIndex
+func queryHash(query string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(query))
+	return h.Sum64()
+}
+
+// isTraceFile tells if a file holds the traces of 'vt tester --trace', false when it can't be read
+func isTraceFile(fileName string) bool {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	_, delim, err := getDecoderAndDelim(file)
+	return err == nil && delim == json.Delim('[')
+}
+
+// compareTraceFiles compares two trace files like compareTraces, without loading them in memory:
+// the second file is indexed first, then the queries of the first one are read and compared one at a time.
+// The queries are compared in the order of the first file, the order they were traced in.
+func compareTraceFiles(out io.Writer, termWidth int, highLighter Highlighter, cfg Config) error {
+	var renames keys.Renames
+	if cfg.RenameFile != "" {
+		var err error
+		renames, err = keys.ReadRenames(cfg.RenameFile)
+		if err != nil {
+			return err
+		}
+	}
+	parser := sqlparser.NewTestParser()
+	rename := func(q *TracedQuery) {
+		if renames != nil {
+			q.Query = renameQuery(parser, renames, q.Query)
+		}
+	}
+
+	fileName1, fileName2 := cfg.Files[0], cfg.Files[1]
+	index := traceIndex{summaries: make(map[uint64]QuerySummary)}
+	err := streamTraceFile(fileName2, cfg.Strict, func(q TracedQuery) {
+		rename(&q)
+		if index.version == "" {
+			index.version = q.VitessVersion
+		}
+		summary := summarizeTrace(q)
+		summary.Q = TracedQuery{}
+		index.summaries[queryHash(q.Query)] = summary
+	})
+	if err != nil {
+		return err
+	}
+
+	version, err := readTraceVersion(fileName1)
+	if err != nil {
+		return err
+	}
+	err = cfg.checkComparable(
+		readingSummary{Name: fileName1, VitessVersion: version},
+		readingSummary{Name: fileName2, VitessVersion: index.version})
+	if err != nil {
+		return err
+	}
+
+	c := &traceComparison{out: out, termWidth: termWidth, highLighter: highLighter, name1: fileName1, name2: fileName2}
+	err = streamTraceFile(fileName1, cfg.Strict, func(q TracedQuery) {
+		rename(&q)
+		s2, found := index.summaries[queryHash(q.Query)]
+		if !found {
+			return
+		}
+		c.add(summarizeTrace(q), s2)
+	})
+	if err != nil {
+		return err
+	}
+	c.printSummary()
+	return nil
+}
+
+// streamTraceFile calls f for each query of a trace file, reading the file one query at a time.
+// Unless strict is set, a truncated or corrupted file is only warned about, after its complete queries.
+func streamTraceFile(fileName string, strict bool, f func(TracedQuery)) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+	decoder, _, err := getDecoderAndDelim(file)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", fileName, err)
+	}
+
+	entries := 0
+	err = decodeArray(decoder, func(q TracedQuery) {
+		entries++
+		f(q)
+	})
+	if err == nil {
+		return nil
+	}
+	if strict || entries == 0 {
+		return fmt.Errorf("error reading json of %s: %w", fileName, err)
+	}
+	log.Warnf("%s is truncated or corrupted (%v), comparing the %d entries that could be read",
+		fileName, err, entries)
+	return nil
+}
+
+// readTraceVersion returns the Vitess version recorded by the first query of a trace file
+func readTraceVersion(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+	decoder, _, err := getDecoderAndDelim(file)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", fileName, err)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return "", fmt.Errorf("error reading json of %s: %w", fileName, err)
+	}
+	if !decoder.More() {
+		return "", nil
+	}
+	// a corrupted first query leaves the version empty, the error is reported when the file is compared
+	var q TracedQuery
+	_ = decoder.Decode(&q)
+	return q.VitessVersion, nil
+}
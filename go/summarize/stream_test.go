@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTraceFile(t *testing.T, fileName string, queries []TracedQuery) {
+	raw, err := json.Marshal(queries)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(fileName, raw, 0o600))
+}
+
+func TestCompareTraceFiles(t *testing.T) {
+	dir := t.TempDir()
+	file1, file2 := filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")
+	trace1, trace2 := tf1(), tf2()
+	trace1.Name, trace2.Name = file1, file2
+	writeTraceFile(t, file1, trace1.TracedQueries)
+	writeTraceFile(t, file2, trace2.TracedQueries)
+	require.True(t, isTraceFile(file1))
+
+	// the streamed comparison prints what the comparison of the loaded files does
+	want := &strings.Builder{}
+	compareTraces(want, 80, noHighlight, trace1, trace2)
+	sb := &strings.Builder{}
+	require.NoError(t, compareTraceFiles(sb, 80, noHighlight, Config{Files: []string{file1, file2}}))
+	require.Equal(t, want.String(), sb.String())
+
+	// the queries of a truncated file are compared, unless the comparison is strict
+	raw, err := os.ReadFile(file2)
+	require.NoError(t, err)
+	truncated := filepath.Join(dir, "truncated.json")
+	require.NoError(t, os.WriteFile(truncated, raw[:len(raw)-20], 0o600))
+	sb.Reset()
+	require.NoError(t, compareTraceFiles(sb, 80, noHighlight, Config{Files: []string{file1, truncated}}))
+	require.Contains(t, sb.String(), "- 1 out of 1 queries showed significant change")
+	err = compareTraceFiles(sb, 80, noHighlight, Config{Files: []string{file1, truncated}, Strict: true})
+	require.ErrorContains(t, err, "error reading json of "+truncated)
+
+	trace2.TracedQueries[0].VitessVersion = "21.0.0"
+	trace1.TracedQueries[0].VitessVersion = "20.0.1"
+	writeTraceFile(t, file1, trace1.TracedQueries)
+	writeTraceFile(t, file2, trace2.TracedQueries)
+	err = compareTraceFiles(sb, 80, noHighlight, Config{Files: []string{file1, file2}})
+	require.ErrorContains(t, err, "use --allow-version-mismatch")
+}
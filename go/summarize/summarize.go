@@ -17,6 +17,8 @@ limitations under the License.
 package summarize
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"iter"
@@ -26,13 +28,17 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/chroma/quick"
 	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/term"
 	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
 
+	"github.com/vitessio/vt/go/dbinfo"
 	"github.com/vitessio/vt/go/keys"
 )
 
@@ -42,6 +48,8 @@ type (
 		Trace      Trace  `json:"Trace"`
 		Query      string `json:"Query"`
 		LineNumber string `json:"LineNumber"`
+		// VitessVersion is the version of the vtgate that planned the query, it is not in older trace files
+		VitessVersion string `json:"VitessVersion,omitempty"`
 	}
 
 	// Trace represents the recursive structure of the Trace field
@@ -67,27 +75,367 @@ type (
 		Name string
 
 		// Only one of these fields will be populated
-		TracedQueries   []TracedQuery // Set when analyzing a 'vt tester --trace' output
-		AnalysedQueries *keys.Output  // Set when analyzing a 'vt keys' output
+		TracedQueries   []TracedQuery  // Set when analyzing a 'vt tester --trace' output
+		AnalysedQueries *keys.Output   // Set when analyzing a 'vt keys' output
+		Latencies       []QueryLatency // Set when analyzing a 'vt tester --latency-file' output
+
+		// VitessVersion is the Vitess version a trace file was written with, empty when the file doesn't record it
+		VitessVersion string
+
+		// Incomplete is set when the file is truncated or corrupted, typically by a crashed run,
+		// and only the entries before the problem could be read
+		Incomplete *incompleteFile
+	}
+
+	incompleteFile struct {
+		// Entries is the number of queries and failures that were recovered
+		Entries int
+		Err     error
 	}
 )
 
-func Run(args []string) {
-	traces := make([]readingSummary, len(args))
-	for i, arg := range args {
-		traces[i] = readTraceFile(arg)
+// Config contains the options of a 'vt summarize' run
+type Config struct {
+	// Files are the trace or keys files to summarize. Two files are compared with each other,
+	// more than two trace files are compared with the first one, see compareTraceMatrix.
+	Files []string
+
+	// TenancyFile is the JSON file declaring the tenancy column of each table, see TenancyConfig.
+	// When set, queries of a keys file that don't filter on the tenancy column are reported.
+	TenancyFile string
+
+	// ShardingKeysFile is the JSON file declaring the column each table would be sharded by, see ShardingKeys.
+	// When set, the share of the queries of a keys file that constrain the sharding keys is reported.
+	ShardingKeysFile string
+
+	// RenameFile maps old table names to new ones, see keys.Renames. The renames are applied to the files
+	// before they are summarized, so captures taken before and after a rename can be compared.
+	RenameFile string
+
+	// TestFiles are the mysqltest files of a test suite. When set, the share of the queries of a keys file
+	// that the test files run too is reported, along with the most used queries they don't run.
+	TestFiles []string
+
+	// DBInfoFile is a file written by 'vt dbinfo'. When set, the filter columns of a keys file
+	// that are not indexed in the database are reported. When the file has the query statistics
+	// of performance_schema, the usage counts of the queries are replaced by their execution counts.
+	DBInfoFile string
+
+	// Diff prints a changelog of the differences between two keys files instead of comparing traces
+	Diff bool
+	// DiffThreshold is the relative change, in percent, of a table usage that is reported in the changelog
+	DiffThreshold float64
+	// FailOnSeverity counts the new findings of a diff of this severity or above in Summary.BlockingFindings,
+	// the vt command exits with an error when there are some
+	FailOnSeverity keys.Severity
+
+	// LatencyThreshold is the percentage by which the median latency of a query must be higher on Vitess
+	// than on MySQL for the query to be listed as a regression
+	LatencyThreshold float64
+
+	// Strict fails on truncated or corrupted files, instead of summarizing the entries that could be read
+	Strict bool
+
+	// AllowVersionMismatch compares two trace files written with different major versions of Vitess,
+	// with a warning, instead of refusing to
+	AllowVersionMismatch bool
+
+	// Format is the format of the summary. When empty, a single keys file is summarized as markdown
+	// and the other files as text.
+	Format string
+	// TrendDir is a directory of keys files, such as one per day. When set, the growth of the usage
+	// of the query structures and tables across these files is reported instead of summarizing Files.
+	TrendDir string
+	// NoColor prints the queries without syntax highlighting, as when NO_COLOR is set in the environment.
+	// The colors are escape codes that only terminals render, so a summary written to a file should have none.
+	NoColor bool
+	// Limits shorten the sections of the markdown, JSON, HTML and PDF formats
+	Limits ReportLimits
+
+	// HotMetric ranks the query statistics of the dbinfo file, which are ranked by their total latency when it is not set,
+	// and the hot queries of the report formats, which are ranked by their usage count when it is not set.
+	// It needs DBInfoFile.
+	HotMetric HotMetric
+
+	// Location is the time zone the times of the summaries are printed in, UTC when nil
+	Location *time.Location
+
+	// VSchemaFile is the file the vschema suggested for the recommended sharding keys of a keys file is written to
+	VSchemaFile string
+}
+
+const (
+	// FormatText prints the summary as text tables, for the terminal
+	FormatText = "text"
+	// FormatMarkdown writes the summary of a keys file as markdown
+	FormatMarkdown = "markdown"
+	// FormatJSON writes the summary of a keys file as JSON
+	FormatJSON = "json"
+	// FormatHTML writes the summary of a keys file as a self-contained HTML report
+	FormatHTML = "html"
+	// FormatPDF writes the summary of a keys file as a PDF document
+	FormatPDF = "pdf"
+)
+
+// Summary is the summary of the files of a config, returned by Run for the vt command, or another program, to write
+type Summary struct {
+	// BlockingFindings is the number of new findings of a diff of the severity FailOnSeverity or above
+	BlockingFindings int
+
+	output []byte
+}
+
+// WriteTo writes the summary, it can be written several times
+func (s *Summary) WriteTo(w io.Writer) (int64, error) {
+	return bytes.NewReader(s.output).WriteTo(w)
+}
+
+func (s *Summary) String() string {
+	return string(s.output)
+}
+
+// Run summarizes or compares the files of the config, and returns the summary for the caller to write.
+// Errors are returned instead of exiting the process, the vt command is the one exiting on them.
+func Run(cfg Config) (*Summary, error) {
+	if len(cfg.Files) == 0 && cfg.TrendDir == "" {
+		return nil, errors.New("no file to summarize")
+	}
+	if len(cfg.Files) > 0 && cfg.TrendDir != "" {
+		return nil, errors.New("--trend summarizes the keys files of its directory, without other files")
+	}
+	switch cfg.Format {
+	case "", FormatText, FormatMarkdown, FormatJSON, FormatHTML, FormatPDF:
+	default:
+		return nil, fmt.Errorf("unknown summary format %q, use text, markdown, json, html or pdf", cfg.Format)
+	}
+	if cfg.HotMetric.expression != "" && cfg.DBInfoFile == "" {
+		return nil, errors.New("--hot-metric ranks the queries by the query statistics of --dbinfo, it needs a dbinfo file")
+	}
+	highLighter := Highlighter(highlightQuery)
+	if cfg.NoColor || os.Getenv("NO_COLOR") != "" {
+		highLighter = noHighlight
+	}
+	summary := &Summary{}
+	var out bytes.Buffer
+	if err := cfg.summarize(&out, highLighter, summary); err != nil {
+		return nil, err
+	}
+	summary.output = out.Bytes()
+	return summary, nil
+}
+
+// summarize writes the summary of the files of the config to out
+func (cfg Config) summarize(out io.Writer, highLighter Highlighter, summary *Summary) error {
+	if cfg.TrendDir != "" {
+		return printTrend(out, cfg)
+	}
+	if len(cfg.Files) == 2 && !cfg.Diff && isTraceFile(cfg.Files[0]) && isTraceFile(cfg.Files[1]) {
+		// the trace files of large workloads don't fit in memory, they are compared as they are read
+		if err := compareTraceFiles(out, terminalWidth(), highLighter, cfg); err != nil {
+			return err
+		}
+		return nil
+	}
+	traces, err := readTraceFiles(cfg.Files, cfg.Strict)
+	if err != nil {
+		return err
+	}
+	for _, trace := range traces {
+		if trace.Incomplete != nil {
+			log.Warnf("%s is truncated or corrupted (%v), summarizing the %d entries that could be read",
+				trace.Name, trace.Incomplete.Err, trace.Incomplete.Entries)
+		}
+	}
+
+	var renames keys.Renames
+	if cfg.RenameFile != "" {
+		renames, err = keys.ReadRenames(cfg.RenameFile)
+		if err != nil {
+			return err
+		}
+		for i := range traces {
+			traces[i].applyRenames(renames)
+		}
 	}
 
 	firstTrace := traces[0]
+	format := cfg.Format
+	if format == "" && len(traces) == 1 && !cfg.Diff && firstTrace.AnalysedQueries != nil {
+		// a keys file is summarized as markdown unless the text format is asked for, the other files only have the text format
+		format = FormatMarkdown
+	}
+	if format != "" && format != FormatText {
+		if len(traces) != 1 || cfg.Diff || firstTrace.AnalysedQueries == nil {
+			return fmt.Errorf("--format=%s only works with a single keys file", format)
+		}
+		inputs, err := cfg.readKeysInputs(firstTrace.AnalysedQueries, renames)
+		if err != nil {
+			return err
+		}
+		if err := printReport(out, format, firstTrace, cfg.Limits, inputs); err != nil {
+			return err
+		}
+		if cfg.VSchemaFile != "" {
+			if err := writeVSchema(cfg.VSchemaFile, recommendShardingKeys(firstTrace.AnalysedQueries, inputs.info), inputs.info); err != nil {
+				return fmt.Errorf("error writing vschema: %w", err)
+			}
+		}
+		return nil
+	}
+	if cfg.Diff {
+		if len(traces) != 2 || firstTrace.AnalysedQueries == nil || traces[1].AnalysedQueries == nil {
+			return errors.New("--diff needs two keys files, the old one and the new one")
+		}
+		findings := printChangelog(out, terminalWidth(), firstTrace, traces[1], cfg.DiffThreshold)
+		if cfg.FailOnSeverity != "" {
+			summary.BlockingFindings = findingsAtLeast(findings, cfg.FailOnSeverity)
+		}
+		return nil
+	}
 	if len(traces) == 1 {
+		if firstTrace.Latencies != nil {
+			printLatencySummary(out, terminalWidth(), firstTrace, cfg.LatencyThreshold)
+			return nil
+		}
 		if firstTrace.AnalysedQueries == nil {
-			printTraceSummary(os.Stdout, terminalWidth(), highlightQuery, firstTrace)
+			printTraceSummary(out, terminalWidth(), highLighter, firstTrace)
 		} else {
-			printKeysSummary(os.Stdout, firstTrace)
+			inputs, err := cfg.readKeysInputs(firstTrace.AnalysedQueries, renames)
+			if err != nil {
+				return err
+			}
+			printKeysSummary(out, firstTrace, inputs.timeLocation())
+			recommendations := recommendShardingKeys(firstTrace.AnalysedQueries, inputs.info)
+			printShardingKeyRecommendations(out, recommendations)
+			if cfg.VSchemaFile != "" {
+				if err := writeVSchema(cfg.VSchemaFile, recommendations, inputs.info); err != nil {
+					return fmt.Errorf("error writing vschema: %w", err)
+				}
+			}
+			if inputs.tenancy != nil {
+				printTenancyViolations(out, checkTenancy(firstTrace.AnalysedQueries, inputs.tenancy))
+			}
+			if inputs.shardingKeys != nil {
+				printShardingKeyCoverage(out, checkShardingKeys(firstTrace.AnalysedQueries, inputs.shardingKeys))
+			}
+			if inputs.tested != nil {
+				printTestCoverage(out, checkTestCoverage(firstTrace.AnalysedQueries, inputs.tested))
+			}
+			if info := inputs.info; info != nil {
+				if len(inputs.weights) > 0 {
+					printQueryWeights(out, terminalWidth(), inputs.weights, cfg.HotMetric)
+				}
+				printTableRisks(out, info)
+				printUnindexedFilters(out, checkIndexes(firstTrace.AnalysedQueries, info))
+				printIndexSuggestions(out, suggestIndexes(firstTrace.AnalysedQueries, info))
+				unusedTables, unusedColumns := checkUnused(firstTrace.AnalysedQueries, info)
+				printUnused(out, unusedTables, unusedColumns)
+			}
+		}
+	} else if len(traces) > 2 {
+		for _, trace := range traces {
+			if trace.AnalysedQueries != nil || trace.Latencies != nil {
+				return errors.New("more than two files can only be compared when they are all trace files")
+			}
+		}
+		for _, trace := range traces[1:] {
+			if err := cfg.checkComparable(firstTrace, trace); err != nil {
+				return err
+			}
 		}
+		compareTraceMatrix(out, terminalWidth(), highLighter, traces)
 	} else {
-		compareTraces(os.Stdout, terminalWidth(), highlightQuery, firstTrace, traces[1])
+		if firstTrace.AnalysedQueries != nil && traces[1].AnalysedQueries != nil {
+			printKeysComparison(out, terminalWidth(), firstTrace, traces[1], cfg.DiffThreshold)
+			return nil
+		}
+		if err := cfg.checkComparable(firstTrace, traces[1]); err != nil {
+			return err
+		}
+		compareTraces(out, terminalWidth(), highLighter, firstTrace, traces[1])
+	}
+	return nil
+}
+
+// keysInputs are the files summarized along a keys file, the sections of the summary they add are left out
+// when they are not given, and the options of these sections
+type keysInputs struct {
+	// location is the time zone the times of the summary are printed in, see timeLocation
+	location *time.Location
+	info     *dbinfo.Info
+	// weights are the query statistics of the dbinfo file, ranked by the hot metric of the config
+	weights      []QueryWeight
+	hotMetric    HotMetric
+	tenancy      TenancyConfig
+	shardingKeys ShardingKeys
+	tested       map[string]bool
+}
+
+// timeLocation returns the time zone the times of the summary are printed in, UTC unless another one is given
+func (inputs keysInputs) timeLocation() *time.Location {
+	if inputs.location == nil {
+		return time.UTC
+	}
+	return inputs.location
+}
+
+// readKeysInputs reads the files of the config that are summarized along the queries of a keys file.
+// The usage counts of the queries are weighted with the query statistics of the dbinfo file,
+// before anything is summarized from them.
+func (cfg Config) readKeysInputs(queries *keys.Output, renames keys.Renames) (keysInputs, error) {
+	inputs := keysInputs{location: cfg.Location, hotMetric: cfg.HotMetric}
+	var err error
+	if cfg.DBInfoFile != "" {
+		inputs.info, err = readDBInfo(cfg.DBInfoFile)
+		if err != nil {
+			return inputs, fmt.Errorf("error reading dbinfo file: %w", err)
+		}
+		inputs.weights = weightQueries(queries, inputs.info.QueryStats)
+		if cfg.HotMetric.expression != "" {
+			rankQueryWeights(inputs.weights, cfg.HotMetric)
+		}
+	}
+	if cfg.TenancyFile != "" {
+		inputs.tenancy, err = readTenancyConfig(cfg.TenancyFile)
+		if err != nil {
+			return inputs, fmt.Errorf("error reading tenancy config: %w", err)
+		}
+	}
+	if cfg.ShardingKeysFile != "" {
+		inputs.shardingKeys, err = readShardingKeys(cfg.ShardingKeysFile)
+		if err != nil {
+			return inputs, fmt.Errorf("error reading sharding keys: %w", err)
+		}
 	}
+	if len(cfg.TestFiles) > 0 {
+		inputs.tested, err = testedStructures(cfg.TestFiles, renames)
+		if err != nil {
+			return inputs, err
+		}
+	}
+	return inputs, nil
+}
+
+// applyRenames renames the tables of the traced queries and of the keys output.
+// Traced queries that can't be parsed are left as they are.
+func (s *readingSummary) applyRenames(renames keys.Renames) {
+	if s.AnalysedQueries != nil {
+		renames.ApplyTo(s.AnalysedQueries)
+	}
+	parser := sqlparser.NewTestParser()
+	for i, tq := range s.TracedQueries {
+		s.TracedQueries[i].Query = renameQuery(parser, renames, tq.Query)
+	}
+}
+
+// renameQuery renames the tables of a query, a query that can't be parsed is returned as it is
+func renameQuery(parser *sqlparser.Parser, renames keys.Renames, query string) string {
+	ast, err := parser.Parse(query)
+	if err != nil {
+		return query
+	}
+	renames.Rewrite(ast)
+	return sqlparser.String(ast)
 }
 
 func visit(trace Trace, f func(Trace)) {
@@ -135,11 +483,6 @@ func summarizeTrace(t TracedQuery) QuerySummary {
 	return summary
 }
 
-func exit(msg string) {
-	fmt.Println(msg)
-	os.Exit(1)
-}
-
 const queryPrefix = "Query: "
 
 func limitQueryLength(query string, termWidth int) string {
@@ -220,59 +563,76 @@ func compareTraces(out io.Writer, termWidth int, highLighter Highlighter, file1,
 	summary1 := summarizeTraces(file1)
 	summary2 := summarizeTraces(file2)
 
-	var significantChanges, totalQueries int
-	var s1RouteCalls, s1DataSent, s1MemoryRows, s1ShardsQueried int
-	var s2RouteCalls, s2DataSent, s2MemoryRows, s2ShardsQueried int
-
+	c := &traceComparison{out: out, termWidth: termWidth, highLighter: highLighter, name1: file1.Name, name2: file2.Name}
 	for _, q := range file1.TracedQueries {
 		s1, ok1 := summary1[q.Query]
 		s2, ok2 := summary2[q.Query]
 		if !ok1 || !ok2 {
 			continue
 		}
-		totalQueries++
+		c.add(s1, s2)
+	}
+	c.printSummary()
+}
 
-		table := tablewriter.NewWriter(out)
-		table.SetHeader([]string{"Metric", file1.Name, file2.Name, "Diff", "% Change"})
-		table.SetAutoFormatHeaders(false)
+// traceComparison compares the queries of two trace files one at a time, and sums their metrics for the summary
+type traceComparison struct {
+	out          io.Writer
+	termWidth    int
+	highLighter  Highlighter
+	name1, name2 string
 
-		m1 := compareMetric(table, "Route Calls", s1.RouteCalls, s2.RouteCalls)
-		m2 := compareMetric(table, "Rows Sent", s1.RowsSent, s2.RowsSent)
-		m3 := compareMetric(table, "Rows In Memory", s1.RowsInMemory, s2.RowsInMemory)
-		m4 := compareMetric(table, "Shards Queried", s1.ShardsQueried, s2.ShardsQueried)
+	significantChanges, totalQueries                        int
+	s1RouteCalls, s1DataSent, s1MemoryRows, s1ShardsQueried int
+	s2RouteCalls, s2DataSent, s2MemoryRows, s2ShardsQueried int
+}
 
-		// we introduce variables to make sure we don't shortcut the evaluation
-		significant := m1 || m2 || m3 || m4
-		if significant {
-			significantChanges++
-		}
+// add prints the comparison of a query, s1 is its summary in the first file and s2 in the second one
+func (c *traceComparison) add(s1, s2 QuerySummary) {
+	c.totalQueries++
 
-		s1RouteCalls += s1.RouteCalls
-		s1DataSent += s1.RowsSent
-		s1MemoryRows += s1.RowsInMemory
-		s1ShardsQueried += s1.ShardsQueried
-		s2RouteCalls += s2.RouteCalls
-		s2DataSent += s2.RowsSent
-		s2MemoryRows += s2.RowsInMemory
-		s2ShardsQueried += s2.ShardsQueried
+	table := tablewriter.NewWriter(c.out)
+	table.SetHeader([]string{"Metric", c.name1, c.name2, "Diff", "% Change"})
+	table.SetAutoFormatHeaders(false)
 
-		printQuery(out, termWidth, highLighter, s1.Q, significant)
-		table.Render()
-		fmt.Fprintln(out)
+	m1 := compareMetric(table, "Route Calls", s1.RouteCalls, s2.RouteCalls)
+	m2 := compareMetric(table, "Rows Sent", s1.RowsSent, s2.RowsSent)
+	m3 := compareMetric(table, "Rows In Memory", s1.RowsInMemory, s2.RowsInMemory)
+	m4 := compareMetric(table, "Shards Queried", s1.ShardsQueried, s2.ShardsQueried)
+
+	// we introduce variables to make sure we don't shortcut the evaluation
+	significant := m1 || m2 || m3 || m4
+	if significant {
+		c.significantChanges++
 	}
 
-	totalRouteCallsChange := float64(s2RouteCalls-s1RouteCalls) / float64(s1RouteCalls) * 100
-	totalDataSentChange := float64(s2DataSent-s1DataSent) / float64(s1DataSent) * 100
-	totalMemoryRowsChange := float64(s2MemoryRows-s1MemoryRows) / float64(s1MemoryRows) * 100
-	totalShardsQueriedChange := float64(s2ShardsQueried-s1ShardsQueried) / float64(s1ShardsQueried) * 100
+	c.s1RouteCalls += s1.RouteCalls
+	c.s1DataSent += s1.RowsSent
+	c.s1MemoryRows += s1.RowsInMemory
+	c.s1ShardsQueried += s1.ShardsQueried
+	c.s2RouteCalls += s2.RouteCalls
+	c.s2DataSent += s2.RowsSent
+	c.s2MemoryRows += s2.RowsInMemory
+	c.s2ShardsQueried += s2.ShardsQueried
+
+	printQuery(c.out, c.termWidth, c.highLighter, s1.Q, significant)
+	table.Render()
+	fmt.Fprintln(c.out)
+}
+
+func (c *traceComparison) printSummary() {
+	totalRouteCallsChange := float64(c.s2RouteCalls-c.s1RouteCalls) / float64(c.s1RouteCalls) * 100
+	totalDataSentChange := float64(c.s2DataSent-c.s1DataSent) / float64(c.s1DataSent) * 100
+	totalMemoryRowsChange := float64(c.s2MemoryRows-c.s1MemoryRows) / float64(c.s1MemoryRows) * 100
+	totalShardsQueriedChange := float64(c.s2ShardsQueried-c.s1ShardsQueried) / float64(c.s1ShardsQueried) * 100
 
 	// Print summary
-	fmt.Fprintln(out, "Summary:")
-	fmt.Fprintf(out, "- %d out of %d queries showed significant change\n", significantChanges, totalQueries)
-	fmt.Fprintf(out, "- Average change in Route Calls: %.2f%%\n", totalRouteCallsChange)
-	fmt.Fprintf(out, "- Average change in Data Sent: %.2f%%\n", totalDataSentChange)
-	fmt.Fprintf(out, "- Average change in Rows In Memory: %.2f%%\n", totalMemoryRowsChange)
-	fmt.Fprintf(out, "- Average change in Shards Queried: %.2f%%\n", totalShardsQueriedChange)
+	fmt.Fprintln(c.out, "Summary:")
+	fmt.Fprintf(c.out, "- %d out of %d queries showed significant change\n", c.significantChanges, c.totalQueries)
+	fmt.Fprintf(c.out, "- Average change in Route Calls: %.2f%%\n", totalRouteCallsChange)
+	fmt.Fprintf(c.out, "- Average change in Data Sent: %.2f%%\n", totalDataSentChange)
+	fmt.Fprintf(c.out, "- Average change in Rows In Memory: %.2f%%\n", totalMemoryRowsChange)
+	fmt.Fprintf(c.out, "- Average change in Shards Queried: %.2f%%\n", totalShardsQueriedChange)
 }
 
 // compareMetric compares two metrics and appends the result to the table, returning true if the change is significant
@@ -296,11 +656,32 @@ func compareMetric(table *tablewriter.Table, metricName string, val1, val2 int)
 	return percentChange < -significantChangeThreshold
 }
 
+// sampleNote tells how the usage counts relate to the query log when only a sample of it was analysed,
+// it is empty when the whole log was
+func sampleNote(percentage float64, maxQueries int) string {
+	switch {
+	case percentage > 0 && maxQueries > 0:
+		return fmt.Sprintf("Usage counts are estimated from a %.2f%% sample of the query log, which stopped after %d queries", percentage, maxQueries)
+	case percentage > 0:
+		return fmt.Sprintf("Usage counts are estimated from a %.2f%% sample of the query log", percentage)
+	case maxQueries > 0:
+		return fmt.Sprintf("Usage counts are those of the first %d queries of the query log only", maxQueries)
+	}
+	return ""
+}
+
 // printKeysSummary goes over all the analysed queries, gathers information about column usage per table,
 // and prints this summary information to the output.
-func printKeysSummary(out io.Writer, file readingSummary) {
+func printKeysSummary(out io.Writer, file readingSummary, loc *time.Location) {
 	_, _ = fmt.Fprintf(out, "Summary from trace file %s\n", file.Name)
+	if note := sampleNote(file.AnalysedQueries.SampleRate*100, file.AnalysedQueries.MaxQueries); note != "" {
+		fmt.Fprintln(out, note)
+	}
 	tableSummaries, failuresSummaries := summarizeQueries(file.AnalysedQueries)
+	if len(file.AnalysedQueries.Tables) > 0 {
+		renderTablesOverview(out, file.AnalysedQueries.Tables)
+		_, _ = fmt.Fprintln(out)
+	}
 	for _, summary := range tableSummaries {
 		fmt.Fprintf(out, "Table: %s used in %d queries\n", summary.Table, summary.QueryCount)
 
@@ -310,6 +691,65 @@ func printKeysSummary(out io.Writer, file readingSummary) {
 		_, _ = fmt.Fprintln(out)
 	}
 
+	if hintSummaries := summarizeHints(file.AnalysedQueries); len(hintSummaries) > 0 {
+		fmt.Fprintln(out, "Query hints:")
+		renderHintsTable(out, hintSummaries)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if users := summarizeUsers(file.AnalysedQueries); len(users) > 0 {
+		fmt.Fprintln(out, "Usage per user:")
+		renderUsersTable(out, users)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if observed := summarizeObserved(file.AnalysedQueries); len(observed) > 0 {
+		printObservedSummary(out, observed)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	printTraffic(out, file.AnalysedQueries, loc)
+
+	if targets, targeted, total := summarizeTargets(file.AnalysedQueries); len(targets) > 0 {
+		fmt.Fprintf(out, "Explicit shard or tablet type targeting: %.2f%% of query uses (%d of %d), these queries complicate resharding\n",
+			float64(targeted)/float64(total)*100, targeted, total)
+		renderTargetsTable(out, targets)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if scans, scanned, total := summarizeFullScans(file.AnalysedQueries); len(scans) > 0 {
+		fmt.Fprintf(out, "Full scan candidates: %.2f%% of query uses (%d of %d) have no selective filter, "+
+			"they read every row of their tables and are scattered on all shards\n", float64(scanned)/float64(total)*100, scanned, total)
+		renderFullScansTable(out, scans)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if filters, filtered, total := summarizeFunctionFilters(file.AnalysedQueries); len(filters) > 0 {
+		fmt.Fprintf(out, "Filters on a function of a column: %.2f%% of query uses (%d of %d), "+
+			"these filters can't use the indexes nor the vindex of the column\n", float64(filtered)/float64(total)*100, filtered, total)
+		renderFunctionFiltersTable(out, filters)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	printColumnValues(out, file.AnalysedQueries.Values)
+
+	if advice := adviseSettings(file.AnalysedQueries); len(advice) > 0 {
+		fmt.Fprintln(out, "Recommended settings:")
+		renderSettingsTable(out, advice)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if findings := summarizeFindings(file.AnalysedQueries); len(findings) > 0 {
+		fmt.Fprintln(out, "Findings:")
+		renderFindingsTable(out, findings)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if types, failed, total := summarizeFailureTypes(file.AnalysedQueries); len(types) > 0 {
+		printFailureTypes(out, types, failed, total)
+		_, _ = fmt.Fprintln(out)
+	}
+
 	if len(failuresSummaries) > 0 {
 		table := tablewriter.NewWriter(out)
 		table.SetAutoFormatHeaders(false)
@@ -323,6 +763,27 @@ func printKeysSummary(out io.Writer, file readingSummary) {
 	}
 }
 
+func renderTablesOverview(out io.Writer, tables []keys.TableStats) {
+	withQPS := slices.ContainsFunc(tables, func(ts keys.TableStats) bool { return ts.QPS > 0 })
+	cols := []string{"Table", "Reads", "Writes", "Read/Write Ratio"}
+	if withQPS {
+		cols = append(cols, "QPS")
+	}
+	table := createTableWriter(out, cols)
+	for _, ts := range tables {
+		ratio := "-"
+		if ts.Writes > 0 {
+			ratio = fmt.Sprintf("%.2f", float64(ts.Reads)/float64(ts.Writes))
+		}
+		row := []string{ts.Table, strconv.Itoa(ts.Reads), strconv.Itoa(ts.Writes), ratio}
+		if withQPS {
+			row = append(row, fmt.Sprintf("%.2f", ts.QPS))
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
 func renderColumnUsageTable(out io.Writer, summary TableSummary) {
 	table := createTableWriter(out, []string{"Column", "Filter %", "Grouping %", "Join %"})
 	for colName, usage := range summary.GetColumns() {
@@ -344,6 +805,86 @@ func renderJoinPredicatesTable(out io.Writer, summary TableSummary) {
 	table.Render()
 }
 
+func renderHintsTable(out io.Writer, hints []HintSummary) {
+	table := createTableWriter(out, []string{"Hint", "Value", "Usage Count", "% of queries"})
+	for _, hint := range hints {
+		table.Append([]string{
+			hint.Name,
+			hint.Value,
+			strconv.Itoa(hint.UsageCount),
+			fmt.Sprintf("%.2f%%", hint.Percentage),
+		})
+	}
+	table.Render()
+}
+
+func renderUsersTable(out io.Writer, users []UserSummary) {
+	table := createTableWriter(out, []string{"User", "Usage Count", "% of queries", "Query Structures", "Most Used Query"})
+	for _, user := range users {
+		table.Append([]string{
+			user.User,
+			strconv.Itoa(user.UsageCount),
+			fmt.Sprintf("%.2f%%", user.Percentage),
+			strconv.Itoa(user.QueryStructures),
+			user.MostUsedQuery,
+		})
+	}
+	table.Render()
+}
+
+func printObservedSummary(out io.Writer, observed []ObservedSummary) {
+	var executions, scatter int
+	for _, o := range observed {
+		executions += o.Executions
+		scatter += o.Scatter
+	}
+	fmt.Fprintf(out, "Observed scatter rate: %.2f%% of %d logged executions\n", float64(scatter)/float64(executions)*100, executions)
+
+	table := createTableWriter(out, []string{"Query", "Executions", "Avg Shard Queries", "Scatter %", "Plan Types"})
+	for _, o := range observed {
+		table.Append([]string{
+			o.QueryStructure,
+			strconv.Itoa(o.Executions),
+			fmt.Sprintf("%.2f", o.AvgShardQueries),
+			fmt.Sprintf("%.2f%%", o.ScatterPercentage),
+			o.PlanTypes,
+		})
+	}
+	table.Render()
+}
+
+func renderTargetsTable(out io.Writer, targets []TargetSummary) {
+	table := createTableWriter(out, []string{"Target", "Uses"})
+	for _, target := range targets {
+		table.Append([]string{target.Target, strconv.Itoa(target.Uses)})
+	}
+	table.Render()
+}
+
+func renderFullScansTable(out io.Writer, scans []FullScanSummary) {
+	table := createTableWriter(out, []string{"Query", "Tables", "Usage Count"})
+	for _, scan := range scans {
+		table.Append([]string{scan.QueryStructure, strings.Join(scan.Tables, ", "), strconv.Itoa(scan.UsageCount)})
+	}
+	table.Render()
+}
+
+func renderFunctionFiltersTable(out io.Writer, filters []FunctionFilterSummary) {
+	table := createTableWriter(out, []string{"Query", "Filters", "Usage Count"})
+	for _, filter := range filters {
+		table.Append([]string{filter.QueryStructure, strings.Join(filter.Filters, ", "), strconv.Itoa(filter.UsageCount)})
+	}
+	table.Render()
+}
+
+func renderFindingsTable(out io.Writer, findings []FindingSummary) {
+	table := createTableWriter(out, []string{"Severity", "Category", "Analyzer", "Finding", "Count"})
+	for _, finding := range findings {
+		table.Append([]string{string(finding.Severity), string(finding.Category), finding.Analyzer, finding.Message, strconv.Itoa(finding.Count)})
+	}
+	table.Render()
+}
+
 func createTableWriter(out io.Writer, cols []string) *tablewriter.Table {
 	table := tablewriter.NewWriter(out)
 	table.SetAutoFormatHeaders(false)
@@ -366,9 +907,67 @@ type TableSummary struct {
 	Failed         bool
 }
 
+// HintSummary contains how often a vtgate query hint (/*vt+ ... */) was used with a specific value
+type HintSummary struct {
+	Name       string  `json:"name"`
+	Value      string  `json:"value"`
+	UsageCount int     `json:"usageCount"`
+	Percentage float64 `json:"percentage"`
+}
+
+// UserSummary is the usage of the queries sent by a user, or by a caller ID for vtgate logs
+type UserSummary struct {
+	User            string  `json:"user"`
+	UsageCount      int     `json:"usageCount"`
+	Percentage      float64 `json:"percentage"`
+	QueryStructures int     `json:"queryStructures"`
+	// MostUsedQuery is the query structure the user sent the most
+	MostUsedQuery string `json:"mostUsedQuery"`
+}
+
+// ObservedSummary describes how a query structure was routed by vtgate in production, as found in a vtgate query log
+type ObservedSummary struct {
+	QueryStructure    string  `json:"queryStructure"`
+	Executions        int     `json:"executions"`
+	Scatter           int     `json:"scatter"`
+	AvgShardQueries   float64 `json:"avgShardQueries"`
+	ScatterPercentage float64 `json:"scatterPercentage"`
+	// PlanTypes lists the plan types used, with their counts, e.g. "Scatter:3, Passthrough:1"
+	PlanTypes string `json:"planTypes"`
+}
+
+// FindingSummary counts how many times an analyzer reported the same finding
+type FindingSummary struct {
+	Analyzer string        `json:"analyzer"`
+	Severity keys.Severity `json:"severity,omitempty"`
+	Category keys.Category `json:"category,omitempty"`
+	Message  string        `json:"message"`
+	Count    int           `json:"count"`
+}
+
+// FullScanSummary is a query without a selective filter, a full table scan candidate
+type FullScanSummary struct {
+	QueryStructure string   `json:"queryStructure"`
+	Tables         []string `json:"tables"`
+	UsageCount     int      `json:"usageCount"`
+}
+
+// FunctionFilterSummary is a query filtering on a function of a column, such as DATE(created_at) = ?
+type FunctionFilterSummary struct {
+	QueryStructure string   `json:"queryStructure"`
+	Filters        []string `json:"filters"`
+	UsageCount     int      `json:"usageCount"`
+}
+
+// TargetSummary counts the query uses with an explicit shard or tablet type target, such as ks:-80 or ks@replica
+type TargetSummary struct {
+	Target string `json:"target"`
+	Uses   int    `json:"uses"`
+}
+
 type FailuresSummary struct {
-	Query string
-	Error string
+	Query string `json:"query"`
+	Error string `json:"error"`
 }
 
 func (ts TableSummary) GetColumns() iter.Seq2[string, ColumnUsage] {
@@ -443,6 +1042,221 @@ func summarizeQueries(queries *keys.Output) ([]TableSummary, []FailuresSummary)
 	return result, failures
 }
 
+// summarizeHints aggregates the hint usage of all query structures, sorted by hint name and value
+func summarizeHints(queries *keys.Output) []HintSummary {
+	var total int
+	usage := make(map[string]int)
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		for hint, count := range query.Hints {
+			usage[hint] += count
+		}
+	}
+
+	result := make([]HintSummary, 0, len(usage))
+	for hint, count := range usage {
+		name, value, _ := strings.Cut(hint, "=")
+		result = append(result, HintSummary{
+			Name:       name,
+			Value:      value,
+			UsageCount: count,
+			Percentage: float64(count) / float64(total) * 100,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+// summarizeUsers aggregates the usage of the query structures per user, the most active users first
+func summarizeUsers(queries *keys.Output) []UserSummary {
+	var total int
+	users := make(map[string]*UserSummary)
+	mostUsed := make(map[string]int)
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		for user, count := range query.Users {
+			summary, found := users[user]
+			if !found {
+				summary = &UserSummary{User: user}
+				users[user] = summary
+			}
+			summary.UsageCount += count
+			summary.QueryStructures++
+			if count > mostUsed[user] {
+				mostUsed[user] = count
+				summary.MostUsedQuery = query.QueryStructure
+			}
+		}
+	}
+
+	result := make([]UserSummary, 0, len(users))
+	for _, summary := range users {
+		summary.Percentage = float64(summary.UsageCount) / float64(total) * 100
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].UsageCount != result[j].UsageCount {
+			return result[i].UsageCount > result[j].UsageCount
+		}
+		return result[i].User < result[j].User
+	})
+	return result
+}
+
+// summarizeObserved lists the query structures that have execution information from a vtgate log,
+// the ones sending the most scatter queries first
+func summarizeObserved(queries *keys.Output) []ObservedSummary {
+	var result []ObservedSummary
+	for _, query := range queries.Queries {
+		observed := query.Observed
+		if observed == nil || observed.Executions == 0 {
+			continue
+		}
+
+		planTypes := make([]string, 0, len(observed.PlanTypes))
+		for planType, count := range observed.PlanTypes {
+			planTypes = append(planTypes, planType+":"+strconv.Itoa(count))
+		}
+		sort.Strings(planTypes)
+
+		result = append(result, ObservedSummary{
+			QueryStructure:    query.QueryStructure,
+			Executions:        observed.Executions,
+			Scatter:           observed.Scatter,
+			AvgShardQueries:   float64(observed.ShardQueries) / float64(observed.Executions),
+			ScatterPercentage: float64(observed.Scatter) / float64(observed.Executions) * 100,
+			PlanTypes:         strings.Join(planTypes, ", "),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Scatter != result[j].Scatter {
+			return result[i].Scatter > result[j].Scatter
+		}
+		return result[i].Executions > result[j].Executions
+	})
+	return result
+}
+
+// summarizeTargets returns the uses of every explicit target, along with the number of query uses
+// that have at least one target and the total number of query uses
+func summarizeTargets(queries *keys.Output) (result []TargetSummary, targeted, total int) {
+	uses := make(map[string]int)
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		var queryTargeted int
+		for target, count := range query.Targets {
+			uses[target] += count
+			// a query joining tables of several targets is only counted once
+			queryTargeted = max(queryTargeted, count)
+		}
+		targeted += queryTargeted
+	}
+
+	for target, count := range uses {
+		result = append(result, TargetSummary{Target: target, Uses: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Uses != result[j].Uses {
+			return result[i].Uses > result[j].Uses
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result, targeted, total
+}
+
+// summarizeFullScans returns the full table scan candidates, the most used first, along with the number of
+// query uses that are full scan candidates and the total number of query uses
+func summarizeFullScans(queries *keys.Output) (result []FullScanSummary, scanned, total int) {
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		if !query.FullScan {
+			continue
+		}
+		scanned += query.UsageCount
+		// a table is listed once for each of its references
+		var tables []string
+		for _, table := range query.TableName {
+			if !slices.Contains(tables, table) {
+				tables = append(tables, table)
+			}
+		}
+		result = append(result, FullScanSummary{
+			QueryStructure: query.QueryStructure,
+			Tables:         tables,
+			UsageCount:     query.UsageCount,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UsageCount > result[j].UsageCount
+	})
+	return result, scanned, total
+}
+
+// summarizeFunctionFilters lists the queries filtering on a function of a column, sorted by decreasing usage,
+// with their uses and the uses of all the queries
+func summarizeFunctionFilters(queries *keys.Output) (result []FunctionFilterSummary, filtered, total int) {
+	for _, query := range queries.Queries {
+		total += query.UsageCount
+		if len(query.FunctionFilters) == 0 {
+			continue
+		}
+		filtered += query.UsageCount
+		filters := make([]string, 0, len(query.FunctionFilters))
+		for _, filter := range query.FunctionFilters {
+			filters = append(filters, filter.String())
+		}
+		result = append(result, FunctionFilterSummary{
+			QueryStructure: query.QueryStructure,
+			Filters:        filters,
+			UsageCount:     query.UsageCount,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UsageCount > result[j].UsageCount
+	})
+	return result, filtered, total
+}
+
+// summarizeFindings groups the identical findings, sorted by decreasing severity, then by analyzer and by count
+func summarizeFindings(queries *keys.Output) []FindingSummary {
+	counts := make(map[FindingSummary]int)
+	for _, finding := range queries.Findings {
+		counts[FindingSummary{
+			Analyzer: finding.Analyzer,
+			Severity: finding.Severity,
+			Category: finding.Category,
+			Message:  finding.Message,
+		}]++
+	}
+
+	result := make([]FindingSummary, 0, len(counts))
+	for finding, count := range counts {
+		finding.Count = count
+		result = append(result, finding)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() > b.Severity.Rank()
+		}
+		if a.Analyzer != b.Analyzer {
+			return a.Analyzer < b.Analyzer
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Message < b.Message
+	})
+	return result
+}
+
 func summarizeColumnUsage(table string, tableSummaries map[string]*TableSummary, query keys.QueryAnalysisResult) {
 	updateColumnUsage := func(columns any, usageType func(*ColumnUsage) *float64) {
 		var colNames []string
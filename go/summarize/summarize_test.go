@@ -18,10 +18,17 @@ package summarize
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
 )
 
 func tf1() readingSummary {
@@ -184,7 +191,8 @@ Summary:
 }
 
 func TestSummarizeTraceFile(t *testing.T) {
-	file := readTraceFile("testdata/trace-log.json")
+	file, err := readTraceFile("testdata/trace-log.json", false)
+	require.NoError(t, err)
 	sb := &strings.Builder{}
 	printTraceSummary(sb, 80, noHighlight, file)
 	expected := `Query: INSERT INTO region (R_REGIONKEY, R_NAME, R_COMMENT) VALUES (1, 'ASIA',...
@@ -391,165 +399,13 @@ Line # 695
 }
 
 func TestSummarizeKeysFile(t *testing.T) {
-	file := readTraceFile("testdata/keys-log.json")
+	file, err := readTraceFile("testdata/keys-log.json", false)
+	require.NoError(t, err)
 	sb := &strings.Builder{}
-	printKeysSummary(sb, file)
-	expected := `Summary from trace file testdata/keys-log.json
-Table: customer used in 8 queries
-+--------------+----------+------------+--------+
-|    Column    | Filter % | Grouping % | Join % |
-+--------------+----------+------------+--------+
-| c_acctbal    | 0.00%    | 12.50%     | 0.00%  |
-| c_address    | 0.00%    | 12.50%     | 0.00%  |
-| c_comment    | 0.00%    | 12.50%     | 0.00%  |
-| c_custkey    | 0.00%    | 37.50%     | 87.50% |
-| c_mktsegment | 12.50%   | 0.00%      | 0.00%  |
-| c_name       | 0.00%    | 25.00%     | 0.00%  |
-| c_nationkey  | 0.00%    | 0.00%      | 50.00% |
-| c_phone      | 0.00%    | 12.50%     | 0.00%  |
-+--------------+----------+------------+--------+
-+---------------------------------------------+
-|               Join Predicate                |
-+---------------------------------------------+
-| customer.c_custkey = orders.o_custkey       |
-| customer.c_nationkey = supplier.s_nationkey |
-| customer.c_nationkey = nation.n_nationkey   |
-+---------------------------------------------+
-
-Table: lineitem used in 18 queries
-+---------------+----------+------------+--------+
-|    Column     | Filter % | Grouping % | Join % |
-+---------------+----------+------------+--------+
-| l_commitdate  | 27.78%   | 0.00%      | 0.00%  |
-| l_orderkey    | 0.00%    | 16.67%     | 72.22% |
-| l_partkey     | 0.00%    | 0.00%      | 16.67% |
-| l_receiptdate | 27.78%   | 0.00%      | 0.00%  |
-| l_returnflag  | 5.56%    | 0.00%      | 0.00%  |
-| l_shipdate    | 22.22%   | 0.00%      | 0.00%  |
-| l_shipmode    | 5.56%    | 5.56%      | 0.00%  |
-| l_suppkey     | 0.00%    | 0.00%      | 38.89% |
-+---------------+----------+------------+--------+
-+-------------------------------------------+
-|              Join Predicate               |
-+-------------------------------------------+
-| lineitem.l_orderkey = orders.o_orderkey   |
-| lineitem.l_suppkey = supplier.s_suppkey   |
-| part.p_partkey = lineitem.l_partkey       |
-| partsupp.ps_suppkey = lineitem.l_suppkey  |
-| partsupp.ps_partkey = lineitem.l_partkey  |
-| lineitem.l_orderkey = lineitem.l_orderkey |
-| lineitem.l_suppkey != lineitem.l_suppkey  |
-+-------------------------------------------+
-
-Table: nation used in 11 queries
-+-------------+----------+------------+--------+
-|   Column    | Filter % | Grouping % | Join % |
-+-------------+----------+------------+--------+
-| n_name      | 27.27%   | 18.18%     | 0.00%  |
-| n_nationkey | 0.00%    | 0.00%      | 90.91% |
-| n_regionkey | 0.00%    | 0.00%      | 27.27% |
-+-------------+----------+------------+--------+
-+-------------------------------------------+
-|              Join Predicate               |
-+-------------------------------------------+
-| supplier.s_nationkey = nation.n_nationkey |
-| nation.n_regionkey = region.r_regionkey   |
-| customer.c_nationkey = nation.n_nationkey |
-+-------------------------------------------+
-
-Table: orders used in 12 queries
-+-----------------+----------+------------+--------+
-|     Column      | Filter % | Grouping % | Join % |
-+-----------------+----------+------------+--------+
-| o_comment       | 8.33%    | 0.00%      | 0.00%  |
-| o_custkey       | 0.00%    | 0.00%      | 58.33% |
-| o_orderdate     | 41.67%   | 16.67%     | 0.00%  |
-| o_orderkey      | 8.33%    | 8.33%      | 83.33% |
-| o_orderpriority | 0.00%    | 8.33%      | 0.00%  |
-| o_orderstatus   | 8.33%    | 0.00%      | 0.00%  |
-| o_shippriority  | 0.00%    | 8.33%      | 0.00%  |
-| o_totalprice    | 0.00%    | 8.33%      | 0.00%  |
-+-----------------+----------+------------+--------+
-+-----------------------------------------+
-|             Join Predicate              |
-+-----------------------------------------+
-| customer.c_custkey = orders.o_custkey   |
-| lineitem.l_orderkey = orders.o_orderkey |
-+-----------------------------------------+
-
-Table: part used in 6 queries
-+-----------+----------+------------+--------+
-|  Column   | Filter % | Grouping % | Join % |
-+-----------+----------+------------+--------+
-| p_brand   | 16.67%   | 16.67%     | 0.00%  |
-| p_name    | 16.67%   | 0.00%      | 0.00%  |
-| p_partkey | 0.00%    | 0.00%      | 66.67% |
-| p_size    | 16.67%   | 16.67%     | 0.00%  |
-| p_type    | 33.33%   | 16.67%     | 0.00%  |
-+-----------+----------+------------+--------+
-+--------------------------------------+
-|            Join Predicate            |
-+--------------------------------------+
-| part.p_partkey = lineitem.l_partkey  |
-| part.p_partkey = partsupp.ps_partkey |
-+--------------------------------------+
-
-Table: partsupp used in 5 queries
-+------------+----------+------------+--------+
-|   Column   | Filter % | Grouping % | Join % |
-+------------+----------+------------+--------+
-| ps_partkey | 0.00%    | 40.00%     | 40.00% |
-| ps_suppkey | 20.00%   | 0.00%      | 60.00% |
-+------------+----------+------------+--------+
-+------------------------------------------+
-|              Join Predicate              |
-+------------------------------------------+
-| partsupp.ps_suppkey = lineitem.l_suppkey |
-| partsupp.ps_partkey = lineitem.l_partkey |
-| partsupp.ps_suppkey = supplier.s_suppkey |
-| part.p_partkey = partsupp.ps_partkey     |
-+------------------------------------------+
-
-Table: region used in 3 queries
-+-------------+----------+------------+--------+
-|   Column    | Filter % | Grouping % | Join % |
-+-------------+----------+------------+--------+
-| r_name      | 66.67%   | 0.00%      | 0.00%  |
-| r_regionkey | 0.00%    | 0.00%      | 66.67% |
-+-------------+----------+------------+--------+
-+-----------------------------------------+
-|             Join Predicate              |
-+-----------------------------------------+
-| nation.n_regionkey = region.r_regionkey |
-+-----------------------------------------+
-
-Table: supplier used in 9 queries
-+-------------+----------+------------+--------+
-|   Column    | Filter % | Grouping % | Join % |
-+-------------+----------+------------+--------+
-| s_comment   | 11.11%   | 0.00%      | 0.00%  |
-| s_name      | 0.00%    | 11.11%     | 0.00%  |
-| s_nationkey | 0.00%    | 0.00%      | 77.78% |
-| s_suppkey   | 0.00%    | 0.00%      | 77.78% |
-+-------------+----------+------------+--------+
-+---------------------------------------------+
-|               Join Predicate                |
-+---------------------------------------------+
-| lineitem.l_suppkey = supplier.s_suppkey     |
-| customer.c_nationkey = supplier.s_nationkey |
-| supplier.s_nationkey = nation.n_nationkey   |
-| partsupp.ps_suppkey = supplier.s_suppkey    |
-+---------------------------------------------+
-
-The 1 following queries have failed:
-+-----------------------+--------------------------------+
-|         Query         |             Error              |
-+-----------------------+--------------------------------+
-| I am a failing query; | syntax error at position 2     |
-|                       | near 'I'                       |
-+-----------------------+--------------------------------+
-
-`
+	printKeysSummary(sb, file, time.UTC)
+	raw, err := os.ReadFile("testdata/keys-summary.txt")
+	require.NoError(t, err)
+	expected := string(raw)
 	x := sb.String()
 	for idx := range expected {
 		if x[idx] != expected[idx] {
@@ -560,3 +416,314 @@ The 1 following queries have failed:
 	assert.Equal(t, len(expected), len(x))
 	assert.Equal(t, expected, x)
 }
+
+func TestRun(t *testing.T) {
+	summary, err := Run(Config{Files: []string{"testdata/keys-log.json"}, Format: FormatText})
+	require.NoError(t, err)
+	expected, err := os.ReadFile("testdata/keys-summary.txt")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(summary.String(), string(expected)))
+	require.Contains(t, summary.String(), "Sharding key recommendations:")
+
+	// the summary can be written several times
+	sb := &strings.Builder{}
+	for range 2 {
+		_, err = summary.WriteTo(sb)
+		require.NoError(t, err)
+	}
+	require.Equal(t, summary.String()+summary.String(), sb.String())
+
+	// a keys file is summarized as markdown by default
+	summary, err = Run(Config{Files: []string{"testdata/keys-log.json"}})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(summary.String(), "# Summary from trace file testdata/keys-log.json\n"))
+
+	summary, err = Run(Config{Files: []string{"testdata/keys-log.json", "testdata/keys-log.json"}, Diff: true, FailOnSeverity: keys.SeverityInfo})
+	require.NoError(t, err)
+	require.Zero(t, summary.BlockingFindings, "a file has no new findings compared with itself")
+}
+
+func TestRunErrors(t *testing.T) {
+	keysFile := "testdata/keys-log.json"
+	tests := []struct {
+		name string
+		cfg  Config
+		err  string
+	}{{
+		name: "no file",
+		err:  "no file to summarize",
+	}, {
+		name: "unknown format",
+		cfg:  Config{Files: []string{keysFile}, Format: "yaml"},
+		err:  `unknown summary format "yaml"`,
+	}, {
+		name: "missing file",
+		cfg:  Config{Files: []string{"testdata/missing.json"}},
+		err:  "error opening file",
+	}, {
+		name: "report of two files",
+		cfg:  Config{Files: []string{keysFile, keysFile}, Format: FormatJSON},
+		err:  "--format=json only works with a single keys file",
+	}, {
+		name: "html report with a missing dbinfo file",
+		cfg:  Config{Files: []string{keysFile}, Format: FormatHTML, DBInfoFile: "testdata/missing.json"},
+		err:  "error reading dbinfo file: open testdata/missing.json",
+	}, {
+		name: "hot metric without a dbinfo file",
+		cfg:  Config{Files: []string{keysFile}, HotMetric: HotMetric{expression: "executions"}},
+		err:  "--hot-metric ranks the queries by the query statistics of --dbinfo, it needs a dbinfo file",
+	}, {
+		name: "diff of one file",
+		cfg:  Config{Files: []string{keysFile}, Diff: true},
+		err:  "--diff needs two keys files",
+	}, {
+		name: "matrix of keys files",
+		cfg:  Config{Files: []string{keysFile, keysFile, keysFile}},
+		err:  "more than two files can only be compared when they are all trace files",
+	}, {
+		name: "missing dbinfo file",
+		cfg:  Config{Files: []string{keysFile}, DBInfoFile: "testdata/missing.json"},
+		err:  "error reading dbinfo file: open testdata/missing.json",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := Run(tt.cfg)
+			require.ErrorContains(t, err, tt.err)
+			require.Nil(t, summary)
+		})
+	}
+}
+
+func TestSampleNote(t *testing.T) {
+	require.Empty(t, sampleNote(0, 0))
+	require.Equal(t, "Usage counts are estimated from a 25.00% sample of the query log", sampleNote(25, 0))
+	require.Equal(t, "Usage counts are those of the first 100 queries of the query log only", sampleNote(0, 100))
+	require.Equal(t, "Usage counts are estimated from a 25.00% sample of the query log, which stopped after 100 queries", sampleNote(25, 100))
+}
+
+func TestSummarizeUsers(t *testing.T) {
+	queries := &keys.Output{Queries: []keys.QueryAnalysisResult{{
+		QueryStructure: "select * from t where id = :1",
+		UsageCount:     6,
+		Users:          map[string]int{"orders": 4, "reports": 2},
+	}, {
+		QueryStructure: "select * from t",
+		UsageCount:     3,
+		Users:          map[string]int{"reports": 3},
+	}, {
+		QueryStructure: "delete from t where id = :1",
+		UsageCount:     1,
+	}}}
+
+	got := summarizeUsers(queries)
+	assert.Equal(t, []UserSummary{
+		{User: "reports", UsageCount: 5, Percentage: 50, QueryStructures: 2, MostUsedQuery: "select * from t"},
+		{User: "orders", UsageCount: 4, Percentage: 40, QueryStructures: 1, MostUsedQuery: "select * from t where id = :1"},
+	}, got)
+
+	sb := &strings.Builder{}
+	printKeysSummary(sb, readingSummary{Name: "users", AnalysedQueries: queries}, time.UTC)
+	assert.Contains(t, sb.String(), "Usage per user:\n")
+	assert.Contains(t, sb.String(), "| reports |           5 | 50.00%       |                2 | select * from t               |")
+	assert.Empty(t, summarizeUsers(&keys.Output{Queries: queries.Queries[2:]}))
+}
+
+func TestSummarizeHints(t *testing.T) {
+	file := readingSummary{
+		Name: "hints",
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{{
+				QueryStructure: "select * from t where id = :1",
+				UsageCount:     3,
+				TableName:      []string{"t"},
+				StatementType:  "SELECT",
+				Hints:          map[string]int{"QUERY_TIMEOUT_MS=1000": 2, "ALLOW_SCATTER": 1},
+			}, {
+				QueryStructure: "delete from t where id = :1",
+				UsageCount:     1,
+				TableName:      []string{"t"},
+				StatementType:  "DELETE",
+			}},
+		},
+	}
+
+	got := summarizeHints(file.AnalysedQueries)
+	assert.Equal(t, []HintSummary{
+		{Name: "ALLOW_SCATTER", UsageCount: 1, Percentage: 25},
+		{Name: "QUERY_TIMEOUT_MS", Value: "1000", UsageCount: 2, Percentage: 50},
+	}, got)
+
+	sb := &strings.Builder{}
+	printKeysSummary(sb, file, time.UTC)
+	assert.Contains(t, sb.String(), `Query hints:
++------------------+-------+-------------+--------------+
+|       Hint       | Value | Usage Count | % of queries |
++------------------+-------+-------------+--------------+
+| ALLOW_SCATTER    |       |           1 | 25.00%       |
+| QUERY_TIMEOUT_MS |  1000 |           2 | 50.00%       |
++------------------+-------+-------------+--------------+
+`)
+}
+
+func TestSummarizeObserved(t *testing.T) {
+	file := readingSummary{
+		Name: "observed",
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{{
+				QueryStructure: "select * from t where id = :1",
+				UsageCount:     6,
+				TableName:      []string{"t"},
+				StatementType:  "SELECT",
+				Observed:       &keys.ObservedExecution{Executions: 6, ShardQueries: 6},
+			}, {
+				QueryStructure: "select * from t where x = :1",
+				UsageCount:     2,
+				TableName:      []string{"t"},
+				StatementType:  "SELECT",
+				Observed: &keys.ObservedExecution{
+					Executions:   2,
+					ShardQueries: 16,
+					Scatter:      2,
+					PlanTypes:    map[string]int{"Scatter": 2},
+				},
+			}, {
+				QueryStructure: "select * from t",
+				UsageCount:     1,
+				TableName:      []string{"t"},
+				StatementType:  "SELECT",
+			}},
+		},
+	}
+
+	sb := &strings.Builder{}
+	printKeysSummary(sb, file, time.UTC)
+	assert.Contains(t, sb.String(), `Observed scatter rate: 25.00% of 8 logged executions
++-------------------------------+------------+-------------------+-----------+------------+
+|             Query             | Executions | Avg Shard Queries | Scatter % | Plan Types |
++-------------------------------+------------+-------------------+-----------+------------+
+| select * from t where x = :1  |          2 |              8.00 | 100.00%   | Scatter:2  |
+| select * from t where id = :1 |          6 |              1.00 | 0.00%     |            |
++-------------------------------+------------+-------------------+-----------+------------+
+`)
+}
+
+func TestSummarizeFindings(t *testing.T) {
+	file := readingSummary{
+		Name: "findings",
+		AnalysedQueries: &keys.Output{
+			Findings: []keys.Finding{
+				{Analyzer: "pii", Severity: "error", Message: "email column read", LineNumber: 3},
+				{Analyzer: "lint", Severity: "warning", Message: "select *", LineNumber: 1},
+				{Analyzer: "pii", Severity: "error", Message: "email column read", LineNumber: 7},
+				{ID: "unbounded-write", Analyzer: "vt", Severity: keys.SeverityHigh, Category: keys.CategoryAntiPattern, Message: "DELETE without a WHERE clause", LineNumber: 9},
+			},
+		},
+	}
+
+	sb := &strings.Builder{}
+	printKeysSummary(sb, file, time.UTC)
+	assert.Contains(t, sb.String(), `Findings:
++----------+--------------+----------+-------------------------------+-------+
+| Severity |   Category   | Analyzer |            Finding            | Count |
++----------+--------------+----------+-------------------------------+-------+
+| error    |              | pii      | email column read             |     2 |
+| high     | anti-pattern | vt       | DELETE without a WHERE clause |     1 |
+| warning  |              | lint     | select *                      |     1 |
++----------+--------------+----------+-------------------------------+-------+
+`)
+}
+
+func TestSummarizeTargets(t *testing.T) {
+	file := readingSummary{
+		Name: "targets",
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{{
+				QueryStructure: "SELECT * FROM `t` WHERE `id` = :_id /* INT64 */",
+				UsageCount:     10,
+				StatementType:  "SELECT",
+				Targets:        map[string]int{"ks:-80": 3, "ks@replica": 1},
+			}, {
+				QueryStructure: "SELECT * FROM `t` JOIN `u` ON `t`.`id` = `u`.`id`",
+				UsageCount:     4,
+				StatementType:  "SELECT",
+				Targets:        map[string]int{"ks@replica": 4, "other@replica": 4},
+			}, {
+				QueryStructure: "SELECT * FROM `u`",
+				UsageCount:     6,
+				StatementType:  "SELECT",
+			}},
+		},
+	}
+
+	sb := &strings.Builder{}
+	printKeysSummary(sb, file, time.UTC)
+	assert.Contains(t, sb.String(), `Explicit shard or tablet type targeting: 35.00% of query uses (7 of 20), these queries complicate resharding
++---------------+------+
+|    Target     | Uses |
++---------------+------+
+| ks@replica    |    5 |
+| other@replica |    4 |
+| ks:-80        |    3 |
++---------------+------+
+`)
+}
+
+func TestSummarizeFunctionFilters(t *testing.T) {
+	created := operators.Column{Table: "orders", Name: "created"}
+	file := readingSummary{
+		Name: "functions",
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{{
+				QueryStructure: "SELECT * FROM orders WHERE DATE(created) = :_created",
+				UsageCount:     3,
+				StatementType:  "SELECT",
+				FunctionFilters: []keys.FunctionColumnUse{
+					{Function: "date", Column: created, Uses: sqlparser.EqualOp},
+				},
+			}, {
+				QueryStructure: "SELECT * FROM orders WHERE id = :_id /* INT64 */",
+				UsageCount:     10,
+				StatementType:  "SELECT",
+			}, {
+				QueryStructure: "SELECT * FROM orders WHERE YEAR(created) BETWEEN :_1 AND :_2",
+				UsageCount:     7,
+				StatementType:  "SELECT",
+				FunctionFilters: []keys.FunctionColumnUse{
+					{Function: "year", Column: created, Uses: sqlparser.GreaterEqualOp},
+					{Function: "year", Column: created, Uses: sqlparser.LessEqualOp},
+				},
+			}},
+		},
+	}
+
+	sb := &strings.Builder{}
+	printKeysSummary(sb, file, time.UTC)
+	assert.Contains(t, sb.String(), `Filters on a function of a column: 50.00% of query uses (10 of 20), these filters can't use the indexes nor the vindex of the column
++--------------------------------------------------------------+--------------------------------------------------+-------------+
+|                            Query                             |                     Filters                      | Usage Count |
++--------------------------------------------------------------+--------------------------------------------------+-------------+
+| SELECT * FROM orders WHERE YEAR(created) BETWEEN :_1 AND :_2 | year(orders.created) ge, year(orders.created) le |           7 |
+| SELECT * FROM orders WHERE DATE(created) = :_created         | date(orders.created) =                           |           3 |
++--------------------------------------------------------------+--------------------------------------------------+-------------+
+`)
+}
+
+func TestApplyRenames(t *testing.T) {
+	file := readingSummary{
+		Name: "renamed",
+		TracedQueries: []TracedQuery{
+			{Query: "select * from music where music.id = 1", LineNumber: "1"},
+			{Query: "select * from users", LineNumber: "2"},
+			{Query: "not sql at all", LineNumber: "3"},
+		},
+		AnalysedQueries: &keys.Output{
+			Queries: []keys.QueryAnalysisResult{{QueryStructure: "select * from `music`", TableName: []string{"music"}}},
+		},
+	}
+
+	file.applyRenames(keys.Renames{"music": "media.song"})
+	assert.Equal(t, "select * from media.song where media.song.id = 1", file.TracedQueries[0].Query)
+	assert.Equal(t, "select * from users", file.TracedQueries[1].Query)
+	assert.Equal(t, "not sql at all", file.TracedQueries[2].Query)
+	assert.Equal(t, []string{"song"}, file.AnalysedQueries.Queries[0].TableName)
+}
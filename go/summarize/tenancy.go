@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// TenancyConfig maps a table name to the column that identifies the tenant owning a row.
+// It is read from a JSON object such as {"orders": "tenant_id", "customers": "tenant_id"}.
+type TenancyConfig map[string]string
+
+// TenancyViolation is a query structure that reads or modifies a table with a tenancy column
+// without filtering on that column with an equality or IN predicate.
+type TenancyViolation struct {
+	Table          string `json:"table"`
+	TenancyColumn  string `json:"tenancyColumn"`
+	QueryStructure string `json:"queryStructure"`
+	StatementType  string `json:"statementType"`
+	UsageCount     int    `json:"usageCount"`
+}
+
+func readTenancyConfig(fileName string) (TenancyConfig, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var cfg TenancyConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("reading tenancy config %s: %w", fileName, err)
+	}
+	return cfg, nil
+}
+
+// checkTenancy returns all the query structures that touch a table of the config without filtering it by
+// its tenancy column. Inserts are not checked since they don't filter rows. The violations are sorted by
+// usage count, the most used query structures first.
+func checkTenancy(queries *keys.Output, cfg TenancyConfig) []TenancyViolation {
+	var violations []TenancyViolation
+	for _, query := range queries.Queries {
+		if query.StatementType == "INSERT" {
+			continue
+		}
+		for _, table := range query.TableName {
+			column, found := tenancyColumn(cfg, table)
+			if !found || filtersOnColumn(query, table, column) {
+				continue
+			}
+			violations = append(violations, TenancyViolation{
+				Table:          table,
+				TenancyColumn:  column,
+				QueryStructure: query.QueryStructure,
+				StatementType:  query.StatementType,
+				UsageCount:     query.UsageCount,
+			})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].UsageCount != violations[j].UsageCount {
+			return violations[i].UsageCount > violations[j].UsageCount
+		}
+		return violations[i].Table < violations[j].Table
+	})
+	return violations
+}
+
+func tenancyColumn(cfg TenancyConfig, table string) (string, bool) {
+	for t, column := range cfg {
+		if strings.EqualFold(t, table) {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// filtersOnColumn tells if the query filters a column of a table with an equality or IN predicate
+func filtersOnColumn(query keys.QueryAnalysisResult, table, column string) bool {
+	for _, filter := range query.FilterColumns {
+		if !strings.EqualFold(filter.Column.Table, table) || !strings.EqualFold(filter.Column.Name, column) {
+			continue
+		}
+		switch filter.Uses {
+		case sqlparser.EqualOp, sqlparser.InOp, sqlparser.NullSafeEqualOp:
+			return true
+		}
+	}
+	return false
+}
+
+func printTenancyViolations(out io.Writer, violations []TenancyViolation) {
+	if len(violations) == 0 {
+		fmt.Fprintln(out, "All queries filter on the tenancy columns")
+		return
+	}
+
+	var total int
+	for _, violation := range violations {
+		total += violation.UsageCount
+	}
+	fmt.Fprintf(out, "%d query structures, used %d times, do not filter on the tenancy column:\n", len(violations), total)
+	table := createTableWriter(out, []string{"Table", "Tenancy Column", "Statement", "Usage Count", "Query"})
+	for _, violation := range violations {
+		table.Append([]string{
+			violation.Table,
+			violation.TenancyColumn,
+			violation.StatementType,
+			strconv.Itoa(violation.UsageCount),
+			violation.QueryStructure,
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestCheckTenancy(t *testing.T) {
+	filter := func(table, column string, op sqlparser.ComparisonExprOperator) operators.ColumnUse {
+		return operators.ColumnUse{Column: operators.Column{Table: table, Name: column}, Uses: op}
+	}
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{
+			QueryStructure: "select * from orders where tenant_id = :1 and id = :2",
+			UsageCount:     10,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{filter("orders", "tenant_id", sqlparser.EqualOp), filter("orders", "id", sqlparser.EqualOp)},
+			StatementType:  "SELECT",
+		}, {
+			QueryStructure: "select * from orders where id = :1",
+			UsageCount:     2,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{filter("orders", "id", sqlparser.EqualOp)},
+			StatementType:  "SELECT",
+		}, {
+			QueryStructure: "delete from orders where tenant_id > :1",
+			UsageCount:     1,
+			TableName:      []string{"orders"},
+			FilterColumns:  []operators.ColumnUse{filter("orders", "tenant_id", sqlparser.GreaterThanOp)},
+			StatementType:  "DELETE",
+		}, {
+			QueryStructure: "insert into orders (tenant_id, id) values (:1, :2)",
+			UsageCount:     5,
+			TableName:      []string{"orders"},
+			StatementType:  "INSERT",
+		}, {
+			QueryStructure: "select * from countries",
+			UsageCount:     7,
+			TableName:      []string{"countries"},
+			StatementType:  "SELECT",
+		}},
+	}
+
+	violations := checkTenancy(queries, TenancyConfig{"Orders": "TENANT_ID"})
+	require.Equal(t, []TenancyViolation{{
+		Table:          "orders",
+		TenancyColumn:  "TENANT_ID",
+		QueryStructure: "select * from orders where id = :1",
+		StatementType:  "SELECT",
+		UsageCount:     2,
+	}, {
+		Table:          "orders",
+		TenancyColumn:  "TENANT_ID",
+		QueryStructure: "delete from orders where tenant_id > :1",
+		StatementType:  "DELETE",
+		UsageCount:     1,
+	}}, violations)
+
+	sb := &strings.Builder{}
+	printTenancyViolations(sb, violations)
+	assert.Equal(t, `2 query structures, used 3 times, do not filter on the tenancy column:
++--------+----------------+-----------+-------------+-----------------------------------------+
+| Table  | Tenancy Column | Statement | Usage Count |                  Query                  |
++--------+----------------+-----------+-------------+-----------------------------------------+
+| orders | TENANT_ID      | SELECT    |           2 | select * from orders where id = :1      |
+| orders | TENANT_ID      | DELETE    |           1 | delete from orders where tenant_id > :1 |
++--------+----------------+-----------+-------------+-----------------------------------------+
+`, sb.String())
+
+	sb.Reset()
+	printTenancyViolations(sb, nil)
+	assert.Equal(t, "All queries filter on the tenancy columns\n", sb.String())
+}
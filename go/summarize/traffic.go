@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+const (
+	// minBatchBuckets is the number of buckets the workload has to cover to tell its batch queries apart
+	minBatchBuckets = 4
+
+	bucketTimeFormat = "2006-01-02 15:04:05"
+)
+
+// BucketSummary is the traffic of the workload in one bucket of a bucketed keys file
+type BucketSummary struct {
+	Start           time.Time `json:"start"`
+	UsageCount      int       `json:"usageCount"`
+	QueryStructures int       `json:"queryStructures"`
+}
+
+// BatchQuerySummary is a query structure whose uses are concentrated in a few buckets, such as the queries of a batch job
+type BatchQuerySummary struct {
+	QueryStructure string `json:"queryStructure"`
+	UsageCount     int    `json:"usageCount"`
+	Buckets        int    `json:"buckets"`
+}
+
+// summarizeBuckets returns the traffic of every bucket with queries, in chronological order
+func summarizeBuckets(queries *keys.Output) []BucketSummary {
+	buckets := make(map[time.Time]*BucketSummary)
+	for _, query := range queries.Queries {
+		for start, count := range query.Buckets {
+			bucket, found := buckets[start]
+			if !found {
+				bucket = &BucketSummary{Start: start}
+				buckets[start] = bucket
+			}
+			bucket.UsageCount += count
+			bucket.QueryStructures++
+		}
+	}
+	result := make([]BucketSummary, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, *bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// summarizeBatchQueries returns the query structures run in a quarter or less of the buckets with traffic,
+// the most used first. The steady traffic of OLTP queries spreads over most buckets, when batch jobs run in a window.
+// Nothing is returned when the workload covers fewer than minBatchBuckets buckets.
+func summarizeBatchQueries(queries *keys.Output, buckets int) []BatchQuerySummary {
+	if buckets < minBatchBuckets {
+		return nil
+	}
+	var result []BatchQuerySummary
+	for _, query := range queries.Queries {
+		if len(query.Buckets) == 0 || len(query.Buckets) > buckets/4 {
+			continue
+		}
+		result = append(result, BatchQuerySummary{
+			QueryStructure: query.QueryStructure,
+			UsageCount:     query.UsageCount,
+			Buckets:        len(query.Buckets),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UsageCount > result[j].UsageCount
+	})
+	return result
+}
+
+// printTraffic prints the traffic of every bucket, starting at the times of the time zone loc
+func printTraffic(out io.Writer, queries *keys.Output, loc *time.Location) {
+	buckets := summarizeBuckets(queries)
+	if len(buckets) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Traffic per bucket of %s, in %s:\n", queries.BucketSize, loc)
+	table := createTableWriter(out, []string{"Bucket", "Usage Count", "Query Structures"})
+	for _, bucket := range buckets {
+		table.Append([]string{bucket.Start.In(loc).Format(bucketTimeFormat), strconv.Itoa(bucket.UsageCount), strconv.Itoa(bucket.QueryStructures)})
+	}
+	table.Render()
+	_, _ = fmt.Fprintln(out)
+
+	batch := summarizeBatchQueries(queries, len(buckets))
+	if len(batch) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Query structures run in %d or fewer of the %d buckets with traffic, such as batch jobs:\n", len(buckets)/4, len(buckets))
+	table = createTableWriter(out, []string{"Query", "Usage Count", "Buckets"})
+	for _, query := range batch {
+		table.Append([]string{query.QueryStructure, strconv.Itoa(query.UsageCount), strconv.Itoa(query.Buckets)})
+	}
+	table.Render()
+	_, _ = fmt.Fprintln(out)
+}
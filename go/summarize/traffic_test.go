@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestTraffic(t *testing.T) {
+	hour := func(h int) time.Time { return time.Date(2024, 11, 5, h, 0, 0, 0, time.UTC) }
+	steady := map[time.Time]int{}
+	for h := 8; h < 16; h++ {
+		steady[hour(h)] = 10
+	}
+	queries := &keys.Output{BucketSize: "1h0m0s", Queries: []keys.QueryAnalysisResult{{
+		QueryStructure: "SELECT * FROM `t` WHERE `id` = :id",
+		UsageCount:     80,
+		Buckets:        steady,
+	}, {
+		QueryStructure: "DELETE FROM `t` WHERE `created` < now()",
+		UsageCount:     3,
+		Buckets:        map[time.Time]int{hour(2): 3},
+	}, {
+		QueryStructure: "SELECT count(*) FROM `t`",
+		UsageCount:     5,
+		Buckets:        map[time.Time]int{hour(12): 4, hour(13): 1},
+	}}}
+
+	buckets := summarizeBuckets(queries)
+	require.Len(t, buckets, 9)
+	require.Equal(t, BucketSummary{Start: hour(2), UsageCount: 3, QueryStructures: 1}, buckets[0])
+	require.Equal(t, BucketSummary{Start: hour(12), UsageCount: 14, QueryStructures: 2}, buckets[5])
+
+	batch := summarizeBatchQueries(queries, len(buckets))
+	require.Equal(t, []BatchQuerySummary{
+		{QueryStructure: "SELECT count(*) FROM `t`", UsageCount: 5, Buckets: 2},
+		{QueryStructure: "DELETE FROM `t` WHERE `created` < now()", UsageCount: 3, Buckets: 1},
+	}, batch)
+	require.Nil(t, summarizeBatchQueries(queries, 3))
+
+	out := &strings.Builder{}
+	printTraffic(out, queries, time.UTC)
+	require.Contains(t, out.String(), "Traffic per bucket of 1h0m0s, in UTC:\n")
+	require.Contains(t, out.String(), "| 2024-11-05 02:00:00 |           3 |                1 |")
+	require.Contains(t, out.String(), "Query structures run in 2 or fewer of the 9 buckets with traffic, such as batch jobs:\n")
+
+	// the buckets start at the times of the time zone of the summary
+	out.Reset()
+	printTraffic(out, queries, time.FixedZone("CEST", 2*60*60))
+	require.Contains(t, out.String(), "Traffic per bucket of 1h0m0s, in CEST:\n")
+	require.Contains(t, out.String(), "| 2024-11-05 04:00:00 |           3 |                1 |")
+
+	out.Reset()
+	printTraffic(out, &keys.Output{Queries: []keys.QueryAnalysisResult{{QueryStructure: "SELECT 1", UsageCount: 1}}}, time.UTC)
+	require.Empty(t, out.String())
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vitessio/vt/go/internal/markdown"
+	"github.com/vitessio/vt/go/keys"
+)
+
+const (
+	// sparkBlocks are the characters of the sparklines, from the lowest to the highest usage
+	sparkBlocks = "▁▂▃▄▅▆▇█"
+
+	sparkWidth  = 120
+	sparkHeight = 24
+)
+
+//go:embed trend.html
+var trendTemplate string
+
+//nolint:gochecknoglobals // this is instead of a const
+var trendDate = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})`)
+
+type (
+	// trendReport is the usage of the query structures and the tables of a series of keys files, such as one per day
+	trendReport struct {
+		Dir string `json:"dir"`
+		// Dates label the files, in order: the date in their name, or their name when it has no date
+		Dates   []string      `json:"dates"`
+		Queries []trendSeries `json:"queries"`
+		Tables  []trendSeries `json:"tables"`
+
+		Omitted omitted `json:"-"`
+	}
+
+	// trendSeries is the usage of a query structure or a table in every file of a trend
+	trendSeries struct {
+		// ID is the query ID of a query structure, the series of the same ID are the same query across the files
+		ID    string `json:"id,omitempty"`
+		Name  string `json:"name"`
+		Usage []int  `json:"usage"`
+		// Growth is the change of the usage from the first file to the last one, in percent.
+		// It is 0 when it was not used in the first file, New tells these apart.
+		Growth float64 `json:"growth"`
+		New    bool    `json:"new,omitempty"`
+
+		// Sparkline draws the usage with block characters, and Points as an SVG polyline
+		Sparkline string `json:"-"`
+		Points    string `json:"-"`
+	}
+
+	trendFile struct {
+		label string
+		file  readingSummary
+	}
+)
+
+// readTrend reads the keys files of a directory and orders them by the date in their names, such as keys-2024-11-02.json,
+// or by name for the files without a date. The other files of the directory are skipped with a warning.
+func readTrend(dir string, cfg Config) ([]trendFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading trend directory: %w", err)
+	}
+	var renames keys.Renames
+	if cfg.RenameFile != "" {
+		renames, err = keys.ReadRenames(cfg.RenameFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var files []trendFile
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		file, err := readTraceFile(filepath.Join(dir, entry.Name()), cfg.Strict)
+		if err != nil {
+			return nil, err
+		}
+		if file.AnalysedQueries == nil {
+			log.Warnf("%s is not a keys file, it is left out of the trend", file.Name)
+			continue
+		}
+		if renames != nil {
+			file.applyRenames(renames)
+		}
+		label := strings.TrimSuffix(entry.Name(), ".json")
+		if date := trendDate.FindStringSubmatch(entry.Name()); date != nil {
+			label = date[1] + "-" + date[2] + "-" + date[3]
+		}
+		files = append(files, trendFile{label: label, file: file})
+	}
+	if len(files) < 2 {
+		return nil, fmt.Errorf("a trend needs at least two keys files, %s has %d", dir, len(files))
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].label < files[j].label })
+	return files, nil
+}
+
+// newTrendReport follows the usage of every query structure and table across the files, the ones whose usage
+// grew the most first. The limits of the reports keep the most grown query structures and tables.
+func newTrendReport(dir string, files []trendFile, limits ReportLimits) trendReport {
+	report := trendReport{Dir: dir}
+	queries := make(map[string][]int)
+	structures := make(map[string]string)
+	tables := make(map[string][]int)
+	for i, f := range files {
+		report.Dates = append(report.Dates, f.label)
+		for _, q := range f.file.AnalysedQueries.Queries {
+			// the keys files written before the query IDs have none, their ID is the one of their structure
+			id := q.ID
+			if id == "" {
+				id = keys.QueryID(q.QueryStructure)
+			}
+			if queries[id] == nil {
+				queries[id] = make([]int, len(files))
+				structures[id] = q.QueryStructure
+			}
+			queries[id][i] += q.UsageCount
+			seen := make(map[string]bool, len(q.TableName))
+			for _, table := range q.TableName {
+				if seen[table] {
+					continue
+				}
+				seen[table] = true
+				if tables[table] == nil {
+					tables[table] = make([]int, len(files))
+				}
+				tables[table][i] += q.UsageCount
+			}
+		}
+	}
+
+	topQueries := limits.TopQueries
+	if topQueries == 0 {
+		topQueries = maxHotQueries
+	}
+	report.Queries, report.Omitted.HotQueries = trendSeriesOf(queries, structures, topQueries)
+	report.Tables, report.Omitted.Tables = trendSeriesOf(tables, nil, limits.TopTables)
+	return report
+}
+
+// trendSeriesOf sorts the usages by the growth of their usage count, keeping at most limit of them when limit is set,
+// and returns the number of usages left out. The usages of the query structures are keyed by their ID and named
+// after their structure in names, the other usages are keyed by their name.
+func trendSeriesOf(usages map[string][]int, names map[string]string, limit int) ([]trendSeries, int) {
+	result := make([]trendSeries, 0, len(usages))
+	for key, usage := range usages {
+		first, last := usage[0], usage[len(usage)-1]
+		series := trendSeries{Name: key, Usage: usage, New: first == 0}
+		if name, ok := names[key]; ok {
+			series.ID, series.Name = key, name
+		}
+		if first > 0 {
+			series.Growth = float64(last-first) / float64(first) * 100
+		}
+		series.Sparkline, series.Points = sparkline(usage)
+		result = append(result, series)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if growthA, growthB := a.Last()-a.First(), b.Last()-b.First(); growthA != growthB {
+			return growthA > growthB
+		}
+		return result[i].Name < result[j].Name
+	})
+	if limit > 0 && len(result) > limit {
+		return result[:limit], len(result) - limit
+	}
+	return result, 0
+}
+
+// sparkline draws the usage with block characters, and as the points of an SVG polyline,
+// both scaled to the highest usage of the series
+func sparkline(usage []int) (string, string) {
+	maxUsage := 0
+	for _, u := range usage {
+		maxUsage = max(maxUsage, u)
+	}
+	blocks := []rune(sparkBlocks)
+	var sb strings.Builder
+	points := make([]string, 0, len(usage))
+	for i, u := range usage {
+		level := 0.0
+		if maxUsage > 0 {
+			level = float64(u) / float64(maxUsage)
+		}
+		sb.WriteRune(blocks[int(level*float64(len(blocks)-1))])
+		x := float64(i) * sparkWidth / float64(max(len(usage)-1, 1))
+		y := sparkHeight - 1 - level*(sparkHeight-2)
+		points = append(points, fmt.Sprintf("%g,%g", round1(x), round1(y)))
+	}
+	return sb.String(), strings.Join(points, " ")
+}
+
+// Change is the growth of the usage, or new when it was not used in the first file
+func (s trendSeries) Change() string {
+	if s.New {
+		return "new"
+	}
+	return fmt.Sprintf("%+.2f%%", s.Growth)
+}
+
+// First and Last are the usage in the first and in the last files
+func (s trendSeries) First() int { return s.Usage[0] }
+func (s trendSeries) Last() int  { return s.Usage[len(s.Usage)-1] }
+
+// printTrend writes the trend of the keys files of a directory in the format of the config
+func printTrend(out io.Writer, cfg Config) error {
+	if cfg.Format == FormatPDF {
+		return errors.New("--trend writes text, markdown, json or html")
+	}
+	files, err := readTrend(cfg.TrendDir, cfg)
+	if err != nil {
+		return err
+	}
+	report := newTrendReport(cfg.TrendDir, files, cfg.Limits)
+	switch cfg.Format {
+	case FormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatHTML:
+		tmpl, err := template.New("trend").Parse(trendTemplate)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(out, report)
+	case FormatMarkdown:
+		printMarkdownTrend(out, report)
+	default:
+		printTextTrend(out, terminalWidth(), report)
+	}
+	return nil
+}
+
+// Title tells which files the trend covers
+func (r trendReport) Title() string {
+	return fmt.Sprintf("Trend of the %d keys files of %s, from %s to %s", len(r.Dates), r.Dir, r.Dates[0], r.Dates[len(r.Dates)-1])
+}
+
+func printTextTrend(out io.Writer, termWidth int, report trendReport) {
+	fmt.Fprintln(out, report.Title())
+	fmt.Fprintln(out, "Query structures, the most grown first:")
+	table := createTableWriter(out, []string{"Query", "Trend", "First", "Last", "Growth"})
+	for _, q := range report.Queries {
+		table.Append([]string{limitQueryLength(q.Name, termWidth/2), q.Sparkline, strconv.Itoa(q.First()), strconv.Itoa(q.Last()), q.Change()})
+	}
+	table.Render()
+	if report.Omitted.HotQueries > 0 {
+		fmt.Fprintf(out, "and %d more...\n", report.Omitted.HotQueries)
+	}
+	fmt.Fprintln(out)
+
+	fmt.Fprintln(out, "Tables, the most grown first:")
+	table = createTableWriter(out, []string{"Table", "Trend", "First", "Last", "Growth"})
+	for _, t := range report.Tables {
+		table.Append([]string{t.Name, t.Sparkline, strconv.Itoa(t.First()), strconv.Itoa(t.Last()), t.Change()})
+	}
+	table.Render()
+	if report.Omitted.Tables > 0 {
+		fmt.Fprintf(out, "and %d more...\n", report.Omitted.Tables)
+	}
+}
+
+func printMarkdownTrend(out io.Writer, report trendReport) {
+	fmt.Fprintf(out, "# %s\n", report.Title())
+	fmt.Fprint(out, "\n## Query structures\n\n")
+	table := markdown.Table(out, []string{"Query", "Trend", "First", "Last", "Growth"})
+	for _, q := range report.Queries {
+		table.Append([]string{markdown.Cell(q.Name), q.Sparkline, strconv.Itoa(q.First()), strconv.Itoa(q.Last()), q.Change()})
+	}
+	table.Render()
+	printMore(out, report.Omitted.HotQueries)
+
+	fmt.Fprint(out, "\n## Tables\n\n")
+	table = markdown.Table(out, []string{"Table", "Trend", "First", "Last", "Growth"})
+	for _, t := range report.Tables {
+		table.Append([]string{t.Name, t.Sparkline, strconv.Itoa(t.First()), strconv.Itoa(t.Last()), t.Change()})
+	}
+	table.Render()
+	printMore(out, report.Omitted.Tables)
+}
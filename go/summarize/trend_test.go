@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func writeKeysFile(t *testing.T, fileName string, usages map[string]int) {
+	output := keys.Output{FileType: keys.FileType, Version: keys.OutputVersion}
+	for structure, usage := range usages {
+		_, table, _ := strings.Cut(structure, "from ")
+		output.Queries = append(output.Queries, keys.QueryAnalysisResult{
+			QueryStructure: structure,
+			UsageCount:     usage,
+			TableName:      []string{strings.Fields(table)[0]},
+			StatementType:  "SELECT",
+		})
+	}
+	raw, err := json.Marshal(output)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(fileName, raw, 0o600))
+}
+
+func trendTestDir(t *testing.T) string {
+	dir := t.TempDir()
+	// the files are ordered by the dates in their names, not by the names
+	writeKeysFile(t, filepath.Join(dir, "keys-2024-11-03.json"), map[string]int{
+		"select * from orders where id = :1": 40, "select * from customer": 4, "select * from orders where status = :1": 5,
+	})
+	writeKeysFile(t, filepath.Join(dir, "b-20241101.json"), map[string]int{
+		"select * from orders where id = :1": 10, "select * from customer": 8,
+	})
+	writeKeysFile(t, filepath.Join(dir, "a-2024-11-02.json"), map[string]int{
+		"select * from orders where id = :1": 20, "select * from customer": 6,
+	})
+	writeTraceFile(t, filepath.Join(dir, "trace.json"), tf1().TracedQueries)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a keys file"), 0o600))
+	return dir
+}
+
+func TestTrend(t *testing.T) {
+	dir := trendTestDir(t)
+	files, err := readTrend(dir, Config{})
+	require.NoError(t, err)
+	report := newTrendReport("keys", files, ReportLimits{})
+	require.Equal(t, []string{"2024-11-01", "2024-11-02", "2024-11-03"}, report.Dates)
+	require.Equal(t, []trendSeries{{
+		ID: keys.QueryID("select * from orders where id = :1"), Name: "select * from orders where id = :1", Usage: []int{10, 20, 40}, Growth: 300, Sparkline: "▂▄█", Points: "0,17.5 60,12 120,1",
+	}, {
+		ID: keys.QueryID("select * from orders where status = :1"), Name: "select * from orders where status = :1", Usage: []int{0, 0, 5}, New: true, Sparkline: "▁▁█", Points: "0,23 60,23 120,1",
+	}, {
+		ID: keys.QueryID("select * from customer"), Name: "select * from customer", Usage: []int{8, 6, 4}, Growth: -50, Sparkline: "█▆▄", Points: "0,1 60,6.5 120,12",
+	}}, report.Queries)
+	require.Equal(t, []string{"orders", "customer"}, []string{report.Tables[0].Name, report.Tables[1].Name})
+	require.Equal(t, []int{10, 20, 45}, report.Tables[0].Usage)
+
+	sb := &strings.Builder{}
+	printMarkdownTrend(sb, newTrendReport("keys", files, ReportLimits{TopQueries: 2}))
+	assert.Equal(t, `# Trend of the 3 keys files of keys, from 2024-11-01 to 2024-11-03
+
+## Query structures
+
+| Query                                  | Trend | First | Last | Growth   |
+|----------------------------------------|-------|-------|------|----------|
+| select * from orders where id = :1     | ▂▄█   |    10 |   40 | +300.00% |
+| select * from orders where status = :1 | ▁▁█   |     0 |    5 | new      |
+
+and 1 more...
+
+## Tables
+
+| Table    | Trend | First | Last | Growth   |
+|----------|-------|-------|------|----------|
+| orders   | ▂▄█   |    10 |   45 | +350.00% |
+| customer | █▆▄   |     8 |    4 | -50.00%  |
+`, sb.String())
+}
+
+func TestTrendQueryID(t *testing.T) {
+	// the same query is followed by its ID, even when its structure is written differently in the files
+	files := []trendFile{{label: "2024-11-01", file: readingSummary{AnalysedQueries: &keys.Output{Queries: []keys.QueryAnalysisResult{
+		{ID: "q1", QueryStructure: "select * from orders where id = :1", UsageCount: 10, TableName: []string{"orders"}},
+	}}}}, {label: "2024-11-02", file: readingSummary{AnalysedQueries: &keys.Output{Queries: []keys.QueryAnalysisResult{
+		{ID: "q1", QueryStructure: "select * from orders where id = :v1", UsageCount: 30, TableName: []string{"orders"}},
+	}}}}}
+	report := newTrendReport("keys", files, ReportLimits{})
+	require.Len(t, report.Queries, 1)
+	require.Equal(t, "q1", report.Queries[0].ID)
+	require.Equal(t, "select * from orders where id = :1", report.Queries[0].Name)
+	require.Equal(t, []int{10, 30}, report.Queries[0].Usage)
+}
+
+func TestPrintTrend(t *testing.T) {
+	dir := trendTestDir(t)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, printTrend(buf, Config{TrendDir: dir, Format: FormatHTML}))
+	require.Contains(t, buf.String(), `<polyline points="0,17.5 60,12 120,1"/>`)
+	require.Contains(t, buf.String(), "<title>10, 20, 40</title>")
+
+	buf.Reset()
+	require.NoError(t, printTrend(buf, Config{TrendDir: dir, Format: FormatJSON}))
+	var report trendReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	require.Len(t, report.Queries, 3)
+
+	require.ErrorContains(t, printTrend(buf, Config{TrendDir: dir, Format: FormatPDF}), "--trend writes text, markdown, json or html")
+	require.NoError(t, os.Remove(filepath.Join(dir, "b-20241101.json")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "a-2024-11-02.json")))
+	require.ErrorContains(t, printTrend(buf, Config{TrendDir: dir}), "a trend needs at least two keys files")
+}
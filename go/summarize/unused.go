@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+type (
+	// UnusedTable is a table of the dbinfo file that no query of the workload uses
+	UnusedTable struct {
+		Table string `json:"table"`
+		// Rows is the estimated number of rows of the table
+		Rows int `json:"rows"`
+	}
+
+	// UnusedColumns are the columns of a used table that no query filters, joins, groups or orders on.
+	// The keys file doesn't record the columns only read in the select list, so these can still be read.
+	UnusedColumns struct {
+		Table   string   `json:"table"`
+		Columns []string `json:"columns"`
+	}
+)
+
+// checkUnused returns the tables of the dbinfo file that no query uses, sorted by name,
+// and for the used tables, the columns that no query filters, joins, groups or orders on.
+// Table and column names are compared case-insensitively.
+func checkUnused(queries *keys.Output, info *dbinfo.Info) ([]UnusedTable, []UnusedColumns) {
+	usedTables := make(map[string]bool)
+	usedColumns := make(map[[2]string]bool)
+	use := func(column operators.Column) {
+		usedColumns[[2]string{strings.ToLower(column.Table), strings.ToLower(column.Name)}] = true
+	}
+	for _, query := range queries.Queries {
+		for _, names := range [][]string{query.TableName, query.AffectedTables} {
+			for _, table := range names {
+				usedTables[strings.ToLower(table)] = true
+			}
+		}
+		for _, uses := range [][]operators.ColumnUse{query.FilterColumns, query.JoinColumns} {
+			for _, column := range uses {
+				use(column.Column)
+			}
+		}
+		for _, columns := range [][]operators.Column{query.GroupingColumns, query.OrderingColumns} {
+			for _, column := range columns {
+				use(column)
+			}
+		}
+		for _, predicate := range query.JoinPredicates {
+			use(predicate.LHS)
+			use(predicate.RHS)
+		}
+		for _, filter := range query.FunctionFilters {
+			use(filter.Column)
+		}
+	}
+
+	var tables []UnusedTable
+	var columns []UnusedColumns
+	for _, table := range info.Tables {
+		name := strings.ToLower(table.Name)
+		if !usedTables[name] {
+			tables = append(tables, UnusedTable{Table: table.Name, Rows: table.Rows})
+			continue
+		}
+		var unused []string
+		for _, column := range table.Columns {
+			if !usedColumns[[2]string{name, strings.ToLower(column.Name)}] {
+				unused = append(unused, column.Name)
+			}
+		}
+		if len(unused) > 0 {
+			columns = append(columns, UnusedColumns{Table: table.Name, Columns: unused})
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Table < tables[j].Table })
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Table < columns[j].Table })
+	return tables, columns
+}
+
+func printUnused(out io.Writer, tables []UnusedTable, columns []UnusedColumns) {
+	if len(tables) == 0 {
+		fmt.Fprintln(out, "All the tables are used by the queries")
+	} else {
+		fmt.Fprintf(out, "%d tables are not used by any query, they can be sharded in any way or dropped:\n", len(tables))
+		table := createTableWriter(out, []string{"Table", "Table Rows"})
+		for _, unused := range tables {
+			table.Append([]string{unused.Table, strconv.Itoa(unused.Rows)})
+		}
+		table.Render()
+	}
+
+	if len(columns) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Columns no query filters, joins, groups or orders on (columns only read in the select list are not known):")
+	table := createTableWriter(out, []string{"Table", "Columns"})
+	for _, unused := range columns {
+		table.Append([]string{unused.Table, strings.Join(unused.Columns, ", ")})
+	}
+	table.Render()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestCheckUnused(t *testing.T) {
+	column := func(table, name string) operators.Column {
+		return operators.Column{Table: table, Name: name}
+	}
+	queries := &keys.Output{
+		Queries: []keys.QueryAnalysisResult{{
+			QueryStructure:  "select * from orders where status = :1 order by created",
+			TableName:       []string{"orders"},
+			FilterColumns:   []operators.ColumnUse{{Column: column("orders", "status"), Uses: sqlparser.EqualOp}},
+			OrderingColumns: []operators.Column{column("orders", "created")},
+		}, {
+			QueryStructure: "select * from Orders join customer on Orders.customer_id = customer.id",
+			TableName:      []string{"Orders", "customer"},
+			JoinPredicates: []operators.JoinPredicate{{LHS: column("Orders", "Customer_ID"), RHS: column("customer", "id"), Uses: sqlparser.EqualOp}},
+		}},
+	}
+	columns := func(names ...string) []dbinfo.ColumnInfo {
+		var result []dbinfo.ColumnInfo
+		for _, name := range names {
+			result = append(result, dbinfo.ColumnInfo{Name: name})
+		}
+		return result
+	}
+	info := &dbinfo.Info{Tables: []dbinfo.TableInfo{{
+		Name:    "orders",
+		Rows:    250000,
+		Columns: columns("id", "customer_id", "status", "created", "note"),
+	}, {
+		Name:    "customer",
+		Rows:    1000,
+		Columns: columns("id"),
+	}, {
+		Name:    "audit_log",
+		Rows:    90000,
+		Columns: columns("id", "message"),
+	}, {
+		Name: "archive",
+		Rows: 12,
+	}}}
+
+	tables, unused := checkUnused(queries, info)
+	require.Equal(t, []UnusedTable{{Table: "archive", Rows: 12}, {Table: "audit_log", Rows: 90000}}, tables)
+	require.Equal(t, []UnusedColumns{{Table: "orders", Columns: []string{"id", "note"}}}, unused)
+
+	sb := &strings.Builder{}
+	printUnused(sb, tables, unused)
+	assert.Equal(t, `2 tables are not used by any query, they can be sharded in any way or dropped:
++-----------+------------+
+|   Table   | Table Rows |
++-----------+------------+
+| archive   |         12 |
+| audit_log |      90000 |
++-----------+------------+
+Columns no query filters, joins, groups or orders on (columns only read in the select list are not known):
++--------+----------+
+| Table  | Columns  |
++--------+----------+
+| orders | id, note |
++--------+----------+
+`, sb.String())
+
+	sb.Reset()
+	printUnused(sb, nil, nil)
+	assert.Equal(t, "All the tables are used by the queries\n", sb.String())
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+// printColumnValues lists the values of the filter columns recorded by 'vt keys --top-values', the columns with
+// the fewest distinct values first, since they are the worst sharding keys
+func printColumnValues(out io.Writer, values []keys.ColumnValues) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Values of the filter columns, a good sharding key has many distinct values and no value used much more than the others:")
+	table := createTableWriter(out, []string{"Column", "Uses", "Distinct Values", "Top Value %", "Top Values"})
+	for _, v := range sortedColumnValues(values) {
+		var top []string
+		for _, value := range v.TopValues {
+			top = append(top, fmt.Sprintf("%s (%d)", value.Value, value.Count))
+		}
+		var share string
+		if len(v.TopValues) > 0 && v.Uses > 0 {
+			share = fmt.Sprintf("%.2f%%", float64(v.TopValues[0].Count)/float64(v.Uses)*100)
+		}
+		table.Append([]string{v.Column.Table + "." + v.Column.Name, strconv.Itoa(v.Uses), strconv.Itoa(v.Distinct), share, strings.Join(top, ", ")})
+	}
+	table.Render()
+	_, _ = fmt.Fprintln(out)
+}
+
+// sortedColumnValues sorts the columns by increasing number of distinct values, keeping the order by column for the same number
+func sortedColumnValues(values []keys.ColumnValues) []keys.ColumnValues {
+	sorted := append([]keys.ColumnValues(nil), values...)
+	slices.SortStableFunc(sorted, func(a, b keys.ColumnValues) int {
+		return cmp.Compare(a.Distinct, b.Distinct)
+	})
+	return sorted
+}
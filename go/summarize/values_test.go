@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestPrintColumnValues(t *testing.T) {
+	values := []keys.ColumnValues{{
+		Column:    operators.Column{Table: "orders", Name: "customer_id"},
+		Uses:      100,
+		Distinct:  80,
+		TopValues: []keys.ValueCount{{Value: "7", Count: 5}, {Value: "3", Count: 2}},
+	}, {
+		Column:    operators.Column{Table: "orders", Name: "state"},
+		Uses:      50,
+		Distinct:  2,
+		TopValues: []keys.ValueCount{{Value: "'active'", Count: 45}, {Value: "'done'", Count: 5}},
+	}}
+
+	out := &strings.Builder{}
+	printColumnValues(out, values)
+	require.Equal(t, `Values of the filter columns, a good sharding key has many distinct values and no value used much more than the others:
++--------------------+------+-----------------+-------------+---------------------------+
+|       Column       | Uses | Distinct Values | Top Value % |        Top Values         |
++--------------------+------+-----------------+-------------+---------------------------+
+| orders.state       |   50 |               2 | 90.00%      | 'active' (45), 'done' (5) |
+| orders.customer_id |  100 |              80 | 5.00%       | 7 (5), 3 (2)              |
++--------------------+------+-----------------+-------------+---------------------------+
+
+`, out.String())
+
+	out.Reset()
+	printColumnValues(out, nil)
+	require.Empty(t, out.String())
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// checkVersions checks that two trace files were written with compatible versions of Vitess. The planners of
+// different major versions plan queries differently, so their traces differ whatever the workload does.
+// It fails for different major versions, and warns about the other differences and the unknown versions.
+func checkVersions(file1, file2 readingSummary) (warning string, err error) {
+	v1, v2 := file1.VitessVersion, file2.VitessVersion
+	switch {
+	case v1 == v2:
+		return "", nil
+	case v1 == "" || v2 == "":
+		known, unknown := file1, file2
+		if v1 == "" {
+			known, unknown = file2, file1
+		}
+		return fmt.Sprintf("%s doesn't record its Vitess version, it may not be comparable with %s, traced with Vitess %s",
+			unknown.Name, known.Name, known.VitessVersion), nil
+	case majorVersion(v1) != majorVersion(v2):
+		return "", fmt.Errorf("%s was traced with Vitess %s and %s with Vitess %s, the plans of different major versions are not comparable",
+			file1.Name, v1, file2.Name, v2)
+	default:
+		return fmt.Sprintf("%s was traced with Vitess %s and %s with Vitess %s", file1.Name, v1, file2.Name, v2), nil
+	}
+}
+
+// checkComparable checks the versions of two trace files before they are compared, printing the warnings to stderr.
+// The files of different major versions are only compared with AllowVersionMismatch.
+func (cfg Config) checkComparable(file1, file2 readingSummary) error {
+	warning, err := checkVersions(file1, file2)
+	if err != nil {
+		if !cfg.AllowVersionMismatch {
+			return fmt.Errorf("%w, use --allow-version-mismatch to compare them anyway", err)
+		}
+		warning = err.Error()
+	}
+	if warning != "" {
+		log.Warn(warning)
+	}
+	return nil
+}
+
+// majorVersion returns the major number of a version such as 21.0.0-SNAPSHOT, or -1 when it can't be read
+func majorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return -1
+	}
+	return n
+}
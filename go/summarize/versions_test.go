@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVersions(t *testing.T) {
+	file := func(name, version string) readingSummary {
+		return readingSummary{Name: name, VitessVersion: version}
+	}
+	tests := []struct {
+		v1, v2  string
+		warning string
+		err     string
+	}{{
+		v1: "21.0.0", v2: "21.0.0",
+	}, {
+		// the files written before the version was recorded
+		v1: "", v2: "",
+	}, {
+		v1: "21.0.0", v2: "21.0.1-SNAPSHOT",
+		warning: "old.json was traced with Vitess 21.0.0 and new.json with Vitess 21.0.1-SNAPSHOT",
+	}, {
+		v1: "", v2: "22.0.0",
+		warning: "old.json doesn't record its Vitess version, it may not be comparable with new.json, traced with Vitess 22.0.0",
+	}, {
+		v1: "20.0.2", v2: "21.0.0",
+		err: "old.json was traced with Vitess 20.0.2 and new.json with Vitess 21.0.0, the plans of different major versions are not comparable",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.v1+" "+tt.v2, func(t *testing.T) {
+			warning, err := checkVersions(file("old.json", tt.v1), file("new.json", tt.v2))
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.warning, warning)
+		})
+	}
+}
+
+func TestReadTraceFileVersion(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "trace.json")
+	content := `[{"Query": "select 1", "LineNumber": "1", "VitessVersion": "21.0.0-SNAPSHOT", "Trace": {"OperatorType": "Route"}}]`
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+
+	summary, err := readTraceFile(fileName, true)
+	require.NoError(t, err)
+	require.Equal(t, "21.0.0-SNAPSHOT", summary.VitessVersion)
+
+	summary, err = readTraceFile("testdata/trace-log.json", true)
+	require.NoError(t, err)
+	require.Empty(t, summary.VitessVersion)
+}
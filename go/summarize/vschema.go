@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+
+	"github.com/vitessio/vt/go/dbinfo"
+)
+
+// suggestVSchema returns a sharded keyspace vschema with the recommended sharding key of every table as its primary vindex.
+// The integer columns get a hash vindex and the other columns, or the columns of unknown types, an xxhash vindex.
+// The tables without a recommendation are left out, they need a vindex before the vschema can be applied.
+func suggestVSchema(recommendations []ShardingKeyRecommendation, info *dbinfo.Info) *vschemapb.Keyspace {
+	ks := &vschemapb.Keyspace{
+		Sharded:  true,
+		Vindexes: make(map[string]*vschemapb.Vindex),
+		Tables:   make(map[string]*vschemapb.Table),
+	}
+	for _, r := range recommendations {
+		vindex := vindexType(columnType(info, r.Table, r.Column))
+		ks.Vindexes[vindex] = &vschemapb.Vindex{Type: vindex}
+		ks.Tables[r.Table] = &vschemapb.Table{
+			ColumnVindexes: []*vschemapb.ColumnVindex{{Column: r.Column, Name: vindex}},
+		}
+	}
+	return ks
+}
+
+// vindexType returns the vindex for a column type, such as bigint unsigned or varchar(64)
+func vindexType(columnType string) string {
+	name, _, _ := strings.Cut(strings.ToLower(columnType), "(")
+	switch strings.TrimSpace(name) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return "hash"
+	default:
+		return "xxhash"
+	}
+}
+
+// columnType returns the type of a column in the dbinfo file, empty when the file or the column is missing
+func columnType(info *dbinfo.Info, table, column string) string {
+	if info == nil {
+		return ""
+	}
+	for _, t := range info.Tables {
+		if !strings.EqualFold(t.Name, table) {
+			continue
+		}
+		for _, c := range t.Columns {
+			if strings.EqualFold(c.Name, column) {
+				return c.Type
+			}
+		}
+	}
+	return ""
+}
+
+// writeVSchema writes the vschema suggested for the recommended sharding keys to a JSON file
+func writeVSchema(fileName string, recommendations []ShardingKeyRecommendation, info *dbinfo.Info) error {
+	b, err := json.MarshalIndent(suggestVSchema(recommendations, info), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, append(b, '\n'), 0o600)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summarize
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	"github.com/vitessio/vt/go/dbinfo"
+)
+
+func TestSuggestVSchema(t *testing.T) {
+	recommendations := []ShardingKeyRecommendation{
+		{Table: "customer", Column: "id"},
+		{Table: "orders", Column: "customer_id"},
+		{Table: "session", Column: "token"},
+	}
+	info := &dbinfo.Info{Tables: []dbinfo.TableInfo{
+		{Name: "customer", Columns: []dbinfo.ColumnInfo{{Name: "id", Type: "bigint unsigned"}}},
+		{Name: "orders", Columns: []dbinfo.ColumnInfo{{Name: "customer_id", Type: "int(11)"}}},
+		{Name: "session", Columns: []dbinfo.ColumnInfo{{Name: "token", Type: "varchar(64)"}}},
+	}}
+
+	fileName := t.TempDir() + "/vschema.json"
+	require.NoError(t, writeVSchema(fileName, recommendations, info))
+	b, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	var ks vschemapb.Keyspace
+	require.NoError(t, json.Unmarshal(b, &ks))
+
+	require.True(t, ks.Sharded)
+	require.Len(t, ks.Vindexes, 2)
+	require.Equal(t, "hash", ks.Vindexes["hash"].Type)
+	require.Equal(t, "xxhash", ks.Vindexes["xxhash"].Type)
+	require.Equal(t, "customer_id", ks.Tables["orders"].ColumnVindexes[0].Column)
+	require.Equal(t, "hash", ks.Tables["orders"].ColumnVindexes[0].Name)
+	require.Equal(t, "xxhash", ks.Tables["session"].ColumnVindexes[0].Name)
+
+	// the vschema is one vtgate accepts
+	_, err = vindexes.BuildKeyspace(&ks, sqlparser.NewTestParser())
+	require.NoError(t, err)
+
+	// without a dbinfo file the column types are unknown
+	ks2 := suggestVSchema(recommendations, nil)
+	require.Len(t, ks2.Vindexes, 1)
+	require.Equal(t, "xxhash", ks2.Tables["customer"].ColumnVindexes[0].Name)
+}
@@ -38,9 +38,13 @@ type (
 		comparer          utils.MySQLCompare
 		cluster           *cluster.LocalProcessCluster
 		vschema           *vindexes.VSchema
+		verifyShards      bool
 	}
 	CreateTableHandler          func(create *sqlparser.CreateTable) func()
-	ComparingQueryRunnerFactory struct{}
+	ComparingQueryRunnerFactory struct {
+		// VerifyShards checks that the rows modified by DML statements are stored on the right shard
+		VerifyShards bool
+	}
 )
 
 func (f ComparingQueryRunnerFactory) Close() {}
@@ -52,6 +56,7 @@ func (f ComparingQueryRunnerFactory) NewQueryRunner(reporter Reporter, handleCre
 		comparer:          comparer,
 		cluster:           cluster,
 		vschema:           vschema,
+		verifyShards:      f.VerifyShards,
 	}
 }
 
@@ -101,11 +106,15 @@ func (nqr *ComparingQueryRunner) executeStmt(query string, ast sqlparser.Stateme
 			return nqr.executeReference(query, ast)
 		case state.NormalExecution():
 			nqr.comparer.Exec(query)
+			nqr.addMinimalQuery(query, ast)
 		case state.IsVitessOnlySet():
 			_, err = nqr.comparer.VtConn.ExecuteFetch(query, 1000, true)
 		case state.IsMySQLOnlySet():
 			_, err = nqr.comparer.MySQLConn.ExecuteFetch(query, 1000, true)
 		}
+		if err == nil && nqr.verifyShards && state.RunOnVitess() {
+			err = nqr.verifyShardPlacement(ast)
+		}
 		if err != nil {
 			nqr.reporter.AddFailure(err)
 		}
@@ -113,6 +122,23 @@ func (nqr *ComparingQueryRunner) executeStmt(query string, ast sqlparser.Stateme
 	return nil
 }
 
+// addMinimalQuery adds the minimal form of a SELECT the comparer failed on to the failure report
+func (nqr *ComparingQueryRunner) addMinimalQuery(query string, ast sqlparser.Statement) {
+	if _, isSelect := ast.(sqlparser.SelectStatement); !isSelect {
+		// running a simpler DML would change the data
+		return
+	}
+	if r, ok := nqr.reporter.(*comparisonReporter); !ok || !r.failed {
+		return
+	}
+	minimal := minimizeQuery(query, func(query string) mismatchKind {
+		return compareOn(nqr.comparer.VtConn, nqr.comparer.MySQLConn, query)
+	})
+	if minimal != query {
+		nqr.reporter.AddInfo(fmt.Sprintf("Minimal query with the same mismatch:\n%s\n", minimal))
+	}
+}
+
 func shouldWeRunCreateTable(ast sqlparser.Statement, state *state.State) (*sqlparser.CreateTable, bool) {
 	if state.IsErrorExpectedSet() || !state.RunOnVitess() {
 		return nil, false
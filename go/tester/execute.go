@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/test/endtoend/cluster"
@@ -29,6 +30,9 @@ import (
 	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/probe"
 )
 
 type RawKeyspaceVindex struct {
@@ -41,11 +45,13 @@ const (
 )
 
 func ExecuteTests(
+	ctx context.Context,
 	info ClusterInfo,
 	fileNames []string,
 	s Suite,
 	vschemaFile, vtexplainVschemaFile string,
 	olap bool,
+	filter data.Filter,
 	factory QueryRunnerFactory,
 ) (failed bool) {
 	vschemaF := vschemaFile
@@ -53,10 +59,16 @@ func ExecuteTests(
 		vschemaF = vtexplainVschemaFile
 	}
 
+	timings := &Timings{}
 	for _, name := range fileNames {
-		errReporter := s.NewReporterForFile(name)
-		vTester := NewTester(name, errReporter, info, olap, info.vschema, vschemaF, factory)
-		err := vTester.Run()
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		errReporter := timings.wrap(name, s.NewReporterForFile(name))
+		vTester := NewTester(name, errReporter, info, olap, info.vschema, vschemaF, filter, factory)
+		err := vTester.Run(ctx)
+		timings.addFile(name, time.Since(start))
 		if err != nil {
 			failed = true
 			continue
@@ -66,6 +78,7 @@ func ExecuteTests(
 	}
 
 	factory.Close()
+	timings.Print(os.Stdout)
 
 	return failed
 }
@@ -77,9 +90,21 @@ type ClusterInfo struct {
 	ksNames         []string
 	vschema         *vindexes.VSchema
 	closer          func()
+
+	// capabilities is nil when no capabilities file was given
+	capabilities *probe.Capabilities
 }
 
-func SetupCluster(cfg Config) (_ ClusterInfo, err error) {
+// SetupCluster starts the cluster the tests run against, or attaches to the one to reuse. The cluster is torn down
+// when the context is cancelled before it is set up.
+func SetupCluster(ctx context.Context, cfg Config) (_ ClusterInfo, err error) {
+	if cfg.ReuseCluster != "" {
+		return attachCluster(ctx, cfg)
+	}
+
+	// the cluster sets VTDATAROOT to its own directory
+	vtdataroot := os.Getenv("VTDATAROOT")
+	start := time.Now()
 	clusterInstance := cluster.NewCluster(defaultCellName, "localhost")
 
 	defer func() {
@@ -93,6 +118,9 @@ func SetupCluster(cfg Config) (_ ClusterInfo, err error) {
 	if err != nil {
 		return ClusterInfo{}, err
 	}
+	if err = ctx.Err(); err != nil {
+		return ClusterInfo{}, err
+	}
 
 	if cfg.BackupDir != "" {
 		clusterInstance.VtTabletExtraArgs = append(clusterInstance.VtTabletExtraArgs,
@@ -108,6 +136,9 @@ func SetupCluster(cfg Config) (_ ClusterInfo, err error) {
 		if err != nil {
 			return ClusterInfo{}, err
 		}
+		if err = ctx.Err(); err != nil {
+			return ClusterInfo{}, err
+		}
 	}
 
 	// Start vtgate
@@ -115,6 +146,9 @@ func SetupCluster(cfg Config) (_ ClusterInfo, err error) {
 	if err != nil {
 		return ClusterInfo{}, err
 	}
+	if err = ctx.Err(); err != nil {
+		return ClusterInfo{}, err
+	}
 
 	if len(ksNames) == 0 {
 		return ClusterInfo{}, errors.New("no keyspaces found in vschema")
@@ -125,13 +159,13 @@ func SetupCluster(cfg Config) (_ ClusterInfo, err error) {
 	var mysqlParams *mysql.ConnParams
 	var closers []func()
 	if cfg.Compare {
-		mysqlParams, closers, err = setupExternalMySQL(keyspaces, clusterInstance)
+		mysqlParams, closers, err = setupExternalMySQL(ctx, keyspaces, clusterInstance)
 		if err != nil {
 			return ClusterInfo{}, err
 		}
 	}
 
-	return ClusterInfo{
+	info := ClusterInfo{
 		clusterInstance: clusterInstance,
 		vtParams:        vtParams,
 		mysqlParams:     mysqlParams,
@@ -143,7 +177,11 @@ func SetupCluster(cfg Config) (_ ClusterInfo, err error) {
 				closer()
 			}
 		},
-	}, nil
+	}
+	if cfg.KeepCluster {
+		info.closer = keepCluster(cfg, info, vtdataroot, time.Since(start))
+	}
+	return info, nil
 }
 
 func startKeyspace(cfg Config, vschema *vindexes.VSchema, keyspace *cluster.Keyspace, clusterInstance *cluster.LocalProcessCluster) error {
@@ -170,7 +208,7 @@ func startKeyspace(cfg Config, vschema *vindexes.VSchema, keyspace *cluster.Keys
 }
 
 // TODO: having a single connection is not correct if we are dealing with multiple mysql databases.
-func setupExternalMySQL(keyspaces []*cluster.Keyspace, clusterInstance *cluster.LocalProcessCluster) (_ *mysql.ConnParams, closers []func(), err error) {
+func setupExternalMySQL(ctx context.Context, keyspaces []*cluster.Keyspace, clusterInstance *cluster.LocalProcessCluster) (_ *mysql.ConnParams, closers []func(), err error) {
 	// Create the mysqld server we will use to compare the results.
 	// We go through all the keyspaces we found in the vschema, and
 	// simply create the mysqld process during the first iteration with
@@ -180,6 +218,12 @@ func setupExternalMySQL(keyspaces []*cluster.Keyspace, clusterInstance *cluster.
 		if conn != nil {
 			conn.Close()
 		}
+		// the mysqld servers already started are stopped when the setup fails or is cancelled
+		if err != nil {
+			for _, closer := range closers {
+				closer()
+			}
+		}
 	}()
 
 	var mysqlParamsValue mysql.ConnParams
@@ -187,20 +231,20 @@ func setupExternalMySQL(keyspaces []*cluster.Keyspace, clusterInstance *cluster.
 		if i > 0 {
 			_, err = conn.ExecuteFetch(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", keyspace.Name), 0, false)
 			if err != nil {
-				return nil, nil, err
+				return nil, closers, err
 			}
 		}
 
 		var closer func()
 		mysqlParamsValue, closer, err = utils.NewMySQL(clusterInstance, keyspace.Name, "")
 		if err != nil {
-			return nil, nil, err
+			return nil, closers, err
 		}
-		conn, err = mysql.Connect(context.Background(), &mysqlParamsValue)
+		closers = append(closers, closer)
+		conn, err = mysql.Connect(ctx, &mysqlParamsValue)
 		if err != nil {
-			return nil, nil, err
+			return nil, closers, err
 		}
-		closers = append(closers, closer)
 	}
 	return &mysqlParamsValue, closers, nil
 }
@@ -0,0 +1,307 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"vitess.io/vitess/go/test/endtoend/utils"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/tester/fuzz"
+	"github.com/vitessio/vt/go/typ"
+)
+
+type (
+	// FuzzConfig configures Fuzz
+	FuzzConfig struct {
+		LogLevel       string
+		VschemaFile    string
+		NumberOfShards int
+
+		// SchemaFile has the CREATE TABLE statements the queries are generated from
+		SchemaFile string
+		Duration   time.Duration
+		// Seed makes a run reproducible, a random seed is used when 0
+		Seed int64
+		// Rows is the number of rows inserted in every table
+		Rows int
+		// MaxFailures stops the run once this many mismatches are found
+		MaxFailures int
+		// OutputDir is where the reproductions of the mismatches are written
+		OutputDir string
+	}
+
+	// fuzzer runs random queries on MySQL and Vitess, and records the queries they disagree on
+	fuzzer struct {
+		cfg       FuzzConfig
+		schema    *fuzz.Schema
+		generator *fuzz.Generator
+		reporter  *fuzzReporter
+		comparer  utils.MySQLCompare
+		// compare tells how the systems disagree on a query, to minimize the queries they disagree on
+		compare func(query string) mismatchKind
+
+		// inserts are the statements filling the tables, by table name
+		inserts map[string]string
+		// signatures are the error mismatches already recorded, the same error is only recorded once
+		signatures map[string]bool
+		queries    int
+		failures   int
+	}
+
+	// fuzzReporter collects the failures of the last query
+	fuzzReporter struct {
+		failures []string
+	}
+)
+
+var _ Reporter = (*fuzzReporter)(nil)
+
+// Fuzz generates random queries from the tables of a schema for the configured duration, runs them on MySQL and on
+// Vitess, and writes a reproduction of every query the two disagree on, be it on the result or on the error.
+// A reproduction is a test file for `vt tester` with the minimal form of the query, the tables it reads and their rows.
+func Fuzz(ctx context.Context, cfg FuzzConfig) error {
+	if cfg.SchemaFile == "" {
+		return wrongUsage("no schema file specified")
+	}
+	if cfg.Duration <= 0 {
+		return wrongUsage("the duration must be positive")
+	}
+
+	sql, err := os.ReadFile(cfg.SchemaFile)
+	if err != nil {
+		return err
+	}
+	schema, err := fuzz.ParseSchema(string(sql))
+	if err != nil {
+		return fmt.Errorf("reading the schema of %s: %w", cfg.SchemaFile, err)
+	}
+
+	if err := CheckEnvironment(); err != nil {
+		return fmt.Errorf("error reading environment variables: %w", err)
+	}
+	setLogLevel(cfg.LogLevel)
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	log.Infof("fuzzing with seed %d", cfg.Seed)
+
+	clusterInfo, err := SetupCluster(ctx, Config{
+		VschemaFile:    cfg.VschemaFile,
+		Sharded:        cfg.VschemaFile == "",
+		NumberOfShards: cfg.NumberOfShards,
+		Compare:        true,
+	})
+	if err != nil {
+		return err
+	}
+	defer clusterInfo.closer()
+
+	f := &fuzzer{
+		cfg:        cfg,
+		schema:     schema,
+		generator:  fuzz.NewGenerator(schema, cfg.Seed, cfg.Rows),
+		reporter:   &fuzzReporter{},
+		inserts:    make(map[string]string),
+		signatures: make(map[string]bool),
+	}
+	return f.run(ctx, clusterInfo)
+}
+
+func (f *fuzzer) run(ctx context.Context, info ClusterInfo) (err error) {
+	// the tables are created by a tester, so the auto-vschema shards them as it does for test files
+	t := NewTester(f.cfg.SchemaFile, f.reporter, info, false, info.vschema, f.cfg.VschemaFile, data.Filter{}, ComparingQueryRunnerFactory{})
+	defer func() {
+		err = errors.Join(err, t.postProcess())
+	}()
+	if err := f.setup(t); err != nil {
+		return err
+	}
+
+	f.comparer, err = utils.NewMySQLCompare(f.reporter, info.vtParams, *info.mysqlParams)
+	if err != nil {
+		return err
+	}
+	defer f.comparer.Close()
+	f.compare = func(query string) mismatchKind {
+		return compareOn(f.comparer.VtConn, f.comparer.MySQLConn, query)
+	}
+
+	start := time.Now()
+	deadline := start.Add(f.cfg.Duration)
+	for time.Now().Before(deadline) && ctx.Err() == nil && (f.cfg.MaxFailures <= 0 || f.failures < f.cfg.MaxFailures) {
+		query := f.generator.Query()
+		f.reporter.AddTestCase(query, f.queries+1)
+		_, _ = f.comparer.ExecAllowAndCompareError(query, utils.CompareOptions{})
+		f.queries++
+		if f.reporter.Failed() {
+			if err := f.record(query); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Ran %d queries in %v with seed %d, %d mismatched\n", f.queries, time.Since(start).Round(time.Second), f.cfg.Seed, f.failures)
+	switch {
+	case f.failures > 0:
+		return fmt.Errorf("MySQL and Vitess disagree on %d queries 😭\nsee the reproductions in %v", f.failures, f.cfg.OutputDir)
+	case ctx.Err() != nil:
+		return fmt.Errorf("fuzzing interrupted: %w", ctx.Err())
+	}
+	return nil
+}
+
+// setup creates the tables of the schema and fills them with random rows
+func (f *fuzzer) setup(t *Tester) error {
+	var statements []string
+	for _, tbl := range f.schema.Tables {
+		f.inserts[strings.ToLower(tbl.Name)] = f.generator.Insert(tbl)
+		statements = append(statements, tbl.Create)
+	}
+	for _, tbl := range f.schema.Tables {
+		statements = append(statements, f.inserts[strings.ToLower(tbl.Name)])
+	}
+
+	for i, stmt := range statements {
+		t.runQuery(data.Query{Query: stmt, Line: i + 1, Type: typ.Query})
+		if t.reporter.Failed() {
+			return fmt.Errorf("setting up the schema: %s", strings.Join(f.reporter.failures, "\n"))
+		}
+	}
+	return nil
+}
+
+// record writes the reproduction of the mismatch of the query, unless an identical error mismatch was already recorded.
+// The reproduction has the minimal form of the query the systems still disagree on.
+func (f *fuzzer) record(query string) error {
+	if signature, ok := errorSignature(query, f.reporter.failures); ok {
+		if f.signatures[signature] {
+			return nil
+		}
+		f.signatures[signature] = true
+	}
+	f.failures++
+
+	err := os.MkdirAll(f.cfg.OutputDir, PERM)
+	if err != nil {
+		return fmt.Errorf("creating the reproductions directory: %w", err)
+	}
+	path := filepath.Join(f.cfg.OutputDir, fmt.Sprintf("fuzz-%d.test", f.failures))
+	err = os.WriteFile(path, []byte(f.reproduction(query, minimizeQuery(query, f.compare))), PERM)
+	if err != nil {
+		return fmt.Errorf("writing the reproduction: %w", err)
+	}
+	log.Warnf("mismatch on query %d, reproduction written to %s", f.queries, path)
+	return nil
+}
+
+// reproduction returns a test file creating and filling the tables the minimal query reads, then running it
+func (f *fuzzer) reproduction(query, minimal string) string {
+	used := make(map[string]bool)
+	if stmt, err := sqlparser.NewTestParser().Parse(minimal); err == nil {
+		for _, name := range sqlparser.ExtractAllTables(stmt) {
+			used[strings.ToLower(name)] = true
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Found by vt fuzz with --seed %d after %d queries\n", f.cfg.Seed, f.queries)
+	flags := "--sharded"
+	if f.cfg.VschemaFile != "" {
+		flags = "--vschema " + f.cfg.VschemaFile
+	}
+	if f.cfg.NumberOfShards > 0 {
+		flags += fmt.Sprintf(" --number-of-shards %d", f.cfg.NumberOfShards)
+	}
+	fmt.Fprintf(&sb, "# Run it with vt tester %s\n", flags)
+	if minimal != query {
+		sb.WriteString("# Minimized from: " + query + "\n")
+	}
+	for _, failure := range f.reporter.failures {
+		for _, line := range strings.Split(strings.TrimSpace(failure), "\n") {
+			sb.WriteString("# " + line + "\n")
+		}
+	}
+	sb.WriteString("\n")
+	for _, tbl := range f.schema.Tables {
+		if used[strings.ToLower(tbl.Name)] {
+			sb.WriteString(tbl.Create + ";\n")
+		}
+	}
+	for _, tbl := range f.schema.Tables {
+		if used[strings.ToLower(tbl.Name)] {
+			sb.WriteString(f.inserts[strings.ToLower(tbl.Name)] + ";\n")
+		}
+	}
+	sb.WriteString("\n" + minimal + ";\n")
+	return sb.String()
+}
+
+//nolint:gochecknoglobals // this is instead of a const
+var (
+	numbersRe       = regexp.MustCompile(`\d+`)
+	quotedStringsRe = regexp.MustCompile(`'[^']*'`)
+)
+
+// errorSignature returns what identifies an error mismatch, leaving out the query and its values, so that the same
+// unsupported feature isn't recorded over and over. The result mismatches have no signature, they are all recorded.
+func errorSignature(query string, failures []string) (string, bool) {
+	if len(failures) == 0 || !strings.HasPrefix(failures[0], "Vitess and MySQL are not erroring the same way") {
+		return "", false
+	}
+	signature := strings.ReplaceAll(failures[0], query, "")
+	signature = quotedStringsRe.ReplaceAllString(signature, "''")
+	return numbersRe.ReplaceAllString(signature, "N"), true
+}
+
+func (r *fuzzReporter) AddTestCase(string, int) {
+	r.failures = nil
+}
+
+func (r *fuzzReporter) EndTestCase() {}
+
+func (r *fuzzReporter) AddFailure(err error) {
+	r.failures = append(r.failures, err.Error())
+}
+
+func (r *fuzzReporter) AddInfo(string) {}
+
+func (r *fuzzReporter) Report() string {
+	return ""
+}
+
+func (r *fuzzReporter) Failed() bool {
+	return len(r.failures) > 0
+}
+
+func (r *fuzzReporter) Errorf(format string, args ...interface{}) {
+	r.AddFailure(fmt.Errorf(format, args...))
+}
+
+func (r *fuzzReporter) FailNow() {}
+
+func (r *fuzzReporter) Helper() {}
@@ -0,0 +1,432 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type (
+	// Generator generates random rows and random SELECT queries from a schema, with joins, aggregations, derived
+	// tables and subqueries. The queries only compare columns of the same kind, and they are ordered on all of
+	// their columns when they have a LIMIT, so MySQL and Vitess have a single correct result to agree on.
+	// The same seed generates the same rows and queries.
+	Generator struct {
+		schema *Schema
+		rnd    *rand.Rand
+		rows   int
+	}
+
+	// source is a table expression of the FROM clause, with the columns it exposes
+	source struct {
+		alias   string
+		expr    string
+		columns []Column
+	}
+
+	// colRef is a column of a source, as written in the query
+	colRef struct {
+		sql string
+		col Column
+	}
+)
+
+// words are the values of the string columns. There are no values equal to each other under a case-insensitive
+// or a PAD SPACE collation, so ordering on string columns leaves no ties between distinct values.
+//
+//nolint:gochecknoglobals // this is instead of a const
+var words = []string{"", "a", "b", "ab", "abc", "b c", "xyz"}
+
+//nolint:gochecknoglobals // this is instead of a const
+var firstDay = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewGenerator returns a generator filling every table with the given number of rows
+func NewGenerator(schema *Schema, seed int64, rows int) *Generator {
+	return &Generator{
+		schema: schema,
+		//nolint:gosec // the queries need to be reproducible, not unpredictable
+		rnd:  rand.New(rand.NewSource(seed)),
+		rows: max(rows, 1),
+	}
+}
+
+// Insert returns the statement inserting the rows of the table
+func (g *Generator) Insert(tbl *Table) string {
+	names := make([]string, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		names = append(names, quoteColumn(col.Name))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "insert into %s (%s) values ", quoteTable(tbl.Name), strings.Join(names, ", "))
+	for row := 1; row <= g.rows; row++ {
+		if row > 1 {
+			sb.WriteString(", ")
+		}
+		values := make([]string, 0, len(tbl.Columns))
+		for _, col := range tbl.Columns {
+			values = append(values, g.value(col, row))
+		}
+		fmt.Fprintf(&sb, "(%s)", strings.Join(values, ", "))
+	}
+	return sb.String()
+}
+
+// Query returns a random SELECT reading one to three tables of the schema
+func (g *Generator) Query() string {
+	sources := []source{g.source("t0", g.chance(15))}
+	from := sources[0].expr
+	for i := 1; i <= g.joinCount(); i++ {
+		src := g.source(fmt.Sprintf("t%d", i), false)
+		on, ok := g.joinCondition(sources, src)
+		if !ok {
+			// a cross join can have too many rows to compare
+			continue
+		}
+		join := "join"
+		if g.chance(30) {
+			join = "left join"
+		}
+		from += fmt.Sprintf(" %s %s on %s", join, src.expr, on)
+		sources = append(sources, src)
+	}
+	refs := refsOf(sources)
+
+	var exprs, groupBy []string
+	aggregated := g.chance(35)
+	if aggregated {
+		for range g.rnd.Intn(3) {
+			groupBy = append(groupBy, g.ref(refs).sql)
+		}
+		exprs = append(exprs, groupBy...)
+		for range 1 + g.rnd.Intn(2) {
+			exprs = append(exprs, g.aggregate(refs))
+		}
+	} else {
+		for range 1 + g.rnd.Intn(4) {
+			exprs = append(exprs, g.ref(refs).sql)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("select ")
+	if !aggregated && g.chance(15) {
+		sb.WriteString("distinct ")
+	}
+	sb.WriteString(strings.Join(exprs, ", "))
+	sb.WriteString(" from " + from)
+	if where := g.where(refs, 0); where != "" {
+		sb.WriteString(" where " + where)
+	}
+	if len(groupBy) > 0 {
+		sb.WriteString(" group by " + strings.Join(groupBy, ", "))
+	}
+	if aggregated && g.chance(20) {
+		fmt.Fprintf(&sb, " having count(*) > %d", g.rnd.Intn(3))
+	}
+	if g.chance(30) {
+		positions := make([]string, len(exprs))
+		for i := range exprs {
+			positions[i] = strconv.Itoa(i + 1)
+		}
+		sb.WriteString(" order by " + strings.Join(positions, ", "))
+		if g.chance(50) {
+			fmt.Fprintf(&sb, " limit %d", 1+g.rnd.Intn(10))
+		}
+	}
+	return sb.String()
+}
+
+func (g *Generator) chance(percent int) bool {
+	return g.rnd.Intn(100) < percent
+}
+
+func (g *Generator) joinCount() int {
+	switch n := g.rnd.Intn(100); {
+	case n < 50:
+		return 0
+	case n < 85:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (g *Generator) table() *Table {
+	return g.schema.Tables[g.rnd.Intn(len(g.schema.Tables))]
+}
+
+func (g *Generator) ref(refs []colRef) colRef {
+	return refs[g.rnd.Intn(len(refs))]
+}
+
+// source returns a table of the schema, or a derived table selecting some of the columns of a table
+func (g *Generator) source(alias string, derived bool) source {
+	tbl := g.table()
+	if !derived {
+		return source{
+			alias:   alias,
+			expr:    fmt.Sprintf("%s as %s", quoteTable(tbl.Name), alias),
+			columns: tbl.Columns,
+		}
+	}
+
+	var columns []Column
+	var names []string
+	for _, col := range tbl.Columns {
+		if len(columns) == 0 || g.chance(60) {
+			columns = append(columns, col)
+			names = append(names, quoteColumn(col.Name))
+		}
+	}
+	inner := fmt.Sprintf("select %s from %s", strings.Join(names, ", "), quoteTable(tbl.Name))
+	if where := g.where(refsOf([]source{{columns: tbl.Columns}}), 1); where != "" {
+		inner += " where " + where
+	}
+	return source{
+		alias:   alias,
+		expr:    fmt.Sprintf("(%s) as %s", inner, alias),
+		columns: columns,
+	}
+}
+
+// joinCondition compares a column of the joined source with a column of the same kind of the previous sources
+func (g *Generator) joinCondition(sources []source, src source) (string, bool) {
+	var conditions []string
+	for _, right := range refsOf([]source{src}) {
+		for _, left := range refsOf(sources) {
+			if left.col.Kind == right.col.Kind {
+				conditions = append(conditions, fmt.Sprintf("%s = %s", left.sql, right.sql))
+			}
+		}
+	}
+	if len(conditions) == 0 {
+		return "", false
+	}
+	return conditions[g.rnd.Intn(len(conditions))], true
+}
+
+func (g *Generator) aggregate(refs []colRef) string {
+	ref := g.ref(refs)
+	numeric := ref.col.Kind == KindInt || ref.col.Kind == KindDecimal
+	switch n := g.rnd.Intn(7); {
+	case n == 0:
+		return "count(*)"
+	case n == 1:
+		return fmt.Sprintf("count(%s)", ref.sql)
+	case n == 2:
+		return fmt.Sprintf("count(distinct %s)", ref.sql)
+	case n == 3:
+		return fmt.Sprintf("min(%s)", ref.sql)
+	case n == 4:
+		return fmt.Sprintf("max(%s)", ref.sql)
+	case numeric && n == 5:
+		return fmt.Sprintf("sum(%s)", ref.sql)
+	case numeric:
+		return fmt.Sprintf("avg(%s)", ref.sql)
+	default:
+		return "count(*)"
+	}
+}
+
+// where returns zero to three predicates on the columns. Subqueries are only generated at depth 0.
+func (g *Generator) where(refs []colRef, depth int) string {
+	var predicates []string
+	for range g.rnd.Intn(4) {
+		predicates = append(predicates, g.predicate(refs, depth))
+	}
+	if len(predicates) == 0 {
+		return ""
+	}
+	where := predicates[0]
+	for _, predicate := range predicates[1:] {
+		op := "and"
+		if g.chance(25) {
+			op = "or"
+		}
+		where = fmt.Sprintf("%s %s %s", where, op, predicate)
+	}
+	return where
+}
+
+func (g *Generator) predicate(refs []colRef, depth int) string {
+	ref := g.ref(refs)
+	switch n := g.rnd.Intn(100); {
+	case n < 35:
+		return fmt.Sprintf("%s %s %s", ref.sql, g.comparison(), g.literal(ref.col))
+	case n < 45:
+		if ref.col.Nullable && g.chance(50) {
+			return ref.sql + " is null"
+		}
+		return ref.sql + " is not null"
+	case n < 55:
+		return fmt.Sprintf("%s in (%s, %s, %s)", ref.sql, g.literal(ref.col), g.literal(ref.col), g.literal(ref.col))
+	case n < 62:
+		return fmt.Sprintf("%s between %s and %s", ref.sql, g.literal(ref.col), g.literal(ref.col))
+	case n < 72:
+		for _, other := range g.rnd.Perm(len(refs)) {
+			if other := refs[other]; other.sql != ref.sql && other.col.Kind == ref.col.Kind {
+				return fmt.Sprintf("%s %s %s", ref.sql, g.comparison(), other.sql)
+			}
+		}
+		return fmt.Sprintf("%s %s %s", ref.sql, g.comparison(), g.literal(ref.col))
+	case n < 78:
+		return fmt.Sprintf("not (%s)", g.predicate(refs, depth))
+	case n < 90 && depth == 0:
+		return g.inSubquery(ref)
+	case depth == 0:
+		return g.exists(ref)
+	default:
+		return fmt.Sprintf("%s %s %s", ref.sql, g.comparison(), g.literal(ref.col))
+	}
+}
+
+func (g *Generator) comparison() string {
+	return []string{"=", "!=", "<", "<=", ">", ">="}[g.rnd.Intn(6)]
+}
+
+// inSubquery returns `col in (select ...)` on a column of the same kind in a table of the schema
+func (g *Generator) inSubquery(outer colRef) string {
+	tbl := g.table()
+	inner := refsOf([]source{{alias: "sq", columns: tbl.Columns}})
+	for _, i := range g.rnd.Perm(len(inner)) {
+		if ref := inner[i]; ref.col.Kind == outer.col.Kind {
+			sub := fmt.Sprintf("select %s from %s as sq", ref.sql, quoteTable(tbl.Name))
+			if where := g.where(inner, 1); where != "" {
+				sub += " where " + where
+			}
+			op := "in"
+			if g.chance(25) {
+				op = "not in"
+			}
+			return fmt.Sprintf("%s %s (%s)", outer.sql, op, sub)
+		}
+	}
+	return fmt.Sprintf("%s %s %s", outer.sql, g.comparison(), g.literal(outer.col))
+}
+
+// exists returns a correlated EXISTS subquery on a column of the same kind in a table of the schema
+func (g *Generator) exists(outer colRef) string {
+	tbl := g.table()
+	inner := refsOf([]source{{alias: "sq", columns: tbl.Columns}})
+	for _, i := range g.rnd.Perm(len(inner)) {
+		if ref := inner[i]; ref.col.Kind == outer.col.Kind {
+			op := "exists"
+			if g.chance(25) {
+				op = "not exists"
+			}
+			return fmt.Sprintf("%s (select 1 from %s as sq where %s = %s)", op, quoteTable(tbl.Name), ref.sql, outer.sql)
+		}
+	}
+	return fmt.Sprintf("%s %s %s", outer.sql, g.comparison(), g.literal(outer.col))
+}
+
+// value returns the value of a column in the given row. Key columns get a distinct value in every row,
+// the other columns get a random value, in a small range so that the joins and the groupings find matches.
+func (g *Generator) value(col Column, row int) string {
+	if !col.Key {
+		if col.Nullable && g.chance(10) {
+			return "null"
+		}
+		return g.literal(col)
+	}
+	switch col.Kind {
+	case KindInt, KindDecimal:
+		return strconv.Itoa(row)
+	case KindString:
+		return quoteString(truncate("k"+strconv.Itoa(row), col.Length))
+	case KindDate:
+		return quoteString(firstDay.AddDate(0, 0, row).Format(time.DateOnly))
+	case KindDateTime:
+		return quoteString(firstDay.Add(time.Duration(row) * time.Hour).Format(time.DateTime))
+	default:
+		return col.Values[row%len(col.Values)]
+	}
+}
+
+// literal returns a random value for the column, used in the rows and in the predicates
+func (g *Generator) literal(col Column) string {
+	switch col.Kind {
+	case KindInt:
+		return strconv.Itoa(g.rnd.Intn(g.spread(col)))
+	case KindDecimal:
+		n := g.rnd.Intn(g.spread(col))
+		if col.Scale == 0 {
+			return strconv.Itoa(n)
+		}
+		// halves are exact in floating point columns too
+		return fmt.Sprintf("%d.%d", n, 5*g.rnd.Intn(2))
+	case KindString:
+		if col.Key && g.chance(50) {
+			return quoteString(truncate("k"+strconv.Itoa(g.rnd.Intn(g.rows+1)), col.Length))
+		}
+		return quoteString(truncate(words[g.rnd.Intn(len(words))], col.Length))
+	case KindDate:
+		return quoteString(firstDay.AddDate(0, 0, g.rnd.Intn(g.spread(col))).Format(time.DateOnly))
+	case KindDateTime:
+		return quoteString(firstDay.Add(time.Duration(g.rnd.Intn(g.spread(col))) * time.Hour).Format(time.DateTime))
+	default:
+		return col.Values[g.rnd.Intn(len(col.Values))]
+	}
+}
+
+// spread is the number of distinct values of the random values of a column
+func (g *Generator) spread(col Column) int {
+	if col.Key {
+		return g.rows + 1
+	}
+	return max(g.rows/2, 2)
+}
+
+func refsOf(sources []source) []colRef {
+	var refs []colRef
+	for _, src := range sources {
+		for _, col := range src.columns {
+			sql := quoteColumn(col.Name)
+			if src.alias != "" {
+				sql = src.alias + "." + sql
+			}
+			refs = append(refs, colRef{sql: sql, col: col})
+		}
+	}
+	return refs
+}
+
+func truncate(s string, length int) string {
+	if length > 0 && len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+func quoteTable(name string) string {
+	return sqlparser.String(sqlparser.NewIdentifierCS(name))
+}
+
+func quoteColumn(name string) string {
+	return sqlparser.String(sqlparser.NewIdentifierCI(name))
+}
+
+func quoteString(s string) string {
+	return sqlparser.String(sqlparser.NewStrLiteral(s))
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzz
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestGeneratorQueries(t *testing.T) {
+	schema, err := ParseSchema(testSchema)
+	require.NoError(t, err)
+
+	parser := sqlparser.NewTestParser()
+	g := NewGenerator(schema, 1, 10)
+	var joins, aggregates, subqueries int
+	for range 1000 {
+		query := g.Query()
+		stmt, err := parser.Parse(query)
+		require.NoError(t, err, query)
+		require.IsType(t, &sqlparser.Select{}, stmt, query)
+		if strings.Contains(query, " join ") {
+			joins++
+		}
+		if strings.Contains(query, "count(") || strings.Contains(query, "sum(") {
+			aggregates++
+		}
+		if strings.Contains(query, "(select ") {
+			subqueries++
+		}
+		if strings.Contains(query, " limit ") {
+			require.Contains(t, query, " order by ", "a limit needs a total order to have a single result")
+		}
+	}
+	require.Positive(t, joins)
+	require.Positive(t, aggregates)
+	require.Positive(t, subqueries)
+}
+
+func TestGeneratorIsReproducible(t *testing.T) {
+	schema, err := ParseSchema(testSchema)
+	require.NoError(t, err)
+
+	a, b := NewGenerator(schema, 42, 10), NewGenerator(schema, 42, 10)
+	for range 100 {
+		require.Equal(t, a.Query(), b.Query())
+	}
+	require.Equal(t, a.Insert(schema.Tables[0]), b.Insert(schema.Tables[0]))
+}
+
+func TestGeneratorInsert(t *testing.T) {
+	schema, err := ParseSchema(testSchema)
+	require.NoError(t, err)
+
+	g := NewGenerator(schema, 1, 3)
+	insert := g.Insert(schema.Tables[0])
+	stmt, err := sqlparser.NewTestParser().Parse(insert)
+	require.NoError(t, err, insert)
+
+	rows := stmt.(*sqlparser.Insert).Rows.(sqlparser.Values)
+	require.Len(t, rows, 3)
+	// the key columns get a distinct value in every row
+	for i, row := range rows {
+		require.Equal(t, sqlparser.NewIntLiteral(strconv.Itoa(i+1)), row[0])
+		require.Equal(t, sqlparser.NewStrLiteral("k"+strconv.Itoa(i+1)), row[2])
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzz
+
+import (
+	"fmt"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type (
+	// Schema is the set of tables the queries are generated from
+	Schema struct {
+		Tables []*Table
+	}
+
+	// Table is a table of the schema, with the statement creating it
+	Table struct {
+		Name    string
+		Create  string
+		Columns []Column
+	}
+
+	// Column is a column the generator can read and write
+	Column struct {
+		Name string
+		Kind Kind
+		// Key is set for the columns of the primary key and of the unique keys, they get distinct values
+		Key      bool
+		Nullable bool
+		// Length is the maximum length of a string column, 0 when unknown
+		Length int
+		// Scale is the number of decimals of a decimal column
+		Scale int
+		// Values are the quoted values of an enum column
+		Values []string
+	}
+
+	// Kind is the kind of values of a column, columns of the same kind can be compared with each other
+	Kind int
+)
+
+const (
+	KindInt Kind = iota
+	KindDecimal
+	KindString
+	KindDate
+	KindDateTime
+	KindEnum
+)
+
+// ParseSchema reads the CREATE TABLE statements of a schema file. The other statements are ignored,
+// and so are the columns the generator doesn't know how to fill, such as JSON, BLOB or generated columns.
+func ParseSchema(sql string) (*Schema, error) {
+	parser := sqlparser.NewTestParser()
+	pieces, err := parser.SplitStatementToPieces(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{}
+	for _, piece := range pieces {
+		stmt, err := parser.Parse(piece)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %q: %w", piece, err)
+		}
+		create, ok := stmt.(*sqlparser.CreateTable)
+		if !ok || create.TableSpec == nil {
+			continue
+		}
+		if tbl := newTable(create); len(tbl.Columns) > 0 {
+			schema.Tables = append(schema.Tables, tbl)
+		}
+	}
+	if len(schema.Tables) == 0 {
+		return nil, fmt.Errorf("the schema has no table with columns to generate queries from")
+	}
+	return schema, nil
+}
+
+func newTable(create *sqlparser.CreateTable) *Table {
+	keys := make(map[string]bool)
+	for _, index := range create.TableSpec.Indexes {
+		if index.Info.Type != sqlparser.IndexTypePrimary && index.Info.Type != sqlparser.IndexTypeUnique {
+			continue
+		}
+		for _, col := range index.Columns {
+			keys[col.Column.Lowered()] = true
+		}
+	}
+
+	tbl := &Table{
+		Name:   create.Table.Name.String(),
+		Create: sqlparser.String(create),
+	}
+	for _, def := range create.TableSpec.Columns {
+		opts := def.Type.Options
+		if opts == nil {
+			opts = &sqlparser.ColumnTypeOptions{}
+		}
+		if opts.As != nil {
+			// generated columns can't be written to
+			continue
+		}
+		kind, ok := kindOf(def.Type.Type)
+		if !ok {
+			continue
+		}
+		isKey := keys[def.Name.Lowered()] || opts.KeyOpt == sqlparser.ColKeyPrimary || opts.KeyOpt == sqlparser.ColKeyUnique ||
+			opts.KeyOpt == sqlparser.ColKeyUniqueKey
+		col := Column{
+			Name:     def.Name.String(),
+			Kind:     kind,
+			Key:      isKey,
+			Nullable: !isKey && (opts.Null == nil || *opts.Null),
+			Values:   def.Type.EnumValues,
+		}
+		if def.Type.Length != nil {
+			col.Length = *def.Type.Length
+		}
+		switch {
+		case def.Type.Scale != nil:
+			col.Scale = *def.Type.Scale
+		case kind == KindDecimal && !isFixedPoint(def.Type.Type):
+			col.Scale = 2
+		}
+		if kind == KindEnum && len(col.Values) == 0 {
+			continue
+		}
+		tbl.Columns = append(tbl.Columns, col)
+	}
+	return tbl
+}
+
+func kindOf(typ string) (Kind, bool) {
+	switch strings.ToLower(typ) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return KindInt, true
+	case "decimal", "numeric", "float", "double", "real":
+		return KindDecimal, true
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return KindString, true
+	case "date":
+		return KindDate, true
+	case "datetime", "timestamp":
+		return KindDateTime, true
+	case "enum":
+		return KindEnum, true
+	default:
+		return 0, false
+	}
+}
+
+// isFixedPoint tells if the type is DECIMAL or NUMERIC, which hold integers when no scale is given
+func isFixedPoint(typ string) bool {
+	return strings.EqualFold(typ, "decimal") || strings.EqualFold(typ, "numeric")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `
+create table customer (
+	id bigint primary key,
+	name varchar(10) not null,
+	email varchar(40) unique,
+	created datetime
+);
+create table orders (
+	id bigint,
+	customer_id bigint not null,
+	total decimal(10, 2),
+	status enum('new', 'paid', 'shipped') not null,
+	placed date,
+	payload json,
+	primary key (id)
+);
+insert into customer (id, name) values (1, 'ignored');
+`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema(testSchema)
+	require.NoError(t, err)
+	require.Len(t, schema.Tables, 2)
+
+	customer := schema.Tables[0]
+	require.Equal(t, "customer", customer.Name)
+	require.Contains(t, customer.Create, "create table customer")
+	require.Equal(t, []Column{
+		{Name: "id", Kind: KindInt, Key: true},
+		{Name: "name", Kind: KindString, Length: 10},
+		{Name: "email", Kind: KindString, Key: true, Length: 40},
+		{Name: "created", Kind: KindDateTime, Nullable: true},
+	}, customer.Columns)
+
+	// the JSON column is left out
+	orders := schema.Tables[1]
+	require.Equal(t, []Column{
+		{Name: "id", Kind: KindInt, Key: true},
+		{Name: "customer_id", Kind: KindInt},
+		{Name: "total", Kind: KindDecimal, Nullable: true, Length: 10, Scale: 2},
+		{Name: "status", Kind: KindEnum, Values: []string{"'new'", "'paid'", "'shipped'"}},
+		{Name: "placed", Kind: KindDate, Nullable: true},
+	}, orders.Columns)
+}
+
+func TestParseSchemaWithoutTables(t *testing.T) {
+	_, err := ParseSchema("create table t (doc json); select 1;")
+	require.Error(t, err)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/tester/fuzz"
+)
+
+func TestErrorSignature(t *testing.T) {
+	a := "select t0.id from t as t0 where t0.id = 1"
+	b := "select t0.id from u as t0 where t0.name = 'abc'"
+	unsupported := func(query string) []string {
+		return []string{"Vitess and MySQL are not erroring the same way.\n" +
+			"Vitess error: VT12001: unsupported: something (errno 1235) during query: " + query + "\nMySQL error: <nil>"}
+	}
+
+	sigA, ok := errorSignature(a, unsupported(a))
+	require.True(t, ok)
+	sigB, ok := errorSignature(b, unsupported(b))
+	require.True(t, ok)
+	require.Equal(t, sigA, sigB, "the same error on different queries has the same signature")
+
+	_, ok = errorSignature(a, []string{"Query (" + a + ") results mismatched."})
+	require.False(t, ok, "result mismatches are always recorded")
+}
+
+func TestFuzzReproduction(t *testing.T) {
+	schema, err := fuzz.ParseSchema("create table t (id bigint primary key, a int); create table u (id bigint primary key, b int);")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	f := &fuzzer{
+		cfg:        FuzzConfig{Seed: 42, OutputDir: dir},
+		schema:     schema,
+		inserts:    map[string]string{"t": "insert into t (id, a) values (1, 2)", "u": "insert into u (id, b) values (1, 3)"},
+		signatures: make(map[string]bool),
+		reporter:   &fuzzReporter{},
+		queries:    7,
+		// the mismatch is on the rows of t with a > 1
+		compare: func(query string) mismatchKind {
+			if strings.Contains(query, "t0.a > 1") && (!strings.Contains(query, "t1.") || strings.Contains(query, "join u")) {
+				return resultMismatch
+			}
+			return noMismatch
+		},
+	}
+	query := "select t0.a, t1.b from t as t0 join u as t1 on t0.id = t1.id where t0.a > 1 and t1.b = 3"
+	f.reporter.AddTestCase(query, 7)
+	f.reporter.Errorf("Query (%s) results mismatched.\nVitess Results:\nMySQL Results:\n[INT32(2)]", query)
+	require.NoError(t, f.record(query))
+
+	out, err := os.ReadFile(filepath.Join(dir, "fuzz-1.test"))
+	require.NoError(t, err)
+	// only the tables the minimal query reads are reproduced
+	require.Equal(t, `# Found by vt fuzz with --seed 42 after 7 queries
+# Run it with vt tester --sharded
+# Minimized from: select t0.a, t1.b from t as t0 join u as t1 on t0.id = t1.id where t0.a > 1 and t1.b = 3
+# Query (select t0.a, t1.b from t as t0 join u as t1 on t0.id = t1.id where t0.a > 1 and t1.b = 3) results mismatched.
+# Vitess Results:
+# MySQL Results:
+# [INT32(2)]
+
+create table t (
+	id bigint primary key,
+	a int
+);
+insert into t (id, a) values (1, 2);
+
+select t0.a from t as t0 where t0.a > 1;
+`, string(out))
+}
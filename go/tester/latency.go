@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/test/endtoend/cluster"
+	"vitess.io/vitess/go/test/endtoend/utils"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/tester/state"
+)
+
+var (
+	_ QueryRunner        = (*LatencyRecorder)(nil)
+	_ QueryRunnerFactory = (*LatencyRecorderFactory)(nil)
+)
+
+type (
+	// LatencyRecorder measures how long the SELECT queries take on MySQL and on Vitess.
+	// After the inner runner ran a query, the query is executed again on both systems, so both
+	// are measured with warm caches, and the durations are grouped by query signature.
+	LatencyRecorder struct {
+		MySQLConn, VtConn *mysql.Conn
+		inner             QueryRunner
+		factory           *LatencyRecorderFactory
+	}
+
+	// LatencyRecorderFactory collects the durations of all the test files and writes
+	// their percentiles to the latency file when it is closed
+	LatencyRecorderFactory struct {
+		latencyFile string
+		runs        int
+		inner       QueryRunnerFactory
+
+		signatures map[string]*latencySamples
+	}
+
+	latencySamples struct {
+		lineNumber    int
+		mysql, vitess []time.Duration
+	}
+
+	// LatencyOutput is the content of a latency file
+	LatencyOutput struct {
+		Latencies []QueryLatency `json:"latencies"`
+	}
+
+	// QueryLatency compares the latencies of the queries with the same signature on MySQL and on Vitess
+	QueryLatency struct {
+		Query      string             `json:"query"`
+		LineNumber int                `json:"lineNumber"`
+		Samples    int                `json:"samples"`
+		MySQL      LatencyPercentiles `json:"mysql"`
+		Vitess     LatencyPercentiles `json:"vitess"`
+	}
+
+	// LatencyPercentiles are in milliseconds
+	LatencyPercentiles struct {
+		P50 float64 `json:"p50"`
+		P95 float64 `json:"p95"`
+		P99 float64 `json:"p99"`
+	}
+)
+
+func NewLatencyRecorderFactory(latencyFile string, runs int, inner QueryRunnerFactory) *LatencyRecorderFactory {
+	return &LatencyRecorderFactory{
+		latencyFile: latencyFile,
+		runs:        max(runs, 1),
+		inner:       inner,
+		signatures:  make(map[string]*latencySamples),
+	}
+}
+
+func (f *LatencyRecorderFactory) NewQueryRunner(reporter Reporter, handleCreateTable CreateTableHandler, comparer utils.MySQLCompare, cluster *cluster.LocalProcessCluster, vschema *vindexes.VSchema) QueryRunner {
+	return &LatencyRecorder{
+		MySQLConn: comparer.MySQLConn,
+		VtConn:    comparer.VtConn,
+		inner:     f.inner.NewQueryRunner(reporter, handleCreateTable, comparer, cluster, vschema),
+		factory:   f,
+	}
+}
+
+func (f *LatencyRecorderFactory) Close() {
+	f.inner.Close()
+
+	file, err := os.Create(f.latencyFile)
+	exitIf(err, "creating latency file")
+	err = f.writeLatencies(file)
+	exitIf(err, "writing latency file")
+	err = file.Close()
+	exitIf(err, "closing latency file")
+}
+
+// writeLatencies writes the percentiles of every signature, in the order of the first line they were seen on
+func (f *LatencyRecorderFactory) writeLatencies(w io.Writer) error {
+	output := LatencyOutput{Latencies: []QueryLatency{}}
+	for signature, samples := range f.signatures {
+		output.Latencies = append(output.Latencies, QueryLatency{
+			Query:      signature,
+			LineNumber: samples.lineNumber,
+			Samples:    len(samples.vitess),
+			MySQL:      percentiles(samples.mysql),
+			Vitess:     percentiles(samples.vitess),
+		})
+	}
+	sort.Slice(output.Latencies, func(i, j int) bool {
+		return output.Latencies[i].LineNumber < output.Latencies[j].LineNumber
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
+
+func (f *LatencyRecorderFactory) record(signature string, line int, mysqlTime, vitessTime time.Duration) {
+	samples, found := f.signatures[signature]
+	if !found {
+		samples = &latencySamples{lineNumber: line}
+		f.signatures[signature] = samples
+	}
+	samples.mysql = append(samples.mysql, mysqlTime)
+	samples.vitess = append(samples.vitess, vitessTime)
+}
+
+func (l *LatencyRecorder) runQuery(q data.Query, ast sqlparser.Statement, state *state.State) error {
+	_, isSelect := ast.(sqlparser.SelectStatement)
+	// queries expecting an error, reference inserts and queries running on one side only are in another state
+	measure := isSelect && state.NormalExecution()
+
+	err := l.inner.runQuery(q, ast, state)
+	if err != nil || !measure {
+		return err
+	}
+
+	signature := querySignature(ast)
+	for range l.factory.runs {
+		mysqlTime, err := timeQuery(l.MySQLConn, q.Query)
+		if err != nil {
+			return err
+		}
+		vitessTime, err := timeQuery(l.VtConn, q.Query)
+		if err != nil {
+			return err
+		}
+		l.factory.record(signature, q.Line, mysqlTime, vitessTime)
+	}
+	return nil
+}
+
+func timeQuery(conn *mysql.Conn, query string) (time.Duration, error) {
+	start := time.Now()
+	_, err := conn.ExecuteFetch(query, 10000, false)
+	return time.Since(start), err
+}
+
+// querySignature returns the structure of the query with its literals replaced by placeholders,
+// so the executions of the same query with different values are compared together
+func querySignature(ast sqlparser.Statement) string {
+	stmt := sqlparser.CloneStatement(ast)
+	err := sqlparser.Normalize(stmt, sqlparser.NewReservedVars("", sqlparser.GetBindvars(stmt)), make(map[string]*querypb.BindVariable))
+	if err != nil {
+		return sqlparser.CanonicalString(ast)
+	}
+	return sqlparser.CanonicalString(stmt)
+}
+
+// percentiles returns the nearest-rank percentiles of the durations
+func percentiles(durations []time.Duration) LatencyPercentiles {
+	if len(durations) == 0 {
+		return LatencyPercentiles{}
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	at := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		return float64(sorted[max(rank-1, 0)]) / float64(time.Millisecond)
+	}
+	return LatencyPercentiles{P50: at(50), P95: at(95), P99: at(99)}
+}
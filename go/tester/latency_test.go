@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestPercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, LatencyPercentiles{P50: 50, P95: 95, P99: 99}, percentiles(durations))
+	require.Equal(t, LatencyPercentiles{P50: 2, P95: 2, P99: 2}, percentiles([]time.Duration{2 * time.Millisecond}))
+	require.Equal(t, LatencyPercentiles{}, percentiles(nil))
+}
+
+func TestWriteLatencies(t *testing.T) {
+	parser := sqlparser.NewTestParser()
+	signature := func(query string) string {
+		ast, err := parser.Parse(query)
+		require.NoError(t, err)
+		return querySignature(ast)
+	}
+	require.Equal(t, signature("select * from t where id = 1"), signature("select * from t where id = 2"))
+
+	f := NewLatencyRecorderFactory("", 0, NullQueryRunnerFactory{})
+	f.record(signature("select * from u where x = 'a'"), 7, time.Millisecond, 3*time.Millisecond)
+	f.record(signature("select * from t where id = 1"), 3, time.Millisecond, 2*time.Millisecond)
+	f.record(signature("select * from t where id = 2"), 5, 3*time.Millisecond, 4*time.Millisecond)
+
+	var sb strings.Builder
+	require.NoError(t, f.writeLatencies(&sb))
+
+	var output LatencyOutput
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &output))
+	require.Equal(t, []QueryLatency{{
+		Query:      "SELECT * FROM `t` WHERE `id` = :_id /* INT64 */",
+		LineNumber: 3,
+		Samples:    2,
+		MySQL:      LatencyPercentiles{P50: 1, P95: 3, P99: 3},
+		Vitess:     LatencyPercentiles{P50: 2, P95: 4, P99: 4},
+	}, {
+		Query:      "SELECT * FROM `u` WHERE `x` = :_x /* VARCHAR */",
+		LineNumber: 7,
+		Samples:    1,
+		MySQL:      LatencyPercentiles{P50: 1, P95: 1, P99: 1},
+		Vitess:     LatencyPercentiles{P50: 3, P95: 3, P99: 3},
+	}}, output.Latencies)
+}
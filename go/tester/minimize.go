@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// maxMinimizeRuns caps the number of queries a minimization runs, each is run on both MySQL and Vitess
+const maxMinimizeRuns = 100
+
+type (
+	// mismatchKind tells how MySQL and Vitess disagree on a query
+	mismatchKind int
+
+	// comparisonReporter tells if the comparer failed on the current test case,
+	// the comparer reports its failures with Errorf and the rest of the tester with AddFailure
+	comparisonReporter struct {
+		Reporter
+		failed bool
+	}
+)
+
+const (
+	noMismatch mismatchKind = iota
+	resultMismatch
+	// vitessErrorMismatch is a query that only fails on Vitess
+	vitessErrorMismatch
+	// mysqlErrorMismatch is a query that only fails on MySQL
+	mysqlErrorMismatch
+)
+
+func (r *comparisonReporter) AddTestCase(query string, lineNo int) {
+	r.failed = false
+	r.Reporter.AddTestCase(query, lineNo)
+}
+
+func (r *comparisonReporter) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	r.Reporter.Errorf(format, args...)
+}
+
+// compareOn runs the query on both systems and tells how their results differ. The rows are compared as the comparer
+// does, in order when the query has an ORDER BY and in any order otherwise, but the types of the columns are not.
+func compareOn(vtConn, mysqlConn *mysql.Conn, query string) mismatchKind {
+	vtQr, vtErr := vtConn.ExecuteFetch(query, 1000, true)
+	mysqlQr, mysqlErr := mysqlConn.ExecuteFetch(query, 1000, true)
+	switch {
+	case vtErr != nil && mysqlErr != nil:
+		return noMismatch
+	case vtErr != nil:
+		return vitessErrorMismatch
+	case mysqlErr != nil:
+		return mysqlErrorMismatch
+	}
+
+	ordered := false
+	if stmt, err := sqlparser.NewTestParser().Parse(query); err == nil {
+		if sel, ok := stmt.(sqlparser.SelectStatement); ok {
+			ordered = sel.GetOrderBy() != nil
+		}
+	}
+	results := []sqltypes.Result{*vtQr}
+	expected := []sqltypes.Result{*mysqlQr}
+	if ordered && sqltypes.ResultsEqual(results, expected) || sqltypes.ResultsEqualUnordered(results, expected) {
+		return noMismatch
+	}
+	return resultMismatch
+}
+
+// minimizeQuery simplifies a SELECT that MySQL and Vitess disagree on. It tries one simplification at a time: dropping
+// a select expression, a join, a predicate or a grouping column, or a whole DISTINCT, WHERE, GROUP BY, HAVING, ORDER BY
+// or LIMIT clause. It keeps every simplification the two systems still disagree on in the same way, as told by compare,
+// until none is left. It returns the query unchanged when it isn't a SELECT, or when it can't be simplified.
+func minimizeQuery(query string, compare func(query string) mismatchKind) string {
+	stmt, err := sqlparser.NewTestParser().Parse(query)
+	if err != nil {
+		return query
+	}
+	sel, ok := stmt.(sqlparser.SelectStatement)
+	if !ok {
+		return query
+	}
+	want := compare(query)
+	if want == noMismatch {
+		return query
+	}
+
+	runs := 1
+	simplified := false
+	for progress := true; progress && runs < maxMinimizeRuns; {
+		progress = false
+		for _, candidate := range simplifications(sel) {
+			if runs >= maxMinimizeRuns {
+				break
+			}
+			runs++
+			if compare(sqlparser.String(candidate)) == want {
+				sel, progress, simplified = candidate, true, true
+				break
+			}
+		}
+	}
+	if !simplified {
+		return query
+	}
+	return sqlparser.String(sel)
+}
+
+// simplifications returns the statements one simplification away from the given one, the biggest ones first.
+// The statements share the unchanged parts of their AST with the given statement, none of them is modified.
+func simplifications(stmt sqlparser.SelectStatement) []sqlparser.SelectStatement {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Union:
+		candidates := []sqlparser.SelectStatement{stmt.Left, stmt.Right}
+		for _, left := range simplifications(stmt.Left) {
+			union := *stmt
+			union.Left = left
+			candidates = append(candidates, &union)
+		}
+		for _, right := range simplifications(stmt.Right) {
+			union := *stmt
+			union.Right = right
+			candidates = append(candidates, &union)
+		}
+		return candidates
+	case *sqlparser.Select:
+		return selectSimplifications(stmt)
+	default:
+		return nil
+	}
+}
+
+func selectSimplifications(sel *sqlparser.Select) []sqlparser.SelectStatement {
+	var candidates []sqlparser.SelectStatement
+	with := func(change func(c *sqlparser.Select)) {
+		c := *sel
+		change(&c)
+		candidates = append(candidates, &c)
+	}
+
+	// the last expressions are dropped first, they are usually the ones reading the joined tables
+	if len(sel.SelectExprs) > 1 {
+		for i := len(sel.SelectExprs) - 1; i >= 0; i-- {
+			with(func(c *sqlparser.Select) { c.SelectExprs = without(sel.SelectExprs, i) })
+		}
+	}
+	for i, from := range sel.From {
+		if len(sel.From) > 1 {
+			with(func(c *sqlparser.Select) { c.From = without(sel.From, i) })
+		}
+		for _, te := range tableSimplifications(from) {
+			with(func(c *sqlparser.Select) { c.From = replaced(sel.From, i, te) })
+		}
+	}
+	if sel.Where != nil {
+		with(func(c *sqlparser.Select) { c.Where = nil })
+		for _, expr := range exprSimplifications(sel.Where.Expr) {
+			with(func(c *sqlparser.Select) { c.Where = sqlparser.NewWhere(sqlparser.WhereClause, expr) })
+		}
+	}
+	if sel.Having != nil {
+		with(func(c *sqlparser.Select) { c.Having = nil })
+		for _, expr := range exprSimplifications(sel.Having.Expr) {
+			with(func(c *sqlparser.Select) { c.Having = sqlparser.NewWhere(sqlparser.HavingClause, expr) })
+		}
+	}
+	if sel.GroupBy != nil {
+		with(func(c *sqlparser.Select) { c.GroupBy = nil })
+		for i := range sel.GroupBy.Exprs {
+			if len(sel.GroupBy.Exprs) > 1 {
+				with(func(c *sqlparser.Select) {
+					c.GroupBy = &sqlparser.GroupBy{Exprs: without(sel.GroupBy.Exprs, i), WithRollup: sel.GroupBy.WithRollup}
+				})
+			}
+		}
+	}
+	if sel.Distinct {
+		with(func(c *sqlparser.Select) { c.Distinct = false })
+	}
+	if sel.OrderBy != nil {
+		with(func(c *sqlparser.Select) { c.OrderBy = nil })
+	}
+	if sel.Limit != nil {
+		with(func(c *sqlparser.Select) { c.Limit = nil })
+	}
+	return candidates
+}
+
+// tableSimplifications replaces a join by one of its sides, and simplifies the query of a derived table
+func tableSimplifications(te sqlparser.TableExpr) []sqlparser.TableExpr {
+	switch te := te.(type) {
+	case *sqlparser.JoinTableExpr:
+		candidates := []sqlparser.TableExpr{te.LeftExpr, te.RightExpr}
+		for _, left := range tableSimplifications(te.LeftExpr) {
+			join := *te
+			join.LeftExpr = left
+			candidates = append(candidates, &join)
+		}
+		for _, right := range tableSimplifications(te.RightExpr) {
+			join := *te
+			join.RightExpr = right
+			candidates = append(candidates, &join)
+		}
+		return candidates
+	case *sqlparser.AliasedTableExpr:
+		derived, ok := te.Expr.(*sqlparser.DerivedTable)
+		if !ok {
+			return nil
+		}
+		var candidates []sqlparser.TableExpr
+		for _, sel := range simplifications(derived.Select) {
+			aliased := *te
+			aliased.Expr = &sqlparser.DerivedTable{Lateral: derived.Lateral, Select: sel}
+			candidates = append(candidates, &aliased)
+		}
+		return candidates
+	default:
+		return nil
+	}
+}
+
+// exprSimplifications replaces an AND or an OR by one of its sides and a NOT by its operand,
+// and simplifies the subqueries of the comparisons and of EXISTS
+func exprSimplifications(expr sqlparser.Expr) []sqlparser.Expr {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		candidates := []sqlparser.Expr{expr.Left, expr.Right}
+		for _, left := range exprSimplifications(expr.Left) {
+			candidates = append(candidates, &sqlparser.AndExpr{Left: left, Right: expr.Right})
+		}
+		for _, right := range exprSimplifications(expr.Right) {
+			candidates = append(candidates, &sqlparser.AndExpr{Left: expr.Left, Right: right})
+		}
+		return candidates
+	case *sqlparser.OrExpr:
+		candidates := []sqlparser.Expr{expr.Left, expr.Right}
+		for _, left := range exprSimplifications(expr.Left) {
+			candidates = append(candidates, &sqlparser.OrExpr{Left: left, Right: expr.Right})
+		}
+		for _, right := range exprSimplifications(expr.Right) {
+			candidates = append(candidates, &sqlparser.OrExpr{Left: expr.Left, Right: right})
+		}
+		return candidates
+	case *sqlparser.NotExpr:
+		candidates := []sqlparser.Expr{expr.Expr}
+		for _, inner := range exprSimplifications(expr.Expr) {
+			candidates = append(candidates, &sqlparser.NotExpr{Expr: inner})
+		}
+		return candidates
+	case *sqlparser.ExistsExpr:
+		var candidates []sqlparser.Expr
+		for _, sel := range simplifications(expr.Subquery.Select) {
+			candidates = append(candidates, &sqlparser.ExistsExpr{Subquery: &sqlparser.Subquery{Select: sel}})
+		}
+		return candidates
+	case *sqlparser.ComparisonExpr:
+		subquery, ok := expr.Right.(*sqlparser.Subquery)
+		if !ok {
+			return nil
+		}
+		var candidates []sqlparser.Expr
+		for _, sel := range simplifications(subquery.Select) {
+			cmp := *expr
+			cmp.Right = &sqlparser.Subquery{Select: sel}
+			candidates = append(candidates, &cmp)
+		}
+		return candidates
+	default:
+		return nil
+	}
+}
+
+// without returns a copy of the slice without its i-th element
+func without[T any](s []T, i int) []T {
+	c := make([]T, 0, len(s)-1)
+	c = append(c, s[:i]...)
+	return append(c, s[i+1:]...)
+}
+
+// replaced returns a copy of the slice with v as its i-th element
+func replaced[T any](s []T, i int, v T) []T {
+	c := make([]T, len(s))
+	copy(c, s)
+	c[i] = v
+	return c
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// mismatchOn returns a comparison that finds a mismatch of the kind on the queries that parse and contain all the parts
+func mismatchOn(kind mismatchKind, parts ...string) func(string) mismatchKind {
+	return func(query string) mismatchKind {
+		if _, err := sqlparser.NewTestParser().Parse(query); err != nil {
+			return noMismatch
+		}
+		for _, part := range parts {
+			if !strings.Contains(query, part) {
+				return noMismatch
+			}
+		}
+		return kind
+	}
+}
+
+func TestMinimizeQuery(t *testing.T) {
+	tests := []struct {
+		name, query string
+		compare     func(string) mismatchKind
+		want        string
+	}{{
+		name:    "predicates and select expressions",
+		query:   "select a, b, c from t where a = 1 and (b > 2 or c < 3) and not d = 4 order by a, b, c limit 3",
+		compare: mismatchOn(resultMismatch, "c < 3"),
+		want:    "select a from t where c < 3",
+	}, {
+		name:    "join",
+		query:   "select t1.a, t2.b from t1 join t2 on t1.id = t2.id left join t3 on t2.id = t3.id where t1.a = 1",
+		compare: mismatchOn(vitessErrorMismatch, "join t2", "left join t3"),
+		want:    "select t1.a from t1 join t2 on t1.id = t2.id left join t3 on t2.id = t3.id",
+	}, {
+		name:    "grouping",
+		query:   "select a, b, count(*) from t group by a, b having count(*) > 1",
+		compare: mismatchOn(resultMismatch, "count(*) from", "group by"),
+		want:    "select count(*) from t group by b",
+	}, {
+		name:    "derived table and subquery",
+		query:   "select x.a from (select a, b from t where b = 1 and a = 2) as x where exists (select 1 from u where u.c = x.a and u.d = 3)",
+		compare: mismatchOn(resultMismatch, "a = 2", "u.d = 3"),
+		want:    "select x.a from (select a from t where a = 2) as x where exists (select 1 from u where u.d = 3)",
+	}, {
+		name:    "no simplification keeps the mismatch",
+		query:   "select a from t where a = 1",
+		compare: mismatchOn(resultMismatch, "select a from t where a = 1"),
+		want:    "select a from t where a = 1",
+	}, {
+		name:    "no mismatch",
+		query:   "select a, b from t",
+		compare: mismatchOn(noMismatch),
+		want:    "select a, b from t",
+	}, {
+		name:    "not a select",
+		query:   "delete from t where a = 1 and b = 2",
+		compare: mismatchOn(resultMismatch),
+		want:    "delete from t where a = 1 and b = 2",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, minimizeQuery(tt.query, tt.compare))
+		})
+	}
+}
+
+func TestMinimizeQueryKeepsTheKindOfMismatch(t *testing.T) {
+	// dropping the predicate turns the wrong result into an error on Vitess, which is another bug
+	compare := func(query string) mismatchKind {
+		if strings.Contains(query, "where") {
+			return resultMismatch
+		}
+		return vitessErrorMismatch
+	}
+	require.Equal(t, "select a from t where a = 1", minimizeQuery("select a, b from t where a = 1", compare))
+}
+
+func TestMinimizeQueryIsBounded(t *testing.T) {
+	runs := 0
+	// only the original query mismatches, so every simplification is tried until the runs are exhausted
+	compare := func(string) mismatchKind {
+		runs++
+		if runs == 1 {
+			return resultMismatch
+		}
+		return noMismatch
+	}
+	var predicates []string
+	for range 200 {
+		predicates = append(predicates, "a = 1")
+	}
+	minimizeQuery("select a from t where "+strings.Join(predicates, " and "), compare)
+	require.Equal(t, maxMinimizeRuns, runs)
+}
+
+func TestComparisonReporter(t *testing.T) {
+	r := &comparisonReporter{Reporter: NewXMLTestSuite().NewReporterForFile("t/a.test")}
+	r.AddTestCase("select 1", 1)
+	r.AddFailure(errors.New("not a comparison"))
+	require.False(t, r.failed)
+	r.Errorf("results mismatched")
+	require.True(t, r.failed)
+
+	r.AddTestCase("select 2", 2)
+	require.False(t, r.failed, "a new test case resets the comparison")
+}
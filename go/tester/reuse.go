@@ -0,0 +1,291 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/test/endtoend/cluster"
+)
+
+type (
+	// clusterState is what a run started with --keep-cluster writes about its cluster,
+	// for the next runs to attach to the cluster instead of starting one
+	clusterState struct {
+		ID      string         `json:"id"`
+		Options clusterOptions `json:"options"`
+
+		// Root is the VTDATAROOT of the cluster, under which all its processes keep their files
+		Root            string             `json:"root"`
+		Hostname        string             `json:"hostname"`
+		Cell            string             `json:"cell"`
+		VtgateMySQLPort int                `json:"vtgateMySQLPort"`
+		Keyspaces       []cluster.Keyspace `json:"keyspaces"`
+		KeyspaceNames   []string           `json:"keyspaceNames"`
+		MySQLParams     *mysql.ConnParams  `json:"mysqlParams,omitempty"`
+
+		Vtgate       cluster.VtgateProcess       `json:"vtgate"`
+		VtctldClient cluster.VtctldClientProcess `json:"vtctldClient"`
+
+		// SetupTime is how long the cluster took to start, the time saved by every run reusing it
+		SetupTime time.Duration `json:"setupTime"`
+	}
+
+	// clusterOptions are the flags that shape the cluster, a cluster is only reused with the same ones
+	clusterOptions struct {
+		Sharded              bool   `json:"sharded,omitempty"`
+		VschemaFile          string `json:"vschemaFile,omitempty"`
+		VtExplainVschemaFile string `json:"vtexplainVschemaFile,omitempty"`
+		NumberOfShards       int    `json:"numberOfShards,omitempty"`
+		BackupDir            string `json:"backupDir,omitempty"`
+		// Compare is set when the cluster has a MySQL to compare the results with
+		Compare bool `json:"compare,omitempty"`
+	}
+)
+
+// clusterTeardownTimeout is how long the processes of a reused cluster have to stop when tearing it down
+const clusterTeardownTimeout = 30 * time.Second
+
+func newClusterOptions(cfg Config) clusterOptions {
+	return clusterOptions{
+		Sharded:              cfg.Sharded,
+		VschemaFile:          cfg.VschemaFile,
+		VtExplainVschemaFile: cfg.VtExplainVschemaFile,
+		NumberOfShards:       cfg.NumberOfShards,
+		BackupDir:            cfg.BackupDir,
+		Compare:              cfg.Compare,
+	}
+}
+
+// compatible tells why a cluster started with these options can't be used with the given ones.
+// A cluster with a MySQL can be used without comparing, but not the opposite.
+func (o clusterOptions) compatible(with clusterOptions) error {
+	if with.Compare && !o.Compare {
+		return errors.New("it was started without a MySQL to compare the results with")
+	}
+	o.Compare, with.Compare = false, false
+	if o != with {
+		return fmt.Errorf("it was started with other flags: %s", o)
+	}
+	return nil
+}
+
+func (o clusterOptions) String() string {
+	var flags []string
+	switch {
+	case o.VschemaFile != "":
+		flags = append(flags, "--vschema "+o.VschemaFile)
+	case o.VtExplainVschemaFile != "":
+		flags = append(flags, "--vtexplain-vschema "+o.VtExplainVschemaFile)
+	case o.Sharded:
+		flags = append(flags, "--sharded")
+	}
+	if o.NumberOfShards > 0 {
+		flags = append(flags, fmt.Sprintf("--number-of-shards %d", o.NumberOfShards))
+	}
+	if o.BackupDir != "" {
+		flags = append(flags, "--backup-path "+o.BackupDir)
+	}
+	if len(flags) == 0 {
+		return "no flags"
+	}
+	return strings.Join(flags, " ")
+}
+
+// clusterStateDir is where the states of the kept clusters are written
+func clusterStateDir(vtdataroot string) string {
+	return filepath.Join(vtdataroot, "vt-clusters")
+}
+
+func clusterStateFile(vtdataroot, id string) string {
+	return filepath.Join(clusterStateDir(vtdataroot), id+".json")
+}
+
+// keptClusters returns the IDs of the clusters kept running, in the given VTDATAROOT
+func keptClusters(vtdataroot string) []string {
+	files, _ := filepath.Glob(filepath.Join(clusterStateDir(vtdataroot), "*.json"))
+	ids := make([]string, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(file), ".json"))
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func newClusterState(cfg Config, info ClusterInfo, setupTime time.Duration) clusterState {
+	c := info.clusterInstance
+	return clusterState{
+		ID:              filepath.Base(c.CurrentVTDATAROOT),
+		Options:         newClusterOptions(cfg),
+		Root:            c.CurrentVTDATAROOT,
+		Hostname:        c.Hostname,
+		Cell:            c.Cell,
+		VtgateMySQLPort: c.VtgateMySQLPort,
+		Keyspaces:       c.Keyspaces,
+		KeyspaceNames:   info.ksNames,
+		MySQLParams:     info.mysqlParams,
+		Vtgate:          c.VtgateProcess,
+		VtctldClient:    c.VtctldClientProcess,
+		SetupTime:       setupTime,
+	}
+}
+
+func (s clusterState) write(vtdataroot string) error {
+	err := os.MkdirAll(clusterStateDir(vtdataroot), PERM)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(clusterStateFile(vtdataroot, s.ID), data, PERM)
+}
+
+func readClusterState(vtdataroot, id string) (clusterState, error) {
+	data, err := os.ReadFile(clusterStateFile(vtdataroot, id))
+	if errors.Is(err, os.ErrNotExist) {
+		kept := keptClusters(vtdataroot)
+		if len(kept) == 0 {
+			return clusterState{}, fmt.Errorf("no cluster %s is kept running, and there is no other one: start one with --keep-cluster", id)
+		}
+		return clusterState{}, fmt.Errorf("no cluster %s is kept running, the kept clusters are: %s", id, strings.Join(kept, ", "))
+	}
+	if err != nil {
+		return clusterState{}, err
+	}
+	var s clusterState
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return clusterState{}, fmt.Errorf("reading the state of cluster %s: %w", id, err)
+	}
+	return s, nil
+}
+
+// keepCluster returns the closer of a cluster kept running after the tests: it writes the state of the cluster,
+// for the next runs to reuse it, and closes nothing
+func keepCluster(cfg Config, info ClusterInfo, vtdataroot string, setupTime time.Duration) func() {
+	return func() {
+		state := newClusterState(cfg, info, setupTime)
+		if err := state.write(vtdataroot); err != nil {
+			log.Errorf("writing the state of cluster %s, it has to be stopped by hand: %v", state.ID, err)
+			return
+		}
+		fmt.Printf("cluster %s is kept running, reuse it with --use-cluster %s\n", state.ID, state.ID)
+	}
+}
+
+// attachCluster returns the cluster kept running by an earlier run, with the vschema of the given config.
+// The cluster is torn down once the tests are done unless cfg.KeepCluster is set.
+func attachCluster(ctx context.Context, cfg Config) (ClusterInfo, error) {
+	vtdataroot := os.Getenv("VTDATAROOT")
+	state, err := readClusterState(vtdataroot, cfg.ReuseCluster)
+	if err != nil {
+		return ClusterInfo{}, err
+	}
+	if err := state.Options.compatible(newClusterOptions(cfg)); err != nil {
+		return ClusterInfo{}, wrongUsage(fmt.Sprintf("cluster %s can't be reused, %s", state.ID, err))
+	}
+
+	keyspaces, vschema := getKeyspaces(cfg.VschemaFile, cfg.VtExplainVschemaFile, defaultKeyspaceName, cfg.Sharded)
+	clusterInstance := &cluster.LocalProcessCluster{
+		Keyspaces:           state.Keyspaces,
+		Cell:                state.Cell,
+		Hostname:            state.Hostname,
+		CurrentVTDATAROOT:   state.Root,
+		OriginalVTDATAROOT:  vtdataroot,
+		VtgateMySQLPort:     state.VtgateMySQLPort,
+		VtgateProcess:       state.Vtgate,
+		VtctldClientProcess: state.VtctldClient,
+	}
+	vtParams := clusterInstance.GetVTParams(state.KeyspaceNames[0])
+	conn, err := mysql.Connect(ctx, &vtParams)
+	if err != nil {
+		_ = os.Remove(clusterStateFile(vtdataroot, state.ID))
+		return ClusterInfo{}, fmt.Errorf("cluster %s is not running anymore, start a new one: %w", state.ID, err)
+	}
+	conn.Close()
+
+	// the vschema can have changed since the cluster started, and the auto-vschema holds the tables of the last run
+	for _, keyspace := range keyspaces {
+		if !slices.Contains(state.KeyspaceNames, keyspace.Name) {
+			return ClusterInfo{}, wrongUsage(fmt.Sprintf("cluster %s can't be reused, it has no keyspace %s", state.ID, keyspace.Name))
+		}
+		if err := clusterInstance.VtctldClientProcess.ApplyVSchema(keyspace.Name, keyspace.VSchema); err != nil {
+			return ClusterInfo{}, fmt.Errorf("applying the vschema of keyspace %s: %w", keyspace.Name, err)
+		}
+	}
+	// the processes read VTDATAROOT to find their files, as they do in a cluster started by this run
+	_ = os.Setenv("VTDATAROOT", state.Root)
+	fmt.Printf("reusing cluster %s, skipping its %s setup\n", state.ID, state.SetupTime.Round(time.Second))
+
+	info := ClusterInfo{
+		clusterInstance: clusterInstance,
+		vtParams:        vtParams,
+		ksNames:         state.KeyspaceNames,
+		vschema:         vschema,
+	}
+	if cfg.Compare {
+		info.mysqlParams = state.MySQLParams
+	}
+	info.closer = func() {
+		_ = os.Setenv("VTDATAROOT", vtdataroot)
+		if cfg.KeepCluster {
+			fmt.Printf("cluster %s is kept running, reuse it with --use-cluster %s\n", state.ID, state.ID)
+			return
+		}
+		if err := stopCluster(state.Root); err != nil {
+			log.Errorf("tearing down cluster %s: %v", state.ID, err)
+			return
+		}
+		_ = os.Remove(clusterStateFile(vtdataroot, state.ID))
+	}
+	return info, nil
+}
+
+// stopCluster stops the processes of a cluster started by an earlier run, and removes its files.
+// This run doesn't own the processes, they are found by the root directory of the cluster in their command line.
+func stopCluster(root string) error {
+	pattern := regexp.QuoteMeta(root)
+	err := exec.Command("pkill", "-f", pattern).Run()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		// pkill exits with 1 when no process matched
+		return fmt.Errorf("stopping the processes: %w", err)
+	}
+
+	deadline := time.Now().Add(clusterTeardownTimeout)
+	for exec.Command("pgrep", "-f", pattern).Run() == nil {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the processes did not stop within %s, their files are left in %s", clusterTeardownTimeout, root)
+		}
+		time.Sleep(time.Second)
+	}
+	return os.RemoveAll(root)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/test/endtoend/cluster"
+)
+
+func TestClusterOptionsCompatible(t *testing.T) {
+	sharded := clusterOptions{Sharded: true, NumberOfShards: 4, Compare: true}
+
+	require.NoError(t, sharded.compatible(sharded))
+	// the trace runs can use the MySQL-less clusters and those of the tester
+	require.NoError(t, sharded.compatible(clusterOptions{Sharded: true, NumberOfShards: 4}))
+
+	err := clusterOptions{Sharded: true, NumberOfShards: 4}.compatible(sharded)
+	require.ErrorContains(t, err, "without a MySQL")
+
+	err = sharded.compatible(clusterOptions{Sharded: true, Compare: true})
+	require.EqualError(t, err, "it was started with other flags: --sharded --number-of-shards 4")
+
+	err = clusterOptions{}.compatible(clusterOptions{VschemaFile: "vschema.json"})
+	require.EqualError(t, err, "it was started with other flags: no flags")
+}
+
+func TestClusterState(t *testing.T) {
+	vtdataroot := t.TempDir()
+
+	_, err := readClusterState(vtdataroot, "vtroot_1")
+	require.ErrorContains(t, err, "start one with --keep-cluster")
+
+	state := clusterState{
+		ID:              "vtroot_15000",
+		Options:         clusterOptions{Sharded: true, Compare: true},
+		Root:            vtdataroot + "/vtroot_15000",
+		Hostname:        "localhost",
+		Cell:            defaultCellName,
+		VtgateMySQLPort: 15306,
+		Keyspaces: []cluster.Keyspace{{
+			Name: defaultKeyspaceName,
+			Shards: []cluster.Shard{{
+				Name:      "-80",
+				Vttablets: []*cluster.Vttablet{{TabletUID: 100, VttabletProcess: &cluster.VttabletProcess{TabletUID: 100, Port: 15100}}},
+			}},
+		}},
+		KeyspaceNames: []string{defaultKeyspaceName},
+		MySQLParams:   &mysql.ConnParams{UnixSocket: "/tmp/mysql.sock", Uname: "root", DbName: defaultKeyspaceName},
+		Vtgate:        cluster.VtgateProcess{Port: 15001, VSchemaURL: "http://localhost:15001/debug/vschema"},
+		SetupTime:     3 * time.Minute,
+	}
+	require.NoError(t, state.write(vtdataroot))
+	require.Equal(t, []string{"vtroot_15000"}, keptClusters(vtdataroot))
+
+	read, err := readClusterState(vtdataroot, "vtroot_15000")
+	require.NoError(t, err)
+	require.Equal(t, state, read)
+
+	_, err = readClusterState(vtdataroot, "vtroot_1")
+	require.EqualError(t, err, "no cluster vtroot_1 is kept running, the kept clusters are: vtroot_15000")
+}
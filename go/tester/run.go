@@ -17,6 +17,7 @@ limitations under the License.
 package tester
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,6 +27,9 @@ import (
 	"github.com/fatih/color"
 	log "github.com/sirupsen/logrus"
 	"vitess.io/vitess/go/test/endtoend/cluster"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/probe"
 )
 
 type Config struct {
@@ -41,6 +45,27 @@ type Config struct {
 	Compare              bool
 
 	BackupDir string
+
+	// Filter selects the queries of the tests to run
+	Filter data.Filter
+
+	// VerifyShards checks, after every DML statement, that the rows are stored on the shard their vindex dictates
+	VerifyShards bool
+
+	// LatencyFile is where the latencies of the SELECT queries on MySQL and on Vitess are written, see LatencyRecorder
+	LatencyFile string
+	// LatencyRuns is the number of times every SELECT query is timed on each system
+	LatencyRuns int
+
+	// CapabilitiesFile is a file written by 'vt probe' describing what the Vitess binaries support.
+	// The features it lacks are checked before starting the cluster, or skipped, instead of failing midway.
+	CapabilitiesFile string
+
+	// KeepCluster leaves the cluster running after the tests, for the next runs to reuse it with ReuseCluster
+	KeepCluster bool
+	// ReuseCluster is the ID of a cluster kept running by an earlier run, used instead of starting a cluster.
+	// It is torn down after the tests, unless KeepCluster is set.
+	ReuseCluster string
 }
 
 func (cfg Config) GetNumberOfShards() int {
@@ -63,6 +88,12 @@ func (e WrongUsageError) Error() string {
 }
 
 func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext runs the tests until they are done or ctx is canceled. When canceled, it stops after the
+// current query, writes the trace and latency files of the queries run so far, and tears down the cluster.
+func RunContext(ctx context.Context, cfg Config) error {
 	err := CheckEnvironment()
 	if err != nil {
 		return fmt.Errorf("error reading environment variables: %w", err)
@@ -78,30 +109,40 @@ func Run(cfg Config) error {
 		return wrongUsage("number-of-shards can only be used with -sharded, -vschema or -vtexplain-vschema")
 	}
 
-	if ll := os.Getenv("LOG_LEVEL"); ll != "" {
-		cfg.LogLevel = ll
-	}
-	if cfg.LogLevel != "" {
-		ll, err := log.ParseLevel(cfg.LogLevel)
-		if err != nil {
-			log.Errorf("error parsing log level %s: %v", cfg.LogLevel, err)
-		}
-		log.SetLevel(ll)
-	}
+	setLogLevel(cfg.LogLevel)
 
 	if len(cfg.Tests) == 0 {
 		return wrongUsage("no tests specified")
 	}
 
+	if cfg.LatencyFile != "" && !cfg.Compare {
+		return wrongUsage("latency-file can only be used when comparing MySQL and Vitess")
+	}
+
+	var capabilities *probe.Capabilities
+	if cfg.CapabilitiesFile != "" {
+		capabilities, err = probe.ReadCapabilities(cfg.CapabilitiesFile)
+		if err != nil {
+			return err
+		}
+		if cfg.TraceFile != "" && !capabilities.VExplainTrace {
+			return fmt.Errorf("tracing needs vexplain trace, which Vitess %s does not support", capabilities.Version)
+		}
+	}
+
 	log.Infof("running tests: %v", cfg.Tests)
 
-	clusterInfo, err := SetupCluster(cfg)
+	clusterInfo, err := SetupCluster(ctx, cfg)
 	if err != nil {
 		return err
 	}
-
 	defer clusterInfo.closer()
 
+	clusterInfo.capabilities = capabilities
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	// remove errors folder if exists
 	err = os.RemoveAll("errors")
 	if err != nil {
@@ -114,8 +155,11 @@ func Run(cfg Config) error {
 	} else {
 		reporterSuite = NewFileReporterSuite(getVschema(clusterInfo.clusterInstance))
 	}
-	failed := ExecuteTests(clusterInfo, cfg.Tests, reporterSuite, cfg.VschemaFile, cfg.VtExplainVschemaFile, cfg.OLAP, getQueryRunnerFactory(cfg))
+	failed := ExecuteTests(ctx, clusterInfo, cfg.Tests, reporterSuite, cfg.VschemaFile, cfg.VtExplainVschemaFile, cfg.OLAP, cfg.Filter, getQueryRunnerFactory(cfg))
 	outputFile := reporterSuite.Close()
+	if ctx.Err() != nil {
+		return fmt.Errorf("tests interrupted, see the results of the tests run so far in %v: %w", outputFile, ctx.Err())
+	}
 	if failed {
 		return fmt.Errorf("some tests failed 😭\nsee errors in %v", outputFile)
 	}
@@ -123,14 +167,33 @@ func Run(cfg Config) error {
 	return nil
 }
 
+// setLogLevel sets the log level from the LOG_LEVEL environment variable, or else from the given level when not empty
+func setLogLevel(level string) {
+	if ll := os.Getenv("LOG_LEVEL"); ll != "" {
+		level = ll
+	}
+	if level == "" {
+		return
+	}
+	ll, err := log.ParseLevel(level)
+	if err != nil {
+		log.Errorf("error parsing log level %s: %v", level, err)
+	}
+	log.SetLevel(ll)
+}
+
 func getQueryRunnerFactory(cfg Config) QueryRunnerFactory {
 	var inner QueryRunnerFactory
 	if cfg.Compare {
-		inner = ComparingQueryRunnerFactory{}
+		inner = ComparingQueryRunnerFactory{VerifyShards: cfg.VerifyShards}
 	} else {
 		inner = NullQueryRunnerFactory{}
 	}
 
+	if cfg.LatencyFile != "" {
+		inner = NewLatencyRecorderFactory(cfg.LatencyFile, cfg.LatencyRuns, inner)
+	}
+
 	if cfg.TraceFile == "" {
 		return inner
 	}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/endtoend/cluster"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// verifyShardPlacement checks, after a DML statement, that every row of the modified tables is stored on the shard
+// its primary vindex maps it to. It reads the rows directly from the primary tablet of each shard, so routing
+// bugs that would be hidden by comparing the aggregated results with MySQL are caught.
+// Tables in unsharded keyspaces and tables whose primary vindex needs a lookup are not verified.
+func (nqr *ComparingQueryRunner) verifyShardPlacement(ast sqlparser.Statement) error {
+	if !sqlparser.IsDMLStatement(ast) {
+		return nil
+	}
+
+	for _, tableName := range sqlparser.ExtractAllTables(ast) {
+		tbl, err := nqr.vschema.FindTable("" /*empty means global search*/, tableName)
+		if err != nil || tbl.Keyspace == nil || !tbl.Keyspace.Sharded || len(tbl.ColumnVindexes) == 0 {
+			continue
+		}
+		primary := tbl.ColumnVindexes[0]
+		vindex, err := singleColumnVindex(primary)
+		if err != nil {
+			return err
+		}
+		if vindex == nil {
+			continue
+		}
+
+		for _, ks := range nqr.cluster.Keyspaces {
+			if ks.Name != tbl.Keyspace.Name {
+				continue
+			}
+			for _, shard := range ks.Shards {
+				if err := verifyShard(shard, ks.Name, tableName, primary.Columns[0].String(), vindex); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// singleColumnVindex returns the vindex used to compute the keyspace id of the rows,
+// or nil if the keyspace id can't be computed from the column alone
+func singleColumnVindex(cv *vindexes.ColumnVindex) (vindexes.SingleColumn, error) {
+	vindex := cv.Vindex
+	if vindex == nil {
+		// the auto-vschema only sets the type of the vindex
+		var err error
+		vindex, err = vindexes.CreateVindex(cv.Type, cv.Name, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating vindex %s: %w", cv.Name, err)
+		}
+	}
+	single, ok := vindex.(vindexes.SingleColumn)
+	if !ok || !single.IsUnique() || single.NeedsVCursor() || len(cv.Columns) != 1 {
+		return nil, nil
+	}
+	return single, nil
+}
+
+func verifyShard(shard cluster.Shard, keyspace, table, column string, vindex vindexes.SingleColumn) error {
+	keyRanges, err := key.ParseShardingSpec(shard.Name)
+	if err != nil || len(keyRanges) != 1 {
+		return fmt.Errorf("parsing the key range of shard %s/%s: %v", keyspace, shard.Name, err)
+	}
+
+	query := fmt.Sprintf("select %s from %s", sqlparser.String(sqlparser.NewIdentifierCI(column)), sqlparser.String(sqlparser.NewIdentifierCS(table)))
+	qr, err := shard.PrimaryTablet().VttabletProcess.QueryTablet(query, keyspace, true)
+	if err != nil {
+		return fmt.Errorf("reading %s from shard %s/%s: %w", table, keyspace, shard.Name, err)
+	}
+
+	ids := make([]sqltypes.Value, 0, len(qr.Rows))
+	for _, row := range qr.Rows {
+		ids = append(ids, row[0])
+	}
+	destinations, err := vindex.Map(context.Background(), nil, ids)
+	if err != nil {
+		return fmt.Errorf("mapping the rows of %s with vindex %s: %w", table, vindex.String(), err)
+	}
+	for i, dest := range destinations {
+		ksid, ok := dest.(key.DestinationKeyspaceID)
+		if !ok {
+			continue
+		}
+		if !key.KeyRangeContains(keyRanges[0], ksid) {
+			return fmt.Errorf("row with %s = %s of table %s is stored on shard %s/%s, but vindex %s maps it to keyspace id %s",
+				column, ids[i].String(), table, keyspace, shard.Name, vindex.String(), ksid.String())
+		}
+	}
+	return nil
+}
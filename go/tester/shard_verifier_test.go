@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+func TestSingleColumnVindex(t *testing.T) {
+	// the auto-vschema only sets the name and type of the vindex
+	vindex, err := singleColumnVindex(&vindexes.ColumnVindex{
+		Name:    "xxhash",
+		Type:    "xxhash",
+		Columns: []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id")},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, vindex)
+
+	// multi-column vindexes can't be verified from a single column
+	vindex, err = singleColumnVindex(&vindexes.ColumnVindex{
+		Name:    "xxhash",
+		Type:    "xxhash",
+		Columns: []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("a"), sqlparser.NewIdentifierCI("b")},
+	})
+	require.NoError(t, err)
+	require.Nil(t, vindex)
+
+	_, err = singleColumnVindex(&vindexes.ColumnVindex{Name: "nope", Type: "does_not_exist"})
+	require.ErrorContains(t, err, "creating vindex nope")
+}
@@ -33,6 +33,7 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 
 	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/probe"
 	"github.com/vitessio/vt/go/tester/state"
 	"github.com/vitessio/vt/go/typ"
 )
@@ -54,6 +55,10 @@ type (
 		vschema     *vindexes.VSchema
 		vschemaFile string
 		vexplain    string
+		filter      data.Filter
+
+		// capabilities tells what the Vitess binaries support, it is nil when unknown
+		capabilities *probe.Capabilities
 
 		state *state.State
 
@@ -72,10 +77,11 @@ type (
 	}
 )
 
-func NewTester(name string, reporter Reporter, info ClusterInfo, olap bool, vschema *vindexes.VSchema, vschemaFile string, factory QueryRunnerFactory) *Tester {
+func NewTester(name string, reporter Reporter, info ClusterInfo, olap bool, vschema *vindexes.VSchema, vschemaFile string, filter data.Filter, factory QueryRunnerFactory) *Tester {
 	t := &Tester{
-		name:            name,
-		reporter:        reporter,
+		name: name,
+		// the comparing query runner minimizes the queries the comparer fails on
+		reporter:        &comparisonReporter{Reporter: reporter},
 		vtParams:        info.vtParams,
 		mysqlParams:     info.mysqlParams,
 		clusterInstance: info.clusterInstance,
@@ -83,6 +89,8 @@ func NewTester(name string, reporter Reporter, info ClusterInfo, olap bool, vsch
 		vschema:         vschema,
 		vschemaFile:     vschemaFile,
 		olap:            olap,
+		filter:          filter,
+		capabilities:    info.capabilities,
 		state:           state.NewState(utils.BinaryIsAtLeastAtVersion),
 	}
 
@@ -96,11 +104,12 @@ func NewTester(name string, reporter Reporter, info ClusterInfo, olap bool, vsch
 		exitIf(err, "connecting to MySQL")
 		mcmp = utils.MySQLCompare{VtConn: vtConn}
 	}
+	t.MySQLConn, t.VtConn = mcmp.MySQLConn, mcmp.VtConn
 	createTableHandler := t.handleCreateTable
 	if !t.autoVSchema() {
 		createTableHandler = func(*sqlparser.CreateTable) func() { return func() {} }
 	}
-	t.qr = factory.NewQueryRunner(reporter, createTableHandler, mcmp, info.clusterInstance, vschema)
+	t.qr = factory.NewQueryRunner(t.reporter, createTableHandler, mcmp, info.clusterInstance, vschema)
 
 	return t
 }
@@ -113,7 +122,11 @@ func (t *Tester) preProcess() {
 }
 
 func (t *Tester) postProcess() error {
-	r, err := t.MySQLConn.ExecuteFetch("show tables", 1000, true)
+	conn := t.MySQLConn
+	if conn == nil {
+		conn = t.VtConn
+	}
+	r, err := conn.ExecuteFetch("show tables", 1000, true)
 	if err != nil {
 		return fmt.Errorf("running show tables: %w", err)
 	}
@@ -193,6 +206,12 @@ func (t *Tester) handleQuery(q data.Query) {
 			t.runQuery(q)
 			return
 		}
+		if t.capabilities != nil && !t.capabilities.SupportsVExplain(t.vexplain) {
+			t.reporter.AddInfo(fmt.Sprintf("Skipping vexplain %s, which Vitess %s does not support\n", t.vexplain, t.capabilities.Version))
+			t.vexplain = ""
+			t.runQuery(q)
+			return
+		}
 		t.runVexplain(q.Query)
 	case typ.VitessOnly:
 		err = vitessOrMySQLOnly(q.Query, t.state.BeginVitessOnly, t.state.EndVitessOnly)
@@ -200,6 +219,8 @@ func (t *Tester) handleQuery(q data.Query) {
 		err = vitessOrMySQLOnly(q.Query, t.state.BeginMySQLOnly, t.state.EndMySQLOnly)
 	case typ.Reference:
 		err = t.state.SetReference()
+	case typ.AssertRowCount:
+		t.assertRowCount(q)
 	default:
 		t.reporter.AddFailure(fmt.Errorf("%s not supported", q.Type.String()))
 	}
@@ -208,7 +229,8 @@ func (t *Tester) handleQuery(q data.Query) {
 	}
 }
 
-func (t *Tester) Run() (err error) {
+// Run runs the queries of the test file, stopping early when ctx is canceled
+func (t *Tester) Run(ctx context.Context) (err error) {
 	t.preProcess()
 	if t.autoVSchema() {
 		defer func() {
@@ -227,8 +249,12 @@ func (t *Tester) Run() (err error) {
 		t.reporter.AddFailure(err)
 		return err
 	}
+	queries = t.filter.Apply(queries)
 
 	for _, q := range queries {
+		if ctx.Err() != nil {
+			break
+		}
 		t.handleQuery(q)
 	}
 	fmt.Printf("%s\n", t.reporter.Report())
@@ -257,6 +283,7 @@ func (t *Tester) runQuery(q data.Query) {
 		return
 	}
 	t.reporter.AddTestCase(q.Query, q.Line)
+	defer t.reporter.EndTestCase()
 	parser := sqlparser.NewTestParser()
 	ast, err := parser.Parse(q.Query)
 	if err != nil {
@@ -267,7 +294,84 @@ func (t *Tester) runQuery(q data.Query) {
 	if err != nil {
 		t.reporter.AddFailure(err)
 	}
-	t.reporter.EndTestCase()
+}
+
+// assertRowCount runs `--assert_rowcount <table> [WHERE <condition>] = <count>`: it counts the rows of the table
+// matching the condition on MySQL and on Vitess, and fails when a count is not the expected one. It checks the side
+// effects of the previous statement that its own result doesn't show, such as foreign key cascades or triggers.
+func (t *Tester) assertRowCount(q data.Query) {
+	t.reporter.AddTestCase("assert_rowcount"+q.Query, q.Line)
+	defer t.reporter.EndTestCase()
+
+	query, expected, err := parseAssertRowCount(q.Query)
+	if err != nil {
+		t.reporter.AddFailure(err)
+		return
+	}
+	check := func(system string, conn *mysql.Conn) {
+		rs, err := conn.ExecuteFetch(query, 1, false)
+		if err != nil {
+			t.reporter.AddFailure(fmt.Errorf("assert_rowcount on %s: %w", system, err))
+			return
+		}
+		if len(rs.Rows) != 1 || len(rs.Rows[0]) != 1 {
+			t.reporter.AddFailure(fmt.Errorf("assert_rowcount on %s: expected a single count but got %d rows for %s", system, len(rs.Rows), query))
+			return
+		}
+		count, err := rs.Rows[0][0].ToInt()
+		if err != nil {
+			t.reporter.AddFailure(fmt.Errorf("assert_rowcount on %s: %w", system, err))
+			return
+		}
+		if count != expected {
+			t.reporter.AddFailure(fmt.Errorf("assert_rowcount on %s: expected %d rows but got %d for %s", system, expected, count, query))
+		}
+	}
+	if t.MySQLConn != nil && t.state.RunOnMySQL() {
+		check("MySQL", t.MySQLConn)
+	}
+	if t.state.RunOnVitess() {
+		check("Vitess", t.VtConn)
+	}
+}
+
+// parseAssertRowCount returns the count query and the expected count of the arguments of an assert_rowcount directive
+func parseAssertRowCount(args string) (string, int, error) {
+	args = strings.TrimSuffix(strings.TrimSpace(args), ";")
+	i := strings.LastIndex(args, "=")
+	if i < 0 || strings.TrimSpace(args[:i]) == "" {
+		return "", 0, fmt.Errorf("expected <table> [WHERE <condition>] = <count> for assert_rowcount in: %v", args)
+	}
+	expected, err := strconv.Atoi(strings.TrimSpace(args[i+1:]))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid row count for assert_rowcount in: %v", args)
+	}
+	query := "select count(*) from " + strings.TrimSpace(args[:i])
+	stmt, err := sqlparser.NewTestParser().Parse(query)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid table or condition for assert_rowcount in %v: %w", args, err)
+	}
+	// the count query returns a single row: it counts the rows of the table, and nothing groups or limits them
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || sel.GroupBy != nil || sel.Having != nil || sel.Limit != nil || sel.OrderBy != nil {
+		return "", 0, fmt.Errorf("expected <table> [WHERE <condition>] = <count> for assert_rowcount in: %v", args)
+	}
+	// the count follows a complete condition: `t where a = 3` is missing its count, it is not a count of 3 for `where a`
+	if sel.Where != nil && !isCondition(sel.Where.Expr) {
+		return "", 0, fmt.Errorf("incomplete condition for assert_rowcount, the count is missing in: %v", args)
+	}
+	return query, expected, nil
+}
+
+// isCondition tells whether an expression is a condition, such as a comparison, rather than a value
+func isCondition(expr sqlparser.Expr) bool {
+	switch expr.(type) {
+	case *sqlparser.ComparisonExpr, *sqlparser.AndExpr, *sqlparser.OrExpr, *sqlparser.XorExpr, *sqlparser.NotExpr,
+		*sqlparser.IsExpr, *sqlparser.BetweenExpr, *sqlparser.ExistsExpr:
+		return true
+	default:
+		return false
+	}
 }
 
 func (t *Tester) findTable(name string) (ks string, err error) {
@@ -0,0 +1,41 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAssertRowCount(t *testing.T) {
+	query, expected, err := parseAssertRowCount(" order_line WHERE order_id = 3 and status = 'open' = 0;")
+	require.NoError(t, err)
+	require.Equal(t, "select count(*) from order_line WHERE order_id = 3 and status = 'open'", query)
+	require.Zero(t, expected)
+
+	query, expected, err = parseAssertRowCount(" customer = 12")
+	require.NoError(t, err)
+	require.Equal(t, "select count(*) from customer", query)
+	require.Equal(t, 12, expected)
+
+	for _, args := range []string{" customer", " = 3", " customer = many", " customer where = 3",
+		" customer where id = 3", " customer where id > 1 group by name = 2", " customer limit 0 = 0"} {
+		_, _, err = parseAssertRowCount(args)
+		require.Error(t, err, args)
+	}
+}
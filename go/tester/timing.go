@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// slowestTestsLimit is the number of test cases listed in the slowest tests report
+const slowestTestsLimit = 20
+
+type (
+	// TestTiming is how long a test case, a query of a test file, took to run.
+	// For the timing of a whole test file, Query and Line are not set.
+	TestTiming struct {
+		File     string
+		Query    string
+		Line     int
+		Duration time.Duration
+	}
+
+	// Timings collects the durations of the test cases and of the test files of a run
+	Timings struct {
+		Tests []TestTiming
+		Files []TestTiming
+	}
+
+	// timingReporter records the duration of the test cases of a file in Timings,
+	// and hands everything to the reporter it wraps
+	timingReporter struct {
+		Reporter
+		timings *Timings
+		current *TestTiming
+		start   time.Time
+	}
+)
+
+var _ Reporter = (*timingReporter)(nil)
+
+func (t *Timings) wrap(file string, reporter Reporter) Reporter {
+	return &timingReporter{Reporter: reporter, timings: t, current: &TestTiming{File: file}}
+}
+
+func (t *Timings) addFile(file string, d time.Duration) {
+	t.Files = append(t.Files, TestTiming{File: file, Duration: d})
+}
+
+func (r *timingReporter) AddTestCase(query string, lineNo int) {
+	r.current.Query = query
+	r.current.Line = lineNo
+	r.start = time.Now()
+	r.Reporter.AddTestCase(query, lineNo)
+}
+
+func (r *timingReporter) EndTestCase() {
+	r.Reporter.EndTestCase()
+	timing := *r.current
+	timing.Duration = time.Since(r.start)
+	r.timings.Tests = append(r.timings.Tests, timing)
+}
+
+// Slowest returns the n slowest test cases, the slowest first
+func (t *Timings) Slowest(n int) []TestTiming {
+	tests := make([]TestTiming, len(t.Tests))
+	copy(tests, t.Tests)
+	sort.SliceStable(tests, func(i, j int) bool {
+		return tests[i].Duration > tests[j].Duration
+	})
+	return tests[:min(n, len(tests))]
+}
+
+// Print writes the duration of every test file and the slowest test cases
+func (t *Timings) Print(out io.Writer) {
+	if len(t.Files) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\nTest files by duration:")
+	files := make([]TestTiming, len(t.Files))
+	copy(files, t.Files)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Duration > files[j].Duration
+	})
+	for _, f := range files {
+		fmt.Fprintf(out, "%10s  %s\n", f.Duration.Round(time.Millisecond), f.File)
+	}
+
+	slowest := t.Slowest(slowestTestsLimit)
+	if len(slowest) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\nSlowest %d tests:\n", len(slowest))
+	for _, test := range slowest {
+		fmt.Fprintf(out, "%10s  %s:%d  %s\n", test.Duration.Round(time.Millisecond), test.File, test.Line, shortQuery(test.Query))
+	}
+}
+
+// shortQuery puts a query on a single line, cutting it after 80 characters
+func shortQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > 80 {
+		return query[:77] + "..."
+	}
+	return query
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tester
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings(t *testing.T) {
+	suite := NewXMLTestSuite()
+	timings := &Timings{}
+	reporter := timings.wrap("t/a.test", suite.NewReporterForFile("t/a.test"))
+	reporter.AddTestCase("select 1", 1)
+	reporter.EndTestCase()
+	reporter.AddTestCase("select sleep(0.02)", 2)
+	time.Sleep(20 * time.Millisecond)
+	reporter.AddFailure(errors.New("too slow"))
+	reporter.EndTestCase()
+	suite.CloseReportForFile()
+	timings.addFile("t/a.test", 30*time.Millisecond)
+	timings.addFile("t/b.test", time.Second)
+
+	require.Len(t, timings.Tests, 2)
+	slowest := timings.Slowest(1)
+	require.Len(t, slowest, 1)
+	require.Equal(t, "select sleep(0.02)", slowest[0].Query)
+	require.Equal(t, 2, slowest[0].Line)
+	require.Equal(t, "t/a.test", slowest[0].File)
+	require.GreaterOrEqual(t, slowest[0].Duration, 20*time.Millisecond)
+	require.Len(t, timings.Slowest(slowestTestsLimit), 2)
+
+	// the JUnit test cases get their duration, in seconds
+	testcases := suite.ts.Suites[0].Testcases
+	require.Len(t, testcases, 2)
+	require.NotEmpty(t, testcases[1].Time)
+	require.NotEqual(t, "0.000", testcases[1].Time)
+	require.NotNil(t, testcases[1].Failure)
+
+	var out bytes.Buffer
+	timings.Print(&out)
+	report := out.String()
+	require.Contains(t, report, "Slowest 2 tests:")
+	require.Less(t, strings.Index(report, "t/b.test"), strings.Index(report, "t/a.test"))
+	require.Contains(t, report, "t/a.test:2  select sleep(0.02)")
+}
+
+func TestShortQuery(t *testing.T) {
+	require.Equal(t, "select * from t where id = 1", shortQuery("select *\n  from t\n  where id = 1"))
+	require.Len(t, shortQuery(strings.Repeat("a ", 100)), 80)
+}
@@ -21,7 +21,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 
+	log "github.com/sirupsen/logrus"
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/test/endtoend/cluster"
 	"vitess.io/vitess/go/test/endtoend/utils"
@@ -30,6 +32,7 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 
 	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/probe"
 	"github.com/vitessio/vt/go/tester/state"
 )
 
@@ -45,10 +48,16 @@ type (
 		reporter             Reporter
 		inner                QueryRunner
 		alreadyWrittenTraces bool
+		factory              *TracerFactory
 	}
 	TracerFactory struct {
 		traceFile *os.File
 		inner     QueryRunnerFactory
+
+		// version is the Vitess version of the vtgate, recorded in every trace so 'vt summarize'
+		// can tell when two trace files come from different planners
+		version     string
+		versionOnce sync.Once
 	}
 )
 
@@ -68,9 +77,23 @@ func (t *TracerFactory) NewQueryRunner(reporter Reporter, handleCreateTable Crea
 		VtConn:    comparer.VtConn,
 		reporter:  reporter,
 		inner:     inner,
+		factory:   t,
 	}
 }
 
+// vitessVersion returns the version of the vtgate, read once from the first connection asking for it
+func (t *TracerFactory) vitessVersion(conn *mysql.Conn) string {
+	t.versionOnce.Do(func() {
+		version, err := probe.ReadVersion(conn)
+		if err != nil {
+			log.Warnf("the traces won't record the Vitess version: %v", err)
+			return
+		}
+		t.version = version
+	})
+	return t.version
+}
+
 func (t *TracerFactory) Close() {
 	_, err := t.traceFile.Write([]byte("]"))
 	exitIf(err, "failed to write closing bracket")
@@ -137,7 +160,15 @@ func (t *Tracer) trace(query data.Query) error {
 	if t.alreadyWrittenTraces {
 		traceEntry.WriteString(",") // Prepend a comma if there are already written traces
 	}
-	traceEntry.WriteString(fmt.Sprintf(`{"Query": %s, "LineNumber": "%d", "Trace": `, queryJSON, query.Line))
+	traceEntry.WriteString(fmt.Sprintf(`{"Query": %s, "LineNumber": "%d", `, queryJSON, query.Line))
+	if version := t.factory.vitessVersion(t.VtConn); version != "" {
+		versionJSON, err := json.Marshal(version)
+		if err != nil {
+			return err
+		}
+		traceEntry.WriteString(fmt.Sprintf(`"VitessVersion": %s, `, versionJSON))
+	}
+	traceEntry.WriteString(`"Trace": `)
 	traceEntry.Write(prettyTrace.Bytes()) // Add the formatted trace
 	traceEntry.WriteString("}")           // Close the JSON object
 
@@ -26,15 +26,22 @@ import (
 
 type XMLTestSuite struct {
 	ts            junit.Testsuites
+	runStartTime  time.Time
 	startTime     time.Time
 	currTestSuite junit.Testsuite
 	currTestCase  *junit.Testcase
+	currStartTime time.Time
 }
 
 var _ Suite = (*XMLTestSuite)(nil)
 
 func NewXMLTestSuite() *XMLTestSuite {
-	return &XMLTestSuite{}
+	return &XMLTestSuite{runStartTime: time.Now()}
+}
+
+// junitSeconds formats a duration the way JUnit reports expect it, in seconds
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
 }
 
 func (xml *XMLTestSuite) NewReporterForFile(name string) Reporter {
@@ -47,7 +54,7 @@ func (xml *XMLTestSuite) NewReporterForFile(name string) Reporter {
 }
 
 func (xml *XMLTestSuite) CloseReportForFile() {
-	xml.currTestSuite.Time = fmt.Sprintf("%v", time.Since(xml.startTime).Seconds())
+	xml.currTestSuite.Time = junitSeconds(time.Since(xml.startTime))
 	xml.ts.AddSuite(xml.currTestSuite)
 }
 
@@ -56,6 +63,7 @@ func (xml *XMLTestSuite) Close() string {
 	file, err := os.Create(fileName)
 	exitIf(err, "creating report.xml file")
 	defer file.Close()
+	xml.ts.Time = junitSeconds(time.Since(xml.runStartTime))
 	err = xml.ts.WriteXML(file)
 	exitIf(err, "writing report.xml file")
 	return fileName
@@ -66,9 +74,11 @@ func (xml *XMLTestSuite) AddTestCase(query string, lineNo int) {
 		Name:   query,
 		Status: fmt.Sprintf("Line No. - %v", lineNo),
 	}
+	xml.currStartTime = time.Now()
 }
 
 func (xml *XMLTestSuite) EndTestCase() {
+	xml.currTestCase.Time = junitSeconds(time.Since(xml.currStartTime))
 	xml.currTestSuite.AddTestcase(*xml.currTestCase)
 	xml.currTestCase = nil
 }
@@ -0,0 +1,328 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wizard
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/vitessio/vt/go/data"
+	"github.com/vitessio/vt/go/dbinfo"
+	"github.com/vitessio/vt/go/keys"
+	"github.com/vitessio/vt/go/summarize"
+	vttester "github.com/vitessio/vt/go/tester"
+)
+
+const (
+	defaultKeysFile   = "keys-log.json"
+	defaultTraceFile  = "trace-log.json"
+	defaultDBInfoFile = "dbinfo.json"
+)
+
+type prompter struct {
+	ctx context.Context
+	in  *bufio.Reader
+	out io.Writer
+	// terminal is the file descriptor of the input when it is a terminal, the secrets are read from it without echo.
+	// It is -1 otherwise.
+	terminal int
+}
+
+type answer struct {
+	text string
+	err  error
+}
+
+// Run walks the user through analysing a query log: it optionally connects to the database of the workload
+// to collect its schema with `vt dbinfo`, runs `vt keys` on the log, optionally traces the workload
+// on a local Vitess cluster, and summarizes the results.
+// Every question has a default that is used when the user just presses enter.
+// Canceling ctx interrupts the question being asked, or the step being run.
+func Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	p := &prompter{ctx: ctx, in: bufio.NewReader(in), out: out, terminal: -1}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.terminal = int(f.Fd())
+	}
+
+	fmt.Fprintln(out, "Welcome to vt! This wizard analyses a query log and produces a summary of the workload.")
+
+	logFile, err := p.ask("Path or URL of the query log to analyse", "")
+	if err != nil {
+		return err
+	}
+	if logFile == "" {
+		return errors.New("a query log is required")
+	}
+
+	inputType, err := p.ask("Format of the query log ("+strings.Join(data.InputTypes, ", ")+")", data.InputTypeMySQLTest)
+	if err != nil {
+		return err
+	}
+	loader, err := data.LoaderFor(inputType)
+	if err != nil {
+		return err
+	}
+
+	live, dbInfoFile, err := p.maybeConnect()
+	if err != nil {
+		return err
+	}
+
+	keysFile, err := p.ask("File to write the keys analysis to", defaultKeysFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Analysing %s...\n", logFile)
+	if err := writeKeys(ctx, keysConfig(logFile, loader, live), keysFile); err != nil {
+		return fmt.Errorf("analysing query log: %w", err)
+	}
+	fmt.Fprintf(out, "Wrote keys analysis to %s\n", keysFile)
+
+	var traceFile string
+	if inputType == data.InputTypeMySQLTest {
+		// only mysqltest files can be replayed by the tester
+		traceFile, err = p.maybeTrace(logFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	summarizeKeys, err := p.confirm("Summarize the keys analysis now?", true)
+	if err != nil {
+		return err
+	}
+	if summarizeKeys {
+		if err := writeSummary(out, summarize.Config{Files: []string{keysFile}, DBInfoFile: dbInfoFile}); err != nil {
+			return err
+		}
+	}
+	if traceFile != "" {
+		if err := writeSummary(out, summarize.Config{Files: []string{traceFile}}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "All done! You can summarize the results again later with `vt summarize %s`\n", keysFile)
+	return nil
+}
+
+// writeSummary summarizes the files of the config to out
+func writeSummary(out io.Writer, cfg summarize.Config) error {
+	summary, err := summarize.Run(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = summary.WriteTo(out)
+	return err
+}
+
+// maybeConnect offers to connect to the database of the workload. The schema of the tables the log doesn't create
+// is then read from it, and its schema and statistics are collected in a dbinfo file for the summary.
+// It returns nil and an empty file name if the user doesn't connect.
+func (p *prompter) maybeConnect() (*keys.LiveSchema, string, error) {
+	connect, err := p.confirm("Connect to the database of the workload to read its schema and statistics?", false)
+	if err != nil || !connect {
+		return nil, "", err
+	}
+
+	var live keys.LiveSchema
+	if live.Host, err = p.ask("Host of the MySQL server or vtgate", "127.0.0.1"); err != nil {
+		return nil, "", err
+	}
+	port, err := p.ask("Port", "3306")
+	if err != nil {
+		return nil, "", err
+	}
+	if live.Port, err = strconv.Atoi(port); err != nil {
+		return nil, "", fmt.Errorf("invalid port %q", port)
+	}
+	if live.User, err = p.ask("User", "root"); err != nil {
+		return nil, "", err
+	}
+	if live.Password, err = p.askSecret("Password (empty for none)"); err != nil {
+		return nil, "", err
+	}
+	if live.Database, err = p.ask("Database, empty for the one of the connection", ""); err != nil {
+		return nil, "", err
+	}
+	dbInfoFile, err := p.ask("File to write the schema and statistics to", defaultDBInfoFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fmt.Fprintf(p.out, "Collecting the schema of the database...\n")
+	err = writeDBInfo(dbinfo.Config{
+		Host:     live.Host,
+		Port:     live.Port,
+		User:     live.User,
+		Password: live.Password,
+		Database: live.Database,
+	}, dbInfoFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("collecting the schema of the database: %w", err)
+	}
+	fmt.Fprintf(p.out, "Wrote the schema and statistics to %s\n", dbInfoFile)
+	return &live, dbInfoFile, nil
+}
+
+// maybeTrace offers to run the workload through `vt trace` when a local Vitess installation is available.
+// It returns the name of the trace file, or an empty string if no tracing was done.
+func (p *prompter) maybeTrace(logFile string) (string, error) {
+	if err := vttester.CheckEnvironment(); err != nil {
+		fmt.Fprintf(p.out, "Skipping tracing on a local Vitess cluster: %s\n", err.Error())
+		return "", nil
+	}
+
+	trace, err := p.confirm("Trace the workload on a local sharded Vitess cluster?", false)
+	if err != nil || !trace {
+		return "", err
+	}
+
+	traceFile, err := p.ask("File to write the trace to", defaultTraceFile)
+	if err != nil {
+		return "", err
+	}
+
+	err = vttester.RunContext(p.ctx, vttester.Config{
+		Tests:     []string{logFile},
+		TraceFile: traceFile,
+		Sharded:   true,
+		LogLevel:  "error",
+	})
+	if err != nil {
+		return "", fmt.Errorf("tracing workload: %w", err)
+	}
+	return traceFile, nil
+}
+
+// keysConfig is the config of `vt keys` for the log, with the defaults of its command line
+func keysConfig(logFile string, loader data.Loader, live *keys.LiveSchema) keys.Config {
+	cfg := keys.DefaultConfig()
+	cfg.FileNames = []string{logFile}
+	cfg.Loader = loader
+	cfg.LiveSchema = live
+	return cfg
+}
+
+func writeKeys(ctx context.Context, cfg keys.Config, keysFile string) error {
+	return writeFile(keysFile, func(w io.Writer) error {
+		return keys.RunTo(ctx, w, cfg)
+	})
+}
+
+func writeDBInfo(cfg dbinfo.Config, dbInfoFile string) error {
+	return writeFile(dbInfoFile, func(w io.Writer) error {
+		return dbinfo.RunTo(w, cfg)
+	})
+}
+
+// writeFile creates the file and writes it with write, the error closing the file is returned too
+func writeFile(fileName string, write func(io.Writer) error) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	text, err := p.read(func() (string, error) { return p.in.ReadString('\n') })
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return def, nil
+	}
+	return text, nil
+}
+
+// askSecret asks for a secret such as a password, which is not echoed when the input is a terminal
+func (p *prompter) askSecret(question string) (string, error) {
+	fmt.Fprintf(p.out, "%s: ", question)
+	if p.terminal < 0 {
+		return p.read(func() (string, error) { return p.in.ReadString('\n') })
+	}
+
+	text, err := p.read(func() (string, error) {
+		secret, err := term.ReadPassword(p.terminal)
+		return string(secret), err
+	})
+	// the newline of the answer is not echoed either
+	fmt.Fprintln(p.out)
+	return text, err
+}
+
+// read returns the trimmed answer read with readLine, or ctx.Err() when ctx is canceled first
+func (p *prompter) read(readLine func() (string, error)) (string, error) {
+	// the read can't be interrupted, so it is left behind when ctx is canceled
+	answers := make(chan answer, 1)
+	go func() {
+		text, err := readLine()
+		answers <- answer{text: text, err: err}
+	}()
+
+	var a answer
+	select {
+	case <-p.ctx.Done():
+		return "", p.ctx.Err()
+	case a = <-answers:
+	}
+	if a.err != nil && !errors.Is(a.err, io.EOF) {
+		return "", a.err
+	}
+	return strings.TrimSpace(a.text), nil
+}
+
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	defStr := "y/N"
+	if def {
+		defStr = "Y/n"
+	}
+	answer, err := p.ask(question+" ("+defStr+")", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected answer %q, expected yes or no", answer)
+	}
+}
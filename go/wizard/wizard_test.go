@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wizard
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vitessio/vt/go/keys"
+)
+
+func TestWizard(t *testing.T) {
+	t.Setenv("VTROOT", "") // make sure we never try to start a cluster
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+
+	// log file, default format, no database, keys file, don't summarize
+	in := strings.NewReader("../../t/demo.test\n\n\n" + keysFile + "\nn\n")
+	out := &strings.Builder{}
+	require.NoError(t, Run(context.Background(), in, out))
+
+	require.Contains(t, out.String(), "Wrote keys analysis to "+keysFile)
+	require.Contains(t, out.String(), "Skipping tracing on a local Vitess cluster")
+	require.NotContains(t, out.String(), "Summary from trace file")
+
+	b, err := os.ReadFile(keysFile)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"queryStructure"`)
+}
+
+func TestWizardRequiresLog(t *testing.T) {
+	err := Run(context.Background(), strings.NewReader("\n"), &strings.Builder{})
+	require.EqualError(t, err, "a query log is required")
+}
+
+func TestWizardInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	in, _ := io.Pipe() // never answers
+	err := Run(ctx, in, &strings.Builder{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWizardSummary(t *testing.T) {
+	t.Setenv("VTROOT", "")
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+
+	// the summary is written to the output of the wizard
+	in := strings.NewReader("../../t/demo.test\n\nn\n" + keysFile + "\ny\n")
+	out := &strings.Builder{}
+	require.NoError(t, Run(context.Background(), in, out))
+	require.Contains(t, out.String(), "Summary from trace file "+keysFile)
+}
+
+func TestWizardConnectionFailure(t *testing.T) {
+	// host, port 1 where nothing listens, user, password, database, dbinfo file
+	dbInfoFile := filepath.Join(t.TempDir(), "dbinfo.json")
+	in := strings.NewReader("../../t/demo.test\n\ny\n127.0.0.1\n1\n\n\n\n" + dbInfoFile + "\n")
+	err := Run(context.Background(), in, &strings.Builder{})
+	require.ErrorContains(t, err, "collecting the schema of the database")
+}
+
+func TestKeysConfig(t *testing.T) {
+	// the wizard analyses the log with the defaults of `vt keys`
+	cfg := keysConfig("../../t/demo.test", nil, nil)
+	require.Equal(t, []string{"../../t/demo.test"}, cfg.FileNames)
+	require.Equal(t, keys.DefaultMaxLiteralLength, cfg.MaxLiteralLength)
+	require.Equal(t, runtime.NumCPU(), cfg.Parallel)
+}